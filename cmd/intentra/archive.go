@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// newArchiveCmd returns a cobra.Command for inspecting the local scan archive.
+func newArchiveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archive",
+		Short: "Inspect the local scan archive",
+		Long: `Inspect scans saved to the local archive (local.archive in config),
+which is used for benchmarking.`,
+	}
+
+	cmd.AddCommand(newArchiveStatsCmd())
+
+	return cmd
+}
+
+// newArchiveStatsCmd returns a cobra.Command for summarizing the local archive.
+func newArchiveStatsCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "stats",
+		Short:         "Summarize the local scan archive",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Summarize the local scan archive: number of archived scans, date range,
+total tokens and cost, per-tool and per-model breakdowns, and how many
+scans include (redacted) events.
+
+Examples:
+  intentra archive stats
+  intentra archive stats --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			dir, err := scanner.ArchiveDir(cfg)
+			if err != nil {
+				return err
+			}
+			st, err := scanner.ComputeArchiveStats(dir)
+			if err != nil {
+				return err
+			}
+			if !cfg.Local.Archive.Enabled && st.Scans == 0 && !jsonOutput {
+				fmt.Fprintln(os.Stdout, "Archive is disabled. Set local.archive.enabled: true in config to start archiving scans.")
+				return nil
+			}
+			return printArchiveStats(os.Stdout, st, jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// printArchiveStats writes archive statistics as a table or JSON.
+func printArchiveStats(w io.Writer, st scanner.ArchiveStats, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal archive stats: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Archive:\t%s\n", st.Dir)
+	fmt.Fprintf(tw, "Scans:\t%d\n", st.Scans)
+	if st.Skipped > 0 {
+		fmt.Fprintf(tw, "Unreadable files:\t%d\n", st.Skipped)
+	}
+	if st.FirstStart != nil && st.LastEnd != nil {
+		fmt.Fprintf(tw, "Date range:\t%s to %s\n",
+			st.FirstStart.Local().Format("2006-01-02 15:04"), st.LastEnd.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(tw, "Tokens:\t%d\n", st.TotalTokens)
+	fmt.Fprintf(tw, "Estimated cost:\t$%.2f\n", st.EstimatedCost)
+	fmt.Fprintf(tw, "With events:\t%d (%d redacted)\n", st.WithEvents, st.Redacted)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	for _, section := range []struct {
+		title  string
+		groups map[string]scanner.ArchiveGroupStats
+	}{
+		{"By tool", st.ByTool},
+		{"By model", st.ByModel},
+	} {
+		if len(section.groups) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		keys := make([]string, 0, len(section.groups))
+		for k := range section.groups {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, k := range keys {
+			g := section.groups[k]
+			fmt.Fprintf(tw, "  %s\t%d scans\t%d tokens\t$%.2f\n", k, g.Scans, g.TotalTokens, g.EstimatedCost)
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+	}
+	return nil
+}
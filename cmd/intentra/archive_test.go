@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+)
+
+func TestPrintArchiveStats(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+	st := scanner.ArchiveStats{
+		Dir:           "/tmp/archive",
+		Scans:         3,
+		FirstStart:    &start,
+		LastEnd:       &end,
+		TotalTokens:   1750,
+		EstimatedCost: 1,
+		WithEvents:    1,
+		Redacted:      1,
+		ByTool: map[string]scanner.ArchiveGroupStats{
+			"cursor": {Scans: 2, TotalTokens: 1500, EstimatedCost: 0.75},
+			"claude": {Scans: 1, TotalTokens: 250, EstimatedCost: 0.25},
+		},
+		ByModel: map[string]scanner.ArchiveGroupStats{
+			"gpt-4o": {Scans: 3, TotalTokens: 1750, EstimatedCost: 1},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := printArchiveStats(&buf, st, false); err != nil {
+		t.Fatalf("printArchiveStats failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Scans:", "1750", "$1.00", "By tool:", "cursor", "By model:", "gpt-4o", "1 (1 redacted)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "claude") > strings.Index(out, "cursor") {
+		t.Error("expected tools sorted by name")
+	}
+
+	buf.Reset()
+	if err := printArchiveStats(&buf, st, true); err != nil {
+		t.Fatalf("printArchiveStats json failed: %v", err)
+	}
+	var decoded scanner.ArchiveStats
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if decoded.Scans != 3 || decoded.ByTool["cursor"].Scans != 2 {
+		t.Errorf("unexpected decoded stats: %+v", decoded)
+	}
+}
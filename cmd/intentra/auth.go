@@ -7,11 +7,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"time"
 	"unicode"
 
+	"github.com/intentrahq/intentra-cli/internal/api"
 	"github.com/intentrahq/intentra-cli/internal/auth"
 	"github.com/intentrahq/intentra-cli/internal/config"
 	"github.com/intentrahq/intentra-cli/internal/debug"
@@ -21,9 +24,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
-
 func newLoginCmd() *cobra.Command {
-	var noBrowser, force bool
+	var opts loginOptions
 
 	cmd := &cobra.Command{
 		Use:           "login",
@@ -35,18 +37,60 @@ func newLoginCmd() *cobra.Command {
 This will:
 1. Generate a device code
 2. Open your browser to authorize (or display URL if --no-browser)
-3. Poll for authorization and save credentials`,
+3. Poll for authorization and save credentials
+
+On headless machines, --device-code prints only the verification URL and
+code, then polls until the code is authorized or expires. --json does the
+same in machine-readable form: one JSON line with verification_uri and
+user_code as soon as the code is issued, then one with the result (status
+logged_in, already_logged_in or failed).
+
+For service accounts, --token stores a pre-issued access token instead of
+running the device flow. The token is checked against the server first.
+Use --token - to read it from stdin and keep it out of the process list and
+shell history. Unlike INTENTRA_TOKEN, the token is kept in secure storage.
+It cannot be refreshed, so log in again with a new token once it expires.
+
+Examples:
+  intentra login
+  intentra login --no-browser
+  intentra login --device-code
+  intentra login --json
+  intentra login --token - < token.txt`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLogin(noBrowser, force)
+			if opts.token == "-" {
+				token, err := readToken(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				opts.token = token
+			}
+			return runLogin(cmd.OutOrStdout(), opts)
 		},
 	}
 
-	cmd.Flags().BoolVar(&noBrowser, "no-browser", false, "Print URL instead of opening browser")
-	cmd.Flags().BoolVar(&force, "force", false, "Force re-authentication even if already logged in")
+	cmd.Flags().BoolVar(&opts.noBrowser, "no-browser", false, "Print URL instead of opening browser")
+	cmd.Flags().BoolVar(&opts.deviceCode, "device-code", false, "Print only the verification URL and code, then wait (for headless machines)")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "Print the device code prompt and the result as JSON lines")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Force re-authentication even if already logged in")
+	cmd.Flags().StringVar(&opts.token, "token", "", "Store a pre-issued access token instead of running the device flow (- reads stdin)")
 
 	return cmd
 }
 
+// readToken reads an access token from r, as for 'login --token -'.
+func readToken(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, httputil.MaxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token from stdin: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("no token on stdin")
+	}
+	return token, nil
+}
+
 func newLogoutCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:           "logout",
@@ -73,84 +117,488 @@ func newStatusCmd() *cobra.Command {
 	}
 }
 
-func runLogin(noBrowser, force bool) error {
-	creds, _ := auth.GetValidCredentials()
-	if creds != nil && !force {
-		fmt.Println("Already logged in.")
-		fmt.Println("Use 'intentra login --force' to re-authenticate.")
-		return nil
+// newAuthCmd returns a cobra.Command grouping authentication utilities.
+func newAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Authentication utilities",
+	}
+
+	cmd.AddCommand(newAuthTestCmd())
+	cmd.AddCommand(newAuthExportCmd())
+	cmd.AddCommand(newAuthImportCmd())
+
+	return cmd
+}
+
+// newAuthTestCmd returns a cobra.Command that checks the configured
+// credentials against the server without sending a scan.
+func newAuthTestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "test",
+		Short:         "Test authentication against the server",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Make a minimal authenticated request to the server using the configured
+auth mode (api_key with HMAC signing, or the JWT from 'intentra login') and
+report whether the server accepted it. No scan data is sent, so this isolates
+credential problems from payload problems. Works before server sync is enabled.
+
+Examples:
+  intentra auth test
+  intentra auth test --debug`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := runAuthTest(cmd.OutOrStdout())
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+			}
+			return err
+		},
+	}
+}
+
+// runAuthTest checks the configured credentials against the server and
+// prints the result.
+func runAuthTest(w io.Writer) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	testCfg := *cfg
+	testCfg.Server.Enabled = true
+	if testCfg.Server.Endpoint == "" {
+		testCfg.Server.Endpoint = config.DefaultAPIEndpoint
+	}
+	client, err := api.NewClient(&testCfg)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.VerifyAuth()
+	if err != nil {
+		return apiExitCode(fmt.Errorf("auth test failed: %w", err))
+	}
+	return printAuthCheck(w, res)
+}
+
+// newAuthExportCmd returns a cobra.Command that writes the stored
+// credentials as a passphrase-encrypted blob for 'auth import'.
+func newAuthExportCmd() *cobra.Command {
+	var output, passphraseFile string
+	var logout bool
+
+	cmd := &cobra.Command{
+		Use:           "export",
+		Short:         "Export credentials for another machine",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Export the stored login credentials, encrypted with a passphrase, so they
+can be loaded on another machine with 'intentra auth import' instead of
+running the device flow there.
+
+The passphrase is read from --passphrase-file, or from the
+INTENTRA_PASSPHRASE environment variable. The local credential store is
+bound to this machine, so the export is encrypted with a key derived from
+the passphrase instead. Anyone with the blob and the passphrase can act as
+you until the refresh token is revoked, so treat both like a password.
+
+Both machines share one refresh token, and the server replaces it on
+every refresh. Once either machine refreshes its login, the other is
+logged out and must log in again. Use --logout to remove the credentials
+from this machine once they are exported, so only the imported copy stays
+in use.
+
+Examples:
+  intentra auth export --passphrase-file pass.txt > creds.txt
+  INTENTRA_PASSPHRASE=... intentra auth export -o creds.txt --logout`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase, err := readPassphrase(passphraseFile)
+			if err != nil {
+				return err
+			}
+			blob, err := exportCredentials(passphrase)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				if _, err := fmt.Fprintln(cmd.OutOrStdout(), blob); err != nil {
+					return err
+				}
+			} else {
+				if err := os.WriteFile(output, []byte(blob+"\n"), 0600); err != nil {
+					return fmt.Errorf("failed to write %s: %w", output, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Credentials exported to %s\n", output)
+			}
+			if !logout {
+				return nil
+			}
+			if err := auth.DeleteCredentialsFromKeyring(); err != nil {
+				return fmt.Errorf("credentials exported, but failed to log out: %w", err)
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "Logged out of this machine.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the export to a file instead of stdout")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "Read the passphrase from a file")
+	cmd.Flags().BoolVar(&logout, "logout", false, "Log out of this machine after exporting")
+
+	return cmd
+}
+
+// newAuthImportCmd returns a cobra.Command that stores credentials written
+// by 'auth export'.
+func newAuthImportCmd() *cobra.Command {
+	var passphraseFile string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:           "import [file]",
+		Short:         "Import credentials exported on another machine",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MaximumNArgs(1),
+		Long: `Load credentials written by 'intentra auth export' into this machine's
+secure storage and register this device, as 'intentra login' would. The
+export is read from the given file, or from stdin when the file is omitted
+or is -.
+
+The passphrase is read from --passphrase-file, or from the
+INTENTRA_PASSPHRASE environment variable.
+
+Examples:
+  intentra auth import creds.txt --passphrase-file pass.txt
+  INTENTRA_PASSPHRASE=... intentra auth import < creds.txt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase, err := readPassphrase(passphraseFile)
+			if err != nil {
+				return err
+			}
+			r := cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open export: %w", err)
+				}
+				defer f.Close()
+				r = f
+			}
+			blob, err := io.ReadAll(io.LimitReader(r, httputil.MaxResponseSize))
+			if err != nil {
+				return fmt.Errorf("failed to read export: %w", err)
+			}
+			return runImport(cmd.OutOrStdout(), string(blob), passphrase, force)
+		},
 	}
 
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "Read the passphrase from a file")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace credentials even if already logged in")
+
+	return cmd
+}
+
+// readPassphrase returns the export passphrase from file, or from
+// INTENTRA_PASSPHRASE when file is empty.
+func readPassphrase(file string) (string, error) {
+	if file == "" {
+		if passphrase := os.Getenv("INTENTRA_PASSPHRASE"); passphrase != "" {
+			return passphrase, nil
+		}
+		return "", fmt.Errorf("no passphrase: use --passphrase-file or set INTENTRA_PASSPHRASE")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// exportCredentials returns the stored credentials encrypted with passphrase.
+func exportCredentials(passphrase string) (string, error) {
+	if os.Getenv("INTENTRA_TOKEN") != "" {
+		return "", fmt.Errorf("credentials come from INTENTRA_TOKEN; unset it to export stored credentials")
+	}
+	// Load config first: it selects the keyring backend.
+	if _, err := loadConfig(); err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	creds, err := auth.GetValidCredentials()
+	if err != nil {
+		return "", withExitCode(ExitCodeAuth, err)
+	}
+	if creds == nil {
+		return "", withExitCode(ExitCodeAuth, fmt.Errorf("not logged in; run 'intentra login' first"))
+	}
+	return auth.ExportCredentials(creds, passphrase)
+}
+
+// runImport decrypts an export, stores the credentials and registers this
+// device.
+func runImport(w io.Writer, blob, passphrase string, force bool) error {
+	// Load config first: it selects the keyring backend.
 	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	if creds, _ := auth.GetValidCredentials(); creds != nil && !force {
+		fmt.Fprintln(w, "Already logged in.")
+		fmt.Fprintln(w, "Use 'intentra auth import --force' to replace the stored credentials.")
+		return nil
+	}
+
+	creds, err := auth.ImportCredentials(blob, passphrase)
+	if err != nil {
+		return withExitCode(ExitCodeAuth, err)
+	}
+	if creds.IsExpired() && creds.RefreshToken == "" {
+		return withExitCode(ExitCodeAuth, fmt.Errorf("exported credentials expired at %s", creds.ExpiresAt.Format(time.RFC3339)))
+	}
+
 	endpoint := cfg.Server.Endpoint
 	if endpoint == "" {
 		endpoint = config.DefaultAPIEndpoint
 	}
 
-	fmt.Println("Initiating device authorization...")
+	if err := auth.StoreCredentialsInKeyring(creds); err != nil {
+		fmt.Fprintf(w, "Warning: secure storage unavailable, using encrypted cache: %v\n", err)
+		if err := auth.WriteEncryptedCache(creds); err != nil {
+			return fmt.Errorf("failed to save credentials: %w", err)
+		}
+	}
+	fmt.Fprintln(w, "✓ Credentials imported")
 
-	deviceResp, err := requestDeviceCode(endpoint)
-	if err != nil {
-		return fmt.Errorf("failed to initiate login: %w", err)
+	// Refresh before registering so an export that has since expired
+	// still works.
+	if valid, err := auth.GetValidCredentials(); err == nil && valid != nil {
+		creds = valid
+	}
+	if err := registerMachine(endpoint, creds.AccessToken); err != nil {
+		fmt.Fprintf(w, "Warning: failed to register device: %v\n", err)
+		fmt.Fprintln(w, "Run 'intentra auth import --force' to retry.")
+	} else {
+		fmt.Fprintln(w, "✓ Device registered")
+	}
+	return nil
+}
+
+// printAuthCheck reports an auth check result, returning an error when the
+// server rejected the credentials.
+func printAuthCheck(w io.Writer, res *api.AuthCheckResult) error {
+	fmt.Fprintf(w, "Auth mode: %s\n", res.Mode)
+	fmt.Fprintf(w, "Endpoint:  %s\n", res.URL)
+	if res.OK() {
+		fmt.Fprintf(w, "Result:    ✓ authenticated (%d)\n", res.StatusCode)
+		return nil
+	}
+	fmt.Fprintf(w, "Result:    ✗ rejected (%d)\n", res.StatusCode)
+	if body := strings.TrimSpace(res.Body); body != "" {
+		fmt.Fprintf(w, "Response:  %s\n", body)
 	}
+	return withExitCode(ExitCodeAuth, fmt.Errorf("server rejected credentials with status %d", res.StatusCode))
+}
 
-	fmt.Println()
-	fmt.Printf("Please visit: %s\n", deviceResp.VerificationURI)
-	fmt.Printf("Enter code: %s\n", deviceResp.UserCode)
-	fmt.Println()
+// loginOptions selects how runLogin presents the device authorization.
+type loginOptions struct {
+	noBrowser  bool // print the URL instead of opening a browser
+	deviceCode bool // headless: print only the URL and code, then poll
+	jsonOutput bool // print the prompt and the result as JSON lines
+	force      bool
+	token      string // pre-issued access token; skips the device flow
+}
 
-	if !noBrowser && deviceResp.VerificationURIComplete != "" {
-		if err := openBrowser(deviceResp.VerificationURIComplete); err != nil {
-			fmt.Println("Could not open browser automatically.")
-			fmt.Println("Please visit the URL above manually.")
-		} else {
-			fmt.Println("Browser opened. Complete authorization in your browser.")
+// loginPrompt is the first line printed by 'login --json'.
+type loginPrompt struct {
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	UserCode                string `json:"user_code"`
+	ExpiresIn               int    `json:"expires_in"`
+}
+
+// loginResult is the last line printed by 'login --json'.
+type loginResult struct {
+	Status           string `json:"status"` // logged_in, already_logged_in or failed
+	DeviceRegistered bool   `json:"device_registered,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+func runLogin(w io.Writer, opts loginOptions) error {
+	// With --json, stdout carries only the JSON lines; progress goes to stderr.
+	info := w
+	if opts.jsonOutput {
+		info = os.Stderr
+	}
+
+	// Load config first: it selects the keyring backend.
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	creds, _ := auth.GetValidCredentials()
+	if creds != nil && !opts.force && opts.token == "" {
+		if opts.jsonOutput {
+			return writeJSONLine(w, loginResult{Status: "already_logged_in"})
 		}
+		fmt.Fprintln(w, "Already logged in.")
+		fmt.Fprintln(w, "Use 'intentra login --force' to re-authenticate.")
+		return nil
 	}
 
-	fmt.Println("Waiting for authorization...")
+	endpoint := cfg.Server.Endpoint
+	if endpoint == "" {
+		endpoint = config.DefaultAPIEndpoint
+	}
 
-	tokenResp, err := pollForToken(endpoint, deviceResp)
+	if opts.token != "" {
+		creds, err = tokenLogin(endpoint, opts.token)
+	} else {
+		creds, err = deviceLogin(w, endpoint, opts)
+	}
 	if err != nil {
-		return fmt.Errorf("authorization failed: %w", err)
+		return loginFailed(w, opts, err)
 	}
 
-	creds = auth.CredentialsFromTokenResponse(tokenResp)
 	if err := auth.StoreCredentialsInKeyring(creds); err != nil {
-		fmt.Printf("Warning: secure storage unavailable, using encrypted cache: %v\n", err)
+		fmt.Fprintf(info, "Warning: secure storage unavailable, using encrypted cache: %v\n", err)
 		if err := auth.WriteEncryptedCache(creds); err != nil {
-			return fmt.Errorf("failed to save credentials: %w", err)
+			return loginFailed(w, opts, fmt.Errorf("failed to save credentials: %w", err))
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("✓ Successfully logged in!")
+	fmt.Fprintln(info)
+	fmt.Fprintln(info, "✓ Successfully logged in!")
 
-	if err := registerMachine(endpoint, creds.AccessToken); err != nil {
-		fmt.Printf("\nWarning: failed to register device: %v\n", err)
-		fmt.Println("You can retry by running 'intentra login' again.")
+	registerErr := registerMachine(endpoint, creds.AccessToken)
+	if registerErr != nil {
+		fmt.Fprintf(info, "\nWarning: failed to register device: %v\n", registerErr)
+		fmt.Fprintln(info, "You can retry by running 'intentra login' again.")
 	} else {
-		fmt.Println("✓ Device registered")
+		fmt.Fprintln(info, "✓ Device registered")
 	}
 
 	// Flush any scans queued while unauthenticated
 	if pending := queue.PendingCount(); pending > 0 {
-		fmt.Printf("\nFound %d offline scan(s). Syncing...\n", pending)
+		fmt.Fprintf(info, "\nFound %d offline scan(s). Syncing...\n", pending)
 		queue.FlushWithJWT(creds.AccessToken)
 	}
 
-	fmt.Println()
-	fmt.Println("You can now use Intentra with server sync enabled.")
-	fmt.Println("Run 'intentra status' to see your account info.")
+	if opts.jsonOutput {
+		return writeJSONLine(w, loginResult{Status: "logged_in", DeviceRegistered: registerErr == nil})
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "You can now use Intentra with server sync enabled.")
+	fmt.Fprintln(w, "Run 'intentra status' to see your account info.")
 
 	return nil
 }
 
+// deviceLogin runs the device authorization flow and returns the issued
+// credentials.
+func deviceLogin(w io.Writer, endpoint string, opts loginOptions) (*auth.Credentials, error) {
+	if !opts.jsonOutput && !opts.deviceCode {
+		fmt.Fprintln(w, "Initiating device authorization...")
+	}
+
+	deviceResp, err := requestDeviceCode(endpoint)
+	if err != nil {
+		return nil, withExitCode(ExitCodeNetwork, fmt.Errorf("failed to initiate login: %w", err))
+	}
+
+	if err := showDevicePrompt(w, deviceResp, opts); err != nil {
+		return nil, err
+	}
+
+	tokenResp, err := pollForToken(endpoint, deviceResp)
+	if err != nil {
+		return nil, withExitCode(ExitCodeAuth, fmt.Errorf("authorization failed: %w", err))
+	}
+	return auth.CredentialsFromTokenResponse(tokenResp), nil
+}
+
+// tokenLogin checks a pre-issued access token against the server and
+// returns credentials for it, filled in with the account's email and user ID.
+func tokenLogin(endpoint, token string) (*auth.Credentials, error) {
+	creds := auth.CredentialsFromToken(token)
+	if creds.IsExpired() {
+		return nil, withExitCode(ExitCodeAuth, fmt.Errorf("token expired at %s", creds.ExpiresAt.Format(time.RFC3339)))
+	}
+
+	profile, err := fetchUserProfile(endpoint, token)
+	if err != nil {
+		return nil, withExitCode(ExitCodeAuth, fmt.Errorf("token rejected: %w", err))
+	}
+	creds.Email = profile.Email
+	creds.UserID = profile.UserID
+	return creds, nil
+}
+
+// showDevicePrompt tells the user where to authorize the device code: a
+// JSON line with --json, the bare URL and code with --device-code, and
+// otherwise instructions plus an attempt to open the browser.
+func showDevicePrompt(w io.Writer, d *auth.DeviceCodeResponse, opts loginOptions) error {
+	if opts.jsonOutput {
+		return writeJSONLine(w, loginPrompt{
+			VerificationURI:         d.VerificationURI,
+			VerificationURIComplete: d.VerificationURIComplete,
+			UserCode:                d.UserCode,
+			ExpiresIn:               d.ExpiresIn,
+		})
+	}
+	if opts.deviceCode {
+		fmt.Fprintf(w, "Visit: %s\n", d.VerificationURI)
+		fmt.Fprintf(w, "Code: %s\n", d.UserCode)
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Please visit: %s\n", d.VerificationURI)
+	fmt.Fprintf(w, "Enter code: %s\n", d.UserCode)
+	fmt.Fprintln(w)
+
+	if !opts.noBrowser && d.VerificationURIComplete != "" {
+		if err := openBrowser(d.VerificationURIComplete); err != nil {
+			fmt.Fprintln(w, "Could not open browser automatically.")
+			fmt.Fprintln(w, "Please visit the URL above manually.")
+		} else {
+			fmt.Fprintln(w, "Browser opened. Complete authorization in your browser.")
+		}
+	}
+
+	fmt.Fprintln(w, "Waiting for authorization...")
+	return nil
+}
+
+// loginFailed reports err as the final JSON line when --json is set, and
+// returns it either way.
+func loginFailed(w io.Writer, opts loginOptions, err error) error {
+	if opts.jsonOutput {
+		if writeErr := writeJSONLine(w, loginResult{Status: "failed", Error: err.Error()}); writeErr != nil {
+			return writeErr
+		}
+	}
+	return err
+}
+
+// writeJSONLine writes v as a single line of JSON.
+func writeJSONLine(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
 func runLogout() error {
+	if _, err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
 	creds, _ := auth.GetValidCredentials()
 	if creds == nil {
 		fmt.Println("You are not logged in.")
@@ -166,6 +614,11 @@ func runLogout() error {
 }
 
 func runStatus() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
 	creds, err := auth.LoadCredentialsFromKeyring()
 	if err != nil {
 		return fmt.Errorf("failed to load credentials: %w", err)
@@ -193,11 +646,6 @@ func runStatus() error {
 		creds = refreshed
 	}
 
-	cfg, err := loadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
 	endpoint := cfg.Server.Endpoint
 	if endpoint == "" {
 		endpoint = config.DefaultAPIEndpoint
@@ -236,6 +684,7 @@ func runStatus() error {
 }
 
 type userProfile struct {
+	UserID       string `json:"user_id"`
 	Email        string `json:"email"`
 	Name         string `json:"name"`
 	CurrentOrgID string `json:"current_org_id"`
@@ -328,7 +777,6 @@ func capitalizeFirst(s string) string {
 	return string(runes)
 }
 
-
 func requestDeviceCode(endpoint string) (*auth.DeviceCodeResponse, error) {
 	url := endpoint + "/oauth/device/code"
 
@@ -463,6 +911,10 @@ func registerMachine(endpoint, accessToken string) error {
 		"os":         metadata.Platform,
 		"hostname":   metadata.Hostname,
 	}
+	if metadata.HostApp != "" {
+		payload["host_app"] = metadata.HostApp
+		payload["host_app_version"] = metadata.HostAppVersion
+	}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
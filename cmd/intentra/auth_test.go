@@ -1,7 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/auth"
+	"github.com/intentrahq/intentra-cli/internal/config"
 )
 
 func init() {
@@ -51,3 +66,296 @@ func TestCapitalizeFirst(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintAuthCheck(t *testing.T) {
+	var buf bytes.Buffer
+	ok := &api.AuthCheckResult{Mode: "api_key", URL: "https://api.example.com/auth/verify", StatusCode: 200}
+	if err := printAuthCheck(&buf, ok); err != nil {
+		t.Errorf("printAuthCheck(200) returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "authenticated (200)") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	rejected := &api.AuthCheckResult{Mode: "api_key", URL: "https://api.example.com/auth/verify", StatusCode: 401, Body: "invalid signature\n"}
+	if err := printAuthCheck(&buf, rejected); err == nil {
+		t.Error("printAuthCheck(401) should return an error")
+	}
+	if !strings.Contains(buf.String(), "rejected (401)") || !strings.Contains(buf.String(), "invalid signature") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+// testServiceToken is a JWT accepted by newLoginServer's profile endpoint,
+// expiring at testServiceTokenExp.
+var (
+	testServiceTokenExp = time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	testServiceToken    = "eyJhbGciOiJIUzI1NiJ9." +
+		base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"svc-1","exp":%d}`, testServiceTokenExp.Unix()))) +
+		".sig"
+)
+
+// newLoginServer serves the device authorization endpoints, answering token
+// polls with tokenResp.
+func newLoginServer(t *testing.T, tokenResp string) {
+	t.Helper()
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_DEVICE_ID", "device-login")
+	t.Cleanup(config.InvalidateCache)
+
+	// TestMain points the keyring at a throwaway file backend shared by the
+	// whole package; start each login test logged out.
+	_ = auth.DeleteCredentialsFromKeyring()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/device/code":
+			_, _ = w.Write([]byte(`{"device_code":"dc-1","user_code":"ABCD-EFGH","verification_uri":"https://intentra.sh/device","verification_uri_complete":"https://intentra.sh/device?code=ABCD-EFGH","expires_in":600,"interval":1}`))
+		case "/oauth/token":
+			_, _ = w.Write([]byte(tokenResp))
+		case "/machines":
+			w.WriteHeader(http.StatusCreated)
+		case "/users/me":
+			if r.Header.Get("Authorization") != "Bearer "+testServiceToken {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"user":{"user_id":"svc-1","email":"ci@example.com"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	origServer := apiServer
+	apiServer = srv.URL
+	t.Cleanup(func() { apiServer = origServer })
+}
+
+func TestRunLogin_JSON(t *testing.T) {
+	newLoginServer(t, `{"access_token":"tok","refresh_token":"ref","token_type":"Bearer","expires_in":3600}`)
+
+	var buf bytes.Buffer
+	if err := runLogin(&buf, loginOptions{jsonOutput: true}); err != nil {
+		t.Fatalf("runLogin failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d:\n%s", len(lines), buf.String())
+	}
+	var prompt loginPrompt
+	if err := json.Unmarshal([]byte(lines[0]), &prompt); err != nil {
+		t.Fatalf("prompt line is not JSON: %v", err)
+	}
+	if prompt.VerificationURI != "https://intentra.sh/device" || prompt.UserCode != "ABCD-EFGH" || prompt.ExpiresIn != 600 {
+		t.Errorf("unexpected prompt: %+v", prompt)
+	}
+	var result loginResult
+	if err := json.Unmarshal([]byte(lines[1]), &result); err != nil {
+		t.Fatalf("result line is not JSON: %v", err)
+	}
+	if result.Status != "logged_in" || !result.DeviceRegistered {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunLogin_JSONFailure(t *testing.T) {
+	newLoginServer(t, `{"error":"access_denied"}`)
+
+	var buf bytes.Buffer
+	err := runLogin(&buf, loginOptions{jsonOutput: true})
+	if exitCode(err) != ExitCodeAuth {
+		t.Fatalf("exit code = %d (%v), want %d", exitCode(err), err, ExitCodeAuth)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var result loginResult
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &result); err != nil {
+		t.Fatalf("result line is not JSON: %v", err)
+	}
+	if result.Status != "failed" || !strings.Contains(result.Error, "denied") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunLogin_DeviceCode(t *testing.T) {
+	newLoginServer(t, `{"access_token":"tok","refresh_token":"ref","token_type":"Bearer","expires_in":3600}`)
+	opened := false
+	origLauncher := browserLauncher
+	browserLauncher = func(string) error { opened = true; return nil }
+	t.Cleanup(func() { browserLauncher = origLauncher })
+
+	var buf bytes.Buffer
+	if err := runLogin(&buf, loginOptions{deviceCode: true}); err != nil {
+		t.Fatalf("runLogin failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Visit: https://intentra.sh/device\nCode: ABCD-EFGH\n") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+	if opened {
+		t.Error("--device-code should not open a browser")
+	}
+}
+
+func TestRunLogin_Token(t *testing.T) {
+	newLoginServer(t, `{"error":"unused"}`)
+
+	var buf bytes.Buffer
+	if err := runLogin(&buf, loginOptions{token: testServiceToken, jsonOutput: true}); err != nil {
+		t.Fatalf("runLogin failed: %v", err)
+	}
+	var result loginResult
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &result); err != nil {
+		t.Fatalf("output is not a single JSON line: %v\n%s", err, buf.String())
+	}
+	if result.Status != "logged_in" || !result.DeviceRegistered {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	creds, err := auth.LoadCredentialsFromKeyring()
+	if err != nil || creds == nil {
+		t.Fatalf("LoadCredentialsFromKeyring = %v, %v", creds, err)
+	}
+	if creds.AccessToken != testServiceToken || creds.Email != "ci@example.com" || creds.UserID != "svc-1" {
+		t.Errorf("stored credentials = %+v", creds)
+	}
+	if !creds.ExpiresAt.Equal(testServiceTokenExp) {
+		t.Errorf("ExpiresAt = %v, want %v", creds.ExpiresAt, testServiceTokenExp)
+	}
+}
+
+func TestRunLogin_TokenRejected(t *testing.T) {
+	newLoginServer(t, `{"error":"unused"}`)
+
+	var buf bytes.Buffer
+	err := runLogin(&buf, loginOptions{token: "not-a-valid-token"})
+	if exitCode(err) != ExitCodeAuth {
+		t.Fatalf("exit code = %d (%v), want %d", exitCode(err), err, ExitCodeAuth)
+	}
+	if creds, _ := auth.LoadCredentialsFromKeyring(); creds != nil {
+		t.Errorf("rejected token was stored: %+v", creds)
+	}
+}
+
+func TestAuthTestCmd_NotLoggedIn(t *testing.T) {
+	newLoginServer(t, `{"error":"unused"}`)
+
+	var stdout, stderr bytes.Buffer
+	cmd := newAuthTestCmd()
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs(nil)
+	err := cmd.Execute()
+	if exitCode(err) != ExitCodeAuth {
+		t.Errorf("exit code = %d (%v), want %d", exitCode(err), err, ExitCodeAuth)
+	}
+	if !strings.Contains(stderr.String(), "intentra login") {
+		t.Errorf("stderr should explain how to log in, got %q", stderr.String())
+	}
+}
+
+func TestReadToken(t *testing.T) {
+	token, err := readToken(strings.NewReader("  abc.def.ghi\n"))
+	if err != nil || token != "abc.def.ghi" {
+		t.Errorf("readToken = %q, %v", token, err)
+	}
+	if _, err := readToken(strings.NewReader("\n")); err == nil {
+		t.Error("readToken should reject empty input")
+	}
+}
+
+func TestRunImport(t *testing.T) {
+	newLoginServer(t, `{"error":"unused"}`)
+
+	blob, err := auth.ExportCredentials(&auth.Credentials{
+		AccessToken:  "moved-token",
+		RefreshToken: "moved-refresh",
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		Email:        "user@example.com",
+	}, "correct horse")
+	if err != nil {
+		t.Fatalf("ExportCredentials failed: %v", err)
+	}
+
+	if err := runImport(io.Discard, blob, "wrong horse", false); exitCode(err) != ExitCodeAuth {
+		t.Errorf("wrong passphrase: exit code = %d (%v), want %d", exitCode(err), err, ExitCodeAuth)
+	}
+
+	var buf bytes.Buffer
+	if err := runImport(&buf, blob, "correct horse", false); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Device registered") {
+		t.Errorf("output should confirm registration:\n%s", buf.String())
+	}
+
+	creds, err := auth.LoadCredentialsFromKeyring()
+	if err != nil || creds == nil {
+		t.Fatalf("LoadCredentialsFromKeyring = %v, %v", creds, err)
+	}
+	if creds.AccessToken != "moved-token" || creds.Email != "user@example.com" {
+		t.Errorf("stored credentials = %+v", creds)
+	}
+
+	exported, err := exportCredentials("another horse")
+	if err != nil {
+		t.Fatalf("exportCredentials failed: %v", err)
+	}
+	if got, err := auth.ImportCredentials(exported, "another horse"); err != nil || got.AccessToken != "moved-token" {
+		t.Errorf("re-export round trip = %+v, %v", got, err)
+	}
+}
+
+func TestAuthExportCmd_Logout(t *testing.T) {
+	newLoginServer(t, `{"error":"unused"}`)
+	t.Setenv("INTENTRA_PASSPHRASE", "correct horse")
+	if err := auth.StoreCredentialsInKeyring(&auth.Credentials{
+		AccessToken:  "tok",
+		RefreshToken: "ref",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("StoreCredentialsInKeyring failed: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "creds.txt")
+	cmd := newAuthExportCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"-o", out, "--logout"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("auth export --logout failed: %v", err)
+	}
+	blob, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("export not written: %v", err)
+	}
+	if got, err := auth.ImportCredentials(strings.TrimSpace(string(blob)), "correct horse"); err != nil || got.RefreshToken != "ref" {
+		t.Errorf("exported credentials = %+v, %v", got, err)
+	}
+	if creds, _ := auth.LoadCredentialsFromKeyring(); creds != nil {
+		t.Errorf("credentials still stored after --logout: %+v", creds)
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	t.Setenv("INTENTRA_PASSPHRASE", "")
+	if _, err := readPassphrase(""); err == nil {
+		t.Error("readPassphrase should fail with no source")
+	}
+
+	t.Setenv("INTENTRA_PASSPHRASE", "from env")
+	if got, _ := readPassphrase(""); got != "from env" {
+		t.Errorf("readPassphrase from env = %q", got)
+	}
+
+	file := filepath.Join(t.TempDir(), "pass.txt")
+	if err := os.WriteFile(file, []byte("from file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := readPassphrase(file); got != "from file" {
+		t.Errorf("readPassphrase from file = %q", got)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// newCostCmd returns a cobra.Command for estimating the cost of ad-hoc token counts.
+func newCostCmd() *cobra.Command {
+	var model string
+	var tool string
+	var inputTokens int
+	var outputTokens int
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "cost",
+		Short:         "Estimate cost for a token count without a scan",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Estimate what a given number of input and output tokens would cost on a model,
+using the same pricing table applied to scans, including any overrides under
+local.pricing in the config file.
+
+Examples:
+  intentra cost --model claude-sonnet-4.5 --input 12000 --output 3000
+  intentra cost --model gpt-4o --input 5000 --output 800 --tool copilot
+  intentra cost --model gemini-2.5-pro --input 1000 --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if inputTokens < 0 || outputTokens < 0 {
+				return fmt.Errorf("token counts must not be negative")
+			}
+			if _, err := loadConfig(); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			b := scanner.EstimateCostBreakdown(inputTokens, outputTokens, model, tool)
+			return printCostBreakdown(os.Stdout, b, jsonOutput)
+		},
+	}
+
+	cmd.Flags().StringVar(&model, "model", "", "Model name (e.g., claude-sonnet-4.5, gpt-4o)")
+	cmd.Flags().StringVar(&tool, "tool", "", "AI tool for pricing multiplier (cursor, claude, gemini, copilot, windsurf)")
+	cmd.Flags().IntVar(&inputTokens, "input", 0, "Number of input tokens")
+	cmd.Flags().IntVar(&outputTokens, "output", 0, "Number of output tokens")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	_ = cmd.MarkFlagRequired("model")
+
+	return cmd
+}
+
+// printCostBreakdown writes a cost breakdown as a table or JSON.
+func printCostBreakdown(w io.Writer, b scanner.CostBreakdown, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cost breakdown: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	pricing := fmt.Sprintf("$%.6f per 1K tokens", b.PricePer1K)
+	if b.MatchedPrefix != "" {
+		pricing += fmt.Sprintf(" (matched %q)", b.MatchedPrefix)
+	} else {
+		pricing += " (default, model not recognized)"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Model:\t%s\n", b.Model)
+	if b.Tool != "" {
+		fmt.Fprintf(tw, "Tool:\t%s\n", b.Tool)
+	}
+	fmt.Fprintf(tw, "Pricing:\t%s\n", pricing)
+	fmt.Fprintf(tw, "Multiplier:\t%.2fx\n", b.Multiplier)
+	fmt.Fprintf(tw, "Input:\t%d tokens\t$%.4f\n", b.InputTokens, b.InputCost)
+	fmt.Fprintf(tw, "Output:\t%d tokens\t$%.4f\n", b.OutputTokens, b.OutputCost)
+	fmt.Fprintf(tw, "Total:\t%d tokens\t$%.4f\n", b.InputTokens+b.OutputTokens, b.TotalCost)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+	return nil
+}
+
+// newScanCostBreakdownCmd returns a cobra.Command that explains how a scan's
+// estimated cost was computed.
+func newScanCostBreakdownCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "cost-breakdown <id>",
+		Short:         "Explain how a scan's cost was estimated",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Recompute a scan's estimated cost step by step: tokens by type, the matched
+pricing prefix and its rate, the tool multiplier, the confidence band, and
+the share attributed to MCP tool calls.
+
+The cost is recomputed with the pricing currently in effect, including
+local.pricing overrides, and compared with the estimate recorded on the
+scan, so a pricing change can be checked against real scans.
+
+When server mode is enabled, the scan is fetched from the API; otherwise it
+is read from local files.
+
+Examples:
+  intentra scan cost-breakdown abc123
+  intentra scan cost-breakdown abc123 --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			scan, err := findScan(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			return printScanCostBreakdown(cmd.OutOrStdout(), scanner.ExplainScanCost(scan), jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// findScan fetches a scan from the server when server mode is enabled, and
+// from local files otherwise.
+func findScan(cfg *config.Config, scanID string) (*models.Scan, error) {
+	if cfg.Server.Enabled {
+		client, err := api.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create API client: %w", err)
+		}
+		resp, err := client.GetScan(scanID)
+		if err != nil {
+			return nil, apiExitCode(err)
+		}
+		return &resp.Scan, nil
+	}
+	scan, err := scanner.LoadScan(scanID)
+	if err != nil {
+		return nil, withExitCode(ExitCodeNotFound, fmt.Errorf("scan not found: %s", scanID))
+	}
+	return scan, nil
+}
+
+// printScanCostBreakdown writes a scan cost breakdown as a worked
+// calculation or JSON.
+func printScanCostBreakdown(w io.Writer, b scanner.ScanCostBreakdown, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal cost breakdown: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	model := b.Model
+	if b.DefaultModel {
+		model += " (scan names no model; tool default)"
+	}
+	pricing := fmt.Sprintf("$%.6f per 1K tokens", b.PricePer1K)
+	if b.MatchedPrefix != "" {
+		pricing += fmt.Sprintf(" (matched %q)", b.MatchedPrefix)
+	} else {
+		pricing += " (default, model not recognized)"
+	}
+	totalTokens := b.InputTokens + b.OutputTokens + b.ThinkingTokens + b.OtherTokens
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Scan:\t%s\n", b.ScanID)
+	fmt.Fprintf(tw, "Model:\t%s\n", model)
+	if b.Tool != "" {
+		fmt.Fprintf(tw, "Tool:\t%s\n", b.Tool)
+	}
+	fmt.Fprintf(tw, "Pricing:\t%s\n", pricing)
+	fmt.Fprintf(tw, "Multiplier:\t%.2fx\n", b.Multiplier)
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "Input:\t%d tokens\t$%.4f\n", b.InputTokens, b.InputCost)
+	fmt.Fprintf(tw, "Output:\t%d tokens\t$%.4f\n", b.OutputTokens, b.OutputCost)
+	fmt.Fprintf(tw, "Thinking:\t%d tokens\t$%.4f\n", b.ThinkingTokens, b.ThinkingCost)
+	if b.OtherTokens > 0 {
+		fmt.Fprintf(tw, "Untyped:\t%d tokens\t$%.4f\n", b.OtherTokens, b.OtherCost)
+	}
+	fmt.Fprintf(tw, "Total:\t%d tokens\t$%.4f\n", totalTokens, b.TotalCost)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	fmt.Fprintf(w, "\n%d tokens / 1000 × $%.6f × %.2f = $%.4f\n", totalTokens, b.PricePer1K, b.Multiplier, b.TotalCost)
+	fmt.Fprintf(w, "Range: $%.4f – $%.4f\n", b.CostLow, b.CostHigh)
+	if b.MCPTools > 0 {
+		share := 0.0
+		if b.RecordedCost > 0 {
+			share = b.MCPCost / b.RecordedCost * 100
+		}
+		fmt.Fprintf(w, "MCP tools: $%.4f (%.1f%% of recorded estimate) across %d tool(s)\n", b.MCPCost, share, b.MCPTools)
+	}
+
+	recorded := fmt.Sprintf("Recorded estimate: $%.4f", b.RecordedCost)
+	if diff := b.TotalCost - b.RecordedCost; diff > 0.00005 || diff < -0.00005 {
+		recorded += fmt.Sprintf(" (differs by %+.4f; pricing has changed since the scan)", diff)
+	}
+	fmt.Fprintln(w, recorded)
+	if b.ReportedCost > 0 {
+		fmt.Fprintf(w, "Tool-reported cost: $%.4f\n", b.ReportedCost)
+	}
+	return nil
+}
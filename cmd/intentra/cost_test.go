@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestPrintCostBreakdown_MatchesEstimator(t *testing.T) {
+	tests := []struct {
+		model  string
+		tool   string
+		input  int
+		output int
+	}{
+		{"claude-sonnet-4.5", "", 12000, 3000},
+		{"gpt-4o-2024-11-20", "copilot", 5000, 800},
+		{"gemini-2.5-pro", "", 1000, 0},
+		{"claude-opus-4.5", "windsurf", 2500, 2500},
+		{"some-unknown-model", "", 1000, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			b := scanner.EstimateCostBreakdown(tt.input, tt.output, tt.model, tt.tool)
+
+			var buf bytes.Buffer
+			if err := printCostBreakdown(&buf, b, false); err != nil {
+				t.Fatalf("printCostBreakdown failed: %v", err)
+			}
+
+			var want float64
+			if tt.tool != "" {
+				want = scanner.EstimateCost(tt.input+tt.output, tt.model, tt.tool)
+			} else {
+				want = scanner.EstimateCost(tt.input+tt.output, tt.model)
+			}
+			wantLine := fmt.Sprintf("%d tokens  $%.4f", tt.input+tt.output, want)
+			if !strings.Contains(buf.String(), wantLine) {
+				t.Errorf("output missing total %q:\n%s", wantLine, buf.String())
+			}
+		})
+	}
+}
+
+func TestPrintCostBreakdown_JSON(t *testing.T) {
+	b := scanner.EstimateCostBreakdown(1000, 500, "claude-sonnet-4.5", "windsurf")
+
+	var buf bytes.Buffer
+	if err := printCostBreakdown(&buf, b, true); err != nil {
+		t.Fatalf("printCostBreakdown failed: %v", err)
+	}
+
+	var got scanner.CostBreakdown
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	want := scanner.EstimateCost(1500, "claude-sonnet-4.5", "windsurf")
+	if diff := got.TotalCost - want; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("total_cost = %f, want %f", got.TotalCost, want)
+	}
+	if got.MatchedPrefix != "claude-sonnet-4.5" {
+		t.Errorf("matched_prefix = %q, want claude-sonnet-4.5", got.MatchedPrefix)
+	}
+}
+
+func TestScanCostBreakdownCmd(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Cleanup(config.InvalidateCache)
+
+	scan := &models.Scan{
+		ID: "scan-cost", Tool: "windsurf", Model: "claude-opus-4.5",
+		InputTokens: 4000, OutputTokens: 1500, ThinkingTokens: 500, TotalTokens: 6000,
+		MCPToolUsage: []models.MCPToolCall{{ServerName: "gh", ToolName: "search", CallCount: 2, EstimatedCost: 0.01}},
+	}
+	scan.EstimatedCost = scanner.EstimateCost(scan.TotalTokens, scan.Model, scan.Tool)
+	if err := scanner.SaveScan(scan); err != nil {
+		t.Fatalf("SaveScan failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := newScanCostBreakdownCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"scan-cost", "--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan cost-breakdown failed: %v", err)
+	}
+	var b scanner.ScanCostBreakdown
+	if err := json.Unmarshal(out.Bytes(), &b); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if got := float64(scan.TotalTokens) / 1000 * b.PricePer1K * b.Multiplier; fmt.Sprintf("%.9f", got) != fmt.Sprintf("%.9f", scan.EstimatedCost) {
+		t.Errorf("tokens × $%f × %.2f = %f, want recorded cost %f", b.PricePer1K, b.Multiplier, got, scan.EstimatedCost)
+	}
+	if sum := b.InputCost + b.OutputCost + b.ThinkingCost; fmt.Sprintf("%.9f", sum) != fmt.Sprintf("%.9f", b.TotalCost) {
+		t.Errorf("per-type costs sum to %f, want %f", sum, b.TotalCost)
+	}
+
+	out.Reset()
+	cmd = newScanCostBreakdownCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"scan-cost"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan cost-breakdown failed: %v", err)
+	}
+	for _, want := range []string{`matched "claude-opus-4.5"`, "Thinking:", "MCP tools: $0.0100", "Recorded estimate:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "differs by") {
+		t.Errorf("recomputed cost should match the recorded estimate:\n%s", out.String())
+	}
+
+	cmd = newScanCostBreakdownCmd()
+	cmd.SetArgs([]string{"missing-scan"})
+	if err := cmd.Execute(); exitCode(err) != ExitCodeNotFound {
+		t.Errorf("exit code = %d (%v), want %d", exitCode(err), err, ExitCodeNotFound)
+	}
+}
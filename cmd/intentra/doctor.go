@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/auth"
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+// Doctor check outcomes. Only failures make the command exit non-zero.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorCheck is one row of the doctor report.
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// newDoctorCmd returns a cobra.Command that diagnoses installation problems.
+func newDoctorCmd() *cobra.Command {
+	var fixPerms, clearUnknown bool
+
+	cmd := &cobra.Command{
+		Use:           "doctor",
+		Short:         "Diagnose installation problems",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Check that intentra is installed and able to sync:
+  - each tool's config directory exists and is writable
+  - intentra hooks are present and point to this executable
+  - credentials are valid (intentra login or api_key config)
+  - the config directory and credential files are private to you
+  - the server endpoint responds to a health check
+  - no hook event types went unrecognized (with local.warn_unknown_events)
+
+Prints a pass/warn/fail table and exits non-zero if any check fails.
+Warnings (e.g. a tool that is not installed) do not affect the exit code.
+With --fix-perms, loose permissions are tightened (0700 for the config
+directory, 0600 for config and credential files). With
+--clear-unknown-events, the record of unrecognized event types is reset
+before the checks run.
+
+Examples:
+  intentra doctor
+  intentra doctor --fix-perms
+  intentra doctor --clear-unknown-events`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if clearUnknown {
+				if err := hooks.ClearUnknownEvents(); err != nil {
+					return err
+				}
+			}
+
+			exe, _ := hooks.CurrentExecutable()
+			var checks []doctorCheck
+			for _, tool := range hooks.AllTools() {
+				checks = append(checks, toolChecks(hooks.Diagnose(tool), exe)...)
+			}
+			checks = append(checks, credentialsCheck(cfg), permissionsCheck(fixPerms), serverCheck(cfg))
+			if c, ok := unknownEventsCheck(cfg); ok {
+				checks = append(checks, c)
+			}
+
+			return printDoctorReport(cmd.OutOrStdout(), checks)
+		},
+	}
+
+	cmd.Flags().BoolVar(&fixPerms, "fix-perms", false, "Tighten permissions of the config directory and credential files")
+	cmd.Flags().BoolVar(&clearUnknown, "clear-unknown-events", false, "Reset the record of unrecognized hook event types")
+
+	return cmd
+}
+
+// resolveHandler resolves a handler path from a hook command; swapped out in tests.
+var resolveHandler = hooks.ResolveHandler
+
+// toolChecks turns a tool diagnosis into config-directory and hook checks.
+// A tool that is not installed is a warning, not a failure.
+func toolChecks(d hooks.ToolDiagnosis, exe string) []doctorCheck {
+	dirCheck := doctorCheck{Name: string(d.Tool) + " config dir"}
+	switch {
+	case d.Dir == "":
+		dirCheck.Status, dirCheck.Detail = checkFail, fmt.Sprintf("cannot locate: %v", d.Error)
+		return []doctorCheck{dirCheck}
+	case !d.DirExists:
+		dirCheck.Status, dirCheck.Detail = checkWarn, d.Dir+" not found (tool not installed?)"
+		return []doctorCheck{dirCheck}
+	case !d.DirWritable:
+		dirCheck.Status, dirCheck.Detail = checkFail, d.Dir+" is not writable"
+	default:
+		dirCheck.Status, dirCheck.Detail = checkPass, d.Dir
+	}
+
+	hookCheck := doctorCheck{Name: string(d.Tool) + " hooks"}
+	switch {
+	case d.Error != nil:
+		hookCheck.Status, hookCheck.Detail = checkFail, d.Error.Error()
+	case !d.Installed:
+		hookCheck.Status, hookCheck.Detail = checkWarn, "not installed (run 'intentra install "+string(d.Tool)+"')"
+	case len(d.HandlerPaths) == 0:
+		hookCheck.Status, hookCheck.Detail = checkWarn, "no intentra hook commands found"
+	default:
+		hookCheck.Status, hookCheck.Detail = handlerStatus(d.HandlerPaths, exe)
+	}
+	return []doctorCheck{dirCheck, hookCheck}
+}
+
+// handlerStatus checks that every referenced handler resolves, and warns when
+// one resolves to a different binary than the one running.
+func handlerStatus(paths []string, exe string) (string, string) {
+	status, details := checkPass, []string(nil)
+	for _, p := range paths {
+		resolved, err := resolveHandler(p)
+		if err != nil {
+			return checkFail, fmt.Sprintf("handler %s not found", p)
+		}
+		if exe != "" && resolved != exe {
+			status = checkWarn
+			details = append(details, fmt.Sprintf("%s resolves to %s, not %s", p, resolved, exe))
+			continue
+		}
+		details = append(details, resolved)
+	}
+	return status, strings.Join(details, "; ")
+}
+
+// credentialsCheck verifies stored JWT credentials, falling back to api_key
+// config. Having neither is a warning since scans are queued locally.
+func credentialsCheck(cfg *config.Config) doctorCheck {
+	c := doctorCheck{Name: "credentials"}
+	creds, err := auth.GetValidCredentials()
+	switch {
+	case err != nil:
+		c.Status, c.Detail = checkFail, err.Error()
+	case creds != nil:
+		c.Status, c.Detail = checkPass, "logged in"
+		if creds.Email != "" {
+			c.Detail += " as " + creds.Email
+		}
+	case cfg.Server.Auth.Mode == config.AuthModeAPIKey:
+		c.Status, c.Detail = checkPass, "api_key configured (key_id "+cfg.Server.Auth.APIKey.KeyID+")"
+	default:
+		c.Status, c.Detail = checkWarn, "not logged in; scans are queued locally (run 'intentra login')"
+	}
+	return c
+}
+
+// permissionsCheck warns when the config directory or credential files are
+// accessible to other users, tightening them first when fix is set.
+func permissionsCheck(fix bool) doctorCheck {
+	c := doctorCheck{Name: "permissions"}
+	issues, err := config.CheckPermissions()
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		return c
+	}
+	if len(issues) == 0 {
+		c.Status, c.Detail = checkPass, "config directory and credentials are private"
+		return c
+	}
+	if fix {
+		if err := config.FixPermissions(issues); err != nil {
+			c.Status, c.Detail = checkFail, err.Error()
+			return c
+		}
+		c.Status, c.Detail = checkPass, fmt.Sprintf("fixed %d path(s)", len(issues))
+		return c
+	}
+
+	details := make([]string, len(issues))
+	for i, issue := range issues {
+		details[i] = issue.String()
+	}
+	c.Status, c.Detail = checkWarn, strings.Join(details, "; ")+" (run 'intentra doctor --fix-perms')"
+	return c
+}
+
+// serverCheck runs a health check against the configured endpoint, or the
+// default API endpoint when none is configured.
+func serverCheck(cfg *config.Config) doctorCheck {
+	testCfg := *cfg
+	testCfg.Server.Enabled = true
+	if testCfg.Server.Endpoint == "" {
+		testCfg.Server.Endpoint = config.DefaultAPIEndpoint
+	}
+
+	c := doctorCheck{Name: "server"}
+	client, err := api.NewClient(&testCfg)
+	if err == nil {
+		err = client.Health()
+	}
+	if err != nil {
+		c.Status, c.Detail = checkFail, fmt.Sprintf("%s: %v", testCfg.Server.Endpoint, err)
+	} else {
+		c.Status, c.Detail = checkPass, testCfg.Server.Endpoint+" is reachable"
+	}
+	return c
+}
+
+// maxUnknownEventsListed caps how many unmapped event types the doctor names.
+const maxUnknownEventsListed = 5
+
+// unknownEventsCheck warns about hook event types no normalizer maps, as
+// recorded while local.warn_unknown_events is enabled. It is skipped when the
+// setting is off and nothing was recorded.
+func unknownEventsCheck(cfg *config.Config) (doctorCheck, bool) {
+	c := doctorCheck{Name: "event types"}
+	events, err := hooks.UnknownEvents()
+	switch {
+	case err != nil:
+		c.Status, c.Detail = checkWarn, err.Error()
+	case len(events) > 0:
+		var names []string
+		for i, e := range events {
+			if i == maxUnknownEventsListed {
+				names = append(names, fmt.Sprintf("%d more", len(events)-i))
+				break
+			}
+			names = append(names, fmt.Sprintf("%s/%s (%d)", e.Tool, e.EventType, e.Count))
+		}
+		c.Status, c.Detail = checkWarn, "unrecognized: "+strings.Join(names, ", ")+"; please report these as missing mappings"
+	case !cfg.Local.WarnUnknownEvents:
+		return c, false
+	default:
+		c.Status, c.Detail = checkPass, "all hook event types recognized"
+	}
+	return c, true
+}
+
+// printDoctorReport writes the check table and returns an error if any
+// check failed.
+func printDoctorReport(w io.Writer, checks []doctorCheck) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	failed := 0
+	for _, c := range checks {
+		mark := "✓"
+		switch c.Status {
+		case checkWarn:
+			mark = "!"
+		case checkFail:
+			mark = "✗"
+			failed++
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", mark, c.Name, c.Status, c.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	fmt.Fprintln(w, "\nNo problems found.")
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/hooks"
+)
+
+func stubResolveHandler(t *testing.T, paths map[string]string) {
+	t.Helper()
+	orig := resolveHandler
+	resolveHandler = func(p string) (string, error) {
+		if r, ok := paths[p]; ok {
+			return r, nil
+		}
+		return "", errors.New("not found")
+	}
+	t.Cleanup(func() { resolveHandler = orig })
+}
+
+func TestToolChecks(t *testing.T) {
+	stubResolveHandler(t, map[string]string{
+		"intentra":      "/usr/local/bin/intentra",
+		"/old/intentra": "/old/intentra",
+	})
+	exe := "/usr/local/bin/intentra"
+
+	tests := []struct {
+		name      string
+		diag      hooks.ToolDiagnosis
+		wantDir   string
+		wantHooks string
+	}{
+		{"missing dir", hooks.ToolDiagnosis{Tool: "cursor", Dir: "/h/.cursor"}, checkWarn, ""},
+		{"unwritable dir", hooks.ToolDiagnosis{Tool: "cursor", Dir: "/h/.cursor", DirExists: true}, checkFail, checkWarn},
+		{"not installed", hooks.ToolDiagnosis{Tool: "cursor", Dir: "/h/.cursor", DirExists: true, DirWritable: true}, checkPass, checkWarn},
+		{"current handler", hooks.ToolDiagnosis{Tool: "cursor", Dir: "/h/.cursor", DirExists: true, DirWritable: true, Installed: true, HandlerPaths: []string{"intentra"}}, checkPass, checkPass},
+		{"other binary", hooks.ToolDiagnosis{Tool: "cursor", Dir: "/h/.cursor", DirExists: true, DirWritable: true, Installed: true, HandlerPaths: []string{"/old/intentra"}}, checkPass, checkWarn},
+		{"missing handler", hooks.ToolDiagnosis{Tool: "cursor", Dir: "/h/.cursor", DirExists: true, DirWritable: true, Installed: true, HandlerPaths: []string{"/gone/intentra"}}, checkPass, checkFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := toolChecks(tt.diag, exe)
+			if checks[0].Status != tt.wantDir {
+				t.Errorf("dir status = %s, want %s (%s)", checks[0].Status, tt.wantDir, checks[0].Detail)
+			}
+			if tt.wantHooks == "" {
+				if len(checks) != 1 {
+					t.Errorf("expected only a dir check, got %+v", checks)
+				}
+				return
+			}
+			if len(checks) != 2 || checks[1].Status != tt.wantHooks {
+				t.Errorf("hook check = %+v, want status %s", checks, tt.wantHooks)
+			}
+		})
+	}
+}
+
+func TestPrintDoctorReport(t *testing.T) {
+	var buf bytes.Buffer
+	checks := []doctorCheck{
+		{Name: "claude hooks", Status: checkPass, Detail: "/usr/local/bin/intentra"},
+		{Name: "cursor config dir", Status: checkWarn, Detail: "not found"},
+	}
+	if err := printDoctorReport(&buf, checks); err != nil {
+		t.Errorf("warnings only should not fail: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No problems found") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	checks = append(checks, doctorCheck{Name: "server", Status: checkFail, Detail: "connection refused"})
+	err := printDoctorReport(&buf, checks)
+	if err == nil || !strings.Contains(err.Error(), "1 check(s) failed") {
+		t.Errorf("expected failure error, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "✗ server") {
+		t.Errorf("expected failed row in output: %q", buf.String())
+	}
+}
+
+func TestPermissionsCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not checked on Windows")
+	}
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+	os.Chmod(dir, 0700)
+	cfgPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(cfgPath, []byte("debug: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(cfgPath, 0644)
+
+	c := permissionsCheck(false)
+	if c.Status != checkWarn || !strings.Contains(c.Detail, "config.yaml is 0644") || !strings.Contains(c.Detail, "--fix-perms") {
+		t.Errorf("check = %+v, want warning about config.yaml", c)
+	}
+
+	if c := permissionsCheck(true); c.Status != checkPass || !strings.Contains(c.Detail, "fixed 1") {
+		t.Errorf("fix check = %+v, want fixed 1 path", c)
+	}
+	if c := permissionsCheck(false); c.Status != checkPass {
+		t.Errorf("check after fix = %+v, want pass", c)
+	}
+}
+
+func TestUnknownEventsCheck(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+	cfg := config.DefaultConfig()
+
+	if _, ok := unknownEventsCheck(cfg); ok {
+		t.Error("check should be skipped when disabled and nothing is recorded")
+	}
+	cfg.Local.WarnUnknownEvents = true
+	if c, ok := unknownEventsCheck(cfg); !ok || c.Status != checkPass {
+		t.Errorf("check = %+v, %v; want pass", c, ok)
+	}
+
+	recorded := `[{"tool":"cursor","event_type":"beforeFoo","count":1},{"tool":"claude","event_type":"MysteryHook","count":4}]`
+	if err := os.WriteFile(filepath.Join(dir, "unknown_events.json"), []byte(recorded), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Local.WarnUnknownEvents = false
+	c, ok := unknownEventsCheck(cfg)
+	if !ok || c.Status != checkWarn {
+		t.Fatalf("check = %+v, %v; want warn", c, ok)
+	}
+	if !strings.Contains(c.Detail, "claude/MysteryHook (4), cursor/beforeFoo (1)") {
+		t.Errorf("detail = %q, want events listed most frequent first", c.Detail)
+	}
+}
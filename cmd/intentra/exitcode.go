@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+)
+
+// Exit codes returned by the CLI, so scripts can branch on the kind of
+// failure. Errors without an ExitError exit with ExitCodeError.
+const (
+	ExitCodeError    = 1 // unclassified failure
+	ExitCodeConfig   = 2 // config could not be loaded or failed validation
+	ExitCodeAuth     = 3 // not logged in, or credentials were rejected
+	ExitCodeNetwork  = 4 // server unreachable or returned an error
+	ExitCodeNotFound = 5 // the requested scan or resource does not exist
+)
+
+// ExitError is an error that selects the process exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// withExitCode wraps err so the process exits with code. It returns nil for
+// a nil err and leaves an error that already carries a code unchanged.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// apiExitCode wraps an error from the API client with the exit code for its
+// cause: rejected credentials, a missing resource, or otherwise a network or
+// server failure.
+func apiExitCode(err error) error {
+	switch {
+	case errors.Is(err, api.ErrUnauthorized), errors.Is(err, api.ErrNotAuthenticated):
+		return withExitCode(ExitCodeAuth, err)
+	case errors.Is(err, api.ErrNotFound):
+		return withExitCode(ExitCodeNotFound, err)
+	default:
+		return withExitCode(ExitCodeNetwork, err)
+	}
+}
+
+// exitCode returns the process exit code for an error returned by a command.
+func exitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitCodeError
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/config"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"plain error", errors.New("boom"), ExitCodeError},
+		{"config", withExitCode(ExitCodeConfig, errors.New("bad yaml")), ExitCodeConfig},
+		{"wrapped", fmt.Errorf("failed to load config: %w", withExitCode(ExitCodeConfig, errors.New("bad yaml"))), ExitCodeConfig},
+		{"inner code kept", withExitCode(ExitCodeNetwork, fmt.Errorf("x: %w", withExitCode(ExitCodeConfig, errors.New("y")))), ExitCodeConfig},
+		{"api unauthorized", apiExitCode(fmt.Errorf("fetch: %w", api.ErrUnauthorized)), ExitCodeAuth},
+		{"api not authenticated", apiExitCode(api.ErrNotAuthenticated), ExitCodeAuth},
+		{"api not found", apiExitCode(fmt.Errorf("scan abc: %w", api.ErrNotFound)), ExitCodeNotFound},
+		{"api other", apiExitCode(errors.New("API returned 500")), ExitCodeNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if withExitCode(ExitCodeAuth, nil) != nil {
+		t.Error("withExitCode(nil) should be nil")
+	}
+}
+
+func TestScanShow_NotFoundExitCode(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Cleanup(config.InvalidateCache)
+
+	cmd := newScanShowCmd()
+	cmd.SetArgs([]string{"does-not-exist"})
+	err := cmd.Execute()
+	if got := exitCode(err); got != ExitCodeNotFound {
+		t.Errorf("exit code = %d (err %v), want %d", got, err, ExitCodeNotFound)
+	}
+}
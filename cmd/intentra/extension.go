@@ -38,6 +38,10 @@ func newExtensionInfoCmd() *cobra.Command {
 }
 
 func runExtensionInfo(jsonOutput bool) error {
+	if _, err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
 	creds, _ := auth.GetValidCredentials()
 	authenticated := creds != nil
 
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/intentrahq/intentra-cli/internal/config"
@@ -15,11 +18,43 @@ func newHooksCmd() *cobra.Command {
 		Short: "Check hook installation status",
 	}
 
-	cmd.AddCommand(newHooksStatusCmd())
+	cmd.AddCommand(newHooksStatusCmd(), newHooksVerifyCmd(), newHooksTestCmd(), newHooksManifestCmd())
 
 	return cmd
 }
 
+// newHooksManifestCmd returns a cobra.Command that prints the install
+// manifest.
+func newHooksManifestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "manifest",
+		Short:         "Print the record of installed hooks as JSON",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Print the install manifest (~/.intentra/install_manifest.json) as JSON.
+For each tool with intentra hooks, it records the config file that was
+changed, the exact hook commands written, when they were installed, and the
+intentra version that installed them. 'intentra uninstall' removes a tool's
+entry. With no hooks installed the tools object is empty.
+
+Examples:
+  intentra hooks manifest
+  intentra hooks manifest | jq '.tools | keys'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := hooks.ReadInstallManifest()
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(m, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal install manifest: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		},
+	}
+}
+
 func saveAPIConfig(server, keyID, secret string) error {
 	cfg, err := config.Load()
 	if err != nil {
@@ -42,6 +77,7 @@ func newHooksStatusCmd() *cobra.Command {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			loadInstallConfig()
 			statuses := hooks.Status()
 
 			fmt.Println("Hook Installation Status:")
@@ -65,3 +101,201 @@ func newHooksStatusCmd() *cobra.Command {
 		},
 	}
 }
+
+func newHooksVerifyCmd() *cobra.Command {
+	var repair bool
+
+	cmd := &cobra.Command{
+		Use:           "verify",
+		Short:         "Check that installed hooks run this executable",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Read each tool's hook config and check that the handler referenced by
+intentra's hook commands resolves to this executable. Hooks left pointing at
+an old path after an upgrade or move silently stop capturing events.
+
+With --repair, hooks for tools with stale handlers are reinstalled pointing at
+this executable (existing non-intentra hooks are kept).
+
+Examples:
+  intentra hooks verify
+  intentra hooks verify --repair`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exe, err := hooks.CurrentExecutable()
+			if err != nil {
+				return err
+			}
+			statuses, err := hooks.VerifyHandlerPaths()
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			stale := printHandlerStatuses(w, statuses, exe)
+			if len(stale) == 0 {
+				return nil
+			}
+			if !repair {
+				return fmt.Errorf("%d tool(s) have stale hooks; run 'intentra hooks verify --repair'", len(stale))
+			}
+
+			handlerPath := hooks.RepairHandlerPath(exe, hooks.ResolveHandler)
+			var failed int
+			for _, tool := range stale {
+				if err := hooks.InstallWithOptions(tool, handlerPath, hooks.InstallOptions{Version: version}); err != nil {
+					fmt.Fprintf(w, "✗ Failed to repair %s: %v\n", tool, err)
+					failed++
+					continue
+				}
+				fmt.Fprintf(w, "✓ Repaired %s hooks (handler: %s)\n", tool, handlerPath)
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to repair %d tool(s)", failed)
+			}
+			fmt.Fprintln(w, "\nPlease restart your AI tools for changes to take effect.")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&repair, "repair", false, "Reinstall hooks whose handler path is stale")
+
+	return cmd
+}
+
+// newHooksTestCmd returns a cobra.Command that previews how a sample hook
+// payload is normalized.
+func newHooksTestCmd() *cobra.Command {
+	var tool string
+	var event string
+	var file string
+
+	cmd := &cobra.Command{
+		Use:           "test",
+		Short:         "Show how a sample hook event would be processed",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Run a sample hook payload through the same normalization as the hook
+handler and print the normalized event, the session key and buffer file it
+would land in, and whether it would end a scan. Nothing is buffered or sent.
+
+The payload is read from --file, or from stdin when --file is omitted or "-".
+
+Examples:
+  intentra hooks test --tool cursor --event afterAgentResponse --file sample.json
+  echo '{"session_id":"s1","prompt":"hi"}' | intentra hooks test --tool claude --event UserPromptSubmit`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isKnownTool(tool) {
+				return fmt.Errorf("unknown tool: %s (supported: %s)", tool, joinTools(hooks.AllTools()))
+			}
+
+			var data []byte
+			var err error
+			if file == "" || file == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read event: %w", err)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			preview, err := hooks.PreviewEvent(data, tool, event, cfg)
+			if err != nil {
+				return fmt.Errorf("failed to normalize event: %w", err)
+			}
+			return printEventPreview(cmd.OutOrStdout(), preview)
+		},
+	}
+
+	cmd.Flags().StringVar(&tool, "tool", "", "Tool that sent the event (cursor, claude, gemini, copilot, windsurf)")
+	cmd.Flags().StringVar(&event, "event", "", "Native hook event name (e.g. afterAgentResponse, PostToolUse)")
+	cmd.Flags().StringVar(&file, "file", "", "JSON payload file (default: stdin)")
+	_ = cmd.MarkFlagRequired("tool")
+	_ = cmd.MarkFlagRequired("event")
+
+	return cmd
+}
+
+func isKnownTool(name string) bool {
+	for _, t := range hooks.AllTools() {
+		if string(t) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func joinTools(tools []hooks.Tool) string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// printEventPreview writes where an event would go, followed by the
+// normalized event as JSON.
+func printEventPreview(w io.Writer, p *hooks.EventPreview) error {
+	fmt.Fprintf(w, "Normalized type: %s\n", p.NormalizedType)
+	fmt.Fprintf(w, "Tool:            %s\n", p.Tool)
+	if p.SessionKey != "" {
+		fmt.Fprintf(w, "Session key:     %s\n", p.SessionKey)
+		fmt.Fprintf(w, "Buffer:          %s\n", p.BufferPath)
+	}
+	switch p.Action {
+	case hooks.PreviewActionEndScan:
+		fmt.Fprintln(w, "Action:          ends the scan (buffer is aggregated and sent)")
+	case hooks.PreviewActionSessionEnd:
+		fmt.Fprintln(w, "Action:          adds session-end metadata to the last scan")
+	default:
+		fmt.Fprintln(w, "Action:          buffered until the scan ends")
+	}
+	for _, note := range p.Notes {
+		fmt.Fprintf(w, "Note:            %s\n", note)
+	}
+
+	data, err := json.MarshalIndent(p.Event, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	fmt.Fprintf(w, "\nEvent:\n%s\n", data)
+	return nil
+}
+
+// printHandlerStatuses writes one line per referenced handler and returns the
+// tools with stale handlers.
+func printHandlerStatuses(w io.Writer, statuses []hooks.HandlerPathStatus, exe string) []hooks.Tool {
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "No intentra hooks installed.")
+		return nil
+	}
+	for _, st := range statuses {
+		switch {
+		case !st.Stale:
+			fmt.Fprintf(w, "✓ %-10s %s\n", st.Tool, st.Path)
+		case st.Resolved == "":
+			fmt.Fprintf(w, "✗ %-10s %s not found\n", st.Tool, st.Path)
+		default:
+			fmt.Fprintf(w, "✗ %-10s %s resolves to %s, not %s\n", st.Tool, st.Path, st.Resolved, exe)
+		}
+	}
+	return hooks.StaleTools(statuses)
+}
+
+// warnStaleHandlers prints a warning after install when the installed hooks
+// still would not run this executable (e.g. another intentra earlier on PATH).
+func warnStaleHandlers() {
+	statuses, err := hooks.VerifyHandlerPaths()
+	if err != nil {
+		return
+	}
+	if stale := hooks.StaleTools(statuses); len(stale) > 0 {
+		fmt.Fprintf(os.Stderr, "\nWarning: hooks for %d tool(s) do not resolve to this executable.\n", len(stale))
+		fmt.Fprintln(os.Stderr, "Run 'intentra hooks verify' for details or 'intentra hooks verify --repair' to fix.")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/hooks"
+)
+
+func TestPrintHandlerStatuses(t *testing.T) {
+	var buf bytes.Buffer
+	if stale := printHandlerStatuses(&buf, nil, "/bin/intentra"); stale != nil {
+		t.Errorf("expected no stale tools, got %v", stale)
+	}
+	if !strings.Contains(buf.String(), "No intentra hooks installed") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	statuses := []hooks.HandlerPathStatus{
+		{Tool: hooks.ToolClaudeCode, Path: "intentra", Resolved: "/bin/intentra"},
+		{Tool: hooks.ToolCursor, Path: "/old/intentra", Stale: true},
+		{Tool: hooks.ToolCopilot, Path: "intentra", Resolved: "/usr/bin/intentra", Stale: true},
+	}
+	stale := printHandlerStatuses(&buf, statuses, "/bin/intentra")
+	if len(stale) != 2 {
+		t.Errorf("stale = %v, want cursor and copilot", stale)
+	}
+	out := buf.String()
+	for _, want := range []string{"/old/intentra not found", "resolves to /usr/bin/intentra, not /bin/intentra"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q: %q", want, out)
+		}
+	}
+}
+
+func TestHooksTestCmd(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+
+	var buf bytes.Buffer
+	cmd := newHooksTestCmd()
+	cmd.SetOut(&buf)
+	cmd.SetIn(strings.NewReader(`{"conversation_id":"c1","text":"done"}`))
+	cmd.SetArgs([]string{"--tool", "cursor", "--event", "afterAgentResponse"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("hooks test failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"Normalized type: after_response",
+		"Session key:     cursor_c1",
+		"Action:          buffered until the scan ends",
+		`"conversation_id": "c1"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	cmd = newHooksTestCmd()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--tool", "vim", "--event", "stop"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+		t.Errorf("error = %v, want unknown tool", err)
+	}
+}
+
+func TestHooksManifestCmd(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_CLAUDE_DIR", t.TempDir())
+
+	manifest := func() hooks.InstallManifest {
+		t.Helper()
+		var buf bytes.Buffer
+		cmd := newHooksManifestCmd()
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("hooks manifest failed: %v", err)
+		}
+		var m hooks.InstallManifest
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+		}
+		return m
+	}
+
+	if m := manifest(); len(m.Tools) != 0 {
+		t.Errorf("manifest before install = %+v, want no tools", m)
+	}
+
+	if err := hooks.InstallWithOptions(hooks.ToolClaudeCode, "intentra", hooks.InstallOptions{Version: "9.9.9"}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	entry, ok := manifest().Tools[hooks.ToolClaudeCode]
+	if !ok || entry.IntentraVersion != "9.9.9" || !strings.HasSuffix(entry.ConfigFile, "settings.json") {
+		t.Errorf("claude entry = %+v (present %v)", entry, ok)
+	}
+
+	if err := hooks.Uninstall(hooks.ToolClaudeCode); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	if m := manifest(); len(m.Tools) != 0 {
+		t.Errorf("manifest after uninstall = %+v, want no tools", m)
+	}
+}
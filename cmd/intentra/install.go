@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/intentrahq/intentra-cli/internal/debug"
 	"github.com/intentrahq/intentra-cli/internal/hooks"
 	"github.com/spf13/cobra"
 )
 
 func newInstallCmd() *cobra.Command {
+	var order string
+
 	cmd := &cobra.Command{
 		Use:           "install [tool]",
 		Short:         "Install hooks for AI tools",
@@ -25,9 +28,17 @@ func newInstallCmd() *cobra.Command {
 Examples:
   intentra install           # Install for all tools
   intentra install cursor    # Install for Cursor only
-  intentra install claude    # Install for Claude Code only`,
+  intentra install claude    # Install for Claude Code only
+  intentra install --order first  # Run intentra before other hooks`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			hookOrder, err := hooks.ParseHookOrder(order)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return err
+			}
+			opts := hooks.InstallOptions{Order: hookOrder, Version: version}
+
 			if apiServer != "" && apiKeyID != "" && apiSecret != "" {
 				if err := saveAPIConfig(apiServer, apiKeyID, apiSecret); err != nil {
 					fmt.Fprintf(os.Stderr, "Error: failed to save config: %v\n", err)
@@ -36,6 +47,8 @@ Examples:
 				fmt.Println("✓ Saved API configuration")
 			}
 
+			loadInstallConfig()
+
 			execPath := "intentra"
 
 			tool := "all"
@@ -44,7 +57,7 @@ Examples:
 			}
 
 			if tool == "all" {
-				results := hooks.InstallAll(execPath)
+				results := hooks.InstallAllWithOptions(execPath, opts)
 				var errors []string
 				for t, err := range results {
 					if err != nil {
@@ -59,22 +72,26 @@ Examples:
 						fmt.Printf("  ✗ %s\n", e)
 					}
 				}
+				warnStaleHandlers()
 				fmt.Println("\nPlease restart your AI tools for hooks to take effect.")
 				return nil
 			}
 
 			t := hooks.Tool(tool)
-			if err := hooks.Install(t, execPath); err != nil {
+			if err := hooks.InstallWithOptions(t, execPath, opts); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				return err
 			}
 
 			fmt.Printf("✓ Hooks installed for %s\n", tool)
+			warnStaleHandlers()
 			fmt.Printf("Please restart %s for hooks to take effect.\n", tool)
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&order, "order", string(hooks.HookOrderLast), "Place intentra hooks first or last among existing hooks (first|last)")
+
 	return cmd
 }
 
@@ -98,6 +115,8 @@ Examples:
   intentra uninstall claude  # Uninstall from Claude Code only`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			loadInstallConfig()
+
 			tool := "all"
 			if len(args) > 0 {
 				tool = args[0]
@@ -137,3 +156,12 @@ Examples:
 
 	return cmd
 }
+
+// loadInstallConfig loads config so local.max_concurrent_installs applies to
+// install, uninstall and hooks status. Those commands must work before a
+// valid config exists, so a config that fails to load keeps the defaults.
+func loadInstallConfig() {
+	if _, err := loadConfig(); err != nil {
+		debug.Log("using default install settings: %v", err)
+	}
+}
@@ -7,12 +7,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/intentrahq/intentra-cli/internal/config"
 	"github.com/intentrahq/intentra-cli/internal/debug"
 	"github.com/intentrahq/intentra-cli/internal/hooks"
+	"github.com/intentrahq/intentra-cli/internal/httputil"
+	"github.com/intentrahq/intentra-cli/internal/queue"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +34,9 @@ var (
 	apiServer string
 	apiKeyID  string
 	apiSecret string
+
+	// strictTLS requires TLS 1.3, overriding server.min_tls_version.
+	strictTLS bool
 )
 
 func main() {
@@ -47,8 +54,20 @@ tracks usage metrics, and optionally syncs data to a central server.`,
 	rootCmd.PersistentFlags().StringVar(&apiServer, "api-server", "", "API server endpoint (e.g., https://app.example.com/api/v1)")
 	rootCmd.PersistentFlags().StringVar(&apiKeyID, "api-key-id", "", "API key ID for authentication")
 	rootCmd.PersistentFlags().StringVar(&apiSecret, "api-secret", "", "API secret for authentication")
+	rootCmd.PersistentFlags().BoolVar(&strictTLS, "strict-tls", false, "require TLS 1.3 for all server connections")
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "write a pprof CPU profile to file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "memprofile", "", "write a pprof heap profile to file on exit")
+	_ = rootCmd.PersistentFlags().MarkHidden("cpuprofile")
+	_ = rootCmd.PersistentFlags().MarkHidden("memprofile")
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if cpuProfile != "" || memProfile != "" {
+			stop, err := startProfiling(cpuProfile, memProfile)
+			if err != nil {
+				return err
+			}
+			stopProfiling = stop
+		}
 		return initDebugMode()
 	}
 
@@ -61,8 +80,16 @@ tracks usage metrics, and optionally syncs data to a central server.`,
 	rootCmd.AddCommand(newLoginCmd())
 	rootCmd.AddCommand(newLogoutCmd())
 	rootCmd.AddCommand(newStatusCmd())
+	rootCmd.AddCommand(newAuthCmd())
 	rootCmd.AddCommand(newExtensionInfoCmd())
 	rootCmd.AddCommand(newSendCmd())
+	rootCmd.AddCommand(newCostCmd())
+	rootCmd.AddCommand(newPricingCmd())
+	rootCmd.AddCommand(newArchiveCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newPingCmd())
+	rootCmd.AddCommand(newToolsCmd())
+	rootCmd.AddCommand(newReportCmd())
 
 	var hookTool string
 	var hookEvent string
@@ -84,8 +111,12 @@ tracks usage metrics, and optionally syncs data to a central server.`,
 	hookCmd.Flags().StringVar(&hookEvent, "event", "", "Hook event type")
 	rootCmd.AddCommand(hookCmd)
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	err := rootCmd.Execute()
+	if profErr := stopProfiling(); profErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", profErr)
+	}
+	if err != nil {
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -135,9 +166,12 @@ func newConfigCmd() *cobra.Command {
 			}
 			if err := cfg.Validate(); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: validation failed: %v\n", err)
-				return err
+				return withExitCode(ExitCodeConfig, err)
 			}
 			fmt.Println("✓ Configuration is valid")
+			if err := cfg.Local.Pricing.DuplicatePrefixes(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v (the last entry for a prefix wins)\n", err)
+			}
 			if cfg.Server.Enabled {
 				fmt.Printf("  Server: %s\n", cfg.Server.Endpoint)
 				fmt.Printf("  Auth: %s\n", cfg.Server.Auth.Mode)
@@ -148,10 +182,101 @@ func newConfigCmd() *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(showCmd, initCmd, validateCmd)
+	cmd.AddCommand(showCmd, initCmd, validateCmd, newConfigSetCmd(), newConfigUnsetCmd(), newConfigResetCmd())
 	return cmd
 }
 
+// newConfigSetCmd returns a cobra.Command for changing a single config key.
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "set <key> <value>",
+		Short:         "Set a configuration key",
+		Args:          cobra.ExactArgs(2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Set one dotted configuration key in the config file, leaving other keys
+as written. Booleans take true/false, durations take values like 30s or 5m.
+The updated configuration must pass validation.
+
+Secret keys (api_key.secret, api_key.hmac_key, anthropic_api_key) are
+stored but never printed.
+
+Examples:
+  intentra config set server.endpoint https://api.example.com
+  intentra config set local.model claude-opus-4.5
+  intentra config set server.timeout 45s
+  intentra config set server.auth.api_key.hmac_key "$HMAC_KEY"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+			if err := config.SetValue(key, value); err != nil {
+				if errors.Is(err, config.ErrUnknownKey) {
+					err = fmt.Errorf("%w\nSupported keys:\n  %s", err, strings.Join(config.SettableKeys(), "\n  "))
+				}
+				return withExitCode(ExitCodeConfig, err)
+			}
+			if config.IsSecretKey(key) {
+				fmt.Fprintf(cmd.OutOrStdout(), "✓ Set %s (value hidden)\n", key)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "✓ Set %s = %s\n", key, value)
+			}
+			return nil
+		},
+	}
+}
+
+// newConfigUnsetCmd returns a cobra.Command for removing a config key.
+func newConfigUnsetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "unset <key>",
+		Short:         "Remove a configuration key",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Remove one dotted key from the config file so its default applies again.
+Whole sections can be removed too. Other keys are left as written.
+
+Examples:
+  intentra config unset server.endpoint
+  intentra config unset local.pricing`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+			if err := config.UnsetValue(key); err != nil {
+				if errors.Is(err, config.ErrKeyNotSet) {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s is not set in the config file\n", key)
+					return nil
+				}
+				return withExitCode(ExitCodeConfig, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✓ Unset %s\n", key)
+			return nil
+		},
+	}
+}
+
+// newConfigResetCmd returns a cobra.Command for restoring the default config.
+func newConfigResetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "reset",
+		Short:         "Reset configuration to defaults",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Rewrite the config file with default values. API key credentials and
+the Anthropic API key are kept; every other setting, including custom
+pricing, is discarded.
+
+Examples:
+  intentra config reset`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.ResetConfig(); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "✓ Configuration reset to defaults (credentials kept)")
+			return nil
+		},
+	}
+}
+
 // newSyncCmd returns a cobra.Command for syncing scans to a server.
 func newSyncCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -181,11 +306,12 @@ Requires server sync to be enabled in config.`,
 				fmt.Println("  Server sync: disabled")
 				fmt.Println("  Running in local-only mode")
 			}
+			printQueueStatus(os.Stdout, queue.GetStatus())
 			return nil
 		},
 	}
 
-	cmd.AddCommand(newSyncNowCmd(), statusCmd)
+	cmd.AddCommand(newSyncNowCmd(), statusCmd, newSyncRetryDeadCmd())
 	return cmd
 }
 
@@ -200,10 +326,33 @@ func loadConfig() (*config.Config, error) {
 		cfg, err = config.Load()
 	}
 	if err != nil {
-		return nil, err
+		return nil, withExitCode(ExitCodeConfig, err)
+	}
+
+	applyFlagOverrides(cfg)
+	if err := hooks.ApplyConfig(cfg); err != nil {
+		return nil, withExitCode(ExitCodeConfig, err)
+	}
+
+	return cfg, nil
+}
+
+// applyFlagOverrides applies --api-server, --api-key-id, and --api-secret to cfg.
+// CLI flags override config file and environment variables unless the config is locked.
+func applyFlagOverrides(cfg *config.Config) {
+	// --strict-tls only tightens security, so it applies even to a locked config.
+	if strictTLS {
+		cfg.Server.MinTLSVersion = httputil.TLSVersion13
+	}
+	if cfg.Locked {
+		for flag, value := range map[string]string{"--api-server": apiServer, "--api-key-id": apiKeyID, "--api-secret": apiSecret} {
+			if value != "" {
+				fmt.Fprintf(os.Stderr, "Warning: config is locked by your administrator; ignoring %s\n", flag)
+			}
+		}
+		return
 	}
 
-	// CLI flags override config file and environment variables
 	if apiServer != "" {
 		cfg.Server.Enabled = true
 		cfg.Server.Endpoint = apiServer
@@ -214,8 +363,6 @@ func loadConfig() (*config.Config, error) {
 	if apiSecret != "" {
 		cfg.Server.Auth.APIKey.Secret = apiSecret
 	}
-
-	return cfg, nil
 }
 
 // initDebugMode initializes debug mode based on config and CLI flag.
@@ -246,5 +393,6 @@ func initDebugMode() error {
 	}
 
 	debug.Enabled = debugMode || cfg.Debug
+	debug.Configure(cfg.Log.Level, cfg.Log.Format)
 	return nil
 }
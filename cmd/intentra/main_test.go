@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/auth"
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/queue"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// TestMain keeps login tests away from the developer's OS keyring: it forces
+// the file backend and opens it in a throwaway directory before any test runs.
+// The keyring is opened once per process, so later per-test config dirs do
+// not move it.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "intentra-test-keyring")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("INTENTRA_NO_KEYCHAIN", "1")
+	os.Setenv("INTENTRA_CONFIG_DIR", dir)
+	_, _ = auth.LoadCredentialsFromKeyring()
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func setAPIFlags(t *testing.T, server, keyID, secret string) {
+	t.Helper()
+	origServer, origKeyID, origSecret := apiServer, apiKeyID, apiSecret
+	apiServer, apiKeyID, apiSecret = server, keyID, secret
+	t.Cleanup(func() {
+		apiServer, apiKeyID, apiSecret = origServer, origKeyID, origSecret
+	})
+}
+
+func TestApplyFlagOverrides_Locked(t *testing.T) {
+	setAPIFlags(t, "https://evil.example.com", "apk_user", "user-secret")
+
+	cfg := config.DefaultConfig()
+	cfg.Locked = true
+	cfg.Server.Endpoint = "https://managed.example.com"
+	cfg.Server.Auth.APIKey.KeyID = "apk_managed"
+
+	applyFlagOverrides(cfg)
+
+	if cfg.Server.Endpoint != "https://managed.example.com" {
+		t.Errorf("endpoint = %q, want managed endpoint", cfg.Server.Endpoint)
+	}
+	if cfg.Server.Auth.APIKey.KeyID != "apk_managed" {
+		t.Errorf("key_id = %q, want apk_managed", cfg.Server.Auth.APIKey.KeyID)
+	}
+	if cfg.Server.Auth.APIKey.Secret != "" {
+		t.Errorf("secret = %q, want unchanged", cfg.Server.Auth.APIKey.Secret)
+	}
+}
+
+func TestApplyFlagOverrides_Unlocked(t *testing.T) {
+	setAPIFlags(t, "https://api.example.com", "apk_user", "user-secret")
+
+	cfg := config.DefaultConfig()
+	applyFlagOverrides(cfg)
+
+	if !cfg.Server.Enabled || cfg.Server.Endpoint != "https://api.example.com" {
+		t.Errorf("expected server override, got enabled=%v endpoint=%q", cfg.Server.Enabled, cfg.Server.Endpoint)
+	}
+	if cfg.Server.Auth.APIKey.KeyID != "apk_user" || cfg.Server.Auth.APIKey.Secret != "user-secret" {
+		t.Errorf("expected key overrides, got %+v", cfg.Server.Auth.APIKey)
+	}
+}
+
+func TestApplyFlagOverrides_StrictTLS(t *testing.T) {
+	setAPIFlags(t, "", "", "")
+	strictTLS = true
+	t.Cleanup(func() { strictTLS = false })
+
+	cfg := config.DefaultConfig()
+	cfg.Locked = true
+	applyFlagOverrides(cfg)
+
+	if cfg.Server.MinTLSVersion != "1.3" {
+		t.Errorf("MinTLSVersion = %q, want 1.3 even for a locked config", cfg.Server.MinTLSVersion)
+	}
+}
+
+func TestPrintFlushResult(t *testing.T) {
+	var buf bytes.Buffer
+	printFlushResult(&buf, queue.FlushResult{Sent: 3, Failed: 1, Remaining: 2})
+	if got, want := buf.String(), "Offline queue: 3 synced, 1 failed, 2 remaining\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	printFlushResult(&buf, queue.FlushResult{Sent: 1, DeadLettered: 2})
+	if !strings.Contains(buf.String(), "2 moved to dead letters") {
+		t.Errorf("expected dead-letter count in %q", buf.String())
+	}
+}
+
+func TestPrintQueueStatus(t *testing.T) {
+	var buf bytes.Buffer
+	printQueueStatus(&buf, queue.Status{Pending: 2})
+	if got, want := buf.String(), "  Offline queue: 2 pending\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	printQueueStatus(&buf, queue.Status{Pending: 3, FailedCount: 2, LastError: "API returned 401"})
+	if !strings.Contains(buf.String(), "Warning: 2 queued scan(s) failed to sync (last error: API returned 401)") {
+		t.Errorf("expected failure warning in %q", buf.String())
+	}
+}
+
+func TestConfigSetCmd_HidesSecrets(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Cleanup(config.InvalidateCache)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"local.model", "claude-opus-4.5"}, "✓ Set local.model = claude-opus-4.5\n"},
+		{[]string{"server.auth.api_key.secret", "hunter2"}, "✓ Set server.auth.api_key.secret (value hidden)\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		cmd := newConfigSetCmd()
+		cmd.SetOut(&buf)
+		cmd.SetArgs(tt.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("config set %v failed: %v", tt.args, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("output = %q, want %q", buf.String(), tt.want)
+		}
+	}
+}
+
+func TestConfigSetCmd_UnknownKeyListsSupported(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+
+	cmd := newConfigSetCmd()
+	cmd.SetArgs([]string{"server.endpoit", "https://api.example.com"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "Supported keys:") || !strings.Contains(err.Error(), "server.endpoint") {
+		t.Errorf("expected unknown key error listing keys, got %v", err)
+	}
+}
+
+func TestConfigUnsetCmd(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Cleanup(config.InvalidateCache)
+
+	if err := config.SetValue("local.model", "claude-opus-4.5"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	for _, want := range []string{"✓ Unset local.model\n", "local.model is not set in the config file\n"} {
+		var buf bytes.Buffer
+		cmd := newConfigUnsetCmd()
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"local.model"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("config unset failed: %v", err)
+		}
+		if buf.String() != want {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	}
+}
+
+func TestSyncedScans(t *testing.T) {
+	scans := []*models.Scan{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	ids := func(ss []*models.Scan) string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := ids(syncedScans(scans, nil)); got != "a,b,c" {
+		t.Errorf("success = %q, want all", got)
+	}
+	partial := &api.BatchError{Failed: map[string]string{"b": "rejected"}}
+	if got := ids(syncedScans(scans, fmt.Errorf("sync: %w", partial))); got != "a,c" {
+		t.Errorf("partial failure = %q, want a,c", got)
+	}
+	if got := syncedScans(scans, errors.New("connection refused")); len(got) != 0 {
+		t.Errorf("total failure kept %d scans, want none", len(got))
+	}
+}
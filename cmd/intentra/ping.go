@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// newPingCmd returns a cobra.Command that checks the server is reachable.
+func newPingCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "ping",
+		Short:         "Check that the server is reachable",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Send an unauthenticated request to the server's /health endpoint and
+report the HTTP status and latency. No login or API key is needed, and
+server sync does not have to be enabled, so this is the first thing to run
+when sync doesn't work.
+
+The endpoint is taken from --api-server, then server.endpoint in the
+config, then the default API endpoint.
+
+Examples:
+  intentra ping
+  intentra ping --api-server https://api.example.com/api/v1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoint, timeout := pingTarget()
+			result, err := api.Ping(endpoint, timeout)
+			if err != nil {
+				return withExitCode(ExitCodeNetwork, fmt.Errorf("%s is unreachable: %w", endpoint, err))
+			}
+			return printPingResult(cmd.OutOrStdout(), result)
+		},
+	}
+}
+
+// pingTarget returns the endpoint and timeout to ping. --api-server is used
+// as given, even over a locked config, since the request carries no
+// credentials. A config that fails to load falls back to the defaults.
+func pingTarget() (string, time.Duration) {
+	cfg, err := loadConfig()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	endpoint := cfg.Server.Endpoint
+	if apiServer != "" {
+		endpoint = apiServer
+	}
+	if endpoint == "" {
+		endpoint = config.DefaultAPIEndpoint
+	}
+	return endpoint, cfg.Server.Timeout
+}
+
+// printPingResult writes a one-line summary and returns an error for
+// non-2xx responses.
+func printPingResult(w io.Writer, r *api.PingResult) error {
+	latency := r.Latency.Round(time.Millisecond)
+	status := fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode))
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		fmt.Fprintf(w, "✗ %s responded %s in %s\n", r.URL, status, latency)
+		if r.Body != "" {
+			fmt.Fprintf(w, "  %s\n", r.Body)
+		}
+		return withExitCode(ExitCodeNetwork, fmt.Errorf("server is unhealthy (status %d)", r.StatusCode))
+	}
+	fmt.Fprintf(w, "✓ %s responded %s in %s\n", r.URL, status, latency)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+)
+
+func TestPingCmd(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Cleanup(config.InvalidateCache)
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	origServer := apiServer
+	apiServer = srv.URL
+	t.Cleanup(func() { apiServer = origServer })
+
+	latency := regexp.MustCompile(` in [0-9.]+(ms|s)\n$`)
+
+	var buf bytes.Buffer
+	cmd := newPingCmd()
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "✓ "+srv.URL+"/health responded 200 OK") || !latency.MatchString(buf.String()) {
+		t.Errorf("output = %q, want status and latency", buf.String())
+	}
+
+	status = http.StatusBadGateway
+	buf.Reset()
+	cmd = newPingCmd()
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("error = %v, want unhealthy 502", err)
+	}
+	if !strings.Contains(buf.String(), "✗ ") || !strings.Contains(buf.String(), "502 Bad Gateway") {
+		t.Errorf("output = %q, want failure line", buf.String())
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// newPricingCmd returns a cobra.Command grouping pricing table tools.
+func newPricingCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pricing",
+		Short: "Inspect pricing overrides",
+		Long: `Inspect the pricing overrides used for cost estimates (local.pricing in
+the config file).`,
+	}
+	cmd.AddCommand(newPricingValidateCmd())
+	return cmd
+}
+
+// newPricingValidateCmd returns a cobra.Command that checks a candidate
+// pricing file and previews its effect.
+func newPricingValidateCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "validate <file>",
+		Short:         "Validate a pricing file and preview its cost changes",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Check a candidate pricing file for malformed entries, negative rates and
+repeated model prefixes, then list the model prices and tool multipliers that
+would change compared with the pricing currently in effect.
+
+The file holds the keys of local.pricing (tool_multipliers, model_prices,
+confidence_pct) at the top level, or is a config file with a local.pricing
+section. Exits with code 2 if the file is invalid.
+
+Examples:
+  intentra pricing validate pricing.yaml
+  intentra pricing validate pricing.yaml --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := loadConfig(); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			result, err := validatePricingFile(args[0])
+			if err != nil {
+				return withExitCode(ExitCodeConfig, err)
+			}
+			if err := printPricingValidation(cmd.OutOrStdout(), result, jsonOutput); err != nil {
+				return err
+			}
+			if !result.Valid {
+				return withExitCode(ExitCodeConfig, fmt.Errorf("%s: %d pricing problem(s)", args[0], len(result.Errors)))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// pricingValidation is the outcome of checking a candidate pricing file.
+// Diff is only set for a valid file.
+type pricingValidation struct {
+	File   string               `json:"file"`
+	Valid  bool                 `json:"valid"`
+	Errors []string             `json:"errors,omitempty"`
+	Diff   *scanner.PricingDiff `json:"diff,omitempty"`
+}
+
+// validatePricingFile loads path and compares it with the active pricing.
+// It returns an error only when the file cannot be read or parsed.
+func validatePricingFile(path string) (pricingValidation, error) {
+	p, err := config.LoadPricingFile(path)
+	if err != nil {
+		return pricingValidation{}, err
+	}
+
+	result := pricingValidation{File: path}
+	for _, err := range []error{p.Validate(), p.DuplicatePrefixes()} {
+		var joined interface{ Unwrap() []error }
+		if errors.As(err, &joined) {
+			for _, e := range joined.Unwrap() {
+				result.Errors = append(result.Errors, e.Error())
+			}
+		} else if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	diff := scanner.ComparePricing(p)
+	result.Valid = true
+	result.Diff = &diff
+	return result, nil
+}
+
+// printPricingValidation writes a validation result as text or JSON.
+func printPricingValidation(w io.Writer, r pricingValidation, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal pricing validation: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	if !r.Valid {
+		fmt.Fprintf(w, "%s is invalid:\n", r.File)
+		for _, e := range r.Errors {
+			fmt.Fprintf(w, "  - %s\n", e)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s is valid.\n", r.File)
+	d := r.Diff
+	if d.Empty() {
+		fmt.Fprintln(w, "No pricing changes compared with the current table.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(d.Models) > 0 {
+		fmt.Fprintln(tw, "\nMODEL\tCURRENT /1K\tNEW /1K\tCHANGE")
+		for _, c := range d.Models {
+			fmt.Fprintf(tw, "%s\t$%.6f\t$%.6f\t%s\n", c.Name, c.Old, c.New, formatChangePct(c))
+		}
+	}
+	if len(d.Tools) > 0 {
+		fmt.Fprintln(tw, "\nTOOL\tCURRENT\tNEW\tCHANGE")
+		for _, c := range d.Tools {
+			fmt.Fprintf(tw, "%s\t%.2fx\t%.2fx\t%s\n", c.Name, c.Old, c.New, formatChangePct(c))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+	if d.OldConfidencePct != d.NewConfidencePct {
+		fmt.Fprintf(w, "\nConfidence band: ±%g%% -> ±%g%%\n", d.OldConfidencePct, d.NewConfidencePct)
+	}
+	return nil
+}
+
+// formatChangePct renders a price change as a signed percentage, or "new"
+// when there was no previous rate to compare against.
+func formatChangePct(c scanner.PriceChange) string {
+	if c.Old == 0 {
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", c.ChangePct)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+)
+
+func writePricingFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidatePricingFile_Valid(t *testing.T) {
+	scanner.SetPricingOverrides(config.PricingConfig{})
+	t.Cleanup(func() { scanner.SetPricingOverrides(config.PricingConfig{}) })
+
+	path := writePricingFile(t, `tool_multipliers:
+  windsurf: 1.5
+model_prices:
+  - prefix: claude-sonnet-4.5
+    price_per_1k: 0.0099
+  - prefix: acme-coder
+    price_per_1k: 0.002
+confidence_pct: 10
+`)
+	r, err := validatePricingFile(path)
+	if err != nil {
+		t.Fatalf("validatePricingFile failed: %v", err)
+	}
+	if !r.Valid || len(r.Errors) != 0 {
+		t.Fatalf("expected valid file, got errors %v", r.Errors)
+	}
+
+	d := r.Diff
+	if len(d.Models) != 2 {
+		t.Fatalf("model changes = %+v, want 2", d.Models)
+	}
+	if c := d.Models[0]; c.Name != "acme-coder" || c.Old != 0.005 || c.New != 0.002 || math.Abs(c.ChangePct+60) > 1e-9 {
+		t.Errorf("acme-coder change = %+v", c)
+	}
+	if c := d.Models[1]; c.Name != "claude-sonnet-4.5" || c.Old != 0.0066 || c.New != 0.0099 || math.Abs(c.ChangePct-50) > 1e-9 {
+		t.Errorf("claude-sonnet-4.5 change = %+v", c)
+	}
+	if len(d.Tools) != 1 || d.Tools[0].Name != "windsurf" || d.Tools[0].Old != 1.2 || d.Tools[0].New != 1.5 {
+		t.Errorf("tool changes = %+v, want windsurf 1.2 -> 1.5", d.Tools)
+	}
+	if d.OldConfidencePct != 20 || d.NewConfidencePct != 10 {
+		t.Errorf("confidence = %g -> %g, want 20 -> 10", d.OldConfidencePct, d.NewConfidencePct)
+	}
+
+	var buf bytes.Buffer
+	if err := printPricingValidation(&buf, r, false); err != nil {
+		t.Fatalf("printPricingValidation failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"is valid", "claude-sonnet-4.5", "+50.0%", "-60.0%", "windsurf", "+25.0%", "±20% -> ±10%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestValidatePricingFile_AgainstActiveOverrides(t *testing.T) {
+	overrides := config.PricingConfig{
+		ModelPrices: []config.ModelPrice{{Prefix: "acme-coder", PricePer1K: 0.002}},
+	}
+	scanner.SetPricingOverrides(overrides)
+	t.Cleanup(func() { scanner.SetPricingOverrides(config.PricingConfig{}) })
+
+	path := writePricingFile(t, `model_prices:
+  - prefix: acme-coder
+    price_per_1k: 0.002
+`)
+	r, err := validatePricingFile(path)
+	if err != nil {
+		t.Fatalf("validatePricingFile failed: %v", err)
+	}
+	if !r.Diff.Empty() {
+		t.Errorf("expected no changes against identical overrides, got %+v", r.Diff)
+	}
+
+	var buf bytes.Buffer
+	if err := printPricingValidation(&buf, r, false); err != nil {
+		t.Fatalf("printPricingValidation failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No pricing changes") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestValidatePricingFile_Invalid(t *testing.T) {
+	path := writePricingFile(t, `tool_multipliers:
+  cursor: -1
+model_prices:
+  - prefix: claude-sonnet-4.5
+    price_per_1k: -0.01
+  - prefix: claude-sonnet-4.5
+    price_per_1k: 0.007
+  - price_per_1k: 0.001
+`)
+	r, err := validatePricingFile(path)
+	if err != nil {
+		t.Fatalf("validatePricingFile failed: %v", err)
+	}
+	if r.Valid || r.Diff != nil {
+		t.Fatalf("expected invalid result without diff, got %+v", r)
+	}
+	wantErrs := []string{
+		"tool_multipliers.cursor must not be negative",
+		"model_prices[0] (claude-sonnet-4.5) price_per_1k must not be negative",
+		"model_prices[2].prefix is required",
+		"model_prices[1] repeats prefix claude-sonnet-4.5 from model_prices[0]",
+	}
+	if len(r.Errors) != len(wantErrs) {
+		t.Fatalf("errors = %q, want %d", r.Errors, len(wantErrs))
+	}
+	for i, want := range wantErrs {
+		if !strings.Contains(r.Errors[i], want) {
+			t.Errorf("errors[%d] = %q, want it to contain %q", i, r.Errors[i], want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := printPricingValidation(&buf, r, false); err != nil {
+		t.Fatalf("printPricingValidation failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "is invalid") || strings.Count(buf.String(), "  - ") != 4 {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestValidatePricingFile_UnknownKey(t *testing.T) {
+	path := writePricingFile(t, `model_price:
+  - prefix: claude-sonnet-4.5
+    price_per_1k: 0.007
+`)
+	if _, err := validatePricingFile(path); err == nil || !strings.Contains(err.Error(), "model_price") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
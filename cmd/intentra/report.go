@@ -0,0 +1,619 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// reportPeriods maps a --period value to the number of days it covers,
+// ending today.
+var reportPeriods = map[string]int{"day": 1, "week": 7, "month": 30}
+
+const (
+	reportTopN = 5
+
+	// A scan is notable when it costs at least notableCostFactor times the
+	// period's average, or reports at least notableErrorCount errors.
+	notableCostFactor = 3.0
+	notableErrorCount = 3
+)
+
+// newReportCmd returns a cobra.Command for generating a usage summary report.
+func newReportCmd() *cobra.Command {
+	var period string
+	var format string
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:           "report",
+		Short:         "Generate a usage summary report",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Summarize usage over a period as text or markdown, ready to paste into
+Slack or email. The report covers total cost and tokens, the top models,
+tools and repositories, a day-by-day trend, and notable sessions (unusually
+expensive or error-heavy).
+
+Scans are fetched from the server when server mode is enabled, otherwise
+read from local storage.
+
+Examples:
+  intentra report                              # This week, as text
+  intentra report --period month --format markdown
+  intentra report --format markdown --output weekly.md`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			days, ok := reportPeriods[period]
+			if !ok {
+				return fmt.Errorf("unsupported period: %s (supported: day, week, month)", period)
+			}
+			if format != "text" && format != "markdown" {
+				return fmt.Errorf("unsupported format: %s (supported: text, markdown)", format)
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			var scans []models.Scan
+			if cfg.Server.Enabled {
+				client, err := api.NewClient(cfg)
+				if err != nil {
+					return fmt.Errorf("failed to create API client: %w", err)
+				}
+				resp, err := client.GetScans(days, 1000)
+				if err != nil {
+					return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
+				}
+				scans = resp.Scans
+			} else {
+				scans, err = scanner.LoadScans()
+				if err != nil {
+					return err
+				}
+			}
+
+			r := buildReport(scans, period, days, time.Now())
+			if outputPath == "" {
+				return writeReport(cmd.OutOrStdout(), r, format)
+			}
+			var buf bytes.Buffer
+			if err := writeReport(&buf, r, format); err != nil {
+				return err
+			}
+			return fileutil.WriteFileAtomic(outputPath, buf.Bytes())
+		},
+	}
+
+	cmd.Flags().StringVar(&period, "period", "week", "Period to cover: day, week, or month")
+	cmd.Flags().StringVar(&format, "format", "text", "Output format: text or markdown")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the report to a file instead of stdout")
+
+	return cmd
+}
+
+// usageReport is the aggregated content of a report.
+type usageReport struct {
+	Period        string
+	Start, End    time.Time
+	Scans         int
+	TotalTokens   int
+	EstimatedCost float64
+	TopModels     []reportGroup
+	TopTools      []reportGroup
+	TopRepos      []reportGroup
+	Days          []reportGroup // one per calendar day, oldest first, Name is the date
+	Notable       []notableScan
+}
+
+// reportGroup totals the scans sharing a model, tool, repository, or day.
+type reportGroup struct {
+	Name   string  `json:"name"`
+	Scans  int     `json:"scans"`
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"estimated_cost"`
+
+	// RepoURLHash and OtherNames are set for scan report's repo groups:
+	// the remote URL hash the scans share, and any names besides Name the
+	// repository was checked out under.
+	RepoURLHash string   `json:"repo_url_hash,omitempty"`
+	OtherNames  []string `json:"other_names,omitempty"`
+}
+
+// notableScan is a session called out in the report, with the reason why.
+type notableScan struct {
+	ID     string
+	Tool   string
+	Start  time.Time
+	Cost   float64
+	Errors int
+	Reason string
+}
+
+// scanReportGroupings lists the --group-by values of scan report.
+var scanReportGroupings = []string{"day", "week", "model", "tool", "repo"}
+
+// newScanReportCmd returns a cobra.Command for totals grouped by day, week,
+// model, tool, or repository.
+func newScanReportCmd() *cobra.Command {
+	var groupBy string
+	var days int
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "report",
+		Short:         "Show scan totals grouped by day, week, model, tool, or repo",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Show scans, tokens, and estimated cost over the last --days calendar days
+(local time, including today), grouped by day, week (starting Monday),
+model, tool, or repo, followed by a grand total.
+
+Day and week rows are listed oldest first, including periods without scans.
+Model, tool, and repo rows are listed by descending cost.
+
+Repositories are grouped by the hash of their remote URL, so clones checked
+out under different directory names count as one. Each row shows the name
+used by most of its scans, followed by any other names. When two remotes
+share a name, the start of each hash tells them apart.
+
+Scans are fetched from the server when server mode is enabled, otherwise
+read from local storage.
+
+Examples:
+  intentra scan report --group-by day --days 14
+  intentra scan report --group-by week --days 60
+  intentra scan report --group-by model --json
+  intentra scan report --group-by repo --days 30`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(scanReportGroupings, groupBy) {
+				return fmt.Errorf("unsupported --group-by: %s (supported: %s)", groupBy, strings.Join(scanReportGroupings, ", "))
+			}
+			if days <= 0 {
+				return fmt.Errorf("--days must be positive")
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			var scans []models.Scan
+			if cfg.Server.Enabled {
+				client, err := api.NewClient(cfg)
+				if err != nil {
+					return fmt.Errorf("failed to create API client: %w", err)
+				}
+				resp, err := client.GetScans(days, 1000)
+				if err != nil {
+					return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
+				}
+				scans = resp.Scans
+			} else {
+				scans, err = scanner.LoadScans()
+				if err != nil {
+					return err
+				}
+			}
+
+			rows, total := groupScans(scans, groupBy, days, time.Now())
+			return writeScanReport(cmd.OutOrStdout(), groupBy, rows, total, jsonOutput)
+		},
+	}
+
+	cmd.Flags().StringVar(&groupBy, "group-by", "day", "Group by day, week, model, tool, or repo")
+	cmd.Flags().IntVar(&days, "days", 7, "Number of calendar days to cover, including today")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// groupScans totals the scans that started within the given number of
+// calendar days ending on now's date, grouped by groupBy. Day and week
+// groups are returned oldest first with empty periods included; model,
+// tool, and repo groups by descending cost, with scans lacking one as
+// "(unknown)".
+func groupScans(scans []models.Scan, groupBy string, days int, now time.Time) ([]reportGroup, reportGroup) {
+	y, m, d := now.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+
+	var key func(models.Scan) string
+	var order []string
+	switch groupBy {
+	case "day":
+		key = func(s models.Scan) string { return s.StartTime.In(now.Location()).Format("2006-01-02") }
+		for i := 0; i < days; i++ {
+			order = append(order, start.AddDate(0, 0, i).Format("2006-01-02"))
+		}
+	case "week":
+		key = func(s models.Scan) string { return weekStart(s.StartTime.In(now.Location())).Format("2006-01-02") }
+		for w := weekStart(start); !w.After(now); w = w.AddDate(0, 0, 7) {
+			order = append(order, w.Format("2006-01-02"))
+		}
+	case "model":
+		key = func(s models.Scan) string { return cmp.Or(s.Model, "(unknown)") }
+	case "tool":
+		key = func(s models.Scan) string { return cmp.Or(s.Tool, "(unknown)") }
+	case "repo":
+		key = repoKey
+	}
+
+	groups := make(map[string]*reportGroup)
+	for _, name := range order {
+		groups[name] = &reportGroup{Name: name}
+	}
+	total := reportGroup{Name: "TOTAL"}
+	var counted []models.Scan
+	for _, s := range filterScansSince(scans, start) {
+		if s.StartTime.After(now) {
+			continue
+		}
+		counted = append(counted, s)
+		addToGroup(groups, key(s), s)
+		total.Scans++
+		total.Tokens += s.TotalTokens
+		total.Cost += s.EstimatedCost
+	}
+	if groupBy == "repo" {
+		nameRepoGroups(groups, counted)
+	}
+
+	if order == nil {
+		return topGroups(groups, len(groups)), total
+	}
+	rows := make([]reportGroup, 0, len(order))
+	for _, name := range order {
+		rows = append(rows, *groups[name])
+	}
+	return rows, total
+}
+
+// repoKey identifies a scan's repository by its remote URL hash, falling
+// back to its name for scans recorded without one.
+func repoKey(s models.Scan) string {
+	if s.RepoURLHash != "" {
+		return s.RepoURLHash
+	}
+	if s.RepoName != "" {
+		return "name:" + strings.ToLower(s.RepoName)
+	}
+	return "(unknown)"
+}
+
+// nameRepoGroups gives each repo group, keyed by repoKey, the name most of
+// its scans were recorded under, listing the rest in OtherNames. Groups of
+// different remotes that end up with the same name get the start of their
+// hash appended.
+func nameRepoGroups(groups map[string]*reportGroup, scans []models.Scan) {
+	names := make(map[string]map[string]int)
+	for _, s := range scans {
+		if s.RepoName == "" {
+			continue
+		}
+		k := repoKey(s)
+		if names[k] == nil {
+			names[k] = make(map[string]int)
+		}
+		names[k][s.RepoName]++
+	}
+
+	seen := make(map[string]int)
+	for k, g := range groups {
+		if !strings.HasPrefix(k, "name:") && k != "(unknown)" {
+			g.RepoURLHash = k
+		}
+		ranked := make([]string, 0, len(names[k]))
+		for name := range names[k] {
+			ranked = append(ranked, name)
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			if names[k][ranked[i]] != names[k][ranked[j]] {
+				return names[k][ranked[i]] > names[k][ranked[j]]
+			}
+			return ranked[i] < ranked[j]
+		})
+		switch {
+		case len(ranked) > 0:
+			g.Name = ranked[0]
+			g.OtherNames = ranked[1:]
+		case g.RepoURLHash != "":
+			g.Name = shortHash(g.RepoURLHash)
+		}
+		seen[g.Name]++
+	}
+	for _, g := range groups {
+		if seen[g.Name] > 1 && g.RepoURLHash != "" {
+			g.Name += " (" + shortHash(g.RepoURLHash) + ")"
+		}
+	}
+}
+
+func shortHash(h string) string {
+	if len(h) > 8 {
+		return h[:8]
+	}
+	return h
+}
+
+// weekStart returns midnight on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+}
+
+// writeScanReport renders grouped totals as a table or JSON.
+func writeScanReport(w io.Writer, groupBy string, rows []reportGroup, total reportGroup, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(map[string]any{
+			"group_by": groupBy,
+			"groups":   rows,
+			"total":    total,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tSCANS\tTOKENS\tCOST\n", strings.ToUpper(groupBy))
+	for _, g := range rows {
+		name := g.Name
+		if len(g.OtherNames) > 0 {
+			name += " (also " + strings.Join(g.OtherNames, ", ") + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t$%.2f\n", name, g.Scans, g.Tokens, g.Cost)
+	}
+	fmt.Fprintf(tw, "%s\t%d\t%d\t$%.2f\n", total.Name, total.Scans, total.Tokens, total.Cost)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+	return nil
+}
+
+// buildReport aggregates the scans that started within the given number of
+// calendar days ending on now's date.
+func buildReport(scans []models.Scan, period string, days int, now time.Time) usageReport {
+	y, m, d := now.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+	r := usageReport{Period: period, Start: start, End: now}
+
+	byModel := make(map[string]*reportGroup)
+	byTool := make(map[string]*reportGroup)
+	byRepo := make(map[string]*reportGroup)
+	byDay := make(map[string]*reportGroup)
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		byDay[day] = &reportGroup{Name: day}
+		r.Days = append(r.Days, reportGroup{Name: day})
+	}
+
+	var inPeriod []models.Scan
+	for _, s := range filterScansSince(scans, start) {
+		if s.StartTime.After(now) {
+			continue
+		}
+		inPeriod = append(inPeriod, s)
+		r.Scans++
+		r.TotalTokens += s.TotalTokens
+		r.EstimatedCost += s.EstimatedCost
+
+		addToGroup(byModel, s.Model, s)
+		addToGroup(byTool, s.Tool, s)
+		addToGroup(byRepo, s.RepoName, s)
+		if g, ok := byDay[s.StartTime.In(now.Location()).Format("2006-01-02")]; ok {
+			g.Scans++
+			g.Tokens += s.TotalTokens
+			g.Cost += s.EstimatedCost
+		}
+	}
+
+	for i := range r.Days {
+		r.Days[i] = *byDay[r.Days[i].Name]
+	}
+	r.TopModels = topGroups(byModel, reportTopN)
+	r.TopTools = topGroups(byTool, reportTopN)
+	r.TopRepos = topGroups(byRepo, reportTopN)
+	r.Notable = notableScans(inPeriod, r.EstimatedCost, reportTopN)
+	return r
+}
+
+func addToGroup(groups map[string]*reportGroup, name string, s models.Scan) {
+	if name == "" {
+		return
+	}
+	g, ok := groups[name]
+	if !ok {
+		g = &reportGroup{Name: name}
+		groups[name] = g
+	}
+	g.Scans++
+	g.Tokens += s.TotalTokens
+	g.Cost += s.EstimatedCost
+}
+
+// topGroups returns up to n groups by descending cost, then scan count.
+func topGroups(groups map[string]*reportGroup, n int) []reportGroup {
+	out := make([]reportGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		if out[i].Scans != out[j].Scans {
+			return out[i].Scans > out[j].Scans
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// notableScans picks up to n high-cost or error-heavy scans, most expensive first.
+func notableScans(scans []models.Scan, totalCost float64, n int) []notableScan {
+	if len(scans) == 0 {
+		return nil
+	}
+	avg := totalCost / float64(len(scans))
+
+	var out []notableScan
+	for _, s := range scans {
+		errCount := 0
+		for _, c := range s.ErrorCounts {
+			errCount += c
+		}
+		var reasons []string
+		if avg > 0 && s.EstimatedCost >= avg*notableCostFactor {
+			reasons = append(reasons, fmt.Sprintf("%.1fx average cost", s.EstimatedCost/avg))
+		}
+		if errCount >= notableErrorCount {
+			reasons = append(reasons, fmt.Sprintf("%d errors", errCount))
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		out = append(out, notableScan{
+			ID:     s.ID,
+			Tool:   s.Tool,
+			Start:  s.StartTime,
+			Cost:   s.EstimatedCost,
+			Errors: errCount,
+			Reason: strings.Join(reasons, ", "),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Cost > out[j].Cost })
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// writeReport renders r as plain text or markdown.
+func writeReport(w io.Writer, r usageReport, format string) error {
+	md := format == "markdown"
+	heading := func(title string) {
+		if md {
+			fmt.Fprintf(w, "\n## %s\n\n", title)
+		} else {
+			fmt.Fprintf(w, "\n%s\n%s\n", title, strings.Repeat("-", len(title)))
+		}
+	}
+	table := func(header []string, rows [][]string) error {
+		if md {
+			fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+			fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(header)))
+			for _, row := range rows {
+				fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+			}
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+		return nil
+	}
+	groupRows := func(groups []reportGroup) [][]string {
+		rows := make([][]string, 0, len(groups))
+		for _, g := range groups {
+			rows = append(rows, []string{g.Name, fmt.Sprint(g.Scans), fmt.Sprint(g.Tokens), fmt.Sprintf("$%.2f", g.Cost)})
+		}
+		return rows
+	}
+
+	title := fmt.Sprintf("Intentra %s report: %s to %s", reportPeriodTitle(r.Period),
+		r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"))
+	if md {
+		fmt.Fprintf(w, "# %s\n", title)
+	} else {
+		fmt.Fprintln(w, title)
+	}
+
+	heading("Summary")
+	summary := []string{
+		fmt.Sprintf("Scans: %d", r.Scans),
+		fmt.Sprintf("Tokens: %d", r.TotalTokens),
+		fmt.Sprintf("Estimated cost: $%.2f", r.EstimatedCost),
+	}
+	for _, line := range summary {
+		if md {
+			line = "- " + line
+		}
+		fmt.Fprintln(w, line)
+	}
+	if r.Scans == 0 {
+		fmt.Fprintln(w, "\nNo scans in this period.")
+		return nil
+	}
+
+	sections := []struct {
+		title  string
+		label  string
+		groups []reportGroup
+	}{
+		{"Top models", "Model", r.TopModels},
+		{"Top tools", "Tool", r.TopTools},
+		{"Top repositories", "Repository", r.TopRepos},
+	}
+	for _, sec := range sections {
+		if len(sec.groups) == 0 {
+			continue
+		}
+		heading(sec.title)
+		if err := table([]string{sec.label, "Scans", "Tokens", "Cost"}, groupRows(sec.groups)); err != nil {
+			return err
+		}
+	}
+
+	heading("Daily trend")
+	if err := table([]string{"Date", "Scans", "Tokens", "Cost"}, groupRows(r.Days)); err != nil {
+		return err
+	}
+
+	heading("Notable sessions")
+	if len(r.Notable) == 0 {
+		fmt.Fprintln(w, "None.")
+		return nil
+	}
+	rows := make([][]string, 0, len(r.Notable))
+	for _, n := range r.Notable {
+		rows = append(rows, []string{n.ID, n.Tool, n.Start.Format("2006-01-02 15:04"), fmt.Sprintf("$%.2f", n.Cost), n.Reason})
+	}
+	return table([]string{"Scan", "Tool", "Started", "Cost", "Reason"}, rows)
+}
+
+// reportPeriodTitle returns the adjective used in the report title.
+func reportPeriodTitle(period string) string {
+	switch period {
+	case "day":
+		return "daily"
+	case "month":
+		return "monthly"
+	default:
+		return "weekly"
+	}
+}
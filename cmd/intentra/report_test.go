@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func reportFixture(now time.Time) []models.Scan {
+	day := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	return []models.Scan{
+		{ID: "scan_a", Tool: "claude", Model: "claude-sonnet-4.5", RepoName: "api", StartTime: day(0), TotalTokens: 1000, EstimatedCost: 1.00},
+		{ID: "scan_b", Tool: "claude", Model: "claude-sonnet-4.5", RepoName: "api", StartTime: day(1), TotalTokens: 2000, EstimatedCost: 1.00},
+		{ID: "scan_c", Tool: "cursor", Model: "gpt-4o", RepoName: "web", StartTime: day(1), TotalTokens: 500, EstimatedCost: 0.50,
+			ErrorCounts: map[string]int{"timeout": 2, "429": 2}},
+		{ID: "scan_d", Tool: "cursor", Model: "gpt-4o", StartTime: day(2), TotalTokens: 500, EstimatedCost: 0.50},
+		{ID: "scan_e", Tool: "claude", Model: "claude-opus-4", RepoName: "api", StartTime: day(3), TotalTokens: 9000, EstimatedCost: 12.00},
+		{ID: "scan_old", Tool: "claude", Model: "claude-opus-4", StartTime: day(10), TotalTokens: 99999, EstimatedCost: 99.00},
+	}
+}
+
+func TestBuildReport_Week(t *testing.T) {
+	now := time.Date(2025, 3, 7, 15, 0, 0, 0, time.UTC)
+	r := buildReport(reportFixture(now), "week", 7, now)
+
+	if r.Scans != 5 || r.TotalTokens != 13000 {
+		t.Errorf("Scans = %d, TotalTokens = %d; want 5, 13000", r.Scans, r.TotalTokens)
+	}
+	if diff := r.EstimatedCost - 15.0; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("EstimatedCost = %f, want 15", r.EstimatedCost)
+	}
+	if got := r.Start.Format("2006-01-02"); got != "2025-03-01" {
+		t.Errorf("Start = %s, want 2025-03-01", got)
+	}
+
+	if len(r.TopModels) != 3 || r.TopModels[0].Name != "claude-opus-4" || r.TopModels[1].Name != "claude-sonnet-4.5" {
+		t.Errorf("TopModels = %+v", r.TopModels)
+	}
+	if len(r.TopRepos) != 2 || r.TopRepos[0].Name != "api" || r.TopRepos[0].Scans != 3 {
+		t.Errorf("TopRepos = %+v (scans without a repo should be skipped)", r.TopRepos)
+	}
+
+	if len(r.Days) != 7 || r.Days[6].Name != "2025-03-07" || r.Days[6].Scans != 1 || r.Days[5].Scans != 2 || r.Days[0].Scans != 0 {
+		t.Errorf("Days = %+v", r.Days)
+	}
+
+	if len(r.Notable) != 2 {
+		t.Fatalf("Notable = %+v, want scan_e (cost) and scan_c (errors)", r.Notable)
+	}
+	if r.Notable[0].ID != "scan_e" || !strings.Contains(r.Notable[0].Reason, "4.0x average cost") {
+		t.Errorf("Notable[0] = %+v", r.Notable[0])
+	}
+	if r.Notable[1].ID != "scan_c" || r.Notable[1].Reason != "4 errors" {
+		t.Errorf("Notable[1] = %+v", r.Notable[1])
+	}
+}
+
+func TestWriteReport_Formats(t *testing.T) {
+	now := time.Date(2025, 3, 7, 15, 0, 0, 0, time.UTC)
+	r := buildReport(reportFixture(now), "week", 7, now)
+
+	var md bytes.Buffer
+	if err := writeReport(&md, r, "markdown"); err != nil {
+		t.Fatalf("writeReport failed: %v", err)
+	}
+	for _, want := range []string{
+		"# Intentra weekly report: 2025-03-01 to 2025-03-07",
+		"## Summary", "- Scans: 5", "- Tokens: 13000", "- Estimated cost: $15.00",
+		"## Top models", "| claude-opus-4 | 1 | 9000 | $12.00 |",
+		"## Top tools", "| claude | 3 | 12000 | $14.00 |",
+		"## Top repositories", "| api | 3 | 12000 | $14.00 |",
+		"## Daily trend", "| 2025-03-06 | 2 | 2500 | $1.50 |", "| 2025-03-01 | 0 | 0 | $0.00 |",
+		"## Notable sessions", "| scan_e | claude |",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown report missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var text bytes.Buffer
+	if err := writeReport(&text, r, "text"); err != nil {
+		t.Fatalf("writeReport failed: %v", err)
+	}
+	for _, want := range []string{"Summary\n-------", "Estimated cost: $15.00", "Daily trend", "4 errors"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, text.String())
+		}
+	}
+	if strings.Contains(text.String(), "|") {
+		t.Errorf("text report should not contain markdown tables:\n%s", text.String())
+	}
+}
+
+func TestWriteReport_Empty(t *testing.T) {
+	now := time.Date(2025, 3, 7, 15, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	if err := writeReport(&buf, buildReport(nil, "day", 1, now), "text"); err != nil {
+		t.Fatalf("writeReport failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Intentra daily report") || !strings.Contains(buf.String(), "No scans in this period.") {
+		t.Errorf("unexpected empty report:\n%s", buf.String())
+	}
+}
+
+func TestReportCmd_RejectsUnknownPeriod(t *testing.T) {
+	cmd := newReportCmd()
+	cmd.SetArgs([]string{"--period", "year"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported period") {
+		t.Errorf("expected unsupported period error, got %v", err)
+	}
+}
+
+func TestGroupScans(t *testing.T) {
+	now := time.Date(2025, 3, 7, 15, 0, 0, 0, time.UTC) // a Friday
+	scans := append(reportFixture(now), models.Scan{ID: "scan_nomodel", Tool: "cursor", StartTime: now, TotalTokens: 10, EstimatedCost: 0.01})
+
+	days, total := groupScans(scans, "day", 14, now)
+	if len(days) != 14 || days[0].Name != "2025-02-22" || days[13].Name != "2025-03-07" {
+		t.Fatalf("day groups = %+v", days)
+	}
+	if days[13].Scans != 2 || days[12].Scans != 2 || days[3].Scans != 1 || days[0].Scans != 0 {
+		t.Errorf("day groups = %+v", days)
+	}
+	if total.Scans != 7 || total.Tokens != 113009 {
+		t.Errorf("total = %+v, want 7 scans and 113009 tokens", total)
+	}
+
+	weeks, _ := groupScans(scans, "week", 14, now)
+	if len(weeks) != 3 || weeks[0].Name != "2025-02-17" || weeks[1].Name != "2025-02-24" || weeks[2].Name != "2025-03-03" {
+		t.Fatalf("week groups = %+v", weeks)
+	}
+	if weeks[0].Scans != 0 || weeks[1].Scans != 1 || weeks[2].Scans != 6 {
+		t.Errorf("week groups = %+v", weeks)
+	}
+
+	byModel, total := groupScans(scans, "model", 7, now)
+	if total.Scans != 6 {
+		t.Errorf("7-day total = %+v, want 6 scans", total)
+	}
+	if len(byModel) != 4 || byModel[0].Name != "claude-opus-4" || byModel[3].Name != "(unknown)" {
+		t.Errorf("model groups = %+v", byModel)
+	}
+}
+
+func TestGroupScans_Repo(t *testing.T) {
+	now := time.Date(2025, 3, 7, 15, 0, 0, 0, time.UTC)
+	scan := func(name, hash string, cost float64) models.Scan {
+		return models.Scan{RepoName: name, RepoURLHash: hash, StartTime: now, TotalTokens: 100, EstimatedCost: cost}
+	}
+	hashA := "aaaaaaaa1111"
+	hashB := "bbbbbbbb2222"
+	scans := []models.Scan{
+		scan("webapp", hashA, 1),
+		scan("webapp", hashA, 1),
+		scan("webapp-old", hashA, 1),
+		scan("api", hashB, 2),
+		scan("Scratch", "", 0.5),
+		scan("scratch", "", 0.5),
+		scan("", "", 0.1),
+	}
+
+	rows, total := groupScans(scans, "repo", 1, now)
+	if total.Scans != 7 {
+		t.Errorf("total = %+v, want 7 scans", total)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("repo groups = %+v, want 4", rows)
+	}
+	if rows[0].Name != "webapp" || rows[0].Scans != 3 || rows[0].RepoURLHash != hashA || !slices.Equal(rows[0].OtherNames, []string{"webapp-old"}) {
+		t.Errorf("webapp group = %+v", rows[0])
+	}
+	if rows[1].Name != "api" || rows[1].RepoURLHash != hashB {
+		t.Errorf("api group = %+v", rows[1])
+	}
+	if rows[2].Scans != 2 || rows[2].RepoURLHash != "" || !strings.EqualFold(rows[2].Name, "scratch") {
+		t.Errorf("scratch group = %+v", rows[2])
+	}
+	if rows[3].Name != "(unknown)" {
+		t.Errorf("unknown group = %+v", rows[3])
+	}
+
+	// Two remotes checked out under the same name stay separate.
+	rows, _ = groupScans([]models.Scan{scan("app", hashA, 1), scan("app", hashB, 2)}, "repo", 1, now)
+	if len(rows) != 2 || rows[0].Name != "app (bbbbbbbb)" || rows[1].Name != "app (aaaaaaaa)" {
+		t.Errorf("same-name groups = %+v", rows)
+	}
+
+	rows, total = groupScans(scans[:3], "repo", 1, now)
+	var out bytes.Buffer
+	if err := writeScanReport(&out, "repo", rows, total, false); err != nil {
+		t.Fatalf("writeScanReport failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "webapp (also webapp-old)") {
+		t.Errorf("report should list other names:\n%s", out.String())
+	}
+}
+
+func TestScanReportCmd(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+
+	cmd := newScanReportCmd()
+	cmd.SetArgs([]string{"--group-by", "hour"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported --group-by") {
+		t.Errorf("expected unsupported --group-by error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd = newScanReportCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--group-by", "tool", "--days", "3"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan report failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "TOOL") || !strings.Contains(out.String(), "TOTAL  0") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+}
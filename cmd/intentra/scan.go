@@ -1,14 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/device"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
 	"github.com/intentrahq/intentra-cli/internal/scanner"
 	"github.com/intentrahq/intentra-cli/pkg/models"
 	"github.com/spf13/cobra"
@@ -32,6 +42,14 @@ func newScanCmd() *cobra.Command {
 	cmd.AddCommand(newScanShowCmd())
 	cmd.AddCommand(newScanTodayCmd())
 	cmd.AddCommand(newScanAggregateCmd())
+	cmd.AddCommand(newScanStatsCmd())
+	cmd.AddCommand(newScanExportCmd())
+	cmd.AddCommand(newScanDeleteCmd())
+	cmd.AddCommand(newScanMarkCmd())
+	cmd.AddCommand(newScanPruneCmd())
+	cmd.AddCommand(newScanSyncLocalCmd())
+	cmd.AddCommand(newScanReportCmd())
+	cmd.AddCommand(newScanCostBreakdownCmd())
 
 	return cmd
 }
@@ -42,6 +60,11 @@ func newScanListCmd() *cobra.Command {
 	var summaryOnly bool
 	var days int
 	var limit int
+	var outputPath string
+	var sinceLastSync bool
+	var since string
+	var status string
+	var filter api.ScanFilter
 
 	cmd := &cobra.Command{
 		Use:           "list",
@@ -53,17 +76,74 @@ func newScanListCmd() *cobra.Command {
 When server mode is enabled, scans are fetched from the API.
 When server mode is disabled (local-only), scans are read from local files.
 
+With --since-last-sync only scans that ended after the previous
+--since-last-sync run are listed, and the mark advances once they are
+written. If more than --limit are new, the oldest are listed first and the
+rest follow on the next run. Each combination of --tool, --repo and
+--status keeps its own mark.
+
+--since narrows the listing to scans started within a duration (4h, 90m,
+2d) or after an RFC3339 timestamp. It cannot be combined with --days.
+
+--status lists only scans in one review state (pending, analyzing or
+reviewed), as set by 'intentra scan mark'. For server scans this is the
+status the server reports.
+
+--tool and --repo list only scans from one AI tool or one repository. The
+repository name is matched case-insensitively. In server mode both are
+passed on to the server.
+
 Examples:
   intentra scan list                    # List recent scans (default limit: 20)
   intentra scan list --limit 100        # List up to 100 scans
   intentra scan list --summary          # Show summary only, no individual scans
-  intentra scan list --days 7           # Look back 7 days`,
-		RunE: func(cmd *cobra.Command, args []string) error {
+  intentra scan list --days 7           # Look back 7 days
+  intentra scan list --since 4h         # Scans from the last four hours
+  intentra scan list --since 2024-05-01T09:00:00Z
+  intentra scan list --status analyzing # Scans being triaged
+  intentra scan list --tool cursor --repo myproject
+  intentra scan list --output scans.json  # Write JSON to a file
+  intentra scan list --since-last-sync --json  # Only scans new since the last pull`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if outputPath != "" {
+				jsonOutput = true
+			}
+
 			cfg, err := loadConfig()
 			if err != nil {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
+			var statusFilter models.ScanStatus
+			if status != "" {
+				if statusFilter, err = models.ParseScanStatus(status); err != nil {
+					return fmt.Errorf("invalid --status: %w", err)
+				}
+			}
+
+			var cutoff time.Time
+			if since != "" {
+				if cmd.Flags().Changed("days") {
+					return fmt.Errorf("--since and --days are mutually exclusive")
+				}
+				if sinceLastSync {
+					return fmt.Errorf("--since and --since-last-sync are mutually exclusive")
+				}
+				if cutoff, err = parseSince(since, time.Now()); err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				days = daysSince(cutoff, days)
+			}
+
+			var mark time.Time
+			markName := listWatermarkName(filter, statusFilter)
+			if sinceLastSync {
+				if mark, err = loadWatermark(markName); err != nil {
+					return err
+				}
+				days = daysSince(watermarkSince(mark), days)
+			}
+
 			var scans []models.Scan
 			var source string
 			var totalScans int
@@ -75,14 +155,21 @@ Examples:
 					return fmt.Errorf("failed to create API client: %w", err)
 				}
 
-				resp, err := client.GetScans(days, limit)
-				if err != nil {
-					return fmt.Errorf("failed to fetch scans from server: %w", err)
-				}
-				scans = resp.Scans
 				source = "server"
-				totalScans = resp.Summary.TotalScans
-				serverSummary = &resp.Summary
+				if sinceLastSync {
+					scans, err = fetchScansSince(client, days, filter, watermarkSince(mark))
+					if err != nil {
+						return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
+					}
+				} else {
+					resp, err := client.GetFilteredScans(days, limit, filter)
+					if err != nil {
+						return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
+					}
+					scans = resp.Scans
+					totalScans = resp.Summary.TotalScans
+					serverSummary = &resp.Summary
+				}
 			} else {
 				localScans, err := scanner.LoadScans()
 				if err != nil {
@@ -94,9 +181,39 @@ Examples:
 			}
 
 			sortScansByTime(scans)
+			if !cutoff.IsZero() {
+				scans = filterScansSince(scans, cutoff)
+				totalScans = len(scans)
+				serverSummary = nil
+			}
+			if statusFilter != "" {
+				scans = filterScansByStatus(scans, statusFilter, source == "server")
+				totalScans = len(scans)
+				serverSummary = nil
+			}
+			if filter != (api.ScanFilter{}) {
+				scans = filterScans(scans, filter)
+				totalScans = len(scans)
+				serverSummary = nil
+			}
+			if sinceLastSync {
+				scans = scansAfterWatermark(scans, mark, limit)
+				totalScans = len(scans)
+				serverSummary = nil
+				defer func() {
+					if err == nil {
+						err = saveWatermark(markName, newestEnd(scans, mark))
+					}
+				}()
+			}
 
 			if len(scans) == 0 {
-				if source == "server" {
+				if outputPath != "" && !summaryOnly {
+					return writeJSONOutput([]byte("[]"), outputPath)
+				}
+				if sinceLastSync {
+					fmt.Println("No new scans since the last sync.")
+				} else if source == "server" {
 					fmt.Println("No scans found on server.")
 				} else {
 					fmt.Println("No scans found. Run 'intentra scan aggregate' to process events.")
@@ -122,7 +239,7 @@ Examples:
 					if err != nil {
 						return fmt.Errorf("failed to marshal summary: %w", err)
 					}
-					fmt.Println(string(data))
+					return writeJSONOutput(data, outputPath)
 				} else if serverSummary != nil && serverSummary.TotalScans > 0 {
 					fmt.Printf("Summary: %d scans, $%.2f total cost\n",
 						serverSummary.TotalScans, serverSummary.TotalCost)
@@ -138,8 +255,7 @@ Examples:
 				if err != nil {
 					return fmt.Errorf("failed to marshal scans: %w", err)
 				}
-				fmt.Println(string(data))
-				return nil
+				return writeJSONOutput(data, outputPath)
 			}
 
 			if serverSummary != nil && serverSummary.TotalScans > 0 {
@@ -189,13 +305,23 @@ Examples:
 	cmd.Flags().BoolVar(&summaryOnly, "summary", false, "Show summary only, no individual scans")
 	cmd.Flags().IntVar(&days, "days", 30, "Number of days to look back (server mode only)")
 	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of scans to display")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write JSON to a file instead of stdout (implies --json)")
+	cmd.Flags().BoolVar(&sinceLastSync, "since-last-sync", false, "Only list scans newer than the previous --since-last-sync run, then advance the mark")
+	cmd.Flags().StringVar(&since, "since", "", "Only list scans started within a duration (4h, 2d) or after an RFC3339 time")
+	cmd.Flags().StringVar(&status, "status", "", "Only list scans with this status (pending, analyzing, reviewed)")
+	cmd.Flags().StringVar(&filter.Tool, "tool", "", "Only list scans from this tool (cursor, claude, gemini, copilot, windsurf)")
+	cmd.Flags().StringVar(&filter.Repo, "repo", "", "Only list scans from this repository (case-insensitive)")
 
 	return cmd
 }
 
 // newScanShowCmd returns a cobra.Command for displaying scan details.
 func newScanShowCmd() *cobra.Command {
-	return &cobra.Command{
+	var raw bool
+	var outputPath string
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
 		Use:           "show <id>",
 		Short:         "Show scan details",
 		SilenceUsage:  true,
@@ -203,16 +329,51 @@ func newScanShowCmd() *cobra.Command {
 		Long: `Show detailed information about a specific scan.
 
 When server mode is enabled, the scan is fetched from the API.
-When server mode is disabled (local-only), the scan is read from local files.`,
+When server mode is disabled (local-only), the scan is read from local files.
+
+The scan is summarized for reading: token split, LLM and tool call counts,
+MCP tool usage by cost, and files modified. Use --json (or --output) for
+the full scan as JSON.
+
+With --raw, the local scan is printed as the exact JSON body intentra would
+POST to the API, including the resolved device ID and rich trace redaction.
+
+Examples:
+  intentra scan show abc123
+  intentra scan show abc123 --json
+  intentra scan show abc123 --raw
+  intentra scan show abc123 --output scan.json`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			scanID := args[0]
+			if outputPath != "" {
+				jsonOutput = true
+			}
 
 			cfg, err := loadConfig()
 			if err != nil {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
+			if raw {
+				scan, err := scanner.LoadScan(scanID)
+				if err != nil {
+					return withExitCode(ExitCodeNotFound, fmt.Errorf("scan not found: %s", scanID))
+				}
+				deviceID, err := device.GetDeviceID()
+				if err != nil {
+					return fmt.Errorf("failed to get device ID: %w", err)
+				}
+				if outputPath == "" {
+					return writeRawPayload(cmd.OutOrStdout(), scan, deviceID, cfg.RichTraces)
+				}
+				var buf bytes.Buffer
+				if err := writeRawPayload(&buf, scan, deviceID, cfg.RichTraces); err != nil {
+					return err
+				}
+				return fileutil.WriteFileAtomic(outputPath, buf.Bytes())
+			}
+
 			if cfg.Server.Enabled {
 				client, err := api.NewClient(cfg)
 				if err != nil {
@@ -221,7 +382,10 @@ When server mode is disabled (local-only), the scan is read from local files.`,
 
 				resp, err := client.GetScan(scanID)
 				if err != nil {
-					return err
+					return apiExitCode(err)
+				}
+				if !jsonOutput {
+					return printScanDetails(cmd.OutOrStdout(), &resp.Scan, resp.ViolationDetails)
 				}
 
 				output := map[string]any{
@@ -235,23 +399,161 @@ When server mode is disabled (local-only), the scan is read from local files.`,
 				if err != nil {
 					return fmt.Errorf("failed to marshal scan: %w", err)
 				}
-				fmt.Println(string(data))
+				return writeJSONOutput(data, outputPath)
 			} else {
 				scan, err := scanner.LoadScan(scanID)
 				if err != nil {
-					return fmt.Errorf("scan not found: %s", scanID)
+					return withExitCode(ExitCodeNotFound, fmt.Errorf("scan not found: %s", scanID))
+				}
+				if !jsonOutput {
+					return printScanDetails(cmd.OutOrStdout(), scan, nil)
 				}
 
 				data, err := json.MarshalIndent(scan, "", "  ")
 				if err != nil {
 					return fmt.Errorf("failed to marshal scan: %w", err)
 				}
-				fmt.Println(string(data))
+				return writeJSONOutput(data, outputPath)
 			}
-
-			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&raw, "raw", false, "Print the exact API payload for a local scan")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write JSON to a file instead of stdout")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output the full scan as JSON")
+
+	return cmd
+}
+
+// printScanDetails writes a human-readable summary of a scan: where it came
+// from, how its tokens split, its call counts, MCP tool usage ordered by
+// cost, and the files it modified.
+func printScanDetails(w io.Writer, s *models.Scan, violations map[string]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Scan:\t%s\n", s.ID)
+	tool := s.Tool
+	if s.ToolVersion != "" {
+		tool += " " + s.ToolVersion
+	}
+	fmt.Fprintf(tw, "Tool:\t%s\n", tool)
+	if s.Model != "" {
+		fmt.Fprintf(tw, "Model:\t%s\n", s.Model)
+	}
+	if !s.StartTime.IsZero() {
+		started := s.StartTime.Local().Format("2006-01-02 15:04:05")
+		if !s.EndTime.IsZero() {
+			started += fmt.Sprintf(" (%s)", s.EndTime.Sub(s.StartTime).Round(time.Second))
+		}
+		fmt.Fprintf(tw, "Started:\t%s\n", started)
+	}
+	if s.RepoName != "" {
+		repo := s.RepoName
+		if s.BranchName != "" {
+			repo += " @ " + s.BranchName
+		}
+		fmt.Fprintf(tw, "Repo:\t%s\n", repo)
+	}
+	fmt.Fprintf(tw, "Cost:\t$%.4f\n", s.EstimatedCost)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Tokens:")
+	for _, row := range []struct {
+		name string
+		n    int
+	}{{"Input", s.InputTokens}, {"Output", s.OutputTokens}, {"Thinking", s.ThinkingTokens}} {
+		fmt.Fprintf(tw, "  %s:\t%d\t%s\n", row.name, row.n, percentOf(row.n, s.TotalTokens))
+	}
+	fmt.Fprintf(tw, "  Total:\t%d\n", s.TotalTokens)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Calls:")
+	fmt.Fprintf(tw, "  LLM:\t%d\n", s.LLMCalls)
+	fmt.Fprintf(tw, "  Tool:\t%d\n", s.ToolCalls)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	if len(s.MCPToolUsage) > 0 {
+		usage := slices.Clone(s.MCPToolUsage)
+		sort.SliceStable(usage, func(i, j int) bool {
+			return usage[i].EstimatedCost > usage[j].EstimatedCost
+		})
+		fmt.Fprintln(w, "\nMCP tools:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  SERVER\tTOOL\tCALLS\tERRORS\tCOST")
+		for _, u := range usage {
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%d\t$%.4f\n", u.ServerName, u.ToolName, u.CallCount, u.ErrorCount, u.EstimatedCost)
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+	}
+
+	if len(s.FilesModified) > 0 {
+		fmt.Fprintln(w, "\nFiles modified:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, f := range s.FilesModified {
+			path, _ := f["file_path"].(string)
+			note := fmt.Sprintf("+%d -%d", jsonInt(f["lines_added"]), jsonInt(f["lines_removed"]))
+			if isNew, _ := f["is_new_file"].(bool); isNew {
+				note += " (new)"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", path, note)
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush output: %w", err)
+		}
+	}
+
+	if len(violations) > 0 {
+		fmt.Fprintln(w, "\nViolations:")
+		for _, k := range slices.Sorted(maps.Keys(violations)) {
+			fmt.Fprintf(w, "  %s: %s\n", k, violations[k])
+		}
+	}
+	return nil
+}
+
+// percentOf formats n as a share of total, or "" when total is zero.
+func percentOf(n, total int) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+}
+
+// jsonInt reads an integer from a FilesModified entry, which holds ints
+// when freshly aggregated and float64s once loaded from JSON.
+func jsonInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// writeRawPayload writes the scan's API payload marshaled exactly as the API
+// client sends it, followed by a newline.
+func writeRawPayload(w io.Writer, scan *models.Scan, deviceID string, richTraces bool) error {
+	data, err := json.Marshal(api.ScanPayload(scan, deviceID, richTraces))
+	if err != nil {
+		return fmt.Errorf("failed to marshal scan: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	return nil
+}
+
+// writeJSONOutput prints data to stdout, or writes it to path when set.
+func writeJSONOutput(data []byte, path string) error {
+	if path == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return fileutil.WriteFileAtomic(path, append(data, '\n'))
 }
 
 // newScanTodayCmd returns a cobra.Command for showing today's scans.
@@ -288,7 +590,7 @@ Examples:
 
 				resp, err := client.GetScans(1, 500)
 				if err != nil {
-					return fmt.Errorf("failed to fetch scans from server: %w", err)
+					return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
 				}
 				scans = resp.Scans
 			} else {
@@ -388,38 +690,794 @@ Examples:
 
 // newScanAggregateCmd returns a cobra.Command for aggregating events into scans.
 func newScanAggregateCmd() *cobra.Command {
-	return &cobra.Command{
+	var outputPath string
+	var toStdout bool
+	var save bool
+
+	cmd := &cobra.Command{
 		Use:           "aggregate",
 		Short:         "Process events into scans",
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		Long: `Process captured events into scans.
+
+By default scans are saved to the local scans directory. With --output or
+--stdout the scans are written there instead; add --save to also keep them
+in the scans directory. Files ending in .jsonl get one scan per line,
+anything else gets a JSON array.
+
+Examples:
+  intentra scan aggregate
+  intentra scan aggregate --output scans.jsonl
+  intentra scan aggregate --stdout | jq '.[].total_tokens'
+  intentra scan aggregate --output scans.json --save`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Keep stdout clean for piping when scans are written there.
+			logOut := io.Writer(os.Stdout)
+			if toStdout {
+				logOut = os.Stderr
+			}
+
 			events, err := scanner.LoadEvents()
 			if err != nil {
 				return fmt.Errorf("failed to load events: %w", err)
 			}
 
 			if len(events) == 0 {
-				fmt.Println("No events found. Use Cursor with hooks installed to generate events.")
+				fmt.Fprintln(logOut, "No events found. Use Cursor with hooks installed to generate events.")
 				return nil
 			}
 
 			scans := scanner.AggregateEvents(events)
-			fmt.Printf("Found %d events, aggregated into %d scans\n", len(events), len(scans))
+			fmt.Fprintf(logOut, "Found %d events, aggregated into %d scans\n", len(events), len(scans))
+
+			if outputPath != "" {
+				if err := writeScansFile(outputPath, scans); err != nil {
+					return err
+				}
+				fmt.Fprintf(logOut, "Wrote %d scans to %s\n", len(scans), outputPath)
+			}
+			if toStdout {
+				if err := writeScans(os.Stdout, scans, false); err != nil {
+					return err
+				}
+			}
+			if (outputPath != "" || toStdout) && !save {
+				return nil
+			}
 
 			for _, scan := range scans {
 				if err := scanner.SaveScan(&scan); err != nil {
-					fmt.Printf("Warning: failed to save scan %s: %v\n", scan.ID, err)
+					fmt.Fprintf(logOut, "Warning: failed to save scan %s: %v\n", scan.ID, err)
 					continue
 				}
 				id := scan.ID
 				if len(id) > 8 {
 					id = id[:8]
 				}
-				fmt.Printf("Saved scan %s (%d events, %d tokens)\n", id, len(scan.Events), scan.TotalTokens)
+				fmt.Fprintf(logOut, "Saved scan %s (%d events, %d tokens)\n", id, len(scan.Events), scan.TotalTokens)
 			}
 
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write scans to a file (.jsonl for JSON Lines, otherwise JSON)")
+	cmd.Flags().BoolVar(&toStdout, "stdout", false, "Write scans to stdout as JSON")
+	cmd.Flags().BoolVar(&save, "save", false, "Also save scans to the scans directory when using --output or --stdout")
+
+	return cmd
+}
+
+// writeScans writes scans to w as a JSON array, or one JSON object per line when jsonl is true.
+func writeScans(w io.Writer, scans []models.Scan, jsonl bool) error {
+	if jsonl {
+		enc := json.NewEncoder(w)
+		for i := range scans {
+			if err := enc.Encode(&scans[i]); err != nil {
+				return fmt.Errorf("failed to encode scan %s: %w", scans[i].ID, err)
+			}
+		}
+		return nil
+	}
+
+	if scans == nil {
+		scans = []models.Scan{}
+	}
+	data, err := json.MarshalIndent(scans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal scans: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return fmt.Errorf("failed to write scans: %w", err)
+	}
+	return nil
+}
+
+// writeScansFile writes scans to path, choosing JSON Lines for a .jsonl extension.
+func writeScansFile(path string, scans []models.Scan) error {
+	var buf bytes.Buffer
+	jsonl := strings.EqualFold(filepath.Ext(path), ".jsonl")
+	if err := writeScans(&buf, scans, jsonl); err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(path, buf.Bytes())
+}
+
+// scanStats holds totals computed across a set of scans.
+type scanStats struct {
+	Scans             int     `json:"scans"`
+	TotalTokens       int     `json:"total_tokens"`
+	EstimatedCost     float64 `json:"estimated_cost"`
+	EstimatedCostLow  float64 `json:"estimated_cost_low"`
+	EstimatedCostHigh float64 `json:"estimated_cost_high"`
+	LLMCalls          int     `json:"llm_calls"`
+	ToolCalls         int     `json:"tool_calls"`
+	RateLimitHits     int     `json:"rate_limit_hits"`
+	RateLimitedScans  int     `json:"rate_limited_scans"`
+	RateLimitedPct    float64 `json:"rate_limited_percent"`
+	RateLimitsPerCall float64 `json:"rate_limits_per_llm_call"`
+	NewFiles          int     `json:"new_files"`
+	ModifiedFiles     int     `json:"modified_files"`
+
+	// Reported-cost comparison, over scans whose tool reported its own cost.
+	// CostDriftPct is how far our estimate is from the reported figure.
+	ReportedCostScans    int     `json:"reported_cost_scans"`
+	ReportedCost         float64 `json:"reported_cost"`
+	EstimatedForReported float64 `json:"estimated_cost_for_reported"`
+	CostDriftPct         float64 `json:"cost_drift_percent"`
+}
+
+// computeScanStats aggregates usage and rate-limit frequency across scans.
+func computeScanStats(scans []models.Scan) scanStats {
+	var st scanStats
+	st.Scans = len(scans)
+	for _, s := range scans {
+		st.TotalTokens += s.TotalTokens
+		st.EstimatedCost += s.EstimatedCost
+		// Scans saved before cost bands existed count at their point estimate.
+		if s.EstimatedCostHigh > 0 {
+			st.EstimatedCostLow += s.EstimatedCostLow
+			st.EstimatedCostHigh += s.EstimatedCostHigh
+		} else {
+			st.EstimatedCostLow += s.EstimatedCost
+			st.EstimatedCostHigh += s.EstimatedCost
+		}
+		st.LLMCalls += s.LLMCalls
+		st.ToolCalls += s.ToolCalls
+		newFiles, modifiedFiles := s.NewFilesCount, s.ModifiedFilesCount
+		if newFiles == 0 && modifiedFiles == 0 {
+			// Scans saved before the counts existed.
+			newFiles, modifiedFiles = scanner.CountFileChanges(s.FilesModified)
+		}
+		st.NewFiles += newFiles
+		st.ModifiedFiles += modifiedFiles
+		st.RateLimitHits += s.RateLimitHits
+		if s.RateLimitHits > 0 {
+			st.RateLimitedScans++
+		}
+		if s.ReportedCost > 0 {
+			st.ReportedCostScans++
+			st.ReportedCost += s.ReportedCost
+			st.EstimatedForReported += s.EstimatedCost
+		}
+	}
+	if st.ReportedCost > 0 {
+		st.CostDriftPct = (st.EstimatedForReported - st.ReportedCost) / st.ReportedCost * 100
+	}
+	if st.Scans > 0 {
+		st.RateLimitedPct = float64(st.RateLimitedScans) / float64(st.Scans) * 100
+	}
+	if st.LLMCalls > 0 {
+		st.RateLimitsPerCall = float64(st.RateLimitHits) / float64(st.LLMCalls)
+	}
+	return st
+}
+
+// printScanStats writes scan statistics as a table or JSON.
+func printScanStats(w io.Writer, st scanStats, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Scans:\t%d\n", st.Scans)
+	fmt.Fprintf(tw, "Tokens:\t%d\n", st.TotalTokens)
+	fmt.Fprintf(tw, "Estimated cost:\t$%.2f (range $%.2f - $%.2f)\n", st.EstimatedCost, st.EstimatedCostLow, st.EstimatedCostHigh)
+	if st.ReportedCostScans > 0 {
+		fmt.Fprintf(tw, "Reported cost:\t$%.2f in %d scans (estimated $%.2f, drift %+.1f%%)\n",
+			st.ReportedCost, st.ReportedCostScans, st.EstimatedForReported, st.CostDriftPct)
+	}
+	fmt.Fprintf(tw, "LLM calls:\t%d\n", st.LLMCalls)
+	fmt.Fprintf(tw, "Tool calls:\t%d\n", st.ToolCalls)
+	if st.NewFiles > 0 || st.ModifiedFiles > 0 {
+		fmt.Fprintf(tw, "Files:\t%d new, %d modified (%.0f%% new)\n",
+			st.NewFiles, st.ModifiedFiles, float64(st.NewFiles)*100/float64(st.NewFiles+st.ModifiedFiles))
+	}
+	fmt.Fprintf(tw, "Rate limits:\t%d hits in %d scans (%.1f%% of scans)\n",
+		st.RateLimitHits, st.RateLimitedScans, st.RateLimitedPct)
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+	return nil
+}
+
+// newScanStatsCmd returns a cobra.Command for showing aggregate statistics over local scans.
+func newScanStatsCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "stats",
+		Short:         "Show aggregate statistics for local scans",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Show totals across locally stored scans, including how often tools hit
+provider rate limits (HTTP 429 or "rate limited" errors).
+
+When tools report their own cost, the reported total is shown next to
+intentra's estimate for the same scans to surface estimation drift.
+
+Examples:
+  intentra scan stats
+  intentra scan stats --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scans, err := scanner.LoadScans()
+			if err != nil {
+				return err
+			}
+			return printScanStats(os.Stdout, computeScanStats(scans), jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// newScanExportCmd returns a cobra.Command for exporting scans as CSV or JSON.
+func newScanExportCmd() *cobra.Command {
+	var format string
+	var days int
+	var limit int
+	var sinceLastSync bool
+
+	cmd := &cobra.Command{
+		Use:           "export",
+		Short:         "Export scans as CSV or JSON",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Export scans from the server (if server mode is enabled) or local storage
+to stdout as CSV or JSON.
+
+CSV output has one row per scan with the columns id, tool, model,
+total_tokens, estimated_cost, start_time, repo_name, and branch_name.
+
+With --since-last-sync only scans that ended after the previous
+--since-last-sync export are written, replacing --days, and the mark
+advances once they are written. This lets periodic jobs pull increments.
+
+Examples:
+  intentra scan export --format csv --days 30 > usage.csv
+  intentra scan export --format json --days 7
+  intentra scan export --since-last-sync >> usage.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "csv" && format != "json" {
+				return fmt.Errorf("unsupported format %q (supported: csv, json)", format)
+			}
+			if days <= 0 {
+				return fmt.Errorf("--days must be positive")
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			var mark time.Time
+			if sinceLastSync {
+				if mark, err = loadWatermark("export"); err != nil {
+					return err
+				}
+				days = daysSince(watermarkSince(mark), days)
+			}
+
+			var scans []models.Scan
+			if cfg.Server.Enabled {
+				client, err := api.NewClient(cfg)
+				if err != nil {
+					return fmt.Errorf("failed to create API client: %w", err)
+				}
+				if sinceLastSync {
+					scans, err = fetchScansSince(client, days, api.ScanFilter{}, watermarkSince(mark))
+					if err != nil {
+						return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
+					}
+				} else {
+					resp, err := client.GetScans(days, limit)
+					if err != nil {
+						return apiExitCode(fmt.Errorf("failed to fetch scans from server: %w", err))
+					}
+					scans = resp.Scans
+				}
+			} else {
+				localScans, err := scanner.LoadScans()
+				if err != nil {
+					return err
+				}
+				scans = filterScansSince(localScans, time.Now().AddDate(0, 0, -days))
+			}
+
+			if sinceLastSync {
+				scans = scansAfterWatermark(scans, mark, limit)
+			} else {
+				sortScansByTime(scans)
+				if limit > 0 && len(scans) > limit {
+					scans = scans[:limit]
+				}
+			}
+
+			if format == "json" {
+				err = writeScans(cmd.OutOrStdout(), scans, false)
+			} else {
+				err = writeScansCSV(cmd.OutOrStdout(), scans)
+			}
+			if err != nil || !sinceLastSync {
+				return err
+			}
+			return saveWatermark("export", newestEnd(scans, mark))
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "csv", "Output format (csv, json)")
+	cmd.Flags().IntVar(&days, "days", 30, "Number of days to look back")
+	cmd.Flags().IntVar(&limit, "limit", 1000, "Maximum number of scans to export (0 for all local scans)")
+	cmd.Flags().BoolVar(&sinceLastSync, "since-last-sync", false, "Only export scans newer than the previous --since-last-sync export, then advance the mark")
+
+	return cmd
+}
+
+// newScanDeleteCmd returns a cobra.Command for deleting a local scan.
+func newScanDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:           "delete <id>",
+		Short:         "Delete a local scan",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Delete a scan from local storage (~/.intentra/scans/). Scans already
+synced to the server are not affected.
+
+Examples:
+  intentra scan delete abc123`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanID := args[0]
+			// Only check that the file exists: a scan that no longer parses
+			// must still be deletable.
+			path, err := scanner.ScanPath(scanID)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(path); err != nil {
+				if os.IsNotExist(err) {
+					return withExitCode(ExitCodeNotFound, fmt.Errorf("scan not found: %s", scanID))
+				}
+				return fmt.Errorf("failed to read scan %s: %w", scanID, err)
+			}
+			if err := scanner.DeleteScan(scanID); err != nil {
+				return fmt.Errorf("failed to delete scan %s: %w", scanID, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✓ Deleted scan %s\n", scanID)
+			return nil
+		},
+	}
+}
+
+// newScanMarkCmd returns a cobra.Command for setting a scan's review status.
+func newScanMarkCmd() *cobra.Command {
+	var server bool
+
+	cmd := &cobra.Command{
+		Use:           "mark <id> <pending|analyzing|reviewed>",
+		Short:         "Set the review status of a scan",
+		Args:          cobra.ExactArgs(2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Set the review status of a local scan, for triaging flagged sessions:
+pending (the default for new scans), analyzing, or reviewed. Use
+'intentra scan list --status' to list the scans in each state.
+
+The review status is separate from sync state, so marking a scan does not
+change whether 'intentra sync' uploads it. With --server the status is also
+updated on the server, for scans that have already been synced.
+
+Examples:
+  intentra scan mark abc123 analyzing
+  intentra scan mark abc123 reviewed --server`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scanID := args[0]
+			status, err := models.ParseScanStatus(args[1])
+			if err != nil {
+				return err
+			}
+
+			if server {
+				cfg, err := loadConfig()
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				if !cfg.Server.Enabled {
+					return fmt.Errorf("--server requires server sync to be enabled")
+				}
+				client, err := api.NewClient(cfg)
+				if err != nil {
+					return fmt.Errorf("failed to create API client: %w", err)
+				}
+				if err := client.UpdateScanStatus(scanID, status); err != nil {
+					return apiExitCode(fmt.Errorf("failed to update scan %s on server: %w", scanID, err))
+				}
+			}
+
+			scan, err := scanner.LoadScan(scanID)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					return fmt.Errorf("failed to read scan %s: %w", scanID, err)
+				}
+				if !server {
+					return withExitCode(ExitCodeNotFound, fmt.Errorf("scan not found: %s", scanID))
+				}
+			} else {
+				scan.ReviewStatus = status
+				if err := scanner.SaveScan(scan); err != nil {
+					return fmt.Errorf("failed to update scan %s: %w", scanID, err)
+				}
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "✓ Marked scan %s %s\n", scanID, status)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&server, "server", false, "Also update the status on the server")
+
+	return cmd
+}
+
+// newScanPruneCmd returns a cobra.Command for deleting old local scans.
+func newScanPruneCmd() *cobra.Command {
+	var olderThan string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:           "prune",
+		Short:         "Delete local scans older than a retention window",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Delete scans in local storage that started longer ago than --older-than.
+The age accepts days (30d) or a Go duration (12h). Scans without a start
+time are kept. Use --dry-run to list the scans that would be deleted.
+
+Examples:
+  intentra scan prune --older-than 30d
+  intentra scan prune --older-than 90d --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return fmt.Errorf("invalid --older-than: %w", err)
+			}
+
+			scans, err := scanner.LoadScans()
+			if err != nil {
+				return fmt.Errorf("failed to load scans: %w", err)
+			}
+			expired := scansOlderThan(scans, time.Now().Add(-age))
+			sortScansByTime(expired)
+
+			w := cmd.OutOrStdout()
+			if dryRun {
+				for _, s := range expired {
+					fmt.Fprintf(w, "Would delete %s (started %s)\n", s.ID, s.StartTime.Local().Format("2006-01-02 15:04"))
+				}
+				fmt.Fprintf(w, "%d scan(s) would be deleted\n", len(expired))
+				return nil
+			}
+
+			deleted := 0
+			for _, s := range expired {
+				if err := scanner.DeleteScan(s.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to delete scan %s: %v\n", s.ID, err)
+					continue
+				}
+				deleted++
+			}
+			fmt.Fprintf(w, "✓ Deleted %d scan(s) older than %s\n", deleted, olderThan)
+			if deleted < len(expired) {
+				return fmt.Errorf("failed to delete %d scan(s)", len(expired)-deleted)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete scans that started longer ago than this (e.g. 30d, 12h)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the scans that would be deleted without deleting them")
+	_ = cmd.MarkFlagRequired("older-than")
+
+	return cmd
+}
+
+// syncLocalLookupLimit is how many server scans are fetched per request when
+// looking up the IDs that sync-local should skip.
+const syncLocalLookupLimit = 1000
+
+// newScanSyncLocalCmd returns a cobra.Command for uploading local scans
+// that are not yet on the server.
+func newScanSyncLocalCmd() *cobra.Command {
+	var batchSize int
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:           "sync-local",
+		Short:         "Upload local scans that the server does not have yet",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Upload every scan in local storage (~/.intentra/scans/) to the server,
+skipping scans whose IDs the server already has. Use this after enabling
+server sync to migrate history recorded while running local-only.
+
+Unlike 'intentra sync now', scans are uploaded regardless of status and
+local files are kept. Scans are sent in batches of --batch-size.
+
+Examples:
+  intentra scan sync-local
+  intentra scan sync-local --dry-run
+  intentra scan sync-local --batch-size 20`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize <= 0 {
+				return fmt.Errorf("--batch-size must be positive")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if !cfg.Server.Enabled {
+				return withExitCode(ExitCodeConfig, fmt.Errorf("server sync is not enabled. Set server.enabled=true in config or use --api-server"))
+			}
+
+			scans, err := scanner.LoadScans()
+			if err != nil {
+				return fmt.Errorf("failed to load scans: %w", err)
+			}
+			w := cmd.OutOrStdout()
+			if len(scans) == 0 {
+				fmt.Fprintln(w, "No local scans to upload.")
+				return nil
+			}
+			sortScansByTime(scans)
+
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create API client: %w", err)
+			}
+			existing, err := remoteScanIDs(client, scans)
+			if err != nil {
+				return apiExitCode(fmt.Errorf("failed to list server scans: %w", err))
+			}
+
+			var pending []*models.Scan
+			for i := range scans {
+				if !existing[scans[i].ID] {
+					pending = append(pending, &scans[i])
+				}
+			}
+			skipped := len(scans) - len(pending)
+
+			if dryRun {
+				for _, s := range pending {
+					fmt.Fprintf(w, "Would upload %s\n", s.ID)
+				}
+				fmt.Fprintf(w, "%d scan(s) would be uploaded, %d already on server\n", len(pending), skipped)
+				return nil
+			}
+
+			uploaded, failed := uploadScanBatches(w, client, pending, batchSize)
+			fmt.Fprintf(w, "Uploaded %d scan(s), %d skipped (already on server), %d failed\n", uploaded, skipped, failed)
+			if failed > 0 {
+				return withExitCode(ExitCodeNetwork, fmt.Errorf("failed to upload %d scan(s)", failed))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&batchSize, "batch-size", 50, "Number of scans to upload per batch")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the scans that would be uploaded without sending them")
+
+	return cmd
+}
+
+// remoteScanIDs returns the IDs of server scans within the window covered
+// by the oldest of the local scans, paging through all of them.
+func remoteScanIDs(client *api.Client, local []models.Scan) (map[string]bool, error) {
+	days := 1
+	for _, s := range local {
+		if s.StartTime.IsZero() {
+			continue
+		}
+		if d := int(time.Since(s.StartTime).Hours()/24) + 1; d > days {
+			days = d
+		}
+	}
+
+	ids := make(map[string]bool)
+	for offset := 0; ; offset += syncLocalLookupLimit {
+		resp, err := client.GetFilteredScans(days, syncLocalLookupLimit, api.ScanFilter{Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, s := range resp.Scans {
+			if !ids[s.ID] {
+				ids[s.ID] = true
+				added++
+			}
+		}
+		// A short page is the last one. A page with nothing new means the
+		// server ignored the offset, so asking again would loop forever.
+		if len(resp.Scans) < syncLocalLookupLimit || added == 0 {
+			return ids, nil
+		}
+	}
+}
+
+// uploadScanBatches sends scans in batches of size, continuing past
+// failures, and returns how many were uploaded and failed.
+func uploadScanBatches(w io.Writer, client *api.Client, scans []*models.Scan, size int) (uploaded, failed int) {
+	for start := 0; start < len(scans); start += size {
+		end := min(start+size, len(scans))
+		batch := scans[start:end]
+		fmt.Fprintf(w, "Uploading scans %d-%d of %d...\n", start+1, end, len(scans))
+		err := client.SendScans(batch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		n := len(syncedScans(batch, err))
+		uploaded += n
+		failed += len(batch) - n
+	}
+	return uploaded, failed
+}
+
+// parseAge parses a positive age given in days ("30d") or as a Go duration ("12h").
+func parseAge(s string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days: %s", s)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("expected days (30d) or a duration (12h), got %q", s)
+		}
+		age = d
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("age must be positive, got %s", s)
+	}
+	return age, nil
+}
+
+// parseSince parses a --since value: an RFC3339 timestamp, or an age
+// accepted by parseAge counted back from now.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		if t.After(now) {
+			return time.Time{}, fmt.Errorf("%s is in the future", s)
+		}
+		return t, nil
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a duration (4h, 2d) or an RFC3339 time, got %q", s)
+	}
+	return now.Add(-age), nil
+}
+
+// scansOlderThan returns the scans that started before cutoff. Scans
+// without a start time are never included.
+func scansOlderThan(scans []models.Scan, cutoff time.Time) []models.Scan {
+	var old []models.Scan
+	for _, s := range scans {
+		if !s.StartTime.IsZero() && s.StartTime.Before(cutoff) {
+			old = append(old, s)
+		}
+	}
+	return old
+}
+
+// filterScansSince returns the scans that started at or after since.
+func filterScansSince(scans []models.Scan, since time.Time) []models.Scan {
+	var filtered []models.Scan
+	for _, s := range scans {
+		if !s.StartTime.Before(since) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// filterScansByStatus returns the scans in review state status: the local
+// review status, or for server scans the server's status. Scans without
+// one count as pending.
+func filterScansByStatus(scans []models.Scan, status models.ScanStatus, server bool) []models.Scan {
+	var filtered []models.Scan
+	for _, s := range scans {
+		got := s.EffectiveReviewStatus()
+		if server {
+			got = s.EffectiveStatus()
+		}
+		if got == status {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// filterScans returns the scans matching filter's tool and repository,
+// ignoring case.
+func filterScans(scans []models.Scan, filter api.ScanFilter) []models.Scan {
+	var filtered []models.Scan
+	for _, s := range scans {
+		if filter.Tool != "" && !strings.EqualFold(s.Tool, filter.Tool) {
+			continue
+		}
+		if filter.Repo != "" && !strings.EqualFold(s.RepoName, filter.Repo) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	return filtered
+}
+
+// scanCSVHeader lists the columns written by writeScansCSV.
+var scanCSVHeader = []string{"id", "tool", "model", "total_tokens", "estimated_cost", "start_time", "repo_name", "branch_name"}
+
+// writeScansCSV writes scans as CSV with a header row. Fields containing
+// commas, quotes, or newlines are quoted per RFC 4180.
+func writeScansCSV(w io.Writer, scans []models.Scan) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(scanCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, s := range scans {
+		startTime := ""
+		if !s.StartTime.IsZero() {
+			startTime = s.StartTime.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			s.ID,
+			s.Tool,
+			s.Model,
+			strconv.Itoa(s.TotalTokens),
+			strconv.FormatFloat(s.EstimatedCost, 'f', 6, 64),
+			startTime,
+			s.RepoName,
+			s.BranchName,
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write scan %s: %w", s.ID, err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
 }
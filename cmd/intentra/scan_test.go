@@ -0,0 +1,994 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/device"
+	"github.com/intentrahq/intentra-cli/internal/scanner"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestComputeScanStats_RateLimits(t *testing.T) {
+	scans := []models.Scan{
+		{TotalTokens: 1000, LLMCalls: 4, RateLimitHits: 2},
+		{TotalTokens: 500, LLMCalls: 4},
+		{TotalTokens: 250, LLMCalls: 2, RateLimitHits: 1},
+		{TotalTokens: 250, LLMCalls: 2},
+	}
+
+	st := computeScanStats(scans)
+	if st.Scans != 4 {
+		t.Errorf("Scans = %d, want 4", st.Scans)
+	}
+	if st.TotalTokens != 2000 {
+		t.Errorf("TotalTokens = %d, want 2000", st.TotalTokens)
+	}
+	if st.RateLimitHits != 3 {
+		t.Errorf("RateLimitHits = %d, want 3", st.RateLimitHits)
+	}
+	if st.RateLimitedScans != 2 {
+		t.Errorf("RateLimitedScans = %d, want 2", st.RateLimitedScans)
+	}
+	if st.RateLimitedPct != 50 {
+		t.Errorf("RateLimitedPct = %f, want 50", st.RateLimitedPct)
+	}
+
+	var buf bytes.Buffer
+	if err := printScanStats(&buf, st, false); err != nil {
+		t.Fatalf("printScanStats failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "3 hits in 2 scans (50.0% of scans)") {
+		t.Errorf("output missing rate-limit line:\n%s", buf.String())
+	}
+}
+
+func TestComputeScanStats_FileCounts(t *testing.T) {
+	scans := []models.Scan{
+		{NewFilesCount: 2, ModifiedFilesCount: 1},
+		{FilesModified: []map[string]any{
+			{"file_path": "a.go", "is_new_file": true},
+			{"file_path": "b.go", "is_new_file": false},
+			{"file_path": "c.go", "is_new_file": false},
+		}},
+	}
+
+	st := computeScanStats(scans)
+	if st.NewFiles != 3 || st.ModifiedFiles != 3 {
+		t.Errorf("files = %d new, %d modified; want 3, 3", st.NewFiles, st.ModifiedFiles)
+	}
+
+	var buf bytes.Buffer
+	if err := printScanStats(&buf, st, false); err != nil {
+		t.Fatalf("printScanStats failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "3 new, 3 modified (50% new)") {
+		t.Errorf("output missing files line:\n%s", buf.String())
+	}
+}
+
+func TestComputeScanStats_Empty(t *testing.T) {
+	st := computeScanStats(nil)
+	if st.Scans != 0 || st.RateLimitedPct != 0 || st.RateLimitsPerCall != 0 {
+		t.Errorf("unexpected stats for no scans: %+v", st)
+	}
+}
+
+func TestWriteScansFile_JSON(t *testing.T) {
+	scans := []models.Scan{
+		{ID: "scan_aaa", TotalTokens: 100},
+		{ID: "scan_bbb", TotalTokens: 200},
+	}
+	path := filepath.Join(t.TempDir(), "scans.json")
+
+	if err := writeScansFile(path, scans); err != nil {
+		t.Fatalf("writeScansFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	var got []models.Scan
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "scan_aaa" || got[1].TotalTokens != 200 {
+		t.Errorf("unexpected scans: %+v", got)
+	}
+}
+
+func TestWriteScansFile_JSONL(t *testing.T) {
+	scans := []models.Scan{
+		{ID: "scan_aaa"},
+		{ID: "scan_bbb"},
+		{ID: "scan_ccc"},
+	}
+	path := filepath.Join(t.TempDir(), "scans.jsonl")
+
+	if err := writeScansFile(path, scans); err != nil {
+		t.Fatalf("writeScansFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		var s models.Scan
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i, err)
+		}
+		if s.ID != scans[i].ID {
+			t.Errorf("line %d ID = %q, want %q", i, s.ID, scans[i].ID)
+		}
+	}
+}
+
+func TestWriteScans_EmptyIsArray(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeScans(&buf, nil, false); err != nil {
+		t.Fatalf("writeScans failed: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("expected empty JSON array, got %q", buf.String())
+	}
+}
+
+func TestScanAggregate_OutputSkipsStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	events := `{"normalized_type":"before_prompt","conversation_id":"conv-1","timestamp":"2025-01-01T00:00:00Z"}
+{"normalized_type":"stop","conversation_id":"conv-1","timestamp":"2025-01-01T00:01:00Z"}
+`
+	if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte(events), 0600); err != nil {
+		t.Fatalf("failed to write events: %v", err)
+	}
+
+	outPath := filepath.Join(dir, "out.jsonl")
+	cmd := newScanAggregateCmd()
+	cmd.SetArgs([]string{"--output", outPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	var s models.Scan
+	if err := json.Unmarshal(bytes.TrimSpace(data), &s); err != nil {
+		t.Fatalf("output is not a JSON line: %v", err)
+	}
+	if s.ConversationID != "conv-1" {
+		t.Errorf("ConversationID = %q, want conv-1", s.ConversationID)
+	}
+
+	if entries, err := os.ReadDir(filepath.Join(dir, "scans")); err == nil && len(entries) > 0 {
+		t.Errorf("expected no scans saved to store, found %d", len(entries))
+	}
+}
+
+func TestWriteRawPayload_MatchesBuildAPIPayload(t *testing.T) {
+	scan := &models.Scan{
+		ID:          "scan-raw",
+		Tool:        "claude",
+		TotalTokens: 1200,
+		RepoName:    "widgets",
+		Events: []models.Event{
+			{NormalizedType: "after_tool", ToolName: "Bash", ToolInput: []byte(`{"command":"ls"}`)},
+		},
+	}
+
+	for _, richTraces := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := writeRawPayload(&buf, scan, "device-1", richTraces); err != nil {
+			t.Fatalf("writeRawPayload failed: %v", err)
+		}
+		want, err := json.Marshal(scan.BuildAPIPayload("device-1", richTraces))
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != string(want) {
+			t.Errorf("richTraces=%v raw payload mismatch:\n got: %s\nwant: %s", richTraces, got, want)
+		}
+	}
+}
+
+func TestScanShow_Raw(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+	t.Setenv("INTENTRA_DEVICE_ID", "device-raw")
+	device.ResetCache()
+	t.Cleanup(device.ResetCache)
+
+	scan := &models.Scan{ID: "scan-raw-cmd", Tool: "cursor", TotalTokens: 42}
+	if err := scanner.SaveScan(scan); err != nil {
+		t.Fatalf("SaveScan failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := newScanShowCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"scan-raw-cmd", "--raw"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan show --raw failed: %v", err)
+	}
+
+	saved, err := scanner.LoadScan("scan-raw-cmd")
+	if err != nil {
+		t.Fatalf("LoadScan failed: %v", err)
+	}
+	want, _ := json.Marshal(saved.BuildAPIPayload("device-raw", false))
+	if got := strings.TrimSpace(out.String()); got != string(want) {
+		t.Errorf("raw output mismatch:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestPrintScanDetails(t *testing.T) {
+	scan := &models.Scan{
+		ID:             "scan-1",
+		Tool:           "claude",
+		Model:          "claude-sonnet-4",
+		InputTokens:    600,
+		OutputTokens:   300,
+		ThinkingTokens: 100,
+		TotalTokens:    1000,
+		LLMCalls:       4,
+		ToolCalls:      9,
+		MCPToolUsage: []models.MCPToolCall{
+			{ServerName: "github", ToolName: "list_prs", CallCount: 2, EstimatedCost: 0.01},
+			{ServerName: "postgres", ToolName: "query", CallCount: 5, ErrorCount: 1, EstimatedCost: 0.25},
+		},
+		FilesModified: []map[string]any{
+			// Loaded from JSON, so numbers are float64.
+			{"file_path": "~/src/main.go", "lines_added": float64(12), "lines_removed": float64(3), "is_new_file": false},
+			{"file_path": "~/src/new.go", "lines_added": 40, "is_new_file": true},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := printScanDetails(&out, scan, nil); err != nil {
+		t.Fatalf("printScanDetails: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"Input:", "600", "60%", "Thinking:", "10%", "LLM:", "4", "Tool:", "9",
+		"SERVER", "+12 -3", "~/src/new.go", "+40 -0 (new)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "postgres") > strings.Index(got, "github") {
+		t.Errorf("MCP tools should be ordered by cost:\n%s", got)
+	}
+	if !strings.Contains(got, "query     5      1") {
+		t.Errorf("MCP row should show calls and errors:\n%s", got)
+	}
+}
+
+func TestWriteScansCSV_Escaping(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	scans := []models.Scan{
+		{ID: "scan-1", Tool: "cursor", Model: "claude-sonnet-4.5", TotalTokens: 1200, EstimatedCost: 0.0079,
+			StartTime: start, RepoName: "acme, inc", BranchName: `feature/"quoted"`},
+		{ID: "scan-2", Tool: "claude", TotalTokens: 10},
+	}
+
+	var buf bytes.Buffer
+	if err := writeScansCSV(&buf, scans); err != nil {
+		t.Fatalf("writeScansCSV failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "id,tool,model,total_tokens,estimated_cost,start_time,repo_name,branch_name" {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+	want := `scan-1,cursor,claude-sonnet-4.5,1200,0.007900,2025-03-01T10:00:00Z,"acme, inc","feature/""quoted"""`
+	if lines[1] != want {
+		t.Errorf("row = %q, want %q", lines[1], want)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 || records[1][6] != "acme, inc" || records[1][7] != `feature/"quoted"` {
+		t.Errorf("round-trip mismatch: %q", records)
+	}
+	if records[2][5] != "" {
+		t.Errorf("zero start time should be empty, got %q", records[2][5])
+	}
+}
+
+func TestScanExport_LocalFiltersByDays(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	now := time.Now()
+	for _, s := range []*models.Scan{
+		{ID: "recent-scan", Tool: "cursor", StartTime: now.Add(-24 * time.Hour)},
+		{ID: "old-scan", Tool: "cursor", StartTime: now.AddDate(0, 0, -40)},
+	} {
+		if err := scanner.SaveScan(s); err != nil {
+			t.Fatalf("SaveScan failed: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	cmd := newScanExportCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format", "csv", "--days", "30"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan export failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "recent-scan") || strings.Contains(out.String(), "old-scan") {
+		t.Errorf("unexpected export:\n%s", out.String())
+	}
+
+	cmd = newScanExportCmd()
+	cmd.SetArgs([]string{"--format", "xml"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestScanExport_SinceLastSync(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+
+	export := func() string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := newScanExportCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--format", "csv", "--since-last-sync"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("scan export failed: %v", err)
+		}
+		return out.String()
+	}
+
+	now := time.Now().Truncate(time.Second)
+	if err := scanner.SaveScan(&models.Scan{ID: "first-scan", Tool: "cursor", StartTime: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("SaveScan failed: %v", err)
+	}
+	if out := export(); !strings.Contains(out, "first-scan") {
+		t.Fatalf("first export missing scan:\n%s", out)
+	}
+	mark, err := loadWatermark("export")
+	if err != nil || !mark.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("watermark = %v, %v; want %v", mark, err, now.Add(-2*time.Hour))
+	}
+
+	if err := scanner.SaveScan(&models.Scan{ID: "second-scan", Tool: "cursor", StartTime: now.Add(-time.Hour)}); err != nil {
+		t.Fatalf("SaveScan failed: %v", err)
+	}
+	out := export()
+	if !strings.Contains(out, "second-scan") || strings.Contains(out, "first-scan") {
+		t.Errorf("second export should only contain the new scan:\n%s", out)
+	}
+	if mark, _ := loadWatermark("export"); !mark.Equal(now.Add(-time.Hour)) {
+		t.Errorf("watermark = %v, want %v", mark, now.Add(-time.Hour))
+	}
+
+	if out := export(); strings.Contains(out, "-scan") {
+		t.Errorf("third export should be empty:\n%s", out)
+	}
+	if mark, _ := loadWatermark("list"); !mark.IsZero() {
+		t.Errorf("list watermark = %v, want unset", mark)
+	}
+}
+
+func TestScansAfterWatermark_KeepsOldest(t *testing.T) {
+	base := time.Now()
+	scans := []models.Scan{
+		{ID: "a", StartTime: base.Add(-3 * time.Hour)},
+		{ID: "b", StartTime: base.Add(-2 * time.Hour)},
+		{ID: "c", StartTime: base.Add(-time.Hour)},
+		{ID: "d", StartTime: base},
+	}
+	got := scansAfterWatermark(scans, base.Add(-3*time.Hour), 2)
+	if len(got) != 2 || got[0].ID != "c" || got[1].ID != "b" {
+		t.Fatalf("got %+v, want [c b]", got)
+	}
+	if n := newestEnd(got, time.Time{}); !n.Equal(base.Add(-time.Hour)) {
+		t.Errorf("newestEnd = %v", n)
+	}
+}
+
+func TestScansAfterWatermark_UsesEndTime(t *testing.T) {
+	base := time.Now()
+	mark := base.Add(-time.Hour)
+	scans := []models.Scan{
+		// Started before the mark but finalized after it.
+		{ID: "long", StartTime: base.Add(-3 * time.Hour), EndTime: base.Add(-30 * time.Minute)},
+		{ID: "done", StartTime: base.Add(-3 * time.Hour), EndTime: base.Add(-2 * time.Hour)},
+		{ID: "new", StartTime: base.Add(-10 * time.Minute), EndTime: base.Add(-5 * time.Minute)},
+	}
+	got := scansAfterWatermark(scans, mark, 1)
+	if len(got) != 1 || got[0].ID != "long" {
+		t.Fatalf("got %+v, want [long]", got)
+	}
+	mark = newestEnd(got, mark)
+	if got := scansAfterWatermark(scans, mark, 0); len(got) != 1 || got[0].ID != "new" {
+		t.Fatalf("next run got %+v, want [new]", got)
+	}
+}
+
+func TestComputeScanStats_CostBand(t *testing.T) {
+	scans := []models.Scan{
+		{EstimatedCost: 1.0, EstimatedCostLow: 0.8, EstimatedCostHigh: 1.2},
+		{EstimatedCost: 0.5}, // saved before cost bands existed
+	}
+
+	st := computeScanStats(scans)
+	if st.EstimatedCostLow > st.EstimatedCost || st.EstimatedCostHigh < st.EstimatedCost {
+		t.Errorf("band [%f, %f] does not bracket %f", st.EstimatedCostLow, st.EstimatedCostHigh, st.EstimatedCost)
+	}
+	if diff := st.EstimatedCostLow - 1.3; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("EstimatedCostLow = %f, want 1.3", st.EstimatedCostLow)
+	}
+	if diff := st.EstimatedCostHigh - 1.7; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("EstimatedCostHigh = %f, want 1.7", st.EstimatedCostHigh)
+	}
+
+	var buf bytes.Buffer
+	if err := printScanStats(&buf, st, false); err != nil {
+		t.Fatalf("printScanStats failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "$1.50 (range $1.30 - $1.70)") {
+		t.Errorf("output missing cost band:\n%s", buf.String())
+	}
+}
+
+func TestComputeScanStats_ReportedCost(t *testing.T) {
+	scans := []models.Scan{
+		{EstimatedCost: 1.2, ReportedCost: 1.0},
+		{EstimatedCost: 0.9, ReportedCost: 1.0},
+		{EstimatedCost: 5.0}, // tool did not report cost
+	}
+
+	st := computeScanStats(scans)
+	if st.ReportedCostScans != 2 {
+		t.Errorf("ReportedCostScans = %d, want 2", st.ReportedCostScans)
+	}
+	if diff := st.EstimatedForReported - 2.1; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("EstimatedForReported = %f, want 2.1", st.EstimatedForReported)
+	}
+	if diff := st.CostDriftPct - 5; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("CostDriftPct = %f, want 5", st.CostDriftPct)
+	}
+
+	var buf bytes.Buffer
+	if err := printScanStats(&buf, st, false); err != nil {
+		t.Fatalf("printScanStats failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "$2.00 in 2 scans (estimated $2.10, drift +5.0%)") {
+		t.Errorf("output missing reported cost comparison:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printScanStats(&buf, computeScanStats(scans[2:]), false); err != nil {
+		t.Fatalf("printScanStats failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "Reported cost") {
+		t.Errorf("reported cost line shown without reported scans:\n%s", buf.String())
+	}
+}
+
+func TestScanShowAndList_Output(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	if err := scanner.SaveScan(&models.Scan{ID: "scan-out", Tool: "cursor", TotalTokens: 7, StartTime: time.Now()}); err != nil {
+		t.Fatalf("SaveScan failed: %v", err)
+	}
+
+	showPath := filepath.Join(dir, "out", "show.json")
+	cmd := newScanShowCmd()
+	cmd.SetArgs([]string{"scan-out", "--output", showPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan show --output failed: %v", err)
+	}
+	var shown models.Scan
+	data, err := os.ReadFile(showPath)
+	if err != nil {
+		t.Fatalf("failed to read show output: %v", err)
+	}
+	if err := json.Unmarshal(data, &shown); err != nil || shown.ID != "scan-out" {
+		t.Errorf("unexpected show output (err=%v): %s", err, data)
+	}
+
+	listPath := filepath.Join(dir, "out", "list.json")
+	cmd = newScanListCmd()
+	cmd.SetArgs([]string{"--output", listPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan list --output failed: %v", err)
+	}
+	var listed []models.Scan
+	data, err = os.ReadFile(listPath)
+	if err != nil {
+		t.Fatalf("failed to read list output: %v", err)
+	}
+	if err := json.Unmarshal(data, &listed); err != nil || len(listed) != 1 {
+		t.Errorf("unexpected list output (err=%v): %s", err, data)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1d", 0, true},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"4h", now.Add(-4 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"2d", now.Add(-48 * time.Hour), false},
+		{"2024-05-01T09:00:00Z", time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), false},
+		{"2024-06-01T00:00:00Z", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"-4h", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.in, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestScanList_Since(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	now := time.Now()
+	for _, s := range []*models.Scan{
+		{ID: "recent-scan", Tool: "cursor", StartTime: now.Add(-time.Hour)},
+		{ID: "older-scan", Tool: "cursor", StartTime: now.Add(-6 * time.Hour)},
+	} {
+		if err := scanner.SaveScan(s); err != nil {
+			t.Fatalf("SaveScan failed: %v", err)
+		}
+	}
+
+	listPath := filepath.Join(dir, "list.json")
+	cmd := newScanListCmd()
+	cmd.SetArgs([]string{"--since", "4h", "--output", listPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("scan list --since failed: %v", err)
+	}
+	var listed []models.Scan
+	data, err := os.ReadFile(listPath)
+	if err != nil {
+		t.Fatalf("failed to read list output: %v", err)
+	}
+	if err := json.Unmarshal(data, &listed); err != nil || len(listed) != 1 || listed[0].ID != "recent-scan" {
+		t.Errorf("unexpected list output (err=%v): %s", err, data)
+	}
+
+	cmd = newScanListCmd()
+	cmd.SetArgs([]string{"--since", "4h", "--days", "7"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestScanMarkAndListStatus(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	now := time.Now()
+	for _, s := range []*models.Scan{
+		{ID: "scan-a", StartTime: now.Add(-time.Hour), Status: models.ScanStatusPending},
+		{ID: "scan-b", StartTime: now.Add(-2 * time.Hour), Status: models.ScanStatusPending},
+		// Already synced: upload state says reviewed, review state is unset.
+		{ID: "scan-c", StartTime: now.Add(-3 * time.Hour), Status: models.ScanStatusReviewed},
+	} {
+		if err := scanner.SaveScan(s); err != nil {
+			t.Fatalf("SaveScan failed: %v", err)
+		}
+	}
+
+	mark := func(args ...string) error {
+		cmd := newScanCmd()
+		cmd.SetOut(io.Discard)
+		cmd.SetArgs(append([]string{"mark"}, args...))
+		return cmd.Execute()
+	}
+	if err := mark("scan-a", "analyzing"); err != nil {
+		t.Fatalf("mark analyzing failed: %v", err)
+	}
+	if err := mark("scan-a", "reviewed"); err != nil {
+		t.Fatalf("mark reviewed failed: %v", err)
+	}
+	if err := mark("scan-b", "analyzing"); err != nil {
+		t.Fatalf("mark analyzing failed: %v", err)
+	}
+	if err := mark("scan-b", "done"); err == nil {
+		t.Error("mark should reject an unknown status")
+	}
+	if err := mark("scan-missing", "reviewed"); exitCode(err) != ExitCodeNotFound {
+		t.Errorf("missing scan: exit code = %d (%v), want %d", exitCode(err), err, ExitCodeNotFound)
+	}
+
+	scan, err := scanner.LoadScan("scan-a")
+	if err != nil || scan.ReviewStatus != models.ScanStatusReviewed {
+		t.Fatalf("scan-a review status = %v (%v), want reviewed", scan, err)
+	}
+	if scan.Status != models.ScanStatusPending {
+		t.Errorf("scan-a status = %q, want pending so sync still uploads it", scan.Status)
+	}
+
+	for _, tc := range []struct {
+		status string
+		want   []string
+	}{
+		{"reviewed", []string{"scan-a"}},
+		{"analyzing", []string{"scan-b"}},
+		{"pending", []string{"scan-c"}},
+	} {
+		listPath := filepath.Join(dir, tc.status+".json")
+		cmd := newScanListCmd()
+		cmd.SetArgs([]string{"--status", tc.status, "--output", listPath})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("scan list --status %s failed: %v", tc.status, err)
+		}
+		var listed []models.Scan
+		data, _ := os.ReadFile(listPath)
+		if err := json.Unmarshal(data, &listed); err != nil {
+			t.Fatalf("bad list output: %v: %s", err, data)
+		}
+		var ids []string
+		for _, s := range listed {
+			ids = append(ids, s.ID)
+		}
+		if !slices.Equal(ids, tc.want) {
+			t.Errorf("--status %s listed %v, want %v", tc.status, ids, tc.want)
+		}
+	}
+
+	cmd := newScanListCmd()
+	cmd.SetArgs([]string{"--status", "done"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid --status") {
+		t.Errorf("expected invalid --status error, got %v", err)
+	}
+}
+
+func TestScanList_ToolAndRepo(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	now := time.Now()
+	for _, s := range []*models.Scan{
+		{ID: "cursor-app", Tool: "cursor", RepoName: "MyProject", StartTime: now.Add(-time.Hour)},
+		{ID: "claude-app", Tool: "claude", RepoName: "myproject", StartTime: now.Add(-2 * time.Hour)},
+		{ID: "cursor-other", Tool: "cursor", RepoName: "other", StartTime: now.Add(-3 * time.Hour)},
+	} {
+		if err := scanner.SaveScan(s); err != nil {
+			t.Fatalf("SaveScan failed: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--tool", "cursor"}, []string{"cursor-app", "cursor-other"}},
+		{[]string{"--tool", "Cursor"}, []string{"cursor-app", "cursor-other"}},
+		{[]string{"--repo", "MYPROJECT"}, []string{"cursor-app", "claude-app"}},
+		{[]string{"--tool", "cursor", "--repo", "myproject"}, []string{"cursor-app"}},
+		{[]string{"--tool", "gemini"}, nil},
+	} {
+		listPath := filepath.Join(dir, "list.json")
+		cmd := newScanListCmd()
+		cmd.SetArgs(append(tc.args, "--output", listPath))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("scan list %v failed: %v", tc.args, err)
+		}
+		var listed []models.Scan
+		data, _ := os.ReadFile(listPath)
+		if err := json.Unmarshal(data, &listed); err != nil {
+			t.Fatalf("bad list output: %v: %s", err, data)
+		}
+		var ids []string
+		for _, s := range listed {
+			ids = append(ids, s.ID)
+		}
+		if !slices.Equal(ids, tc.want) {
+			t.Errorf("scan list %v listed %v, want %v", tc.args, ids, tc.want)
+		}
+	}
+}
+
+func TestScanDeleteAndPrune(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+
+	now := time.Now()
+	for _, s := range []*models.Scan{
+		{ID: "scan-old", StartTime: now.AddDate(0, 0, -60)},
+		{ID: "scan-older", StartTime: now.AddDate(0, 0, -90)},
+		{ID: "scan-new", StartTime: now.AddDate(0, 0, -1)},
+		{ID: "scan-undated"},
+	} {
+		if err := scanner.SaveScan(s); err != nil {
+			t.Fatalf("SaveScan failed: %v", err)
+		}
+	}
+
+	run := func(cmdArgs ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := newScanCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(cmdArgs)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("prune", "--older-than", "30d", "--dry-run")
+	if err != nil {
+		t.Fatalf("prune --dry-run failed: %v", err)
+	}
+	if !strings.Contains(out, "Would delete scan-older") || !strings.Contains(out, "2 scan(s) would be deleted") {
+		t.Errorf("dry-run output = %q", out)
+	}
+	if scans, _ := scanner.LoadScans(); len(scans) != 4 {
+		t.Fatalf("dry-run deleted scans: %d left, want 4", len(scans))
+	}
+
+	if out, err = run("prune", "--older-than", "30d"); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if !strings.Contains(out, "Deleted 2 scan(s)") {
+		t.Errorf("prune output = %q", out)
+	}
+	scans, _ := scanner.LoadScans()
+	if len(scans) != 2 {
+		t.Errorf("after prune: %d scans left, want scan-new and scan-undated", len(scans))
+	}
+
+	if _, err = run("delete", "scan-new"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := scanner.LoadScan("scan-new"); err == nil {
+		t.Error("scan-new still exists after delete")
+	}
+	if _, err = run("delete", "scan-new"); exitCode(err) != ExitCodeNotFound {
+		t.Errorf("deleting a missing scan: err = %v, want not-found exit code", err)
+	}
+
+	// A scan file that no longer parses can still be deleted.
+	path, err := scanner.ScanPath("scan-corrupt")
+	if err != nil {
+		t.Fatalf("ScanPath failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatalf("failed to write corrupt scan: %v", err)
+	}
+	if _, err = run("delete", "scan-corrupt"); err != nil {
+		t.Fatalf("delete of corrupt scan failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("corrupt scan file still exists after delete")
+	}
+}
+
+func TestRemoteScanIDs_Pages(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_TOKEN", "test-token")
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	t.Cleanup(config.InvalidateCache)
+
+	const total = syncLocalLookupLimit + 5
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var page []map[string]string
+		for i := offset; i < total && i < offset+syncLocalLookupLimit; i++ {
+			page = append(page, map[string]string{"scan_id": fmt.Sprintf("scan-%d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"scans": page})
+	}))
+	defer srv.Close()
+	setAPIFlags(t, srv.URL, "", "")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ids, err := remoteScanIDs(client, nil)
+	if err != nil {
+		t.Fatalf("remoteScanIDs failed: %v", err)
+	}
+	if len(ids) != total || !ids[fmt.Sprintf("scan-%d", total-1)] {
+		t.Errorf("got %d remote IDs, want all %d across pages", len(ids), total)
+	}
+}
+
+func TestFetchScansSince_PagesToMark(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_TOKEN", "test-token")
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	t.Cleanup(config.InvalidateCache)
+
+	// Newest first, one minute apart; the mark falls on the second page.
+	now := time.Now().UTC().Truncate(time.Second)
+	const total = 3 * watermarkPageSize
+	mark := now.Add(-(watermarkPageSize + 10) * time.Minute)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var page []map[string]any
+		for i := offset; i < total && i < offset+watermarkPageSize; i++ {
+			page = append(page, map[string]any{
+				"scan_id":    fmt.Sprintf("scan-%d", i),
+				"start_time": now.Add(-time.Duration(i) * time.Minute),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"scans": page})
+	}))
+	defer srv.Close()
+	setAPIFlags(t, srv.URL, "", "")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	scans, err := fetchScansSince(client, 30, api.ScanFilter{}, mark)
+	if err != nil {
+		t.Fatalf("fetchScansSince failed: %v", err)
+	}
+	if requests != 2 || len(scans) != 2*watermarkPageSize {
+		t.Errorf("fetched %d scans in %d requests, want %d in 2", len(scans), requests, 2*watermarkPageSize)
+	}
+	if after := scansAfterWatermark(scans, mark, 0); len(after) != watermarkPageSize+10 {
+		t.Errorf("%d scans after the mark, want every one of the %d", len(after), watermarkPageSize+10)
+	}
+}
+
+func TestListWatermarkName(t *testing.T) {
+	if got := listWatermarkName(api.ScanFilter{}, ""); got != "list" {
+		t.Errorf("unfiltered name = %q, want list", got)
+	}
+	filtered := listWatermarkName(api.ScanFilter{Tool: "Cursor", Repo: "MyRepo"}, models.ScanStatusReviewed)
+	if filtered == "list" {
+		t.Error("a filtered run shares the unfiltered watermark")
+	}
+	if other := listWatermarkName(api.ScanFilter{Tool: "cursor", Repo: "myrepo"}, models.ScanStatusReviewed); other != filtered {
+		t.Errorf("names differ only by case: %q vs %q", other, filtered)
+	}
+	if other := listWatermarkName(api.ScanFilter{Tool: "claude"}, ""); other == filtered {
+		t.Error("different filters share a watermark")
+	}
+}
+
+func TestScanSyncLocal(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_TOKEN", "test-token")
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	t.Cleanup(config.InvalidateCache)
+
+	now := time.Now()
+	for _, s := range []*models.Scan{
+		{ID: "scan-a", GenerationID: "gen-a", StartTime: now.AddDate(0, 0, -40), Status: models.ScanStatusReviewed},
+		{ID: "scan-b", GenerationID: "gen-b", StartTime: now.AddDate(0, 0, -2)},
+		{ID: "scan-c", GenerationID: "gen-c", StartTime: now.AddDate(0, 0, -1)},
+	} {
+		if err := scanner.SaveScan(s); err != nil {
+			t.Fatalf("SaveScan failed: %v", err)
+		}
+	}
+
+	var mu sync.Mutex
+	var uploaded []string
+	var lookupDays string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			lookupDays = r.URL.Query().Get("days")
+			_ = json.NewEncoder(w).Encode(map[string]any{"scans": []map[string]string{{"scan_id": "scan-b"}}})
+		case http.MethodPost:
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip: %v", err)
+				return
+			}
+			var payload map[string]any
+			if err := json.NewDecoder(zr).Decode(&payload); err != nil {
+				t.Errorf("decode: %v", err)
+				return
+			}
+			mu.Lock()
+			uploaded = append(uploaded, payload["generation_id"].(string))
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+	setAPIFlags(t, srv.URL, "", "")
+
+	run := func(cmdArgs ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := newScanCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(cmdArgs)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("sync-local", "--dry-run")
+	if err != nil {
+		t.Fatalf("sync-local --dry-run failed: %v", err)
+	}
+	if !strings.Contains(out, "2 scan(s) would be uploaded, 1 already on server") || len(uploaded) != 0 {
+		t.Errorf("dry-run output = %q, uploaded %v", out, uploaded)
+	}
+	if lookupDays != "41" {
+		t.Errorf("lookup days = %q, want 41 to cover the oldest local scan", lookupDays)
+	}
+
+	out, err = run("sync-local", "--batch-size", "1")
+	if err != nil {
+		t.Fatalf("sync-local failed: %v", err)
+	}
+	sort.Strings(uploaded)
+	if strings.Join(uploaded, ",") != "gen-a,gen-c" {
+		t.Errorf("uploaded = %v, want [gen-a gen-c]", uploaded)
+	}
+	if !strings.Contains(out, "Uploaded 2 scan(s), 1 skipped (already on server), 0 failed") {
+		t.Errorf("output = %q", out)
+	}
+	if _, err := scanner.LoadScan("scan-a"); err != nil {
+		t.Errorf("local scan should be kept: %v", err)
+	}
+}
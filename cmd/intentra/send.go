@@ -41,7 +41,22 @@ func newSendCmd() *cobra.Command {
 			case "send_scan":
 				return deferredSendScan(p)
 			case "patch_session_end":
+				if _, err := loadConfig(); err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
 				return deferredPatchSessionEnd(p.ScanID, p.Reason, p.DurationMs)
+			case "finalize_idle":
+				cfg, err := loadConfig()
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				return hooks.FinalizeIdleSession(p.SessionKey, cfg)
+			case "flush_deferred":
+				cfg, err := loadConfig()
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				return hooks.FlushDeferredSession(p.SessionKey, p.Tool, cfg)
 			default:
 				return fmt.Errorf("unknown action: %s", p.Action)
 			}
@@ -95,10 +110,7 @@ func deferredSendScan(p models.SendPayload) error {
 	}
 
 	if !synced {
-		if err := queue.Enqueue(scan); err != nil {
-			return fmt.Errorf("failed to enqueue scan: %w", err)
-		}
-		return nil
+		return hooks.StoreUnsyncedScan(scan, cfg, credsErr)
 	}
 
 	if creds != nil {
@@ -112,17 +124,33 @@ func deferredSendScan(p models.SendPayload) error {
 	return nil
 }
 
-// deferredPatchSessionEnd patches session-end metadata on an already-sent scan.
+// patchSessionEnd sends a session-end PATCH; swapped out in tests.
+var patchSessionEnd = api.PatchSessionEndContext
+
+// deferredPatchSessionEnd patches session-end metadata on an already-sent
+// scan. If the PATCH cannot be sent within buffer.session_end_grace it is
+// queued and retried on the next sync instead of being lost.
 func deferredPatchSessionEnd(scanID, reason string, durationMs int64) error {
+	if reason == "" && durationMs <= 0 {
+		return nil
+	}
+	pending := queue.SessionEnd{ScanID: scanID, Reason: reason, DurationMs: durationMs}
+
 	creds, err := auth.GetValidCredentials()
 	if err != nil {
 		debug.Warn("credential check failed: %v", err)
 	}
 
 	if creds == nil {
-		debug.Warn("skipping patch_session_end for %s: not authenticated", scanID)
-		return nil
+		debug.Warn("queueing patch_session_end for %s: not authenticated", scanID)
+		return queue.EnqueueSessionEnd(pending)
 	}
 
-	return api.PatchSessionEnd(scanID, creds.AccessToken, reason, durationMs)
+	ctx, cancel := queue.SessionEndContext()
+	defer cancel()
+	if err := patchSessionEnd(ctx, scanID, creds.AccessToken, reason, durationMs); err != nil {
+		debug.Warn("patch_session_end for %s failed, queueing for retry: %v", scanID, err)
+		return queue.EnqueueSessionEnd(pending)
+	}
+	return nil
 }
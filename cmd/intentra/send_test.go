@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/queue"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestDeferredPatchSessionEnd_QueuesOnFailure(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_TOKEN", "test-token")
+
+	fail := true
+	var calls int
+	orig := patchSessionEnd
+	patchSessionEnd = func(_ context.Context, scanID, accessToken, reason string, durationMs int64) error {
+		calls++
+		if fail {
+			return errors.New("PATCH returned 503")
+		}
+		return nil
+	}
+	t.Cleanup(func() { patchSessionEnd = orig })
+
+	if err := deferredPatchSessionEnd("scan-1", "user_exit", 1200); err != nil {
+		t.Fatalf("deferredPatchSessionEnd failed: %v", err)
+	}
+	pending, err := queue.PendingSessionEnds()
+	if err != nil || len(pending) != 1 || pending[0].ScanID != "scan-1" || pending[0].Reason != "user_exit" {
+		t.Fatalf("pending = %+v, %v; want the failed PATCH queued", pending, err)
+	}
+
+	fail = false
+	if err := flushOfflineQueue(0); err != nil {
+		t.Fatalf("flushOfflineQueue failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("PATCH attempted %d times, want 2", calls)
+	}
+	if pending, _ := queue.PendingSessionEnds(); len(pending) != 0 {
+		t.Errorf("%d session ends still queued after retry", len(pending))
+	}
+}
+
+func TestSendCmd_PatchSessionEndLoadsConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+	t.Setenv("INTENTRA_TOKEN", "test-token")
+	t.Cleanup(config.InvalidateCache)
+	t.Cleanup(func() { queue.SetSessionEndGrace(config.DefaultSessionEndGrace) })
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("buffer:\n  session_end_grace: 3s\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(models.SendPayload{Action: "patch_session_end", ScanID: "scan-1", Reason: "user_exit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadPath := filepath.Join(dir, "payload.json")
+	if err := os.WriteFile(payloadPath, payload, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining time.Duration
+	orig := patchSessionEnd
+	patchSessionEnd = func(ctx context.Context, scanID, accessToken, reason string, durationMs int64) error {
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining = time.Until(deadline)
+		}
+		return nil
+	}
+	t.Cleanup(func() { patchSessionEnd = orig })
+
+	cmd := newSendCmd()
+	cmd.SetArgs([]string{"--payload", payloadPath})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("__send failed: %v", err)
+	}
+	if remaining <= 2*time.Second || remaining > 3*time.Second {
+		t.Errorf("PATCH deadline in %v, want buffer.session_end_grace of 3s from config", remaining)
+	}
+}
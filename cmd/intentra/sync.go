@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"time"
 
 	"github.com/intentrahq/intentra-cli/internal/api"
 	"github.com/intentrahq/intentra-cli/internal/auth"
@@ -13,7 +18,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var keepLocal bool
+var (
+	keepLocal   bool
+	syncLimit   int
+	syncTimeout time.Duration
+)
 
 // newSyncNowCmd returns the sync now command with flags.
 func newSyncNowCmd() *cobra.Command {
@@ -24,15 +33,28 @@ func newSyncNowCmd() *cobra.Command {
 		SilenceErrors: true,
 		Long: `Sync all pending scans to the server and clean up local files.
 
+Scans queued while offline are flushed first using your login session,
+followed by pending local scan files when server sync is enabled. Use
+--limit to sync a bounded batch from each. --timeout bounds the upload of
+local scan files; scans not sent in time, or when interrupted with
+Ctrl-C, stay pending for the next sync.
+
 By default, local scan files are deleted after successful sync since
 the server is the source of truth. Use --keep-local to preserve files.
 
 Local scan files are automatically preserved when debug mode is enabled
-(-d flag, debug: true in config, or INTENTRA_DEBUG=true).`,
+(-d flag, debug: true in config, or INTENTRA_DEBUG=true).
+
+Examples:
+  intentra sync now
+  intentra sync now --limit 50
+  intentra sync now --timeout 30s`,
 		RunE: runSyncNow,
 	}
 
 	cmd.Flags().BoolVar(&keepLocal, "keep-local", false, "Keep local scan files after syncing")
+	cmd.Flags().IntVar(&syncLimit, "limit", 0, "Maximum number of scans to sync from each source (0 for all)")
+	cmd.Flags().DurationVar(&syncTimeout, "timeout", 0, "Maximum time to spend uploading local scans (0 for no limit)")
 
 	return cmd
 }
@@ -44,7 +66,24 @@ func runSyncNow(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return err
 	}
+	if syncLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	if syncTimeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+
+	// Flush the offline queue first; it only needs a login session.
+	queued := queue.PendingCount()
+	if err := flushOfflineQueue(syncLimit); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	if !cfg.Server.Enabled {
+		if queued > 0 {
+			fmt.Println("Skipping local scan files: server sync is not enabled.")
+			return nil
+		}
 		fmt.Fprintln(os.Stderr, "Error: server sync is not enabled. Set server.enabled=true in config or set INTENTRA_SERVER_ENDPOINT")
 		return fmt.Errorf("server sync not enabled")
 	}
@@ -71,6 +110,9 @@ func runSyncNow(cmd *cobra.Command, args []string) error {
 		fmt.Println("No pending scans to sync. All scans have been reviewed.")
 		return nil
 	}
+	if syncLimit > 0 && len(pending) > syncLimit {
+		pending = pending[:syncLimit]
+	}
 
 	fmt.Printf("Syncing %d scans to %s...\n", len(pending), cfg.Server.Endpoint)
 
@@ -79,11 +121,22 @@ func runSyncNow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create API client: %w", err)
 	}
 
-	if err := client.SendScans(pending); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if syncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, syncTimeout)
+		defer cancel()
+	}
+
+	err = client.SendScansContext(ctx, pending)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: some scans failed to sync: %v\n", err)
 	} else {
 		fmt.Printf("✓ Successfully synced %d scans\n", len(pending))
 	}
+	// Only scans the server accepted are marked reviewed or cleaned up.
+	pending = syncedScans(pending, err)
 
 	preserveLocal := keepLocal || debug.Enabled
 	if preserveLocal {
@@ -110,16 +163,154 @@ func runSyncNow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Cleaned up %d local scan files (server is now source of truth)\n", deleted)
 	}
 
-	// Also flush any encrypted offline queue entries
-	if queuedCount := queue.PendingCount(); queuedCount > 0 {
-		fmt.Printf("Flushing %d offline queued scan(s)...\n", queuedCount)
-		creds, err := auth.GetValidCredentials()
-		if err != nil || creds == nil {
-			fmt.Fprintln(os.Stderr, "Warning: cannot flush offline queue - not authenticated. Run 'intentra login' first.")
-		} else {
-			queue.FlushWithJWT(creds.AccessToken)
+	return nil
+}
+
+// syncedScans returns the scans accepted by a SendScans call that returned
+// err: all of them on success, those not listed in a *api.BatchError on
+// partial failure, and none otherwise.
+func syncedScans(scans []*models.Scan, err error) []*models.Scan {
+	if err == nil {
+		return scans
+	}
+	var batchErr *api.BatchError
+	if !errors.As(err, &batchErr) {
+		return nil
+	}
+	var synced []*models.Scan
+	for _, s := range scans {
+		if _, failed := batchErr.Failed[s.ID]; !failed {
+			synced = append(synced, s)
 		}
 	}
+	return synced
+}
+
+// flushOfflineQueue sends up to limit scans from the encrypted offline queue
+// (0 for all), then retries queued session-end PATCHes, and prints how many
+// were synced and how many failed.
+func flushOfflineQueue(limit int) error {
+	pending := queue.PendingCount()
+	sessionEnds, _ := queue.PendingSessionEnds()
+	if pending == 0 && len(sessionEnds) == 0 {
+		fmt.Println("No offline queued scans.")
+		return nil
+	}
+
+	creds, err := auth.GetValidCredentials()
+	if err != nil || creds == nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot flush %d offline queued scan(s) - not authenticated. Run 'intentra login' first.\n", pending)
+		return nil
+	}
+
+	if pending > 0 {
+		batch := pending
+		if limit > 0 && batch > limit {
+			batch = limit
+		}
+		fmt.Printf("Flushing %d offline queued scan(s)...\n", batch)
 
+		res, err := queue.Flush(func(scans []*models.Scan) error {
+			return api.SendScansWithJWT(scans, creds.AccessToken)
+		}, limit)
+		switch {
+		case errors.Is(err, queue.ErrFlushLocked):
+			fmt.Println("Offline queue is being flushed by another process; run 'intentra sync now' again shortly.")
+		case err != nil:
+			return err
+		default:
+			printFlushResult(os.Stdout, res)
+		}
+	}
+
+	sent, failed := queue.FlushSessionEnds(func(ctx context.Context, se queue.SessionEnd) error {
+		return patchSessionEnd(ctx, se.ScanID, creds.AccessToken, se.Reason, se.DurationMs)
+	})
+	if sent+failed > 0 {
+		fmt.Printf("Session ends: %d synced, %d failed\n", sent, failed)
+	}
 	return nil
 }
+
+// printFlushResult writes a one-line summary of an offline queue flush.
+func printFlushResult(w io.Writer, res queue.FlushResult) {
+	fmt.Fprintf(w, "Offline queue: %d synced, %d failed", res.Sent, res.Failed)
+	if res.DeadLettered > 0 {
+		fmt.Fprintf(w, ", %d moved to dead letters after repeated failures", res.DeadLettered)
+	}
+	fmt.Fprintf(w, ", %d remaining\n", res.Remaining)
+}
+
+// printQueueStatus writes the offline queue section of sync status, warning
+// when queued scans keep failing rather than merely waiting.
+func printQueueStatus(w io.Writer, st queue.Status) {
+	fmt.Fprintf(w, "  Offline queue: %d pending\n", st.Pending)
+	if st.DeadCount > 0 {
+		fmt.Fprintf(w, "  Dead letters: %d (run 'intentra sync retry-dead' to requeue)\n", st.DeadCount)
+	}
+	if st.FailedCount == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  Warning: %d queued scan(s) failed to sync", st.FailedCount)
+	if st.LastError != "" {
+		fmt.Fprintf(w, " (last error: %s)", st.LastError)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  Check 'intentra auth status' and run 'intentra sync now' to retry.")
+}
+
+// newSyncRetryDeadCmd returns the sync retry-dead command.
+func newSyncRetryDeadCmd() *cobra.Command {
+	var list bool
+
+	cmd := &cobra.Command{
+		Use:           "retry-dead",
+		Short:         "Requeue scans that repeatedly failed to sync",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `Move dead-lettered scans back into the offline queue.
+
+A queued scan that fails to sync buffer.max_sync_attempts times is moved
+out of the queue so it stops blocking other scans. Once the cause is fixed
+(for example after 'intentra login' renews an expired session), requeue
+them with this command and run 'intentra sync now'. Use --list to show the
+dead-lettered scans and their last error without requeueing.
+
+Examples:
+  intentra sync retry-dead --list
+  intentra sync retry-dead && intentra sync now`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := cmd.OutOrStdout()
+			if list {
+				dead, err := queue.DeadLetters()
+				if err != nil {
+					return fmt.Errorf("failed to read dead letters: %w", err)
+				}
+				if len(dead) == 0 {
+					fmt.Fprintln(w, "No dead-lettered scans.")
+					return nil
+				}
+				for _, d := range dead {
+					fmt.Fprintf(w, "%s\t%d attempt(s)\t%s\n", d.Scan.ID, d.Attempts, d.LastError)
+				}
+				return nil
+			}
+
+			n, err := queue.RetryDead()
+			if err != nil {
+				return fmt.Errorf("failed to requeue dead letters: %w", err)
+			}
+			if n == 0 {
+				fmt.Fprintln(w, "No dead-lettered scans.")
+				return nil
+			}
+			fmt.Fprintf(w, "✓ Requeued %d scan(s). Run 'intentra sync now' to send them.\n", n)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List dead-lettered scans without requeueing them")
+
+	return cmd
+}
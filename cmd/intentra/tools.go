@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/intentrahq/intentra-cli/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+// newToolsCmd returns a cobra.Command listing supported tools and their hook events.
+func newToolsCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:           "tools",
+		Short:         "List supported tools and the hook events they capture",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Long: `List each supported AI tool with its hooks config file, the native hook
+events intentra installs, and which events end a scan or carry session-end
+metadata.
+
+Examples:
+  intentra tools
+  intentra tools --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var infos []hooks.ToolInfo
+			for _, tool := range hooks.AllTools() {
+				infos = append(infos, hooks.DescribeTool(tool))
+			}
+			return printToolInfos(cmd.OutOrStdout(), infos, jsonOutput)
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// printToolInfos writes tool metadata as text or JSON.
+func printToolInfos(w io.Writer, infos []hooks.ToolInfo, jsonOutput bool) error {
+	if jsonOutput {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal tools: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%s)\n", info.Name, info.Tool)
+		if info.ConfigFile != "" {
+			fmt.Fprintf(w, "  Config:      %s\n", info.ConfigFile)
+		}
+		fmt.Fprintf(w, "  Events:      %s\n", strings.Join(info.Events, ", "))
+		fmt.Fprintf(w, "  Ends scan:   %s\n", orNone(info.StopEvents))
+		fmt.Fprintf(w, "  Session end: %s\n", orNone(info.SessionEndEvents))
+		if info.Notes != "" {
+			fmt.Fprintf(w, "  Note:        %s\n", info.Notes)
+		}
+	}
+	return nil
+}
+
+func orNone(events []string) string {
+	if len(events) == 0 {
+		return "(none)"
+	}
+	return strings.Join(events, ", ")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/hooks"
+)
+
+func TestToolsCmd_ListsAllToolsAndEvents(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := newToolsCmd()
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("tools --json failed: %v", err)
+	}
+
+	var infos []hooks.ToolInfo
+	if err := json.Unmarshal(buf.Bytes(), &infos); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(infos) != 5 {
+		t.Fatalf("expected 5 tools, got %d", len(infos))
+	}
+	for i, tool := range hooks.AllTools() {
+		if infos[i].Tool != tool {
+			t.Errorf("tool %d = %s, want %s", i, infos[i].Tool, tool)
+		}
+		if len(infos[i].Events) == 0 || !slices.Equal(infos[i].Events, hooks.HookEvents(tool)) {
+			t.Errorf("%s events = %v, want %v", tool, infos[i].Events, hooks.HookEvents(tool))
+		}
+		if len(infos[i].StopEvents) == 0 {
+			t.Errorf("%s has no stop event", tool)
+		}
+	}
+
+	buf.Reset()
+	cmd = newToolsCmd()
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("tools failed: %v", err)
+	}
+	out := buf.String()
+	for _, info := range infos {
+		if !strings.Contains(out, info.Name+" ("+string(info.Tool)+")") {
+			t.Errorf("text output missing %s", info.Name)
+		}
+		for _, ev := range info.Events {
+			if !strings.Contains(out, ev) {
+				t.Errorf("text output missing %s event %s", info.Tool, ev)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// watermarkFile stores, per command, the end time of the newest scan
+// returned by a --since-last-sync run.
+const watermarkFile = "scan_watermarks.json"
+
+// watermarkLookback widens the lookback for --since-last-sync. Scans are
+// selected by start time before the watermark is applied, so a session
+// that started well before the mark but ended after it would otherwise be
+// missed.
+const watermarkLookback = 24 * time.Hour
+
+// watermarkPageSize is how many server scans are fetched per request when
+// paging back to the watermark.
+const watermarkPageSize = 1000
+
+func watermarkPath() (string, error) {
+	dir, err := config.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, watermarkFile), nil
+}
+
+// loadWatermark returns the high-water mark recorded for name, or the zero
+// time if none has been recorded yet.
+func loadWatermark(name string) (time.Time, error) {
+	path, err := watermarkPath()
+	if err != nil {
+		return time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read watermark: %w", err)
+	}
+	var marks map[string]time.Time
+	if err := json.Unmarshal(data, &marks); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return marks[name], nil
+}
+
+// saveWatermark records mark as the high-water mark for name.
+func saveWatermark(name string, mark time.Time) error {
+	path, err := watermarkPath()
+	if err != nil {
+		return err
+	}
+	marks := map[string]time.Time{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &marks)
+	}
+	marks[name] = mark.UTC()
+	data, err := json.MarshalIndent(marks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watermark: %w", err)
+	}
+	return fileutil.WriteFileAtomic(path, data)
+}
+
+// scanEnd returns when s was finalized, falling back to its start time for
+// scans without an end time.
+func scanEnd(s models.Scan) time.Time {
+	if s.EndTime.IsZero() {
+		return s.StartTime
+	}
+	return s.EndTime
+}
+
+// scansAfterWatermark returns the scans that ended after mark, keeping the
+// earliest-ending limit of them (0 for all) so the next run picks up where
+// this one stopped. The result is sorted latest first.
+func scansAfterWatermark(scans []models.Scan, mark time.Time, limit int) []models.Scan {
+	var after []models.Scan
+	for _, s := range scans {
+		if scanEnd(s).After(mark) {
+			after = append(after, s)
+		}
+	}
+	if limit > 0 && len(after) > limit {
+		sort.SliceStable(after, func(i, j int) bool {
+			return scanEnd(after[i]).Before(scanEnd(after[j]))
+		})
+		after = after[:limit]
+	}
+	sortScansByTime(after)
+	return after
+}
+
+// newestEnd returns the latest end time among scans, or mark if none is
+// later.
+func newestEnd(scans []models.Scan, mark time.Time) time.Time {
+	for _, s := range scans {
+		if end := scanEnd(s); end.After(mark) {
+			mark = end
+		}
+	}
+	return mark
+}
+
+// watermarkSince returns the start-time cutoff used to fetch candidates
+// for mark; the zero mark stays zero.
+func watermarkSince(mark time.Time) time.Time {
+	if mark.IsZero() {
+		return mark
+	}
+	return mark.Add(-watermarkLookback)
+}
+
+// daysSince returns the server lookback in whole days covering mark,
+// or fallback when no mark has been recorded.
+func daysSince(mark time.Time, fallback int) int {
+	if mark.IsZero() {
+		return fallback
+	}
+	return max(int(math.Ceil(time.Since(mark).Hours()/24)), 1)
+}
+
+// listWatermarkName returns the watermark name for a scan list run. Each
+// combination of --tool, --repo and --status keeps its own mark, so a
+// filtered run never advances past scans its filter left out.
+func listWatermarkName(filter api.ScanFilter, status models.ScanStatus) string {
+	q := url.Values{}
+	if filter.Tool != "" {
+		q.Set("tool", strings.ToLower(filter.Tool))
+	}
+	if filter.Repo != "" {
+		q.Set("repo", strings.ToLower(filter.Repo))
+	}
+	if status != "" {
+		q.Set("status", string(status))
+	}
+	if len(q) == 0 {
+		return "list"
+	}
+	return "list?" + q.Encode()
+}
+
+// fetchScansSince pages through the server's scans in the last days until it
+// reaches one started at or before since, so a --since-last-sync run sees
+// every scan after the mark rather than only the newest page.
+func fetchScansSince(client *api.Client, days int, filter api.ScanFilter, since time.Time) ([]models.Scan, error) {
+	seen := make(map[string]bool)
+	var scans []models.Scan
+	for offset := 0; ; offset += watermarkPageSize {
+		filter.Offset = offset
+		resp, err := client.GetFilteredScans(days, watermarkPageSize, filter)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		reached := false
+		for _, s := range resp.Scans {
+			if !since.IsZero() && !s.StartTime.After(since) {
+				reached = true
+			}
+			if !seen[s.ID] {
+				seen[s.ID] = true
+				scans = append(scans, s)
+				added++
+			}
+		}
+		// As in remoteScanIDs, a short page is the last one and a page with
+		// nothing new means the server ignored the offset.
+		if reached || len(resp.Scans) < watermarkPageSize || added == 0 {
+			return scans, nil
+		}
+	}
+}
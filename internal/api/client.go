@@ -6,16 +6,21 @@ package api
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/hmac"
 	cryptoRand "crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/intentrahq/intentra-cli/internal/auth"
@@ -38,6 +43,45 @@ func gzipCompress(data []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// Errors returned by the client, for callers that handle these cases.
+var (
+	// ErrNotAuthenticated means neither a login nor API key auth is configured.
+	ErrNotAuthenticated = errors.New("not authenticated - run 'intentra login' or configure api_key auth in config.yaml")
+	// ErrUnauthorized means the server rejected the credentials (HTTP 401).
+	ErrUnauthorized = errors.New("authentication failed - run 'intentra login' to re-authenticate")
+	// ErrNotFound means the requested resource does not exist (HTTP 404).
+	ErrNotFound = errors.New("not found")
+	// ErrBatchUnsupported means the server has no batch upload endpoint.
+	ErrBatchUnsupported = errors.New("batch upload not supported by server")
+)
+
+// BatchError reports the scans that failed to upload when others in the
+// same SendScans or SendScanBatch call succeeded or were still attempted.
+type BatchError struct {
+	Failed map[string]string // scan ID to error message
+}
+
+func (e *BatchError) Error() string {
+	ids := make([]string, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return "0 scan(s) failed to sync"
+	}
+	slices.Sort(ids)
+	return fmt.Sprintf("%d scan(s) failed to sync (first: %s: %s)", len(ids), ids[0], e.Failed[ids[0]])
+}
+
+// batchResponse is the body of a POST /scans/batch response. Scans not
+// listed in Failed were accepted.
+type batchResponse struct {
+	Failed []struct {
+		ScanID string `json:"scan_id"`
+		Error  string `json:"error"`
+	} `json:"failed"`
+}
+
 // UserAgent is the User-Agent header value sent with all API requests.
 const UserAgent = "intentra-cli/1.0"
 
@@ -47,6 +91,15 @@ type ScansResponse struct {
 	Summary ScansSummary  `json:"summary"`
 }
 
+// ScanFilter narrows GET /scans to scans from one tool or repository.
+// Empty fields match every scan. Offset skips that many matching scans, for
+// paging through more than one request's limit.
+type ScanFilter struct {
+	Tool   string
+	Repo   string
+	Offset int
+}
+
 // ScansSummary contains aggregated scan statistics.
 type ScansSummary struct {
 	TotalScans          int     `json:"total_scans"`
@@ -64,6 +117,10 @@ type ScanDetailResponse struct {
 type Client struct {
 	cfg        *config.Config
 	httpClient *http.Client
+
+	// batchUnsupported is set once the server answers 404 to a batch
+	// upload, so later SendScans calls go straight to per-scan uploads.
+	batchUnsupported bool
 }
 
 // NewClient creates a new API client configured with the provided settings.
@@ -73,7 +130,8 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	}
 
 	httpClient := &http.Client{
-		Timeout: cfg.Server.Timeout,
+		Timeout:   cfg.Server.Timeout,
+		Transport: httputil.Transport(),
 	}
 
 	return &Client{
@@ -82,69 +140,244 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	}, nil
 }
 
-// SendScan sends a single scan to the API with gzip compression.
+// SendScan sends a single scan to the API with gzip compression. Connection
+// errors and 429/5xx responses are retried per server.max_retries.
 func (c *Client) SendScan(scan *models.Scan) error {
+	return c.SendScanContext(context.Background(), scan)
+}
+
+// SendScanContext is SendScan with a context that can cancel the request
+// and any pending retries.
+func (c *Client) SendScanContext(ctx context.Context, scan *models.Scan) error {
 	deviceID, err := device.GetDeviceID()
 	if err != nil {
 		return fmt.Errorf("failed to get device ID: %w", err)
 	}
 
-	jsonBody, err := json.Marshal(scan.BuildAPIPayload(deviceID, c.cfg.RichTraces))
+	jsonBody, err := json.Marshal(ScanPayload(scan, deviceID, c.cfg.RichTraces))
 	if err != nil {
 		return fmt.Errorf("failed to marshal scan: %w", err)
 	}
 
-	compressed, err := gzipCompress(jsonBody)
+	resp, err := c.postCompressed(ctx, "/scans", scan.ID, jsonBody)
 	if err != nil {
-		return fmt.Errorf("failed to compress scan: %w", err)
+		return err
 	}
+	defer resp.Body.Close()
 
-	url := c.cfg.Server.Endpoint + "/scans"
-	req, err := http.NewRequest("POST", url, bytes.NewReader(compressed))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return readAPIError(resp)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Content-Encoding", "gzip")
-	req.Header.Set("User-Agent", UserAgent)
+	return nil
+}
 
-	if err := c.addAuth(req); err != nil {
-		return fmt.Errorf("failed to add auth: %w", err)
+// SendScanBatch sends scans in a single POST /scans/batch request, signed
+// once over the whole body. It returns ErrBatchUnsupported if the server
+// has no batch endpoint, and a *BatchError naming the scans the server
+// rejected on partial failure.
+func (c *Client) SendScanBatch(scans []*models.Scan) error {
+	return c.SendScanBatchContext(context.Background(), scans)
+}
+
+// SendScanBatchContext is SendScanBatch with a context that can cancel the
+// request and any pending retries.
+func (c *Client) SendScanBatchContext(ctx context.Context, scans []*models.Scan) error {
+	deviceID, err := device.GetDeviceID()
+	if err != nil {
+		return fmt.Errorf("failed to get device ID: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	payloads := make([]map[string]any, 0, len(scans))
+	for _, scan := range scans {
+		p := ScanPayload(scan, deviceID, c.cfg.RichTraces)
+		p["scan_id"] = scan.ID
+		payloads = append(payloads, p)
+	}
+	jsonBody, err := json.Marshal(map[string]any{"scans": payloads})
 	if err != nil {
-		debug.LogHTTP("POST", url, 0)
-		return fmt.Errorf("request failed: %w", err)
+		return fmt.Errorf("failed to marshal scans: %w", err)
+	}
+
+	resp, err := c.postCompressed(ctx, "/scans/batch", batchIdempotencyKey(scans), jsonBody)
+	if err != nil {
+		return err
 	}
 	defer resp.Body.Close()
-	debug.LogHTTP("POST", url, resp.StatusCode)
+	return readBatchResponse(resp)
+}
 
-	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
-		if readErr != nil {
-			return fmt.Errorf("API returned %d (failed to read body: %w)", resp.StatusCode, readErr)
-		}
-		return fmt.Errorf("API returned %d: %s", resp.StatusCode, string(respBody))
+// readBatchResponse interprets a POST /scans/batch response: nil when every
+// scan was accepted, a *BatchError naming the rejected ones, or
+// ErrBatchUnsupported when the server has no batch endpoint.
+func readBatchResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusMultiStatus:
+	case http.StatusNotFound:
+		return ErrBatchUnsupported
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	default:
+		return readAPIError(resp)
 	}
 
-	return nil
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var result batchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	failed := make(map[string]string, len(result.Failed))
+	for _, f := range result.Failed {
+		failed[f.ScanID] = f.Error
+	}
+	return &BatchError{Failed: failed}
 }
 
-// SendScans sends a batch of scans to the API by calling SendScan for each.
+// SendScans sends scans to the API, as one batch request when
+// server.batch_upload is enabled and otherwise one request per scan.
+// If some fail, the error is a *BatchError listing them. Sending one by one
+// stops at the first scan the server could not take because it was
+// unreachable or failed (5xx); the remaining scans are listed as failed
+// with that error. Other errors mean the whole batch failed.
 func (c *Client) SendScans(scans []*models.Scan) error {
-	for _, scan := range scans {
-		if err := c.SendScan(scan); err != nil {
+	return c.SendScansContext(context.Background(), scans)
+}
+
+// SendScansContext is SendScans with a context. Once ctx is done, scans
+// not yet attempted are reported as failed with the context's error.
+func (c *Client) SendScansContext(ctx context.Context, scans []*models.Scan) error {
+	if c.cfg.Server.BatchUpload && !c.batchUnsupported && len(scans) > 0 {
+		err := c.SendScanBatchContext(ctx, scans)
+		if !errors.Is(err, ErrBatchUnsupported) {
 			return err
 		}
+		debug.Log("server has no batch upload endpoint; sending scans one by one")
+		c.batchUnsupported = true
+	}
+
+	return sendEach(ctx, scans, c.SendScanContext)
+}
+
+// sendEach sends scans one at a time with send, as SendScans does without
+// batch upload: it stops at the first scan the server could not take and
+// returns a *BatchError listing every scan that failed or was not tried.
+func sendEach(ctx context.Context, scans []*models.Scan, send func(context.Context, *models.Scan) error) error {
+	failed := make(map[string]string)
+	var stopErr error
+	for _, scan := range scans {
+		if stopErr == nil {
+			stopErr = ctx.Err()
+		}
+		if stopErr != nil {
+			failed[scan.ID] = stopErr.Error()
+			continue
+		}
+		if err := send(ctx, scan); err != nil {
+			failed[scan.ID] = err.Error()
+			if serverUnavailable(err) {
+				// The rest would only wait out the same retries.
+				stopErr = err
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return &BatchError{Failed: failed}
 	}
 	return nil
 }
 
-// addAuth adds authentication headers based on config.
+// postCompressed gzips jsonBody and POSTs it to path on the server with
+// auth headers, retrying per server.max_retries.
+func (c *Client) postCompressed(ctx context.Context, path, idempotencyKey string, jsonBody []byte) (*http.Response, error) {
+	compressed, err := gzipCompress(jsonBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress scan: %w", err)
+	}
+
+	url := c.cfg.Server.Endpoint + path
+	resp, err := doWithRetry(c.httpClient, retryPolicyFromConfig(c.cfg.Server), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("User-Agent", UserAgent)
+		setIdempotencyKey(req, idempotencyKey)
+
+		if err := c.addAuth(req, compressed); err != nil {
+			return nil, fmt.Errorf("failed to add auth: %w", err)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// setIdempotencyKey sets the Idempotency-Key header when key is non-empty.
+// POSTs are retried after timeouts and 5xx responses, when the server may
+// already have stored the scan; the key lets it recognize the repeat.
+func setIdempotencyKey(req *http.Request, key string) {
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+}
+
+// batchIdempotencyKey returns the Idempotency-Key for a batch of scans: a
+// hash of their IDs, so resending the same batch reuses the key.
+func batchIdempotencyKey(scans []*models.Scan) string {
+	h := sha256.New()
+	for _, scan := range scans {
+		h.Write([]byte(scan.ID))
+		h.Write([]byte{0})
+	}
+	return "batch-" + hex.EncodeToString(h.Sum(nil))
+}
+
+// statusError is a non-success response from the API.
+type statusError struct {
+	StatusCode int
+	msg        string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// readAPIError builds an error from a non-success response status and body.
+func readAPIError(resp *http.Response) error {
+	respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
+	if readErr != nil {
+		return &statusError{StatusCode: resp.StatusCode, msg: fmt.Sprintf("API returned %d (failed to read body: %v)", resp.StatusCode, readErr)}
+	}
+	return &statusError{StatusCode: resp.StatusCode, msg: fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(respBody))}
+}
+
+// serverUnavailable reports whether err means the server could not be
+// reached or failed (5xx), as opposed to rejecting one request.
+func serverUnavailable(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var se *statusError
+	return errors.As(err, &se) && se.StatusCode >= 500
+}
+
+// addAuth adds authentication headers based on config. body is the request
+// body as sent, used for HMAC body hashing; nil for requests without a body.
 // Priority: JWT credentials (from 'intentra login') > config auth mode (api_key)
-func (c *Client) addAuth(req *http.Request) error {
+func (c *Client) addAuth(req *http.Request, body []byte) error {
 	creds, err := auth.GetValidCredentials()
 	if err != nil {
 		debug.Warn("credential check failed: %v", err)
@@ -155,17 +388,27 @@ func (c *Client) addAuth(req *http.Request) error {
 
 	switch c.cfg.Server.Auth.Mode {
 	case config.AuthModeAPIKey:
-		return c.addAPIKeyAuth(req)
+		return c.addAPIKeyAuth(req, body)
 	default:
-		return fmt.Errorf("not authenticated - run 'intentra login' or configure api_key auth in config.yaml")
+		return ErrNotAuthenticated
 	}
 }
 
+// Signing inputs, swapped out in tests to produce reproducible signatures.
+var (
+	nonceReader io.Reader = cryptoRand.Reader
+	now                   = time.Now
+)
+
 // addAPIKeyAuth adds API key authentication headers for Enterprise organizations.
 // When hmac_key is configured, signs the request with HMAC-SHA256 so the raw
 // secret never leaves the client. Falls back to legacy bcrypt mode when only
 // secret is configured (for keys created before HMAC support).
-func (c *Client) addAPIKeyAuth(req *http.Request) error {
+//
+// With server.auth.hmac.body_hash_mode the hex SHA-256 of body is appended to
+// the signed message and sent as X-API-Body-Hash, so the signature covers the
+// payload without copying it into the message.
+func (c *Client) addAPIKeyAuth(req *http.Request, body []byte) error {
 	if req.URL.Scheme != "https" {
 		return fmt.Errorf("API key auth requires HTTPS; refusing to send credentials over HTTP")
 	}
@@ -181,10 +424,10 @@ func (c *Client) addAPIKeyAuth(req *http.Request) error {
 		return fmt.Errorf("API key auth requires hmac_key (preferred) or secret")
 	}
 
-	timestamp := time.Now().UTC().Format(time.RFC3339)
+	timestamp := now().UTC().Format(time.RFC3339)
 
 	nonceBytes := make([]byte, 16)
-	if _, err := io.ReadFull(cryptoRand.Reader, nonceBytes); err != nil {
+	if _, err := io.ReadFull(nonceReader, nonceBytes); err != nil {
 		return fmt.Errorf("failed to generate nonce: %w", err)
 	}
 	nonce := hex.EncodeToString(nonceBytes)
@@ -195,6 +438,12 @@ func (c *Client) addAPIKeyAuth(req *http.Request) error {
 
 	if hmacKey != "" {
 		message := fmt.Sprintf("%s\n%s\n%s\n%s", req.Method, req.URL.Path, timestamp, nonce)
+		if c.cfg.Server.Auth.HMAC.BodyHashMode {
+			bodyHash := sha256.Sum256(body)
+			bodyHashHex := hex.EncodeToString(bodyHash[:])
+			req.Header.Set("X-API-Body-Hash", bodyHashHex)
+			message += "\n" + bodyHashHex
+		}
 		mac := hmac.New(sha256.New, []byte(hmacKey))
 		mac.Write([]byte(message))
 		signature := hex.EncodeToString(mac.Sum(nil))
@@ -219,63 +468,147 @@ func (c *Client) addJWTAuthWithCreds(req *http.Request, creds *auth.Credentials)
 	return nil
 }
 
-// doJWTRequest executes an authenticated JSON request against the default API endpoint.
-func doJWTRequest(method, path, accessToken string, body []byte, acceptedStatuses ...int) error {
+// doJWTRequest executes an authenticated JSON request against the default API endpoint,
+// retrying transient failures according to the package retry policy. A
+// non-empty idempotencyKey is sent with every attempt; see setIdempotencyKey.
+func doJWTRequest(method, path, accessToken, idempotencyKey string, body []byte, acceptedStatuses ...int) error {
+	return doJWTRequestContext(context.Background(), method, path, accessToken, idempotencyKey, body, acceptedStatuses...)
+}
+
+// doJWTRequestContext is doJWTRequest with a context that bounds the request
+// and its retries.
+func doJWTRequestContext(ctx context.Context, method, path, accessToken, idempotencyKey string, body []byte, acceptedStatuses ...int) error {
+	resp, err := jwtRequest(ctx, method, path, accessToken, idempotencyKey, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if slices.Contains(acceptedStatuses, resp.StatusCode) {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
+	return &statusError{StatusCode: resp.StatusCode, msg: fmt.Sprintf("%s returned %d: %s", method, resp.StatusCode, string(respBody))}
+}
+
+// jwtRequest sends a gzipped JSON request to the default API endpoint with
+// JWT auth, retrying per the package retry policy. The caller owns the
+// response body.
+func jwtRequest(ctx context.Context, method, path, accessToken, idempotencyKey string, body []byte) (*http.Response, error) {
 	deviceID, err := device.GetDeviceID()
 	if err != nil {
-		return fmt.Errorf("failed to get device ID: %w", err)
+		return nil, fmt.Errorf("failed to get device ID: %w", err)
 	}
 
 	compressed, err := gzipCompress(body)
 	if err != nil {
-		return fmt.Errorf("failed to compress request body: %w", err)
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
 	}
 
 	reqURL := config.DefaultAPIEndpoint + path
-	req, err := http.NewRequest(method, reqURL, bytes.NewReader(compressed))
+	resp, err := doWithRetry(httputil.DefaultClient, defaultRetryPolicy, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(compressed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s request: %w", method, err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("User-Agent", UserAgent)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("X-Machine-ID", deviceID)
+		setIdempotencyKey(req, idempotencyKey)
+		return req, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create %s request: %w", method, err)
+		return nil, fmt.Errorf("%s request failed: %w", method, err)
 	}
+	return resp, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Content-Encoding", "gzip")
-	req.Header.Set("User-Agent", UserAgent)
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("X-Machine-ID", deviceID)
+// SendScanWithJWT sends a scan to the default API endpoint using JWT auth.
+func SendScanWithJWT(scan *models.Scan, accessToken string) error {
+	deviceID, err := device.GetDeviceID()
+	if err != nil {
+		return fmt.Errorf("failed to get device ID: %w", err)
+	}
 
-	resp, err := httputil.DefaultClient.Do(req)
+	jsonBody, err := json.Marshal(ScanPayload(scan, deviceID, false))
 	if err != nil {
-		debug.LogHTTP(method, reqURL, 0)
-		return fmt.Errorf("%s request failed: %w", method, err)
+		return fmt.Errorf("failed to marshal scan: %w", err)
 	}
-	defer resp.Body.Close()
-	debug.LogHTTP(method, reqURL, resp.StatusCode)
 
-	if slices.Contains(acceptedStatuses, resp.StatusCode) {
-		return nil
+	return doJWTRequest("POST", "/scans", accessToken, scan.ID, jsonBody,
+		http.StatusAccepted, http.StatusOK, http.StatusCreated)
+}
+
+// jwtBatchUpload is server.batch_upload for SendScansWithJWT; see
+// SetBatchUpload. jwtBatchUnsupported records that the server has no batch
+// endpoint, so later calls go straight to single sends.
+var (
+	jwtBatchUpload      atomic.Bool
+	jwtBatchUnsupported atomic.Bool
+)
+
+// SetBatchUpload installs server.batch_upload for scans sent without a
+// Client (SendScansWithJWT). Call it once after loading config.
+func SetBatchUpload(enabled bool) {
+	jwtBatchUpload.Store(enabled)
+}
+
+// SendScansWithJWT sends scans to the default API endpoint using JWT auth,
+// as one POST /scans/batch request when server.batch_upload is enabled and
+// otherwise one request per scan. Failures are reported as by SendScans.
+func SendScansWithJWT(scans []*models.Scan, accessToken string) error {
+	ctx := context.Background()
+	if jwtBatchUpload.Load() && !jwtBatchUnsupported.Load() && len(scans) > 0 {
+		err := sendScanBatchWithJWT(ctx, scans, accessToken)
+		if !errors.Is(err, ErrBatchUnsupported) {
+			return err
+		}
+		debug.Log("server has no batch upload endpoint; sending scans one by one")
+		jwtBatchUnsupported.Store(true)
 	}
-	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
-	return fmt.Errorf("%s returned %d: %s", method, resp.StatusCode, string(respBody))
+	return sendEach(ctx, scans, func(_ context.Context, scan *models.Scan) error {
+		return SendScanWithJWT(scan, accessToken)
+	})
 }
 
-// SendScanWithJWT sends a scan to the default API endpoint using JWT auth.
-func SendScanWithJWT(scan *models.Scan, accessToken string) error {
+// sendScanBatchWithJWT is SendScanBatch for JWT auth against the default
+// API endpoint.
+func sendScanBatchWithJWT(ctx context.Context, scans []*models.Scan, accessToken string) error {
 	deviceID, err := device.GetDeviceID()
 	if err != nil {
 		return fmt.Errorf("failed to get device ID: %w", err)
 	}
 
-	jsonBody, err := json.Marshal(scan.BuildAPIPayload(deviceID, false))
+	payloads := make([]map[string]any, 0, len(scans))
+	for _, scan := range scans {
+		p := ScanPayload(scan, deviceID, false)
+		p["scan_id"] = scan.ID
+		payloads = append(payloads, p)
+	}
+	jsonBody, err := json.Marshal(map[string]any{"scans": payloads})
 	if err != nil {
-		return fmt.Errorf("failed to marshal scan: %w", err)
+		return fmt.Errorf("failed to marshal scans: %w", err)
 	}
 
-	return doJWTRequest("POST", "/scans", accessToken, jsonBody,
-		http.StatusAccepted, http.StatusOK, http.StatusCreated)
+	resp, err := jwtRequest(ctx, "POST", "/scans/batch", accessToken, batchIdempotencyKey(scans), jsonBody)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readBatchResponse(resp)
 }
 
 // PatchSessionEnd sends a PATCH to update session-end metadata on a scan.
 func PatchSessionEnd(scanID, accessToken, reason string, durationMs int64) error {
+	return PatchSessionEndContext(context.Background(), scanID, accessToken, reason, durationMs)
+}
+
+// PatchSessionEndContext is PatchSessionEnd with a context that can cancel
+// the request and its retries.
+func PatchSessionEndContext(ctx context.Context, scanID, accessToken, reason string, durationMs int64) error {
 	body := map[string]any{}
 	if reason != "" {
 		body["session_end_reason"] = reason
@@ -293,12 +626,157 @@ func PatchSessionEnd(scanID, accessToken, reason string, durationMs int64) error
 		return fmt.Errorf("failed to marshal session end body: %w", err)
 	}
 
-	return doJWTRequest("PATCH", "/scans/"+url.PathEscape(scanID)+"/session", accessToken, jsonBody,
+	return doJWTRequestContext(ctx, "PATCH", "/scans/"+url.PathEscape(scanID)+"/session", accessToken, "", jsonBody,
 		http.StatusOK, http.StatusNoContent)
 }
 
+// Health checks that the server is reachable via an unauthenticated GET to
+// /health, returning an error unless it answers 2xx.
+func (c *Client) Health() error {
+	return c.HealthContext(context.Background())
+}
+
+// HealthContext is Health with a context that can cancel the request.
+func (c *Client) HealthContext(ctx context.Context) error {
+	result, err := ping(ctx, c.httpClient, c.cfg.Server.Endpoint)
+	if err != nil {
+		return err
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		return fmt.Errorf("API returned %d: %s", result.StatusCode, result.Body)
+	}
+	return nil
+}
+
+// PingResult is the outcome of an unauthenticated health check.
+type PingResult struct {
+	URL        string
+	StatusCode int
+	Latency    time.Duration
+	Body       string
+}
+
+// Ping sends an unauthenticated GET to endpoint's /health. It needs neither
+// credentials nor server.enabled, so it works before login. An error means
+// the request failed; any HTTP status, including non-2xx, is returned in
+// the result.
+func Ping(endpoint string, timeout time.Duration) (*PingResult, error) {
+	return ping(context.Background(), &http.Client{Timeout: timeout, Transport: httputil.Transport()}, endpoint)
+}
+
+func ping(ctx context.Context, httpClient *http.Client, endpoint string) (*PingResult, error) {
+	url := strings.TrimRight(endpoint, "/") + "/health"
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		debug.LogHTTP("GET", url, 0)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	debug.LogHTTP("GET", url, resp.StatusCode)
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
+	return &PingResult{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Latency:    time.Since(start),
+		Body:       strings.TrimSpace(string(body)),
+	}, nil
+}
+
+// AuthCheckResult is the server's answer to an authentication check.
+type AuthCheckResult struct {
+	Mode       string // auth mode used: api_key or jwt
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+// OK reports whether the server accepted the credentials.
+func (r *AuthCheckResult) OK() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// VerifyAuth makes an authenticated GET to /auth/verify with the configured
+// auth mode, without sending a scan. Unlike addAuth it does not prefer stored
+// JWT credentials when api_key auth is configured, so the configured keys are
+// what get tested. Rejections are reported in the result; errors are
+// reserved for requests that could not be built or sent.
+func (c *Client) VerifyAuth() (*AuthCheckResult, error) {
+	return c.VerifyAuthContext(context.Background())
+}
+
+// VerifyAuthContext is VerifyAuth with a context that can cancel the request.
+func (c *Client) VerifyAuthContext(ctx context.Context) (*AuthCheckResult, error) {
+	result := &AuthCheckResult{URL: c.cfg.Server.Endpoint + "/auth/verify"}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", result.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	if c.cfg.Server.Auth.Mode == config.AuthModeAPIKey {
+		result.Mode = config.AuthModeAPIKey
+		if err := c.addAPIKeyAuth(req, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		result.Mode = "jwt"
+		creds, err := auth.GetValidCredentials()
+		if err != nil {
+			return nil, err
+		}
+		if creds == nil {
+			return nil, ErrNotAuthenticated
+		}
+		if err := c.addJWTAuthWithCreds(req, creds); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		debug.LogHTTP("GET", result.URL, 0)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	debug.LogHTTP("GET", result.URL, resp.StatusCode)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httputil.MaxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	result.StatusCode = resp.StatusCode
+	result.Body = string(body)
+	return result, nil
+}
+
 // GetScans retrieves scans from the API.
 func (c *Client) GetScans(days, limit int) (*ScansResponse, error) {
+	return c.GetScansContext(context.Background(), days, limit)
+}
+
+// GetScansContext is GetScans with a context that can cancel the request.
+func (c *Client) GetScansContext(ctx context.Context, days, limit int) (*ScansResponse, error) {
+	return c.GetFilteredScansContext(ctx, days, limit, ScanFilter{})
+}
+
+// GetFilteredScans retrieves scans matching filter from the API, passing
+// the tool and repo as query parameters.
+func (c *Client) GetFilteredScans(days, limit int, filter ScanFilter) (*ScansResponse, error) {
+	return c.GetFilteredScansContext(context.Background(), days, limit, filter)
+}
+
+// GetFilteredScansContext is GetFilteredScans with a context that can cancel
+// the request.
+func (c *Client) GetFilteredScansContext(ctx context.Context, days, limit int, filter ScanFilter) (*ScansResponse, error) {
 	if days <= 0 {
 		days = 30
 	}
@@ -306,16 +784,28 @@ func (c *Client) GetScans(days, limit int) (*ScansResponse, error) {
 		limit = 50
 	}
 
-	url := fmt.Sprintf("%s/scans?days=%d&limit=%d", c.cfg.Server.Endpoint, days, limit)
+	query := url.Values{}
+	query.Set("days", strconv.Itoa(days))
+	query.Set("limit", strconv.Itoa(limit))
+	if filter.Tool != "" {
+		query.Set("tool", filter.Tool)
+	}
+	if filter.Repo != "" {
+		query.Set("repo", filter.Repo)
+	}
+	if filter.Offset > 0 {
+		query.Set("offset", strconv.Itoa(filter.Offset))
+	}
+	url := c.cfg.Server.Endpoint + "/scans?" + query.Encode()
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", UserAgent)
 
-	if err := c.addAuth(req); err != nil {
+	if err := c.addAuth(req, nil); err != nil {
 		return nil, err
 	}
 
@@ -333,7 +823,7 @@ func (c *Client) GetScans(days, limit int) (*ScansResponse, error) {
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("authentication failed - run 'intentra login' to re-authenticate")
+		return nil, ErrUnauthorized
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -348,22 +838,75 @@ func (c *Client) GetScans(days, limit int) (*ScansResponse, error) {
 	return &result, nil
 }
 
+// UpdateScanStatus sets the review status of a scan on the server.
+func (c *Client) UpdateScanStatus(scanID string, status models.ScanStatus) error {
+	return c.UpdateScanStatusContext(context.Background(), scanID, status)
+}
+
+// UpdateScanStatusContext is UpdateScanStatus with a context that can cancel
+// the request.
+func (c *Client) UpdateScanStatusContext(ctx context.Context, scanID string, status models.ScanStatus) error {
+	if scanID == "" {
+		return fmt.Errorf("scan ID is required")
+	}
+
+	jsonBody, err := json.Marshal(map[string]string{"status": string(status)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/scans/%s", c.cfg.Server.Endpoint, url.PathEscape(scanID))
+	resp, err := doWithRetry(c.httpClient, retryPolicyFromConfig(c.cfg.Server), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", UserAgent)
+
+		if err := c.addAuth(req, jsonBody); err != nil {
+			return nil, fmt.Errorf("failed to add auth: %w", err)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return fmt.Errorf("scan %s: %w", scanID, ErrNotFound)
+	}
+	return readAPIError(resp)
+}
+
 // GetScan retrieves a single scan by ID from the API.
 func (c *Client) GetScan(scanID string) (*ScanDetailResponse, error) {
+	return c.GetScanContext(context.Background(), scanID)
+}
+
+// GetScanContext is GetScan with a context that can cancel the request.
+func (c *Client) GetScanContext(ctx context.Context, scanID string) (*ScanDetailResponse, error) {
 	if scanID == "" {
 		return nil, fmt.Errorf("scan ID is required")
 	}
 
 	url := fmt.Sprintf("%s/scans/%s", c.cfg.Server.Endpoint, url.PathEscape(scanID))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", UserAgent)
 
-	if err := c.addAuth(req); err != nil {
+	if err := c.addAuth(req, nil); err != nil {
 		return nil, err
 	}
 
@@ -381,11 +924,11 @@ func (c *Client) GetScan(scanID string) (*ScanDetailResponse, error) {
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("authentication failed - run 'intentra login' to re-authenticate")
+		return nil, ErrUnauthorized
 	}
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("scan not found: %s", scanID)
+		return nil, fmt.Errorf("scan %s: %w", scanID, ErrNotFound)
 	}
 
 	if resp.StatusCode != http.StatusOK {
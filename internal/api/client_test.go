@@ -0,0 +1,575 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/httputil"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// hmacVerifier is a mock server handler that accepts requests signed with key.
+func hmacVerifier(t *testing.T, keyID, key string) http.HandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/verify" {
+			http.NotFound(w, r)
+			return
+		}
+		ts := r.Header.Get("X-API-Timestamp")
+		nonce := r.Header.Get("X-API-Nonce")
+		if r.Header.Get("X-API-Key-ID") != keyID || ts == "" || nonce == "" {
+			http.Error(w, "missing auth headers", http.StatusUnauthorized)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte(key))
+		fmt.Fprintf(mac, "%s\n%s\n%s\n%s", r.Method, r.URL.Path, ts, nonce)
+		want := hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(r.Header.Get("X-API-Key-Signature")), []byte(want)) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true}`)
+	}
+}
+
+func newAPIKeyClient(t *testing.T, srv *httptest.Server, hmacKey string) *Client {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Server.Enabled = true
+	cfg.Server.Endpoint = srv.URL
+	cfg.Server.Auth.Mode = config.AuthModeAPIKey
+	cfg.Server.Auth.APIKey.KeyID = "key-1"
+	cfg.Server.Auth.APIKey.HMACKey = hmacKey
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.httpClient = srv.Client()
+	return client
+}
+
+func TestVerifyAuth_ValidSignature(t *testing.T) {
+	srv := httptest.NewTLSServer(hmacVerifier(t, "key-1", "s3cret"))
+	defer srv.Close()
+
+	res, err := newAPIKeyClient(t, srv, "s3cret").VerifyAuth()
+	if err != nil {
+		t.Fatalf("VerifyAuth: %v", err)
+	}
+	if !res.OK() || res.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want 200 (body %q)", res.StatusCode, res.Body)
+	}
+	if res.Mode != config.AuthModeAPIKey {
+		t.Errorf("Mode = %q, want %q", res.Mode, config.AuthModeAPIKey)
+	}
+}
+
+func TestVerifyAuth_WrongKeyRejected(t *testing.T) {
+	srv := httptest.NewTLSServer(hmacVerifier(t, "key-1", "s3cret"))
+	defer srv.Close()
+
+	res, err := newAPIKeyClient(t, srv, "wrong").VerifyAuth()
+	if err != nil {
+		t.Fatalf("VerifyAuth: %v", err)
+	}
+	if res.OK() || res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("StatusCode = %d, want 401", res.StatusCode)
+	}
+	if res.Body == "" {
+		t.Error("expected server response body to be captured")
+	}
+}
+
+func TestVerifyAuth_RefusesPlainHTTP(t *testing.T) {
+	srv := httptest.NewServer(hmacVerifier(t, "key-1", "s3cret"))
+	defer srv.Close()
+
+	if _, err := newAPIKeyClient(t, srv, "s3cret").VerifyAuth(); err == nil {
+		t.Error("expected api_key auth over HTTP to be refused")
+	}
+}
+
+func signedMessageFor(req *http.Request) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s", req.Method, req.URL.Path,
+		req.Header.Get("X-API-Timestamp"), req.Header.Get("X-API-Nonce"))
+}
+
+func hmacHex(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestAddAPIKeyAuth_LegacySignature(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Auth.APIKey = config.APIKeyConfig{KeyID: "key-1", HMACKey: "s3cret"}
+	c := &Client{cfg: cfg}
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/scans", nil)
+	if err := c.addAPIKeyAuth(req, []byte(`{"big":"payload"}`)); err != nil {
+		t.Fatalf("addAPIKeyAuth: %v", err)
+	}
+	if h := req.Header.Get("X-API-Body-Hash"); h != "" {
+		t.Errorf("X-API-Body-Hash = %q, want unset in legacy mode", h)
+	}
+	if got, want := req.Header.Get("X-API-Key-Signature"), hmacHex("s3cret", signedMessageFor(req)); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestAddAPIKeyAuth_BodyHashMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Auth.APIKey = config.APIKeyConfig{KeyID: "key-1", HMACKey: "s3cret"}
+	cfg.Server.Auth.HMAC.BodyHashMode = true
+	c := &Client{cfg: cfg}
+
+	body := []byte(`{"big":"payload"}`)
+	sum := sha256.Sum256(body)
+	wantHash := hex.EncodeToString(sum[:])
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/scans", nil)
+	if err := c.addAPIKeyAuth(req, body); err != nil {
+		t.Fatalf("addAPIKeyAuth: %v", err)
+	}
+	if got := req.Header.Get("X-API-Body-Hash"); got != wantHash {
+		t.Errorf("X-API-Body-Hash = %q, want %q", got, wantHash)
+	}
+	want := hmacHex("s3cret", signedMessageFor(req)+"\n"+wantHash)
+	if got := req.Header.Get("X-API-Key-Signature"); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Enabled = true
+	cfg.Server.Endpoint = srv.URL
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if err := client.Health(); err != nil {
+		t.Errorf("Health() = %v, want nil", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := client.Health(); err == nil {
+		t.Error("Health() should fail on 503")
+	}
+}
+
+// fixSigningInputs pins the nonce source and clock so signatures are reproducible.
+func fixSigningInputs(t *testing.T) {
+	t.Helper()
+	origReader, origNow := nonceReader, now
+	nonce := make([]byte, 16)
+	for i := range nonce {
+		nonce[i] = byte(i)
+	}
+	nonceReader = bytes.NewReader(nonce)
+	now = func() time.Time { return time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { nonceReader, now = origReader, origNow })
+}
+
+func TestAddAPIKeyAuth_KnownSignature(t *testing.T) {
+	tests := []struct {
+		name     string
+		bodyHash bool
+		want     string
+	}{
+		{"legacy", false, "2676d91bb735b506284ec1a7742717fe87e772fd01c85da5ac68bacb967bdbf7"},
+		{"body hash", true, "d62b1ae0182508aa612831b34d12cea6b49b00935b64b4a0aa367f9dc8be875a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixSigningInputs(t)
+			cfg := config.DefaultConfig()
+			cfg.Server.Auth.APIKey = config.APIKeyConfig{KeyID: "key-1", HMACKey: "s3cret"}
+			cfg.Server.Auth.HMAC.BodyHashMode = tt.bodyHash
+			c := &Client{cfg: cfg}
+
+			req, _ := http.NewRequest("POST", "https://api.example.com/scans", nil)
+			if err := c.addAPIKeyAuth(req, []byte(`{"big":"payload"}`)); err != nil {
+				t.Fatalf("addAPIKeyAuth: %v", err)
+			}
+			if got := req.Header.Get("X-API-Nonce"); got != "000102030405060708090a0b0c0d0e0f" {
+				t.Errorf("nonce = %s", got)
+			}
+			if got := req.Header.Get("X-API-Timestamp"); got != "2025-03-01T10:00:00Z" {
+				t.Errorf("timestamp = %s", got)
+			}
+			if got := req.Header.Get("X-API-Key-Signature"); got != tt.want {
+				t.Errorf("signature = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAPIKeyAuth_NonceSourceError(t *testing.T) {
+	orig := nonceReader
+	nonceReader = bytes.NewReader(nil)
+	t.Cleanup(func() { nonceReader = orig })
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Auth.APIKey = config.APIKeyConfig{KeyID: "key-1", HMACKey: "s3cret"}
+	req, _ := http.NewRequest("GET", "https://api.example.com/auth/verify", nil)
+	if err := (&Client{cfg: cfg}).addAPIKeyAuth(req, nil); err == nil {
+		t.Error("expected error when the nonce source is exhausted")
+	}
+}
+
+func TestPing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key-ID") != "" {
+			t.Error("ping must not send credentials")
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("maintenance\n"))
+	}))
+	defer srv.Close()
+
+	result, err := Ping(srv.URL+"/", 5*time.Second)
+	if err != nil {
+		t.Fatalf("Ping() = %v", err)
+	}
+	if result.StatusCode != http.StatusServiceUnavailable || result.Body != "maintenance" {
+		t.Errorf("result = %+v, want 503 maintenance", result)
+	}
+	if result.URL != srv.URL+"/health" {
+		t.Errorf("URL = %s, want %s/health", result.URL, srv.URL)
+	}
+	if result.Latency < 5*time.Millisecond {
+		t.Errorf("Latency = %s, want at least 5ms", result.Latency)
+	}
+
+	srv.Close()
+	if _, err := Ping(srv.URL, time.Second); err == nil {
+		t.Error("Ping() should fail when the server is down")
+	}
+}
+
+func TestNewClient_UsesConfiguredTLS(t *testing.T) {
+	if err := httputil.ConfigureTLS(httputil.TLSVersion13, nil); err != nil {
+		t.Fatalf("ConfigureTLS failed: %v", err)
+	}
+	t.Cleanup(func() { httputil.ConfigureTLS(httputil.TLSVersion12, nil) })
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Enabled = true
+	cfg.Server.Endpoint = "https://api.example.com"
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		t.Fatalf("client transport = %T, want *http.Transport with TLS config", client.httpClient.Transport)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %#x, want TLS 1.3", transport.TLSClientConfig.MinVersion)
+	}
+}
+
+func TestSendScanBatch_PartialFailure(t *testing.T) {
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	var requests int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scans/batch" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		if r.Header.Get("X-API-Key-Signature") == "" {
+			t.Error("batch request should be signed")
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("gzip: %v", err)
+			return
+		}
+		var body struct {
+			Scans []map[string]any `json:"scans"`
+		}
+		if err := json.NewDecoder(zr).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+			return
+		}
+		if len(body.Scans) != 3 || body.Scans[1]["scan_id"] != "scan-2" {
+			t.Errorf("batch body = %v", body.Scans)
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `{"failed":[{"scan_id":"scan-2","error":"invalid model"}]}`)
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	client.cfg.Server.BatchUpload = true
+	scans := []*models.Scan{{ID: "scan-1"}, {ID: "scan-2"}, {ID: "scan-3"}}
+
+	err := client.SendScans(scans)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("SendScans error = %v, want *BatchError", err)
+	}
+	if len(batchErr.Failed) != 1 || batchErr.Failed["scan-2"] != "invalid model" {
+		t.Errorf("Failed = %v, want only scan-2", batchErr.Failed)
+	}
+	if requests != 1 {
+		t.Errorf("made %d batch requests, want 1", requests)
+	}
+}
+
+func TestSendScans_FallsBackWithoutBatchEndpoint(t *testing.T) {
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	var batchRequests, scanRequests int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/scans/batch":
+			batchRequests++
+			http.NotFound(w, r)
+		case "/scans":
+			scanRequests++
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	client.cfg.Server.BatchUpload = true
+	scans := []*models.Scan{{ID: "scan-1"}, {ID: "scan-2"}}
+
+	for i := 0; i < 2; i++ {
+		if err := client.SendScans(scans); err != nil {
+			t.Fatalf("SendScans: %v", err)
+		}
+	}
+	if batchRequests != 1 {
+		t.Errorf("batch endpoint tried %d times, want 1", batchRequests)
+	}
+	if scanRequests != 4 {
+		t.Errorf("per-scan requests = %d, want 4", scanRequests)
+	}
+}
+
+func TestSendScansContext_Canceled(t *testing.T) {
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	var requests atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	client.cfg.Server.MaxRetries = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.SendScansContext(ctx, []*models.Scan{{ID: "scan-1"}, {ID: "scan-2"}})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failed) != 2 {
+		t.Fatalf("SendScansContext error = %v, want both scans failed", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d requests, want 1 (remaining scans skipped after cancel)", n)
+	}
+}
+
+func TestSendScans_StopsWhenServerUnavailable(t *testing.T) {
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	var requests atomic.Int32
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	client.cfg.Server.MaxRetries = 0
+	scans := []*models.Scan{{ID: "scan-1"}, {ID: "scan-2"}, {ID: "scan-3"}}
+
+	var batchErr *BatchError
+	if err := client.SendScans(scans); !errors.As(err, &batchErr) || len(batchErr.Failed) != 3 {
+		t.Fatalf("SendScans error = %v, want all scans failed", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d requests against an unavailable server, want 1", n)
+	}
+
+	// A rejected scan does not stop the rest.
+	requests.Store(0)
+	status = http.StatusBadRequest
+	if err := client.SendScans(scans); !errors.As(err, &batchErr) || len(batchErr.Failed) != 3 {
+		t.Fatalf("SendScans error = %v, want all scans failed", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("made %d requests for rejected scans, want 3", n)
+	}
+}
+
+func TestSendScan_RetriesWithIdempotencyKey(t *testing.T) {
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	stubSleep(t)
+	var keys []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	client.cfg.Server.MaxRetries = 1
+	if err := client.SendScan(&models.Scan{ID: "scan-1"}); err != nil {
+		t.Fatalf("SendScan: %v", err)
+	}
+	if !slices.Equal(keys, []string{"scan-1", "scan-1"}) {
+		t.Errorf("Idempotency-Key per attempt = %q, want the scan ID on both", keys)
+	}
+
+	keys = nil
+	client.cfg.Server.BatchUpload = true
+	if err := client.SendScans([]*models.Scan{{ID: "scan-1"}, {ID: "scan-2"}}); err != nil {
+		t.Fatalf("SendScans: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("batch Idempotency-Key per attempt = %q, want one stable key", keys)
+	}
+}
+
+func TestBatchError_Empty(t *testing.T) {
+	if got := (&BatchError{}).Error(); got == "" {
+		t.Error("empty BatchError should still describe itself")
+	}
+}
+
+func TestUpdateScanStatus(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	var got map[string]string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/scans/scan-1" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-API-Key-Signature") == "" {
+			t.Error("status update should be signed")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	if err := client.UpdateScanStatus("scan-1", models.ScanStatusReviewed); err != nil {
+		t.Fatalf("UpdateScanStatus: %v", err)
+	}
+	if got["status"] != "reviewed" {
+		t.Errorf("body = %v, want status reviewed", got)
+	}
+
+	if err := client.UpdateScanStatus("scan-2", models.ScanStatusReviewed); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing scan: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUpdateScanStatus_RetriesAndReportsStatus(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	stubSleep(t)
+	var requests atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "bad status")
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	client.cfg.Server.MaxRetries = 2
+	err := client.UpdateScanStatus("scan-1", models.ScanStatusReviewed)
+	var se *statusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusBadRequest || !strings.Contains(err.Error(), "bad status") {
+		t.Errorf("err = %v, want the 400 response after retrying the 503", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestGetFilteredScans_QueryParams(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	var query map[string][]string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scans" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"scans":[{"scan_id":"scan-1"}],"summary":{"total_scans":1}}`)
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	if _, err := client.GetFilteredScans(7, 20, ScanFilter{Tool: "cursor", Repo: "My Project"}); err != nil {
+		t.Fatalf("GetFilteredScans: %v", err)
+	}
+	for key, want := range map[string]string{"days": "7", "limit": "20", "tool": "cursor", "repo": "My Project"} {
+		if got := query[key]; len(got) != 1 || got[0] != want {
+			t.Errorf("query %s = %v, want %q", key, got, want)
+		}
+	}
+
+	if _, err := client.GetScans(7, 20); err != nil {
+		t.Fatalf("GetScans: %v", err)
+	}
+	if _, ok := query["tool"]; ok {
+		t.Errorf("unfiltered request should not send tool: %v", query)
+	}
+}
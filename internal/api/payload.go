@@ -0,0 +1,54 @@
+package api
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// scanMetadata is attached to every scan payload. Replaced by
+// SetScanMetadata.
+var scanMetadata map[string]string
+
+// SetScanMetadata installs local.metadata for all scans sent by this
+// process, including those sent without a Client. Call it once after
+// loading config. Metadata that bypassed config validation is brought
+// within its limits here: invalid keys and entries past the limit are
+// dropped and long values are truncated, each with a warning.
+func SetScanMetadata(m map[string]string) {
+	if len(m) == 0 {
+		scanMetadata = nil
+		return
+	}
+	keys := slices.Sorted(maps.Keys(m))
+	scanMetadata = make(map[string]string, min(len(keys), config.MaxMetadataEntries))
+	for _, k := range keys {
+		if !config.ValidMetadataKey(k) {
+			debug.Warn("dropping invalid local.metadata key %q", k)
+			continue
+		}
+		if len(scanMetadata) == config.MaxMetadataEntries {
+			debug.Warn("dropping local.metadata.%s: at most %d entries are sent", k, config.MaxMetadataEntries)
+			continue
+		}
+		v := m[k]
+		if len(v) > config.MaxMetadataValueLen {
+			debug.Warn("truncating local.metadata.%s from %d to %d bytes", k, len(v), config.MaxMetadataValueLen)
+			v = models.TruncateUTF8(v, config.MaxMetadataValueLen)
+		}
+		scanMetadata[k] = v
+	}
+}
+
+// ScanPayload returns the request body sent for scan: its API payload plus
+// the configured metadata, if any.
+func ScanPayload(scan *models.Scan, deviceID string, richTraces bool) map[string]any {
+	p := scan.BuildAPIPayload(deviceID, richTraces)
+	if len(scanMetadata) > 0 {
+		p["metadata"] = maps.Clone(scanMetadata)
+	}
+	return p
+}
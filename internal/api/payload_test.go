@@ -0,0 +1,87 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestSendScan_IncludesMetadata(t *testing.T) {
+	t.Setenv("INTENTRA_DEVICE_ID", "test-device")
+	SetScanMetadata(map[string]string{"team": "platform", "cost_center": "eng-42"})
+	t.Cleanup(func() { SetScanMetadata(nil) })
+
+	var got map[string]any
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("gzip: %v", err)
+			return
+		}
+		if err := json.NewDecoder(zr).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	client := newAPIKeyClient(t, srv, "s3cret")
+	if err := client.SendScan(&models.Scan{ID: "scan-1", Tool: "cursor"}); err != nil {
+		t.Fatalf("SendScan: %v", err)
+	}
+	md, ok := got["metadata"].(map[string]any)
+	if !ok || md["team"] != "platform" || md["cost_center"] != "eng-42" || len(md) != 2 {
+		t.Errorf("metadata = %v", got["metadata"])
+	}
+}
+
+func TestScanPayload_OmitsEmptyMetadata(t *testing.T) {
+	SetScanMetadata(nil)
+	p := ScanPayload(&models.Scan{ID: "scan-1"}, "dev", false)
+	if _, ok := p["metadata"]; ok {
+		t.Errorf("payload should not carry metadata: %v", p["metadata"])
+	}
+
+	src := map[string]string{"env": "ci"}
+	SetScanMetadata(src)
+	t.Cleanup(func() { SetScanMetadata(nil) })
+	src["env"] = "changed"
+	p = ScanPayload(&models.Scan{ID: "scan-1"}, "dev", false)
+	if md := p["metadata"].(map[string]string); md["env"] != "ci" {
+		t.Errorf("metadata should be copied when set, got %v", md)
+	}
+}
+
+func TestSetScanMetadata_EnforcesLimits(t *testing.T) {
+	m := map[string]string{
+		"bad key": "x",
+		"long":    strings.Repeat("é", config.MaxMetadataValueLen),
+		"zz":      "over the limit",
+	}
+	for i := 0; i < config.MaxMetadataEntries-1; i++ {
+		m[fmt.Sprintf("k%02d", i)] = "v"
+	}
+	SetScanMetadata(m)
+	t.Cleanup(func() { SetScanMetadata(nil) })
+
+	md := ScanPayload(&models.Scan{ID: "scan-1"}, "dev", false)["metadata"].(map[string]string)
+	if len(md) != config.MaxMetadataEntries {
+		t.Errorf("sent %d metadata entries, want %d", len(md), config.MaxMetadataEntries)
+	}
+	if _, ok := md["bad key"]; ok {
+		t.Error("invalid key should be dropped")
+	}
+	if _, ok := md["zz"]; ok {
+		t.Error("entries past the limit should be dropped")
+	}
+	if v, ok := md["long"]; !ok || len(v) > config.MaxMetadataValueLen || !strings.HasPrefix(strings.Repeat("é", config.MaxMetadataValueLen), v) {
+		t.Errorf("long value = %q (%d bytes), want it truncated on a rune boundary", v, len(v))
+	}
+}
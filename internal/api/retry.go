@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
+)
+
+// maxRetryDelay caps a single wait between attempts, including waits
+// requested by the server via Retry-After.
+const maxRetryDelay = time.Minute
+
+// RetryPolicy controls how transient request failures are retried.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt; 0 disables
+	Backoff    time.Duration // base delay, doubled on each retry
+}
+
+// defaultRetryPolicy is used by the package-level JWT requests, which have no
+// config of their own. Replaced by SetRetryPolicy.
+var defaultRetryPolicy = RetryPolicy{MaxRetries: 3, Backoff: time.Second}
+
+// sleep waits d or until ctx is done; swapped out in tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetRetryPolicy installs the retry settings from config for requests made
+// without a Client (SendScanWithJWT, PatchSessionEnd). Call it once after
+// loading config.
+func SetRetryPolicy(s config.ServerConfig) {
+	defaultRetryPolicy = retryPolicyFromConfig(s)
+}
+
+func retryPolicyFromConfig(s config.ServerConfig) RetryPolicy {
+	return RetryPolicy{MaxRetries: s.MaxRetries, Backoff: s.RetryBackoff}
+}
+
+// doWithRetry sends the request built by newReq, retrying connection errors
+// and 429/5xx responses according to policy. newReq is called once per
+// attempt so bodies and signed headers are fresh. The final response (or
+// error) is returned to the caller, who owns the response body. Retries
+// stop as soon as the request's context is done.
+func doWithRetry(client *http.Client, policy RetryPolicy, newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			debug.LogHTTP(req.Method, req.URL.String(), 0)
+		} else {
+			debug.LogHTTP(req.Method, req.URL.String(), resp.StatusCode)
+		}
+
+		ctx := req.Context()
+		if attempt >= policy.MaxRetries || ctx.Err() != nil || !isRetryable(resp, err) {
+			return resp, err
+		}
+
+		delay := backoffDelay(policy.Backoff, attempt)
+		if resp != nil {
+			if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now()); ok {
+				delay = min(ra, maxRetryDelay)
+			}
+			resp.Body.Close()
+		}
+		debug.Warn("%s %s failed (attempt %d/%d), retrying in %s", req.Method, req.URL.Path, attempt+1, policy.MaxRetries+1, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isRetryable reports whether a request outcome is worth retrying.
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoffDelay returns base*2^attempt with up to 50% jitter, capped at
+// maxRetryDelay.
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base
+	for i := 0; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryDelay)
+	return d/2 + rand.N(d/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header given as delay-seconds or an
+// HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+)
+
+// stubSleep records requested delays instead of sleeping.
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	orig := sleep
+	sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { sleep = orig })
+	return &delays
+}
+
+func serveStatuses(t *testing.T, headers http.Header, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		code := statuses[len(statuses)-1]
+		if int(n) <= len(statuses) {
+			code = statuses[n-1]
+		}
+		for k, v := range headers {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func getter(url string) func() (*http.Request, error) {
+	return func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
+	}
+}
+
+func TestDoWithRetry_RetriesServerErrors(t *testing.T) {
+	delays := stubSleep(t)
+	srv, calls := serveStatuses(t, nil, 503, 500, 200)
+
+	resp, err := doWithRetry(srv.Client(), RetryPolicy{MaxRetries: 3, Backoff: 100 * time.Millisecond}, getter(srv.URL))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+	if len(*delays) != 2 {
+		t.Fatalf("delays = %v, want 2 entries", *delays)
+	}
+	if d := (*delays)[0]; d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Errorf("first delay = %s, want within [50ms, 100ms]", d)
+	}
+	if d := (*delays)[1]; d < 100*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("second delay = %s, want within [100ms, 200ms]", d)
+	}
+}
+
+func TestDoWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	stubSleep(t)
+	srv, calls := serveStatuses(t, nil, 400)
+
+	resp, err := doWithRetry(srv.Client(), RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}, getter(srv.URL))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	resp.Body.Close()
+
+	if *calls != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+}
+
+func TestDoWithRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	stubSleep(t)
+	srv, calls := serveStatuses(t, nil, 502)
+
+	resp, err := doWithRetry(srv.Client(), RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}, getter(srv.URL))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 502 {
+		t.Errorf("status = %d, want 502", resp.StatusCode)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+}
+
+func TestDoWithRetry_HonorsRetryAfter(t *testing.T) {
+	delays := stubSleep(t)
+	srv, _ := serveStatuses(t, http.Header{"Retry-After": {"7"}}, 429, 200)
+
+	resp, err := doWithRetry(srv.Client(), RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}, getter(srv.URL))
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(*delays) != 1 || (*delays)[0] != 7*time.Second {
+		t.Errorf("delays = %v, want [7s]", *delays)
+	}
+}
+
+func TestDoWithRetry_RetriesConnectionErrors(t *testing.T) {
+	delays := stubSleep(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	_, err := doWithRetry(http.DefaultClient, RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}, getter(url))
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+	if len(*delays) != 2 {
+		t.Errorf("delays = %v, want 2 entries", *delays)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"30", 30 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoffDelay_Capped(t *testing.T) {
+	if d := backoffDelay(0, 3); d != 0 {
+		t.Errorf("zero base delay = %s, want 0", d)
+	}
+	if d := backoffDelay(time.Second, 20); d > maxRetryDelay || d < maxRetryDelay/2 {
+		t.Errorf("capped delay = %s, want within [%s, %s]", d, maxRetryDelay/2, maxRetryDelay)
+	}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	orig := defaultRetryPolicy
+	t.Cleanup(func() { defaultRetryPolicy = orig })
+
+	SetRetryPolicy(config.ServerConfig{MaxRetries: 5, RetryBackoff: 2 * time.Second})
+	if defaultRetryPolicy != (RetryPolicy{MaxRetries: 5, Backoff: 2 * time.Second}) {
+		t.Errorf("defaultRetryPolicy = %+v", defaultRetryPolicy)
+	}
+}
+
+func TestDoWithRetry_StopsWhenContextDone(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := doWithRetry(srv.Client(), RetryPolicy{MaxRetries: 3, Backoff: time.Minute}, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("retry wait ignored the context (took %s)", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
@@ -1,11 +1,19 @@
 package auth
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/99designs/keyring"
+	"github.com/intentrahq/intentra-cli/internal/config"
 )
 
 func TestCredentialsIsExpired_Valid(t *testing.T) {
@@ -87,6 +95,89 @@ func TestCredentialsIsValid(t *testing.T) {
 	}
 }
 
+// setClock fixes the token expiry clock at t0 for the rest of the test.
+func setClock(t *testing.T, t0 time.Time) {
+	t.Helper()
+	orig := now
+	t.Cleanup(func() { now = orig })
+	now = func() time.Time { return t0 }
+}
+
+func TestCredentialsIsExpired_BufferBoundary(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	setClock(t, t0)
+	t.Cleanup(func() { SetExpiryBuffer(config.DefaultTokenExpiryBuffer) })
+
+	tests := []struct {
+		name    string
+		buffer  time.Duration
+		expires time.Duration
+		want    bool
+	}{
+		{"exactly at default buffer", config.DefaultTokenExpiryBuffer, 5 * time.Minute, false},
+		{"one second inside default buffer", config.DefaultTokenExpiryBuffer, 5*time.Minute - time.Second, true},
+		{"longer buffer", 15 * time.Minute, 10 * time.Minute, true},
+		{"no buffer, not yet expired", 0, time.Second, false},
+		{"no buffer, expired", 0, -time.Second, true},
+		{"negative keeps default", -time.Minute, 4 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetExpiryBuffer(tt.buffer)
+			creds := &Credentials{AccessToken: "tok", ExpiresAt: t0.Add(tt.expires)}
+			if got := creds.IsExpired(); got != tt.want {
+				t.Errorf("IsExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCredentialsFromTokenResponse_FixedClock(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	setClock(t, t0)
+	creds := CredentialsFromTokenResponse(&TokenResponse{AccessToken: "tok", ExpiresIn: 3600})
+	if want := t0.Add(time.Hour); !creds.ExpiresAt.Equal(want) {
+		t.Fatalf("ExpiresAt = %v, want %v", creds.ExpiresAt, want)
+	}
+
+	// A clock that has drifted 56 minutes forward sees the token inside the buffer.
+	setClock(t, t0.Add(56*time.Minute))
+	if !creds.IsExpired() {
+		t.Error("expected credentials to count as expired within the buffer")
+	}
+}
+
+func TestCredentialsFromToken(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	setClock(t, t0)
+	exp := t0.Add(30 * 24 * time.Hour)
+	jwt := func(claims string) string {
+		return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  time.Time
+	}{
+		{"jwt with exp", jwt(fmt.Sprintf(`{"sub":"svc","exp":%d}`, exp.Unix())), exp},
+		{"jwt without exp", jwt(`{"sub":"svc"}`), t0.Add(unknownTokenLifetime)},
+		{"malformed payload", "a.!!!.c", t0.Add(unknownTokenLifetime)},
+		{"opaque token", "opaque-token", t0.Add(unknownTokenLifetime)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := CredentialsFromToken(tt.token)
+			if creds.AccessToken != tt.token || creds.TokenType != "Bearer" || creds.RefreshToken != "" {
+				t.Errorf("unexpected credentials: %+v", creds)
+			}
+			if !creds.ExpiresAt.Equal(tt.want) {
+				t.Errorf("ExpiresAt = %v, want %v", creds.ExpiresAt, tt.want)
+			}
+		})
+	}
+}
+
 func TestCredentialsFromTokenResponse(t *testing.T) {
 	before := time.Now()
 	resp := &TokenResponse{
@@ -162,14 +253,48 @@ func TestEncryptDecrypt_WrongKey(t *testing.T) {
 	}
 }
 
+func TestExportImportCredentials(t *testing.T) {
+	creds := &Credentials{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		Email:        "user@example.com",
+	}
+
+	blob, err := ExportCredentials(creds, "correct horse")
+	if err != nil {
+		t.Fatalf("ExportCredentials() error: %v", err)
+	}
+	if strings.Contains(blob, "access") || strings.Contains(blob, "\n") {
+		t.Errorf("blob should be a single opaque line, got %q", blob)
+	}
+
+	got, err := ImportCredentials(blob+"\n", "correct horse")
+	if err != nil {
+		t.Fatalf("ImportCredentials() error: %v", err)
+	}
+	if *got != *creds {
+		t.Errorf("ImportCredentials() = %+v, want %+v", got, creds)
+	}
+
+	if _, err := ImportCredentials(blob, "wrong horse"); err == nil {
+		t.Error("import with the wrong passphrase should fail")
+	}
+	if _, err := ImportCredentials("not-a-blob", "correct horse"); err == nil {
+		t.Error("import of a non-export should fail")
+	}
+	if _, err := ExportCredentials(creds, "short"); err == nil {
+		t.Error("export should reject a short passphrase")
+	}
+}
+
 func TestWriteReadEncryptedCache(t *testing.T) {
 	tmpDir := t.TempDir()
 	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
 
 	// Reset keyring singleton so it re-opens with test config
-	ringOnce = sync.Once{}
-	ring = nil
-	ringOpenErr = nil
+	resetKeyring()
 
 	creds := &Credentials{
 		AccessToken:  "test-access-token",
@@ -269,9 +394,7 @@ func TestDeleteEncryptedCache(t *testing.T) {
 	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
 
 	// Reset keyring singleton
-	ringOnce = sync.Once{}
-	ring = nil
-	ringOpenErr = nil
+	resetKeyring()
 
 	creds := &Credentials{
 		AccessToken:  "tok",
@@ -351,3 +474,287 @@ func TestReadEncryptedCache_NoFile(t *testing.T) {
 		t.Error("ReadEncryptedCache() should return nil when no file exists")
 	}
 }
+
+// resetKeyring makes the next keyring access open it afresh with the
+// current environment and backend.
+func resetKeyring() {
+	ringOnce = sync.Once{}
+	ring = nil
+	ringOpenErr = nil
+	ringBackends = nil
+}
+
+// useLegacyTestKeyring points the keyring at a fresh file backend in a temp
+// config dir, skipping the test on hosts without a legacy machine ID.
+func useLegacyTestKeyring(t *testing.T) string {
+	t.Helper()
+	if _, ok := DeriveLegacyKey(CacheKeySalt, CacheKeyInfo); !ok {
+		t.Skip("no legacy Linux machine ID on this host")
+	}
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+	t.Setenv("INTENTRA_NO_KEYCHAIN", "1")
+	resetKeyring()
+	t.Cleanup(resetKeyring)
+	return dir
+}
+
+func TestReadEncryptedCache_LegacyKeyMigrates(t *testing.T) {
+	dir := useLegacyTestKeyring(t)
+	legacy, _ := DeriveLegacyKey(CacheKeySalt, CacheKeyInfo)
+
+	plaintext := []byte(`{"access_token":"legacy-token","token_type":"Bearer"}`)
+	ciphertext, err := Encrypt(plaintext, legacy)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	cacheFile := filepath.Join(dir, "credentials.enc")
+	if err := os.WriteFile(cacheFile, append([]byte{encryptedCacheVersion}, ciphertext...), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := ReadEncryptedCache()
+	if err != nil || creds == nil || creds.AccessToken != "legacy-token" {
+		t.Fatalf("ReadEncryptedCache = %+v, %v; want the legacy cache", creds, err)
+	}
+
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ReadCacheKey()
+	if err != nil {
+		t.Fatalf("ReadCacheKey: %v", err)
+	}
+	if _, err := Decrypt(data[1:], key); err != nil {
+		t.Errorf("cache not re-encrypted under the current key: %v", err)
+	}
+}
+
+func TestLoadCredentialsFromKeyring_LegacyFileKeyring(t *testing.T) {
+	dir := useLegacyTestKeyring(t)
+	t.Setenv("INTENTRA_TOKEN", "")
+
+	open := func(prompt keyring.PromptFunc) keyring.Keyring {
+		t.Helper()
+		kr, err := keyring.Open(keyring.Config{
+			ServiceName:      serviceName,
+			FileDir:          dir,
+			FilePasswordFunc: prompt,
+			AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+		})
+		if err != nil {
+			t.Fatalf("keyring.Open: %v", err)
+		}
+		return kr
+	}
+	item := keyring.Item{Key: credentialsKey, Data: []byte(`{"access_token":"legacy-token"}`)}
+	if err := open(legacyFilePasswordPrompt).Set(item); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	creds, err := LoadCredentialsFromKeyring()
+	if err != nil || creds == nil || creds.AccessToken != "legacy-token" {
+		t.Fatalf("LoadCredentialsFromKeyring = %+v, %v; want the legacy login", creds, err)
+	}
+	if _, err := open(filePasswordPrompt).Get(credentialsKey); err != nil {
+		t.Errorf("keyring item not re-encrypted under the current password: %v", err)
+	}
+}
+
+// setupRefreshTest stores creds in a fresh encrypted cache and replaces the
+// refresh endpoint with one that issues a one-hour token and counts calls.
+func setupRefreshTest(t *testing.T, t0 time.Time, creds *Credentials) *int32 {
+	t.Helper()
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	resetKeyring()
+	setClock(t, t0)
+	if err := WriteEncryptedCache(creds); err != nil {
+		t.Fatalf("WriteEncryptedCache() error: %v", err)
+	}
+
+	var calls int32
+	orig := refreshHTTP
+	t.Cleanup(func() { refreshHTTP = orig })
+	refreshHTTP = func(c *Credentials) (*Credentials, error) {
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return &Credentials{
+			AccessToken:  fmt.Sprintf("access-%d", n),
+			RefreshToken: c.RefreshToken,
+			TokenType:    "Bearer",
+			ExpiresAt:    t0.Add(time.Hour),
+		}, nil
+	}
+	return &calls
+}
+
+func TestRefreshIfDue(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("not yet due", func(t *testing.T) {
+		calls := setupRefreshTest(t, t0, &Credentials{AccessToken: "old", RefreshToken: "ref", ExpiresAt: t0.Add(30 * time.Minute)})
+		if got, want := refreshIfDue(), 24*time.Minute; got != want {
+			t.Errorf("wait = %s, want %s", got, want)
+		}
+		if *calls != 0 {
+			t.Errorf("refresh calls = %d, want 0", *calls)
+		}
+	})
+
+	t.Run("due", func(t *testing.T) {
+		calls := setupRefreshTest(t, t0, &Credentials{AccessToken: "old", RefreshToken: "ref", ExpiresAt: t0.Add(6 * time.Minute)})
+		if got, want := refreshIfDue(), 54*time.Minute; got != want {
+			t.Errorf("wait = %s, want %s", got, want)
+		}
+		if *calls != 1 {
+			t.Errorf("refresh calls = %d, want 1", *calls)
+		}
+		stored, err := LoadCredentialsFromKeyring()
+		if err != nil || stored == nil || stored.AccessToken != "access-1" {
+			t.Errorf("stored credentials = %+v, %v; want access-1", stored, err)
+		}
+	})
+
+	t.Run("not logged in", func(t *testing.T) {
+		t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+		resetKeyring()
+		if got := refreshIfDue(); got != refresherRetry {
+			t.Errorf("wait = %s, want %s", got, refresherRetry)
+		}
+	})
+}
+
+func TestRefreshCredentials_NoDuplicateWithRefresher(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	expired := &Credentials{AccessToken: "old", RefreshToken: "ref", ExpiresAt: t0.Add(time.Minute)}
+	calls := setupRefreshTest(t, t0, expired)
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 4)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 0 {
+				refreshIfDue()
+				return
+			}
+			creds, err := GetValidCredentials()
+			if err != nil {
+				t.Errorf("GetValidCredentials() error: %v", err)
+				return
+			}
+			tokens[i] = creds.AccessToken
+		}(i)
+	}
+	wg.Wait()
+
+	if *calls != 1 {
+		t.Errorf("refresh calls = %d, want 1", *calls)
+	}
+	for i, tok := range tokens[1:] {
+		if tok != "access-1" {
+			t.Errorf("caller %d got token %q, want access-1", i+1, tok)
+		}
+	}
+}
+
+func TestStartRefresher_StopsOnCancel(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	setupRefreshTest(t, t0, &Credentials{AccessToken: "old", RefreshToken: "ref", ExpiresAt: t0.Add(time.Hour)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := StartRefresher(ctx)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("refresher did not stop after cancel")
+	}
+}
+
+func TestGetBackendsForPlatform_KeyringBackend(t *testing.T) {
+	t.Setenv("INTENTRA_NO_KEYCHAIN", "")
+	t.Setenv("INTENTRA_KEYRING_FILE", "")
+	resetKeyring()
+	t.Cleanup(func() { SetKeyringBackend("") })
+
+	tests := []struct {
+		backend string
+		want    []keyring.BackendType
+	}{
+		{config.KeyringBackendFile, []keyring.BackendType{keyring.FileBackend}},
+		{config.KeyringBackendSecretService, []keyring.BackendType{keyring.SecretServiceBackend}},
+		{config.KeyringBackendKeychain, []keyring.BackendType{keyring.KeychainBackend}},
+		{config.KeyringBackendWinCred, []keyring.BackendType{keyring.WinCredBackend}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			SetKeyringBackend(tt.backend)
+			if got := getBackendsForPlatform(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("backends = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	SetKeyringBackend("")
+	if got := getBackendsForPlatform(); len(got) < 2 || got[len(got)-1] != keyring.FileBackend {
+		t.Errorf("auto backends = %v, want the platform order ending in the file backend", got)
+	}
+
+	t.Setenv("INTENTRA_NO_KEYCHAIN", "1")
+	SetKeyringBackend(config.KeyringBackendKeychain)
+	if got := getBackendsForPlatform(); fmt.Sprint(got) != fmt.Sprint([]keyring.BackendType{keyring.FileBackend}) {
+		t.Errorf("INTENTRA_NO_KEYCHAIN backends = %v, want file only", got)
+	}
+}
+
+func TestKeyringConfig_ForceFile(t *testing.T) {
+	t.Setenv("INTENTRA_NO_KEYCHAIN", "")
+	resetKeyring()
+	SetKeyringBackend(config.KeyringBackendSecretService)
+	t.Cleanup(func() { SetKeyringBackend("") })
+
+	for _, tt := range []struct {
+		env  string
+		want []keyring.BackendType
+	}{
+		{"", []keyring.BackendType{keyring.SecretServiceBackend}},
+		{"0", []keyring.BackendType{keyring.SecretServiceBackend}},
+		{"1", []keyring.BackendType{keyring.FileBackend}},
+		{"true", []keyring.BackendType{keyring.FileBackend}},
+	} {
+		t.Setenv("INTENTRA_KEYRING_FILE", tt.env)
+		if got := keyringConfig().AllowedBackends; fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("INTENTRA_KEYRING_FILE=%q: backends = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestSetKeyringBackend_AfterOpen(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("INTENTRA_NO_KEYCHAIN", "")
+	t.Setenv("INTENTRA_KEYRING_FILE", "")
+	resetKeyring()
+	t.Cleanup(func() {
+		resetKeyring()
+		SetKeyringBackend("")
+	})
+
+	if err := SetKeyringBackend(config.KeyringBackendFile); err != nil {
+		t.Fatalf("SetKeyringBackend before open: %v", err)
+	}
+	if _, err := openKeyring(); err != nil {
+		t.Fatalf("openKeyring: %v", err)
+	}
+	if err := SetKeyringBackend(config.KeyringBackendFile); err != nil {
+		t.Errorf("setting the same backend again: %v", err)
+	}
+	if err := SetKeyringBackend(config.KeyringBackendSecretService); err == nil {
+		t.Error("changing the backend after the keyring opened should fail")
+	}
+	if got := getBackendsForPlatform(); fmt.Sprint(got) != fmt.Sprint([]keyring.BackendType{keyring.FileBackend}) {
+		t.Errorf("backends = %v, want the file backend kept", got)
+	}
+}
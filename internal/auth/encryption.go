@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 
 	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
 	"github.com/intentrahq/intentra-cli/internal/device"
 	"golang.org/x/crypto/hkdf"
 )
@@ -112,8 +113,16 @@ func ReadEncryptedCache() (*Credentials, error) {
 	}
 
 	plaintext, err := Decrypt(data[1:], key)
+	legacyKey := false
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+		legacy, ok := DeriveLegacyKey(CacheKeySalt, CacheKeyInfo)
+		if !ok {
+			return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+		}
+		if plaintext, err = Decrypt(data[1:], legacy); err != nil {
+			return nil, fmt.Errorf("failed to decrypt credentials: %w", err)
+		}
+		legacyKey = true
 	}
 
 	var creds Credentials
@@ -121,6 +130,14 @@ func ReadEncryptedCache() (*Credentials, error) {
 		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
 	}
 
+	// Re-encrypt a cache written under the legacy key so the next read
+	// no longer needs it.
+	if legacyKey {
+		if err := WriteEncryptedCache(&creds); err != nil {
+			debug.Warn("failed to re-encrypt credential cache: %v", err)
+		}
+	}
+
 	return &creds, nil
 }
 
@@ -239,7 +256,22 @@ func DeriveKey(salt, info string) ([]byte, error) {
 		fmt.Fprintf(os.Stderr, "Warning: using fallback machine ID for key derivation: %v\n", err)
 		machineID = "fallback-machine-id"
 	}
+	return deriveKeyFrom(machineID, salt, info)
+}
+
+// DeriveLegacyKey derives the key that releases reading the bare Linux
+// machine ID produced for salt and info, so data they encrypted can still
+// be decrypted. ok is false when there is no such key distinct from DeriveKey's.
+func DeriveLegacyKey(salt, info string) (key []byte, ok bool) {
+	machineID, ok := device.GetLegacyHardwareID()
+	if !ok {
+		return nil, false
+	}
+	key, err := deriveKeyFrom(machineID, salt, info)
+	return key, err == nil
+}
 
+func deriveKeyFrom(machineID, salt, info string) ([]byte, error) {
 	currentUser, err := user.Current()
 	username := "unknown"
 	if err == nil {
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Exported credentials are encrypted with a key derived from a passphrase
+// rather than the machine-bound cache key, so they can be imported on
+// another machine. The blob is a single line: exportPrefix followed by the
+// base64 of salt || nonce || ciphertext.
+const (
+	exportPrefix     = "intentra-credentials-v1:"
+	exportSaltSize   = 16
+	exportIterations = 600000
+
+	// MinPassphraseLength is the shortest passphrase accepted for export.
+	MinPassphraseLength = 8
+)
+
+// ExportCredentials encrypts creds with a key derived from passphrase and
+// returns the blob for ImportCredentials.
+func ExportCredentials(creds *Credentials, passphrase string) (string, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return "", fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	salt := make([]byte, exportSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	ciphertext, err := Encrypt(plaintext, passphraseKey(passphrase, salt))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+
+	return exportPrefix + base64.StdEncoding.EncodeToString(append(salt, ciphertext...)), nil
+}
+
+// ImportCredentials decrypts a blob produced by ExportCredentials.
+func ImportCredentials(blob, passphrase string) (*Credentials, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(blob), exportPrefix)
+	if !ok {
+		return nil, fmt.Errorf("not an exported credentials blob")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	if len(data) < exportSaltSize+nonceSize {
+		return nil, fmt.Errorf("exported credentials too short")
+	}
+
+	plaintext, err := Decrypt(data[exportSaltSize:], passphraseKey(passphrase, data[:exportSaltSize]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials (wrong passphrase?)")
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
+	}
+	if creds.AccessToken == "" {
+		return nil, fmt.Errorf("exported credentials have no access token")
+	}
+
+	return &creds, nil
+}
+
+func passphraseKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, exportIterations, keySize, sha256.New)
+}
@@ -2,14 +2,18 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/99designs/keyring"
 	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
 )
 
 const (
@@ -22,30 +26,135 @@ var (
 	ring        keyring.Keyring
 	ringOpenErr error
 	ringOnce    sync.Once
+
+	// ringBackends is the backend list the keyring was opened with, nil
+	// until openKeyring runs. Guarded by backendMu.
+	ringBackends []keyring.BackendType
 )
 
-func openKeyring() (keyring.Keyring, error) {
-	ringOnce.Do(func() {
-		backends := getBackendsForPlatform()
-
-		cfg := keyring.Config{
-			ServiceName:                    serviceName,
-			KeychainTrustApplication:       true,
-			KeychainSynchronizable:         false,
-			KeychainAccessibleWhenUnlocked: true,
-			FileDir:                        func() string { d, _ := config.GetConfigDir(); return d }(),
-			FilePasswordFunc:               filePasswordPrompt,
-			AllowedBackends:                backends,
+// keyringBackend is server.auth.keyring_backend; see SetKeyringBackend.
+// Guarded by backendMu.
+var (
+	keyringBackend = config.KeyringBackendAuto
+	backendMu      sync.Mutex
+)
+
+// SetKeyringBackend installs server.auth.keyring_backend. Call it once after
+// loading config, before credentials are first read or stored; the keyring
+// is opened only once per process, so changing the backend after that
+// returns an error instead of being silently ignored. An empty name means
+// auto.
+func SetKeyringBackend(name string) error {
+	if name == "" {
+		name = config.KeyringBackendAuto
+	}
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	if ringBackends != nil {
+		if want := backendsFor(name); !slices.Equal(want, ringBackends) {
+			return fmt.Errorf("keyring backend %q set after the keyring was opened with %v", name, ringBackends)
 		}
+	}
+	keyringBackend = name
+	return nil
+}
 
+func openKeyring() (keyring.Keyring, error) {
+	ringOnce.Do(func() {
+		cfg := keyringConfig()
+		backendMu.Lock()
+		ringBackends = cfg.AllowedBackends
+		backendMu.Unlock()
 		ring, ringOpenErr = keyring.Open(cfg)
+		if ringOpenErr == nil {
+			ring = &legacyFileKeyring{Keyring: ring, dir: cfg.FileDir}
+		}
 	})
 	return ring, ringOpenErr
 }
 
-func getBackendsForPlatform() []keyring.BackendType {
+// legacyFileKeyring reads file keyring items written by releases that
+// derived the file password from the bare Linux machine ID. An item that
+// only opens with the legacy password is stored again under the current
+// one, so each item is migrated on its first read.
+type legacyFileKeyring struct {
+	keyring.Keyring
+	dir string
+}
+
+func (k *legacyFileKeyring) Get(key string) (keyring.Item, error) {
+	item, err := k.Keyring.Get(key)
+	if err == nil || errors.Is(err, keyring.ErrKeyNotFound) {
+		return item, err
+	}
+
+	legacy, lerr := keyring.Open(keyring.Config{
+		ServiceName:      serviceName,
+		FileDir:          k.dir,
+		FilePasswordFunc: legacyFilePasswordPrompt,
+		AllowedBackends:  []keyring.BackendType{keyring.FileBackend},
+	})
+	if lerr != nil {
+		return item, err
+	}
+	old, lerr := legacy.Get(key)
+	if lerr != nil {
+		return item, err
+	}
+	if err := k.Keyring.Set(old); err != nil {
+		debug.Warn("failed to re-encrypt keyring item %q: %v", key, err)
+	}
+	return old, nil
+}
+
+func keyringConfig() keyring.Config {
+	return keyring.Config{
+		ServiceName:                    serviceName,
+		KeychainTrustApplication:       true,
+		KeychainSynchronizable:         false,
+		KeychainAccessibleWhenUnlocked: true,
+		FileDir:                        func() string { d, _ := config.GetConfigDir(); return d }(),
+		FilePasswordFunc:               filePasswordPrompt,
+		AllowedBackends:                getBackendsForPlatform(),
+	}
+}
+
+// forceFileKeyring reports whether the environment forces the file backend:
+// INTENTRA_NO_KEYCHAIN set to anything, or INTENTRA_KEYRING_FILE set to a
+// true value (1, true). This is the quick override for CI and containers,
+// where probing the OS keyring can hang on dbus, and it wins over
+// server.auth.keyring_backend.
+func forceFileKeyring() bool {
 	if os.Getenv("INTENTRA_NO_KEYCHAIN") != "" {
+		return true
+	}
+	force, _ := strconv.ParseBool(os.Getenv("INTENTRA_KEYRING_FILE"))
+	return force
+}
+
+func getBackendsForPlatform() []keyring.BackendType {
+	backendMu.Lock()
+	name := keyringBackend
+	backendMu.Unlock()
+	return backendsFor(name)
+}
+
+// backendsFor returns the backends tried for server.auth.keyring_backend
+// name, after the environment override.
+func backendsFor(name string) []keyring.BackendType {
+	if forceFileKeyring() {
+		return []keyring.BackendType{keyring.FileBackend}
+	}
+
+	switch name {
+	case config.KeyringBackendFile:
 		return []keyring.BackendType{keyring.FileBackend}
+	case config.KeyringBackendSecretService:
+		return []keyring.BackendType{keyring.SecretServiceBackend}
+	case config.KeyringBackendKeychain:
+		return []keyring.BackendType{keyring.KeychainBackend}
+	case config.KeyringBackendWinCred:
+		return []keyring.BackendType{keyring.WinCredBackend}
 	}
 
 	switch runtime.GOOS {
@@ -69,6 +178,10 @@ func getBackendsForPlatform() []keyring.BackendType {
 	}
 }
 
+// filePasswordPrompt supplies the file backend's password without prompting:
+// it is derived from the machine ID and username via DeriveKey, like the
+// encrypted cache key, so the file keyring only opens for the same user on
+// the machine that wrote it.
 func filePasswordPrompt(prompt string) (string, error) {
 	key, err := DeriveKey(CacheKeySalt, CacheKeyInfo)
 	if err != nil {
@@ -77,6 +190,16 @@ func filePasswordPrompt(prompt string) (string, error) {
 	return string(key[:16]), nil
 }
 
+// legacyFilePasswordPrompt is filePasswordPrompt for releases that derived
+// the key from the bare Linux machine ID; see DeriveLegacyKey.
+func legacyFilePasswordPrompt(prompt string) (string, error) {
+	key, ok := DeriveLegacyKey(CacheKeySalt, CacheKeyInfo)
+	if !ok {
+		return "", errors.New("no legacy key for file backend")
+	}
+	return string(key[:16]), nil
+}
+
 func StoreCredentialsInKeyring(creds *Credentials) error {
 	return WithCredentialLock(func() error {
 		return storeCredentialsInKeyringUnlocked(creds)
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/debug"
+)
+
+// Proactive refresh timing for StartRefresher.
+const (
+	// refresherLead is how long before the expiry buffer begins the
+	// refresher renews the token, so lazy callers never see it expired.
+	refresherLead = time.Minute
+	// refresherRetry is the wait after a failed refresh, when not logged
+	// in, and the shortest wait between refreshes.
+	refresherRetry = time.Minute
+)
+
+// StartRefresher refreshes the stored access token shortly before it
+// expires, until ctx is done, so long-running processes do not hit 401s
+// mid-flush. One-shot commands do not need it: GetValidCredentials
+// refreshes lazily. Refreshes go through RefreshCredentials, so one running
+// at the same time in this or another process is not repeated. The returned
+// channel is closed once the refresher has stopped.
+func StartRefresher(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			timer := time.NewTimer(refreshIfDue())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+	return done
+}
+
+// refreshIfDue refreshes the stored credentials if they are within
+// refresherLead of the expiry buffer, and returns how long to wait before
+// checking again.
+func refreshIfDue() time.Duration {
+	creds, err := LoadCredentialsFromKeyring()
+	if err != nil || creds == nil || creds.RefreshToken == "" {
+		return refresherRetry
+	}
+	if wait := untilRefresh(creds); wait > 0 {
+		return wait
+	}
+
+	refreshed, err := RefreshCredentials(creds)
+	if err != nil {
+		debug.Log("background token refresh failed: %v", err)
+		return refresherRetry
+	}
+	return max(untilRefresh(refreshed), refresherRetry)
+}
+
+// untilRefresh is how long until creds are due for a proactive refresh.
+func untilRefresh(creds *Credentials) time.Duration {
+	return creds.ExpiresAt.Sub(now()) - expiryBuffer - refresherLead
+}
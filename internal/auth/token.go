@@ -5,11 +5,15 @@ package auth
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/intentrahq/intentra-cli/internal/config"
@@ -48,10 +52,24 @@ type TokenResponse struct {
 	ErrorDesc    string `json:"error_description,omitempty"`
 }
 
+// now is the clock used for token expiry; swapped out in tests.
+var now = time.Now
+
+// expiryBuffer is how long before ExpiresAt credentials count as expired.
+var expiryBuffer = config.DefaultTokenExpiryBuffer
+
+// SetExpiryBuffer installs server.auth.expiry_buffer. Call it once after
+// loading config; negative values keep the default.
+func SetExpiryBuffer(d time.Duration) {
+	if d < 0 {
+		d = config.DefaultTokenExpiryBuffer
+	}
+	expiryBuffer = d
+}
+
 // IsExpired returns true if the credentials have expired or will expire within the buffer period.
 func (c *Credentials) IsExpired() bool {
-	buffer := 5 * time.Minute
-	return time.Now().Add(buffer).After(c.ExpiresAt)
+	return now().Add(expiryBuffer).After(c.ExpiresAt)
 }
 
 // IsValid returns true if credentials exist and are not expired.
@@ -62,7 +80,7 @@ func (c *Credentials) IsValid() bool {
 
 // CredentialsFromTokenResponse creates Credentials from a TokenResponse.
 func CredentialsFromTokenResponse(resp *TokenResponse) *Credentials {
-	expiresAt := time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	expiresAt := now().Add(time.Duration(resp.ExpiresIn) * time.Second)
 
 	return &Credentials{
 		AccessToken:  resp.AccessToken,
@@ -73,6 +91,46 @@ func CredentialsFromTokenResponse(resp *TokenResponse) *Credentials {
 	}
 }
 
+// unknownTokenLifetime is how long a pre-issued token is trusted when its
+// expiry cannot be read from it.
+const unknownTokenLifetime = time.Hour
+
+// CredentialsFromToken creates Credentials for a pre-issued access token,
+// such as a service account's. ExpiresAt is the token's exp claim when it is
+// a JWT that has one, and unknownTokenLifetime from now otherwise. There is
+// no refresh token, so the token must be replaced once it expires.
+func CredentialsFromToken(token string) *Credentials {
+	expiresAt, ok := tokenExpiry(token)
+	if !ok {
+		expiresAt = now().Add(unknownTokenLifetime)
+	}
+	return &Credentials{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+	}
+}
+
+// tokenExpiry reads the exp claim of a JWT. The signature is not checked;
+// the server verifies the token on every request.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(claims.Exp), 0), true
+}
+
 // GetValidCredentials loads credentials from secure storage, refreshes if needed, and returns them if valid.
 // Returns (nil, nil) when the user is simply not logged in, and (nil, err) on system failures.
 func GetValidCredentials() (*Credentials, error) {
@@ -94,12 +152,17 @@ func GetValidCredentials() (*Credentials, error) {
 
 	refreshed, err := RefreshCredentials(creds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to refresh credentials: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrRefreshFailed, err)
 	}
 
 	return refreshed, nil
 }
 
+// ErrRefreshFailed is returned by GetValidCredentials when the stored login
+// has expired and could not be refreshed. Other errors mean the credentials
+// could not be read at all.
+var ErrRefreshFailed = errors.New("failed to refresh credentials")
+
 // RefreshCredentials uses the refresh token to obtain new credentials.
 func RefreshCredentials(creds *Credentials) (*Credentials, error) {
 	if creds.RefreshToken == "" {
@@ -110,6 +173,11 @@ func RefreshCredentials(creds *Credentials) (*Credentials, error) {
 		return creds, nil
 	}
 
+	// One refresh at a time within the process; a caller that waited here
+	// picks up the new token in the check below.
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
 	// Quick unlocked check — if someone already refreshed, use that
 	currentCreds, _ := LoadCredentialsFromKeyring()
 	if currentCreds != nil && currentCreds.IsValid() && currentCreds.AccessToken != creds.AccessToken {
@@ -117,7 +185,7 @@ func RefreshCredentials(creds *Credentials) (*Credentials, error) {
 	}
 
 	// Perform refresh (outside lock — HTTP call can be slow)
-	newCreds, err := doRefreshHTTP(creds)
+	newCreds, err := refreshHTTP(creds)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +212,13 @@ func RefreshCredentials(creds *Credentials) (*Credentials, error) {
 	return newCreds, nil
 }
 
+// refreshMu serializes RefreshCredentials within the process. Across
+// processes the re-check under WithCredentialLock keeps the first result.
+var refreshMu sync.Mutex
+
+// refreshHTTP exchanges a refresh token; swapped out in tests.
+var refreshHTTP = doRefreshHTTP
+
 // doRefreshHTTP performs the HTTP refresh token exchange.
 func doRefreshHTTP(creds *Credentials) (*Credentials, error) {
 	cfg, err := config.Load()
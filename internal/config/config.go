@@ -4,13 +4,19 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/internal/httputil"
 	"github.com/spf13/viper"
 )
 
@@ -24,11 +30,44 @@ var (
 // DefaultAPIEndpoint is the default Intentra API server endpoint.
 const DefaultAPIEndpoint = "https://api.intentra.sh"
 
+// DefaultMaxMCPEntries is the default cap on MCP tool usage entries per scan.
+const DefaultMaxMCPEntries = 50
+
+// DefaultMaxConcurrentInstalls is the default number of tools whose hooks
+// are installed, removed or checked at once.
+const DefaultMaxConcurrentInstalls = 4
+
+// DefaultSyslogTag is the default syslog program name for scan records.
+const DefaultSyslogTag = "intentra"
+
+// DefaultMaxSyncAttempts is the default number of failed syncs after which
+// a queued scan is moved to the dead-letter directory.
+const DefaultMaxSyncAttempts = 10
+
+// DefaultSessionEndGrace is the default time a session-end PATCH may take
+// before it is queued for the next sync.
+const DefaultSessionEndGrace = 10 * time.Second
+
+// DefaultMaxSessionBytes is the default size at which a session's event
+// buffer is flushed as a partial scan.
+const DefaultMaxSessionBytes = 20 << 20
+
+// DefaultTokenExpiryBuffer is how long before expiry a login token is
+// treated as expired and refreshed.
+const DefaultTokenExpiryBuffer = 5 * time.Minute
+
 // AuthModeAPIKey is the config value for API key authentication.
 const AuthModeAPIKey = "api_key"
 
+// ErrConfigLocked is returned when attempting to modify a locked (managed) config file.
+var ErrConfigLocked = errors.New("config is locked by your administrator")
+
 // Config represents the intentra configuration.
 type Config struct {
+	// Locked marks a managed configuration. Environment variable and CLI flag
+	// overrides are ignored and the file is not rewritten by the CLI.
+	Locked bool `mapstructure:"locked"`
+
 	// Debug mode enables HTTP request logging and local scan saving
 	Debug bool `mapstructure:"debug"`
 
@@ -55,12 +94,66 @@ type ServerConfig struct {
 	Endpoint string        `mapstructure:"endpoint"`
 	Timeout  time.Duration `mapstructure:"timeout"`
 	Auth     AuthConfig    `mapstructure:"auth"`
+
+	// MinSyncInterval is the minimum time between syncs for one session. Stop
+	// events arriving sooner keep their events buffered; they are sent by the
+	// next stop or by a flush scheduled for when the interval has passed.
+	// Zero disables the limit.
+	MinSyncInterval time.Duration `mapstructure:"min_sync_interval"`
+
+	// MaxRetries is how many times a failed scan upload is retried on
+	// connection errors and 429/5xx responses. RetryBackoff is the base delay,
+	// doubled (with jitter) on each retry; a Retry-After header overrides it.
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+
+	// OnAuthFailure decides what happens to a scan that cannot be sent
+	// because the login has expired and could not be refreshed: buffer it
+	// in the offline queue, drop it, or save it locally only.
+	OnAuthFailure string `mapstructure:"on_auth_failure"`
+
+	// MinTLSVersion is the lowest TLS version accepted from the server: 1.2
+	// or 1.3. TLSCipherSuites restricts the cipher suites offered on TLS 1.2
+	// connections (Go names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256);
+	// empty uses Go's defaults.
+	MinTLSVersion   string   `mapstructure:"min_tls_version"`
+	TLSCipherSuites []string `mapstructure:"tls_cipher_suites"`
+
+	// BatchUpload sends multiple scans in one POST /scans/batch request
+	// with a single signature. Servers without the endpoint (404) fall
+	// back to one request per scan.
+	BatchUpload bool `mapstructure:"batch_upload"`
 }
 
 // AuthConfig contains authentication settings.
 type AuthConfig struct {
 	Mode   string       `mapstructure:"mode"` // api_key (or use 'intentra login' for JWT)
 	APIKey APIKeyConfig `mapstructure:"api_key"`
+	HMAC   HMACConfig   `mapstructure:"hmac"`
+
+	// ExpiryBuffer treats a login token as expired this long before it
+	// actually expires, so it is refreshed before requests start failing.
+	// Raise it behind slow proxies or on machines with clock skew.
+	ExpiryBuffer time.Duration `mapstructure:"expiry_buffer"`
+
+	// KeyringBackend restricts where login credentials are stored: auto
+	// tries the OS keyring backends in platform order, file skips the OS
+	// keyring entirely (no dbus on headless Linux), and secret-service,
+	// keychain or wincred use only that OS keyring.
+	KeyringBackend string `mapstructure:"keyring_backend"`
+}
+
+// HMACConfig contains request signing options for api_key auth with hmac_key.
+type HMACConfig struct {
+	// BodyHashMode adds the SHA-256 of the request body to the signed message
+	// and sends it as X-API-Body-Hash. Off by default for servers that only
+	// verify the legacy method/path/timestamp/nonce signature.
+	BodyHashMode bool `mapstructure:"body_hash_mode"`
+
+	// DeviceID pins the device ID sent with scans and signed requests instead
+	// of deriving it from hardware, for containers and MDM-managed machines.
+	// INTENTRA_DEVICE_ID and INTENTRA_DEVICE_ID_FILE take precedence.
+	DeviceID string `mapstructure:"device_id"`
 }
 
 // APIKeyConfig contains API key authentication settings for Enterprise organizations.
@@ -80,6 +173,166 @@ type LocalConfig struct {
 	MinEventsPerScan int           `mapstructure:"min_events_per_scan"`
 	CharsPerToken    int           `mapstructure:"chars_per_token"`
 	Archive          ArchiveConfig `mapstructure:"archive"`
+
+	// CollectGitMetadata enables capturing repo, branch, commit, and hashed author
+	// identity for scans. Disable via config or INTENTRA_NO_GIT=1.
+	CollectGitMetadata bool `mapstructure:"collect_git_metadata"`
+
+	// RepoHost controls how the git remote host is reported: hash, plain, or off.
+	RepoHost string `mapstructure:"repo_host"`
+
+	// ModelSelection controls which model a multi-event scan is attributed to
+	// and priced as: first or dominant.
+	ModelSelection string `mapstructure:"model_selection"`
+
+	// KeepBuffers moves each consumed session buffer to the consumed buffers
+	// directory, named after the resulting scan, instead of deleting it.
+	KeepBuffers bool `mapstructure:"keep_buffers"`
+
+	// SaveScans lets debug mode write each scan to the local scans
+	// directory. Disable it to keep debug logging without persisting scans.
+	SaveScans bool `mapstructure:"save_scans"`
+
+	// WarnUnknownEvents records each tool/event pair whose hook event type
+	// is not mapped to a normalized type, so 'intentra doctor' can list them
+	// as missing mappings to report.
+	WarnUnknownEvents bool `mapstructure:"warn_unknown_events"`
+
+	// WindsurfIdleTimeout ends a Windsurf scan once the session has been idle
+	// this long, instead of at every response (Windsurf has no stop hook).
+	// Zero keeps one scan per response. It must stay below StaleBufferAge.
+	WindsurfIdleTimeout time.Duration `mapstructure:"windsurf_idle_timeout"`
+
+	// MaxMCPEntries caps the MCP tool usage and tool call count entries
+	// reported per scan. The most-called tools are kept and the rest are
+	// rolled into one "other" entry. Zero disables the cap.
+	MaxMCPEntries int `mapstructure:"max_mcp_entries"`
+
+	// MaxConcurrentInstalls bounds how many tools 'intentra install',
+	// 'intentra uninstall' and 'intentra hooks status' work on at once.
+	// 1 handles them one at a time.
+	MaxConcurrentInstalls int `mapstructure:"max_concurrent_installs"`
+
+	// SessionFallback controls grouping of events that carry no conversation or session ID.
+	SessionFallback SessionFallbackConfig `mapstructure:"session_fallback"`
+
+	// Pricing overrides the built-in cost estimation tables.
+	Pricing PricingConfig `mapstructure:"pricing"`
+
+	// MCP extends how MCP tool calls are attributed to servers.
+	MCP MCPConfig `mapstructure:"mcp"`
+
+	// Syslog writes a one-line record per scan to the system log.
+	Syslog SyslogConfig `mapstructure:"syslog"`
+
+	// Metadata is attached to every scan sent to the server under a
+	// "metadata" object (team, cost center, environment, ...). Viper
+	// lowercases the keys.
+	Metadata map[string]string `mapstructure:"metadata"`
+}
+
+// SyslogConfig contains settings for emitting scans to syslog/journald.
+type SyslogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Network and Address select a remote syslog server (e.g. udp and
+	// logs.example.com:514). Both empty uses the local syslog daemon.
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	// Tag is the syslog program name; defaults to intentra.
+	Tag string `mapstructure:"tag"`
+}
+
+// MCPConfig contains settings for attributing MCP tool calls.
+type MCPConfig struct {
+	// ServerOverrides map MCP tool names to server names, for tools whose
+	// payload does not name a server. They take precedence over the built-in
+	// table. A list rather than a map because viper lowercases map keys.
+	ServerOverrides []MCPServerOverride `mapstructure:"server_overrides"`
+}
+
+// MCPServerOverride attributes calls to the MCP tool Tool to Server.
+type MCPServerOverride struct {
+	Tool   string `mapstructure:"tool"`
+	Server string `mapstructure:"server"`
+}
+
+// PricingConfig overrides built-in cost estimation rates. Entries here are
+// consulted before the built-in tables, which remain the fallback.
+type PricingConfig struct {
+	// ToolMultipliers maps a tool name (cursor, windsurf, ...) to a cost multiplier.
+	ToolMultipliers map[string]float64 `mapstructure:"tool_multipliers"`
+	// ModelPrices lists per-model prices. A list rather than a map because
+	// model names contain dots, which viper treats as key separators.
+	ModelPrices []ModelPrice `mapstructure:"model_prices"`
+	// ConfidencePct is the half-width of the low/high band reported around
+	// cost estimates, as a percentage of the estimate. Zero uses the default (20).
+	ConfidencePct float64 `mapstructure:"confidence_pct"`
+}
+
+// ModelPrice is the price per 1K tokens for models whose ID starts with Prefix.
+type ModelPrice struct {
+	Prefix     string  `mapstructure:"prefix"`
+	PricePer1K float64 `mapstructure:"price_per_1k"`
+}
+
+// Session fallback strategies for events without a conversation or session ID.
+const (
+	// SessionFallbackIdleGap starts a new synthetic conversation per device after an idle gap.
+	SessionFallbackIdleGap = "idle_gap"
+	// SessionFallbackCwd starts a new synthetic conversation per working directory after an idle gap.
+	SessionFallbackCwd = "cwd"
+	// SessionFallbackDevice groups all such events into a single per-device session (legacy behavior).
+	SessionFallbackDevice = "device"
+)
+
+// Repo host reporting modes for the git remote host.
+const (
+	// RepoHostHash sends a SHA-256 hash of the remote host.
+	RepoHostHash = "hash"
+	// RepoHostPlain sends the remote host in plaintext (e.g. github.com).
+	RepoHostPlain = "plain"
+	// RepoHostOff omits the remote host.
+	RepoHostOff = "off"
+)
+
+// Model selection strategies for scans whose events name more than one model.
+const (
+	// ModelSelectionFirst uses the first event that names a model.
+	ModelSelectionFirst = "first"
+	// ModelSelectionDominant uses the model with the most tokens across events,
+	// falling back to the first model when no event reports tokens.
+	ModelSelectionDominant = "dominant"
+)
+
+// Policies for server.on_auth_failure.
+const (
+	// AuthFailureBuffer queues the scan offline until credentials are valid again.
+	AuthFailureBuffer = "buffer"
+	// AuthFailureDrop discards the scan.
+	AuthFailureDrop = "drop"
+	// AuthFailureLocal saves the scan to local storage without queueing it.
+	AuthFailureLocal = "local"
+)
+
+// Backends for server.auth.keyring_backend.
+const (
+	KeyringBackendAuto          = "auto"
+	KeyringBackendFile          = "file"
+	KeyringBackendSecretService = "secret-service"
+	KeyringBackendKeychain      = "keychain"
+	KeyringBackendWinCred       = "wincred"
+)
+
+// Log formats for logging.format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// SessionFallbackConfig contains settings for grouping events without conversation IDs.
+type SessionFallbackConfig struct {
+	Strategy string        `mapstructure:"strategy"` // idle_gap, cwd, or device
+	IdleGap  time.Duration `mapstructure:"idle_gap"`
 }
 
 // ArchiveConfig contains local scan archive settings for benchmarking.
@@ -98,6 +351,16 @@ type BufferConfig struct {
 	MaxAgeHours    int           `mapstructure:"max_age_hours"`
 	FlushInterval  time.Duration `mapstructure:"flush_interval"`
 	FlushThreshold int           `mapstructure:"flush_threshold"`
+	// MaxSyncAttempts is how many times a queued scan may fail to sync
+	// before it is dead-lettered; 'intentra sync retry-dead' requeues it.
+	MaxSyncAttempts int `mapstructure:"max_sync_attempts"`
+	// SessionEndGrace bounds how long a session-end PATCH may take, retries
+	// included, before it is queued and retried on the next sync.
+	SessionEndGrace time.Duration `mapstructure:"session_end_grace"`
+	// MaxSessionBytes caps a session's on-disk event buffer. When an event
+	// would push it past the cap, the buffered events are sent as a partial
+	// scan and a fresh buffer is started. Zero disables the cap.
+	MaxSessionBytes int64 `mapstructure:"max_session_bytes"`
 }
 
 // LogConfig contains logging settings.
@@ -112,36 +375,58 @@ func DefaultConfig() *Config {
 	return &Config{
 		Debug: false,
 		Server: ServerConfig{
-			Enabled:  false,
-			Endpoint: "",
-			Timeout:  30 * time.Second,
+			Enabled:       false,
+			Endpoint:      "",
+			Timeout:       30 * time.Second,
+			MaxRetries:    3,
+			RetryBackoff:  time.Second,
+			OnAuthFailure: AuthFailureBuffer,
+			MinTLSVersion: httputil.TLSVersion12,
 			Auth: AuthConfig{
-				Mode: "",
+				Mode:           "",
+				ExpiryBuffer:   DefaultTokenExpiryBuffer,
+				KeyringBackend: KeyringBackendAuto,
 			},
 		},
 		Local: LocalConfig{
-			Model:            "claude-3-5-haiku-latest",
-			ScanTimeout:      30,
-			MinEventsPerScan: 2,
-			CharsPerToken:    4,
+			Model:                 "claude-3-5-haiku-latest",
+			ScanTimeout:           30,
+			MinEventsPerScan:      2,
+			CharsPerToken:         4,
+			CollectGitMetadata:    true,
+			SaveScans:             true,
+			RepoHost:              RepoHostHash,
+			ModelSelection:        ModelSelectionFirst,
+			MaxMCPEntries:         DefaultMaxMCPEntries,
+			MaxConcurrentInstalls: DefaultMaxConcurrentInstalls,
 			Archive: ArchiveConfig{
 				Enabled:       false,
 				Path:          filepath.Join(dataDir, "archive"),
 				Redacted:      true,
 				IncludeEvents: false,
 			},
+			Syslog: SyslogConfig{
+				Tag: DefaultSyslogTag,
+			},
+			SessionFallback: SessionFallbackConfig{
+				Strategy: SessionFallbackIdleGap,
+				IdleGap:  30 * time.Minute,
+			},
 		},
 		Buffer: BufferConfig{
-			Enabled:        false,
-			Path:           filepath.Join(dataDir, "buffer.db"),
-			MaxSizeMB:      50,
-			MaxAgeHours:    24,
-			FlushInterval:  30 * time.Second,
-			FlushThreshold: 10,
+			Enabled:         false,
+			Path:            filepath.Join(dataDir, "buffer.db"),
+			MaxSizeMB:       50,
+			MaxAgeHours:     24,
+			FlushInterval:   30 * time.Second,
+			FlushThreshold:  10,
+			MaxSyncAttempts: DefaultMaxSyncAttempts,
+			SessionEndGrace: DefaultSessionEndGrace,
+			MaxSessionBytes: DefaultMaxSessionBytes,
 		},
 		Log: LogConfig{
 			Level:  "warn",
-			Format: "text",
+			Format: LogFormatText,
 		},
 	}
 }
@@ -193,31 +478,46 @@ func loadImpl() (*Config, error) {
 	v.SetDefault("local.archive.path", cfg.Local.Archive.Path)
 	v.SetDefault("local.archive.redacted", cfg.Local.Archive.Redacted)
 	v.SetDefault("local.archive.include_events", cfg.Local.Archive.IncludeEvents)
+	v.SetDefault("local.collect_git_metadata", cfg.Local.CollectGitMetadata)
+	v.SetDefault("local.repo_host", cfg.Local.RepoHost)
+	v.SetDefault("local.model_selection", cfg.Local.ModelSelection)
+	v.SetDefault("local.keep_buffers", cfg.Local.KeepBuffers)
+	v.SetDefault("local.save_scans", cfg.Local.SaveScans)
+	v.SetDefault("local.warn_unknown_events", cfg.Local.WarnUnknownEvents)
+	v.SetDefault("local.syslog.enabled", cfg.Local.Syslog.Enabled)
+	v.SetDefault("local.syslog.network", cfg.Local.Syslog.Network)
+	v.SetDefault("local.syslog.address", cfg.Local.Syslog.Address)
+	v.SetDefault("local.syslog.tag", cfg.Local.Syslog.Tag)
+	v.SetDefault("local.windsurf_idle_timeout", cfg.Local.WindsurfIdleTimeout)
+	v.SetDefault("local.max_mcp_entries", cfg.Local.MaxMCPEntries)
+	v.SetDefault("local.max_concurrent_installs", cfg.Local.MaxConcurrentInstalls)
+	v.SetDefault("local.session_fallback.strategy", cfg.Local.SessionFallback.Strategy)
+	v.SetDefault("local.session_fallback.idle_gap", cfg.Local.SessionFallback.IdleGap)
 	v.SetDefault("buffer.enabled", cfg.Buffer.Enabled)
 	v.SetDefault("buffer.path", cfg.Buffer.Path)
 	v.SetDefault("buffer.max_size_mb", cfg.Buffer.MaxSizeMB)
 	v.SetDefault("buffer.max_age_hours", cfg.Buffer.MaxAgeHours)
 	v.SetDefault("buffer.flush_interval", cfg.Buffer.FlushInterval)
 	v.SetDefault("buffer.flush_threshold", cfg.Buffer.FlushThreshold)
+	v.SetDefault("buffer.max_sync_attempts", cfg.Buffer.MaxSyncAttempts)
+	v.SetDefault("buffer.max_session_bytes", cfg.Buffer.MaxSessionBytes)
+	v.SetDefault("buffer.session_end_grace", cfg.Buffer.SessionEndGrace)
 
-	// Environment variable overrides
 	v.SetEnvPrefix("INTENTRA")
-	v.AutomaticEnv()
 
 	// Try to read config file (ignore if not exists)
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, fmt.Errorf("error reading config: %w", err)
 		}
-	} else {
-		// Warn if the config file is world-readable (group or other permissions set)
-		if cfgPath := v.ConfigFileUsed(); cfgPath != "" {
-			if info, statErr := os.Stat(cfgPath); statErr == nil {
-				if info.Mode().Perm()&0o077 != 0 {
-					fmt.Fprintf(os.Stderr, "Warning: config file %s has overly permissive permissions %o; consider chmod 600\n", cfgPath, info.Mode().Perm())
-				}
-			}
-		}
+	}
+	// Warn if the config dir, credentials or config file are readable by others
+	warnPermissions(v.ConfigFileUsed())
+
+	// Environment variable overrides, unless the config file is locked.
+	// Checked before AutomaticEnv so INTENTRA_LOCKED cannot unlock it.
+	if !v.GetBool("locked") {
+		v.AutomaticEnv()
 	}
 
 	// Unmarshal
@@ -246,12 +546,15 @@ func LoadWithFile(cfgFile string) (*Config, error) {
 	}
 
 	v.SetEnvPrefix("INTENTRA")
-	v.AutomaticEnv()
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("error reading config: %w", err)
 	}
 
+	if !v.GetBool("locked") {
+		v.AutomaticEnv()
+	}
+
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("error parsing config: %w", err)
 	}
@@ -263,35 +566,223 @@ func LoadWithFile(cfgFile string) (*Config, error) {
 
 // applyEnvOverrides expands environment variables in sensitive fields and
 // applies environment variable overrides. Called by both Load and LoadWithFile.
+// When the config is locked, overrides are ignored; ${VAR} references written
+// in the file itself are still expanded.
 func (cfg *Config) applyEnvOverrides() {
 	cfg.Server.Auth.APIKey.KeyID = os.ExpandEnv(cfg.Server.Auth.APIKey.KeyID)
 	cfg.Server.Auth.APIKey.Secret = os.ExpandEnv(cfg.Server.Auth.APIKey.Secret)
 	cfg.Server.Auth.APIKey.HMACKey = os.ExpandEnv(cfg.Server.Auth.APIKey.HMACKey)
 	cfg.Local.AnthropicAPIKey = os.ExpandEnv(cfg.Local.AnthropicAPIKey)
 
-	if keyID := os.Getenv("INTENTRA_API_KEY_ID"); keyID != "" {
+	if keyID := cfg.envOverride("INTENTRA_API_KEY_ID"); keyID != "" {
 		cfg.Server.Auth.APIKey.KeyID = keyID
 	}
-	if secret := os.Getenv("INTENTRA_API_SECRET"); secret != "" {
+	if secret := cfg.envOverride("INTENTRA_API_SECRET"); secret != "" {
 		cfg.Server.Auth.APIKey.Secret = secret
 	}
-	if hmacKey := os.Getenv("INTENTRA_API_HMAC_KEY"); hmacKey != "" {
+	if hmacKey := cfg.envOverride("INTENTRA_API_HMAC_KEY"); hmacKey != "" {
 		cfg.Server.Auth.APIKey.HMACKey = hmacKey
 	}
-	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+	if key := cfg.envOverride("ANTHROPIC_API_KEY"); key != "" {
 		cfg.Local.AnthropicAPIKey = key
 	}
-	if endpoint := os.Getenv("INTENTRA_SERVER_ENDPOINT"); endpoint != "" {
+	if endpoint := cfg.envOverride("INTENTRA_SERVER_ENDPOINT"); endpoint != "" {
 		cfg.Server.Enabled = true
 		cfg.Server.Endpoint = endpoint
 	}
-	if os.Getenv("INTENTRA_RICH_TRACES") == "true" || os.Getenv("INTENTRA_RICH_TRACES") == "1" {
+	if v := cfg.envOverride("INTENTRA_RICH_TRACES"); v == "true" || v == "1" {
 		cfg.RichTraces = true
 	}
+	// Opting out of git metadata only collects less, so it applies even
+	// to a locked config.
+	if v := os.Getenv("INTENTRA_NO_GIT"); v == "true" || v == "1" {
+		cfg.Local.CollectGitMetadata = false
+	}
 }
 
-// Validate checks if the configuration is valid for server sync.
+// envOverride returns the value of an override environment variable, or ""
+// when the config is locked, logging that the override was ignored.
+func (cfg *Config) envOverride(name string) string {
+	v := os.Getenv(name)
+	if v != "" && cfg.Locked {
+		debug.Log("config is locked; ignoring %s", name)
+		return ""
+	}
+	return v
+}
+
+// StaleBufferAge is how long a session buffer may go unmodified before hook
+// runs remove it as abandoned.
+const StaleBufferAge = 30 * time.Minute
+
+// MaxWindsurfIdleTimeout is the longest local.windsurf_idle_timeout allowed,
+// so an idle session is finalized before its buffer goes stale.
+const MaxWindsurfIdleTimeout = StaleBufferAge - time.Minute
+
+// MaxSessionIdleGap is the longest local.session_fallback.idle_gap allowed.
+const MaxSessionIdleGap = 24 * time.Hour
+
+// Duration bounds enforced by Validate.
+const (
+	minServerTimeout  = time.Second
+	maxServerTimeout  = 10 * time.Minute
+	maxSyncInterval   = time.Hour
+	maxRetries        = 10
+	minRetryBackoff   = 10 * time.Millisecond
+	maxRetryBackoff   = time.Minute
+	minFlushInterval  = time.Second
+	maxFlushInterval  = 24 * time.Hour
+	minSessionIdleGap = time.Minute
+	maxSessionIdleGap = MaxSessionIdleGap
+	minWindsurfIdle   = time.Second
+	maxWindsurfIdle   = MaxWindsurfIdleTimeout
+	maxMCPEntries     = 1000
+	maxConcurrency    = 16
+	maxSyncAttempts   = 100
+	minSessionEndWait = time.Second
+	maxSessionEndWait = 5 * time.Minute
+	minSessionBytes   = 64 << 10
+	maxSessionBytes   = 1 << 30
+
+	maxDeviceIDLen = 128
+
+	maxTokenExpiryBuffer = time.Hour
+)
+
+// Limits on local.metadata, enforced by Validate and again when the
+// metadata is attached to scans.
+const (
+	MaxMetadataEntries  = 32
+	MaxMetadataKeyLen   = 64
+	MaxMetadataValueLen = 256
+)
+
+// Validate checks that durations are sane and, when server sync is enabled,
+// that the server settings are complete.
 func (c *Config) Validate() error {
+	if err := validateDuration("server.timeout", c.Server.Timeout, minServerTimeout, maxServerTimeout); err != nil {
+		return err
+	}
+	if c.Server.MinSyncInterval != 0 {
+		if err := validateDuration("server.min_sync_interval", c.Server.MinSyncInterval, time.Millisecond, maxSyncInterval); err != nil {
+			return err
+		}
+	}
+	if c.Server.Auth.ExpiryBuffer != 0 {
+		if err := validateDuration("server.auth.expiry_buffer", c.Server.Auth.ExpiryBuffer, time.Second, maxTokenExpiryBuffer); err != nil {
+			return err
+		}
+	}
+	if c.Server.MaxRetries < 0 || c.Server.MaxRetries > maxRetries {
+		return fmt.Errorf("server.max_retries must be between 0 and %d, got %d", maxRetries, c.Server.MaxRetries)
+	}
+	if c.Server.MaxRetries > 0 {
+		if err := validateDuration("server.retry_backoff", c.Server.RetryBackoff, minRetryBackoff, maxRetryBackoff); err != nil {
+			return err
+		}
+	}
+	if err := validateDuration("buffer.flush_interval", c.Buffer.FlushInterval, minFlushInterval, maxFlushInterval); err != nil {
+		return err
+	}
+	if c.Buffer.MaxSyncAttempts < 1 || c.Buffer.MaxSyncAttempts > maxSyncAttempts {
+		return fmt.Errorf("buffer.max_sync_attempts must be between 1 and %d, got %d", maxSyncAttempts, c.Buffer.MaxSyncAttempts)
+	}
+	if err := validateDuration("buffer.session_end_grace", c.Buffer.SessionEndGrace, minSessionEndWait, maxSessionEndWait); err != nil {
+		return err
+	}
+	if n := c.Buffer.MaxSessionBytes; n != 0 && (n < minSessionBytes || n > maxSessionBytes) {
+		return fmt.Errorf("buffer.max_session_bytes must be 0 (no limit) or between %d and %d, got %d", int64(minSessionBytes), int64(maxSessionBytes), n)
+	}
+	if err := c.Local.Pricing.Validate(); err != nil {
+		return err
+	}
+	if _, err := httputil.ParseTLSVersion(c.Server.MinTLSVersion); err != nil {
+		return fmt.Errorf("invalid server.min_tls_version: %w", err)
+	}
+	if _, err := httputil.ParseCipherSuites(c.Server.TLSCipherSuites); err != nil {
+		return fmt.Errorf("invalid server.tls_cipher_suites: %w", err)
+	}
+	switch c.Local.Syslog.Network {
+	case "":
+		if c.Local.Syslog.Address != "" {
+			return fmt.Errorf("local.syslog.address requires local.syslog.network")
+		}
+	case "udp", "tcp", "unix", "unixgram":
+		if c.Local.Syslog.Address == "" {
+			return fmt.Errorf("local.syslog.network %s requires local.syslog.address", c.Local.Syslog.Network)
+		}
+	default:
+		return fmt.Errorf("unknown local.syslog.network: %s (supported: udp, tcp, unix, unixgram)", c.Local.Syslog.Network)
+	}
+	if id := c.Server.Auth.HMAC.DeviceID; id != "" {
+		if strings.TrimSpace(id) != id || len(id) > maxDeviceIDLen {
+			return fmt.Errorf("server.auth.hmac.device_id must be at most %d characters without surrounding spaces", maxDeviceIDLen)
+		}
+	}
+	if err := validateMetadata(c.Local.Metadata); err != nil {
+		return err
+	}
+	for i, o := range c.Local.MCP.ServerOverrides {
+		if strings.TrimSpace(o.Tool) == "" || strings.TrimSpace(o.Server) == "" {
+			return fmt.Errorf("local.mcp.server_overrides[%d] needs both tool and server", i)
+		}
+	}
+	switch c.Server.Auth.KeyringBackend {
+	case KeyringBackendAuto, KeyringBackendFile, KeyringBackendSecretService, KeyringBackendKeychain, KeyringBackendWinCred, "":
+	default:
+		return fmt.Errorf("unknown server.auth.keyring_backend: %s (supported: %s, %s, %s, %s, %s)",
+			c.Server.Auth.KeyringBackend, KeyringBackendAuto, KeyringBackendFile,
+			KeyringBackendSecretService, KeyringBackendKeychain, KeyringBackendWinCred)
+	}
+	switch c.Server.OnAuthFailure {
+	case AuthFailureBuffer, AuthFailureDrop, AuthFailureLocal, "":
+	default:
+		return fmt.Errorf("unknown server.on_auth_failure: %s (supported: %s, %s, %s)",
+			c.Server.OnAuthFailure, AuthFailureBuffer, AuthFailureDrop, AuthFailureLocal)
+	}
+	if c.Local.WindsurfIdleTimeout != 0 {
+		if err := validateDuration("local.windsurf_idle_timeout", c.Local.WindsurfIdleTimeout, minWindsurfIdle, maxWindsurfIdle); err != nil {
+			return err
+		}
+	}
+	if c.Local.MaxMCPEntries < 0 || c.Local.MaxMCPEntries > maxMCPEntries {
+		return fmt.Errorf("local.max_mcp_entries must be between 0 and %d, got %d", maxMCPEntries, c.Local.MaxMCPEntries)
+	}
+	if c.Local.MaxConcurrentInstalls < 1 || c.Local.MaxConcurrentInstalls > maxConcurrency {
+		return fmt.Errorf("local.max_concurrent_installs must be between 1 and %d, got %d", maxConcurrency, c.Local.MaxConcurrentInstalls)
+	}
+	switch c.Local.RepoHost {
+	case RepoHostHash, RepoHostPlain, RepoHostOff, "":
+	default:
+		return fmt.Errorf("unknown local.repo_host: %s (supported: %s, %s, %s)",
+			c.Local.RepoHost, RepoHostHash, RepoHostPlain, RepoHostOff)
+	}
+	switch c.Local.ModelSelection {
+	case ModelSelectionFirst, ModelSelectionDominant, "":
+	default:
+		return fmt.Errorf("unknown local.model_selection: %s (supported: %s, %s)",
+			c.Local.ModelSelection, ModelSelectionFirst, ModelSelectionDominant)
+	}
+	if _, err := debug.ParseLevel(c.Log.Level); err != nil {
+		return fmt.Errorf("invalid logging.level: %w", err)
+	}
+	switch c.Log.Format {
+	case LogFormatText, LogFormatJSON, "":
+	default:
+		return fmt.Errorf("unknown logging.format: %s (supported: %s, %s)",
+			c.Log.Format, LogFormatText, LogFormatJSON)
+	}
+	switch c.Local.SessionFallback.Strategy {
+	case SessionFallbackIdleGap, SessionFallbackCwd, "":
+		if err := validateDuration("local.session_fallback.idle_gap", c.Local.SessionFallback.IdleGap, minSessionIdleGap, maxSessionIdleGap); err != nil {
+			return err
+		}
+	case SessionFallbackDevice:
+	default:
+		return fmt.Errorf("unknown local.session_fallback.strategy: %s (supported: %s, %s, %s)",
+			c.Local.SessionFallback.Strategy, SessionFallbackIdleGap, SessionFallbackCwd, SessionFallbackDevice)
+	}
+
 	if !c.Server.Enabled {
 		return nil
 	}
@@ -320,28 +811,148 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Validate rejects negative multipliers and prices, empty model prefixes,
+// and confidence bands outside [0, 100). Every problem found is reported,
+// joined with errors.Join. Repeated prefixes are left to DuplicatePrefixes.
+func (p PricingConfig) Validate() error {
+	var errs []error
+	for _, tool := range slices.Sorted(maps.Keys(p.ToolMultipliers)) {
+		if m := p.ToolMultipliers[tool]; m < 0 {
+			errs = append(errs, fmt.Errorf("local.pricing.tool_multipliers.%s must not be negative, got %g", tool, m))
+		}
+	}
+	if p.ConfidencePct < 0 || p.ConfidencePct >= 100 {
+		errs = append(errs, fmt.Errorf("local.pricing.confidence_pct must be between 0 and 100, got %g", p.ConfidencePct))
+	}
+	for i, mp := range p.ModelPrices {
+		if strings.TrimSpace(mp.Prefix) == "" {
+			errs = append(errs, fmt.Errorf("local.pricing.model_prices[%d].prefix is required", i))
+			continue
+		}
+		if mp.PricePer1K < 0 {
+			errs = append(errs, fmt.Errorf("local.pricing.model_prices[%d] (%s) price_per_1k must not be negative, got %g", i, mp.Prefix, mp.PricePer1K))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DuplicatePrefixes reports model_prices entries that repeat an earlier
+// prefix, joined with errors.Join. The last entry for a prefix wins, so a
+// config with duplicates still works; only 'intentra pricing validate'
+// treats them as errors.
+func (p PricingConfig) DuplicatePrefixes() error {
+	var errs []error
+	seen := make(map[string]int, len(p.ModelPrices))
+	for i, mp := range p.ModelPrices {
+		prefix := strings.TrimSpace(mp.Prefix)
+		if prefix == "" {
+			continue
+		}
+		if j, dup := seen[prefix]; dup {
+			errs = append(errs, fmt.Errorf("local.pricing.model_prices[%d] repeats prefix %s from model_prices[%d]", i, prefix, j))
+		} else {
+			seen[prefix] = i
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateMetadata bounds local.metadata so it cannot bloat every payload,
+// and restricts keys to names the server can index.
+func validateMetadata(m map[string]string) error {
+	if len(m) > MaxMetadataEntries {
+		return fmt.Errorf("local.metadata has %d entries, at most %d are allowed", len(m), MaxMetadataEntries)
+	}
+	for k, v := range m {
+		if !ValidMetadataKey(k) {
+			return fmt.Errorf("invalid local.metadata key %q: use 1-%d letters, digits, '_', '-' or '.'", k, MaxMetadataKeyLen)
+		}
+		if len(v) > MaxMetadataValueLen {
+			return fmt.Errorf("local.metadata.%s must be at most %d bytes, got %d", k, MaxMetadataValueLen, len(v))
+		}
+	}
+	return nil
+}
+
+// ValidMetadataKey reports whether k can be used as a local.metadata key.
+func ValidMetadataKey(k string) bool {
+	if k == "" || len(k) > MaxMetadataKeyLen {
+		return false
+	}
+	for _, r := range k {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validateDuration checks that d is within [min, max]. Bare numbers in YAML
+// (e.g. "timeout: 30") decode as nanoseconds, so tiny values get a unit hint.
+func validateDuration(name string, d, min, max time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("%s must be positive, got %s", name, d)
+	}
+	if d < time.Millisecond {
+		return fmt.Errorf("%s is %s; durations need a unit (e.g. 30s, 5m)", name, d)
+	}
+	if d < min || d > max {
+		return fmt.Errorf("%s must be between %s and %s, got %s", name, min, max, d)
+	}
+	return nil
+}
+
 // Print outputs the current configuration (redacting secrets).
 func (c *Config) Print() {
 	fmt.Println("=== Intentra Configuration ===")
 	fmt.Println()
 
+	if c.Locked {
+		fmt.Println("Locked: true (managed by administrator)")
+	}
 	fmt.Printf("Debug: %v\n", c.Debug)
 	fmt.Println()
 
 	fmt.Println("Server Sync:")
 	fmt.Printf("  Enabled: %v\n", c.Server.Enabled)
+	if c.Server.Auth.ExpiryBuffer != DefaultTokenExpiryBuffer {
+		fmt.Printf("  Token Expiry Buffer: %s\n", c.Server.Auth.ExpiryBuffer)
+	}
+	if b := c.Server.Auth.KeyringBackend; b != "" && b != KeyringBackendAuto {
+		fmt.Printf("  Keyring Backend: %s\n", b)
+	}
 	if c.Server.Enabled {
 		fmt.Printf("  Endpoint: %s\n", c.Server.Endpoint)
 		fmt.Printf("  Timeout: %s\n", c.Server.Timeout)
+		if c.Server.MinSyncInterval > 0 {
+			fmt.Printf("  Min Sync Interval: %s\n", c.Server.MinSyncInterval)
+		}
+		fmt.Printf("  Retries: %d (backoff %s)\n", c.Server.MaxRetries, c.Server.RetryBackoff)
+		fmt.Printf("  On Auth Failure: %s\n", c.Server.OnAuthFailure)
+		fmt.Printf("  Min TLS Version: %s\n", c.Server.MinTLSVersion)
+		if c.Server.BatchUpload {
+			fmt.Printf("  Batch Upload: enabled\n")
+		}
+		if len(c.Server.TLSCipherSuites) > 0 {
+			fmt.Printf("  TLS Cipher Suites: %s\n", strings.Join(c.Server.TLSCipherSuites, ", "))
+		}
 		if c.Server.Auth.Mode != "" {
 			fmt.Printf("  Auth Mode: %s\n", c.Server.Auth.Mode)
 		} else {
 			fmt.Printf("  Auth Mode: jwt (via 'intentra login')\n")
 		}
+		if c.Server.Auth.HMAC.DeviceID != "" {
+			fmt.Printf("  Device ID: %s (pinned)\n", c.Server.Auth.HMAC.DeviceID)
+		}
 		if c.Server.Auth.Mode == AuthModeAPIKey {
 			fmt.Printf("  Key ID: %s\n", c.Server.Auth.APIKey.KeyID)
 			if c.Server.Auth.APIKey.HMACKey != "" {
 				fmt.Printf("  HMAC Key: [REDACTED] (HMAC signing enabled)\n")
+				if c.Server.Auth.HMAC.BodyHashMode {
+					fmt.Printf("  HMAC Body Hash: enabled\n")
+				}
 			} else if c.Server.Auth.APIKey.Secret != "" {
 				fmt.Printf("  Secret: [REDACTED] (legacy mode)\n")
 			}
@@ -354,6 +965,36 @@ func (c *Config) Print() {
 	if c.Local.AnthropicAPIKey != "" {
 		fmt.Printf("  Anthropic API Key: [REDACTED]\n")
 	}
+	fmt.Printf("  Keep Buffers: %v\n", c.Local.KeepBuffers)
+	fmt.Printf("  Save Scans (debug): %v\n", c.Local.SaveScans)
+	fmt.Printf("  Warn Unknown Events: %v\n", c.Local.WarnUnknownEvents)
+	if c.Local.WindsurfIdleTimeout > 0 {
+		fmt.Printf("  Windsurf Idle Timeout: %s\n", c.Local.WindsurfIdleTimeout)
+	}
+	if c.Local.MaxMCPEntries > 0 {
+		fmt.Printf("  Max MCP Entries: %d\n", c.Local.MaxMCPEntries)
+	} else {
+		fmt.Printf("  Max MCP Entries: unlimited\n")
+	}
+	fmt.Printf("  Max Concurrent Installs: %d\n", c.Local.MaxConcurrentInstalls)
+	if c.Local.Syslog.Enabled {
+		target := "local"
+		if c.Local.Syslog.Address != "" {
+			target = c.Local.Syslog.Network + "://" + c.Local.Syslog.Address
+		}
+		fmt.Printf("  Syslog: %s (tag %s)\n", target, c.Local.Syslog.Tag)
+	}
+	if len(c.Local.Metadata) > 0 {
+		keys := make([]string, 0, len(c.Local.Metadata))
+		for k := range c.Local.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Println("  Metadata:")
+		for _, k := range keys {
+			fmt.Printf("    %s: %s\n", k, c.Local.Metadata[k])
+		}
+	}
 	fmt.Println()
 
 	fmt.Println("Archive:")
@@ -368,6 +1009,13 @@ func (c *Config) Print() {
 	fmt.Printf("  Path: %s\n", c.Buffer.Path)
 	fmt.Printf("  Max Size: %d MB\n", c.Buffer.MaxSizeMB)
 	fmt.Printf("  Flush Interval: %s\n", c.Buffer.FlushInterval)
+	fmt.Printf("  Max Sync Attempts: %d\n", c.Buffer.MaxSyncAttempts)
+	fmt.Printf("  Session End Grace: %s\n", c.Buffer.SessionEndGrace)
+	if c.Buffer.MaxSessionBytes > 0 {
+		fmt.Printf("  Max Session Bytes: %d\n", c.Buffer.MaxSessionBytes)
+	} else {
+		fmt.Println("  Max Session Bytes: unlimited")
+	}
 }
 
 // PrintSample outputs a sample configuration file.
@@ -384,10 +1032,35 @@ server:
   enabled: false
   endpoint: "https://api.intentra.sh"
   timeout: 30s
+  # Minimum time between syncs for one session; bursts of stop events are
+  # coalesced into the next sync (0 disables)
+  min_sync_interval: 0s
+  # Retries for failed uploads (connection errors, 429 and 5xx responses),
+  # with exponential backoff starting at retry_backoff (0 disables retries)
+  max_retries: 3
+  retry_backoff: 1s
+  # Scans that can't be sent because the login expired and could not be
+  # refreshed (e.g. offline): buffer (queue for later), drop, or local
+  # (save locally only)
+  on_auth_failure: buffer
+  # Lowest TLS version accepted from the server: 1.2 or 1.3
+  min_tls_version: "1.2"
+  # Restrict TLS 1.2 cipher suites (Go names); empty uses Go's secure defaults
+  # tls_cipher_suites:
+  #   - TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384
+  #   - TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+  # Upload several scans per request (POST /scans/batch) when syncing
+  batch_upload: false
   auth:
     # Auth mode: api_key
     # Leave mode empty to use JWT from 'intentra login' (recommended)
     mode: ""
+    # Refresh the login token this long before it expires (0s to refresh
+    # only once it has expired)
+    expiry_buffer: 5m
+    # Where login credentials are stored: auto, file (skips the OS keyring,
+    # e.g. in containers without dbus), secret-service, keychain or wincred
+    keyring_backend: auto
 
     # API key authentication (Enterprise only)
     # Generate keys in Settings > API Keys on the web dashboard
@@ -395,6 +1068,9 @@ server:
     #   key_id: "${INTENTRA_API_KEY_ID}"       # API key ID (apk_...)
     #   hmac_key: "${INTENTRA_API_HMAC_KEY}"   # HMAC signing key (preferred, never transmitted)
     #   secret: "${INTENTRA_API_SECRET}"       # Legacy mode: raw secret (use hmac_key instead)
+    # hmac:
+    #   body_hash_mode: false   # Also sign a SHA-256 of the request body (X-API-Body-Hash)
+    #   device_id: ""           # Pin the device ID instead of deriving it from hardware
 
 # Local settings
 local:
@@ -404,6 +1080,46 @@ local:
   min_events_per_scan: 2
   chars_per_token: 4
 
+  # Capture repo name, branch, commit, and hashed git author email on scans
+  collect_git_metadata: true
+  # Git remote host (e.g. github.com): hash, plain, or off
+  repo_host: hash
+  # Model a scan is priced as when events name several models: first, or
+  # dominant (the model with the most tokens)
+  model_selection: first
+  # Keep raw session buffers in ~/.intentra/consumed/ after aggregation, named
+  # by scan ID, for debugging (the newest 50 within 7 days are kept)
+  keep_buffers: false
+  # In debug mode, also save each scan to ~/.intentra/scans/. Set false to
+  # keep debug logging without writing scan files
+  save_scans: true
+  # Record hook events of unrecognized types so 'intentra doctor' lists
+  # them as missing mappings
+  warn_unknown_events: false
+  # Windsurf has no stop hook, so each response ends a scan. Set an idle
+  # timeout (e.g. 2m) to end the scan only once the session goes quiet
+  windsurf_idle_timeout: 0s
+  # MCP tools and tool call counts reported per scan; the least-called are
+  # rolled into "other" (0 for no limit)
+  max_mcp_entries: 50
+  # Tools whose hooks install, uninstall and 'hooks status' handle at once
+  # (1 for one at a time)
+  max_concurrent_installs: 4
+
+  # One line per scan to syslog/journald (not supported on Windows).
+  # Leave network and address empty for the local daemon.
+  syslog:
+    enabled: false
+    # network: udp
+    # address: logs.example.com:514
+    tag: intentra
+
+  # Static key/value pairs attached to every scan sent to the server, for
+  # filtering by team, cost center, environment, etc. (up to 32 entries)
+  # metadata:
+  #   team: platform
+  #   cost_center: eng-42
+
   # Local scan archive (for benchmarking)
   archive:
     enabled: false
@@ -411,6 +1127,28 @@ local:
     redacted: true
     include_events: false
 
+  # Grouping for events without a conversation or session ID
+  # strategy: idle_gap (per device), cwd (per working directory), or device (single session)
+  session_fallback:
+    strategy: idle_gap
+    idle_gap: 30m
+
+  # Cost estimation overrides, consulted before the built-in tables
+  # pricing:
+  #   confidence_pct: 20        # +/- band reported around cost estimates
+  #   tool_multipliers:
+  #     windsurf: 1.2
+  #   model_prices:
+  #     - prefix: claude-sonnet-4.5
+  #       price_per_1k: 0.0066
+
+  # Server names for MCP tools whose calls don't name one, e.g. tools from
+  # private MCP servers. These win over the built-in table.
+  # mcp:
+  #   server_overrides:
+  #     - tool: search_tickets
+  #       server: helpdesk
+
 # Buffer for offline resilience
 buffer:
   enabled: true
@@ -419,11 +1157,14 @@ buffer:
   max_age_hours: 24
   flush_interval: 30s
   flush_threshold: 10
+  max_sync_attempts: 10  # failed syncs before a scan is dead-lettered
+  max_session_bytes: 20971520  # flush a partial scan past this size; 0 disables
+  session_end_grace: 10s  # time a session-end PATCH gets before it is queued
 
 # Logging
 logging:
-  level: warn
-  format: text
+  level: warn   # debug, info, warn, or error; debug mode forces debug
+  format: text  # text or json (one JSON object per line)
 `
 	fmt.Print(sample)
 }
@@ -438,38 +1179,38 @@ func ConfigExists() bool {
 	return statErr == nil
 }
 
-// SaveConfig writes the configuration to the config file.
-// It preserves existing values and only updates specified fields.
+// SaveConfig writes the configuration to the config file. The existing
+// file is round-tripped: keys SaveConfig does not manage are kept as
+// written, and a file that cannot be parsed is reported rather than
+// replaced.
 func SaveConfig(cfg *Config) error {
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return fmt.Errorf("failed to determine config path: %w", err)
-	}
-
-	configDir, err := GetConfigDir()
+	v, configPath, err := openConfigFile()
 	if err != nil {
-		return fmt.Errorf("failed to determine config directory: %w", err)
-	}
-	if err := os.MkdirAll(configDir, 0700); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	v := viper.New()
-	v.SetConfigType("yaml")
-
-	if _, err := os.Stat(configPath); err == nil {
-		v.SetConfigFile(configPath)
-		if err := v.ReadInConfig(); err != nil {
-			v = viper.New()
-			v.SetConfigType("yaml")
-		}
+		return err
 	}
+	setConfigValues(v, cfg)
+	return writeConfigFile(v, configPath)
+}
 
+// setConfigValues sets the keys SaveConfig manages from cfg. Keys not listed
+// here, such as local.pricing and credentials, keep whatever value v already
+// holds.
+func setConfigValues(v *viper.Viper, cfg *Config) {
 	v.Set("debug", cfg.Debug)
 	v.Set("server.enabled", cfg.Server.Enabled)
 	v.Set("server.endpoint", cfg.Server.Endpoint)
 	v.Set("server.timeout", cfg.Server.Timeout.String())
+	v.Set("server.min_sync_interval", cfg.Server.MinSyncInterval.String())
+	v.Set("server.max_retries", cfg.Server.MaxRetries)
+	v.Set("server.retry_backoff", cfg.Server.RetryBackoff.String())
+	v.Set("server.on_auth_failure", cfg.Server.OnAuthFailure)
+	v.Set("server.min_tls_version", cfg.Server.MinTLSVersion)
+	v.Set("server.batch_upload", cfg.Server.BatchUpload)
 	v.Set("server.auth.mode", cfg.Server.Auth.Mode)
+	v.Set("server.auth.hmac.body_hash_mode", cfg.Server.Auth.HMAC.BodyHashMode)
+	v.Set("server.auth.hmac.device_id", cfg.Server.Auth.HMAC.DeviceID)
+	v.Set("server.auth.expiry_buffer", cfg.Server.Auth.ExpiryBuffer.String())
+	v.Set("server.auth.keyring_backend", cfg.Server.Auth.KeyringBackend)
 	v.Set("local.model", cfg.Local.Model)
 	v.Set("local.scan_timeout", cfg.Local.ScanTimeout)
 	v.Set("local.min_events_per_scan", cfg.Local.MinEventsPerScan)
@@ -478,11 +1219,31 @@ func SaveConfig(cfg *Config) error {
 	v.Set("local.archive.path", cfg.Local.Archive.Path)
 	v.Set("local.archive.redacted", cfg.Local.Archive.Redacted)
 	v.Set("local.archive.include_events", cfg.Local.Archive.IncludeEvents)
+	v.Set("local.collect_git_metadata", cfg.Local.CollectGitMetadata)
+	v.Set("local.repo_host", cfg.Local.RepoHost)
+	v.Set("local.model_selection", cfg.Local.ModelSelection)
+	v.Set("local.keep_buffers", cfg.Local.KeepBuffers)
+	v.Set("local.save_scans", cfg.Local.SaveScans)
+	v.Set("local.warn_unknown_events", cfg.Local.WarnUnknownEvents)
+	v.Set("local.windsurf_idle_timeout", cfg.Local.WindsurfIdleTimeout.String())
+	v.Set("local.max_mcp_entries", cfg.Local.MaxMCPEntries)
+	v.Set("local.max_concurrent_installs", cfg.Local.MaxConcurrentInstalls)
+	v.Set("local.syslog.enabled", cfg.Local.Syslog.Enabled)
+	v.Set("local.syslog.network", cfg.Local.Syslog.Network)
+	v.Set("local.syslog.address", cfg.Local.Syslog.Address)
+	v.Set("local.syslog.tag", cfg.Local.Syslog.Tag)
+	v.Set("local.session_fallback.strategy", cfg.Local.SessionFallback.Strategy)
+	v.Set("local.session_fallback.idle_gap", cfg.Local.SessionFallback.IdleGap.String())
 	v.Set("logging.level", cfg.Log.Level)
 	v.Set("logging.format", cfg.Log.Format)
+}
 
-	// Write to temp file first, then atomically rename
-	tmpPath := configPath + ".tmp"
+// writeConfigFile writes v to configPath with 0600 permissions, via a temp
+// file and atomic rename. The temp file keeps the config extension so viper
+// can infer the encoding from it.
+func writeConfigFile(v *viper.Viper, configPath string) error {
+	ext := filepath.Ext(configPath)
+	tmpPath := strings.TrimSuffix(configPath, ext) + ".tmp" + ext
 	if err := v.WriteConfigAs(tmpPath); err != nil {
 		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write config: %w", err)
@@ -497,4 +1258,3 @@ func SaveConfig(cfg *Config) error {
 	}
 	return nil
 }
-
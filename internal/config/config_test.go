@@ -1,9 +1,14 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/spf13/viper"
 )
 
 func TestGetConfigDir(t *testing.T) {
@@ -19,6 +24,31 @@ func TestGetConfigDir(t *testing.T) {
 	}
 }
 
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"~/.intentra/buffer.db", filepath.Join(home, ".intentra", "buffer.db")},
+		{"~", home},
+		{"/var/lib/intentra/buffer.db", "/var/lib/intentra/buffer.db"},
+		{"~other/buffer.db", "~other/buffer.db"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := ExpandHome(tt.in)
+		if err != nil {
+			t.Fatalf("ExpandHome(%q) error: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
 func TestLoadConfig(t *testing.T) {
 	// Use temp dir for test
 	tmpDir := t.TempDir()
@@ -36,3 +66,297 @@ func TestLoadConfig(t *testing.T) {
 		t.Error("Expected server sync to be disabled by default")
 	}
 }
+
+func TestValidate_Durations(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"zero timeout", func(c *Config) { c.Server.Timeout = 0 }, "server.timeout must be positive"},
+		{"negative timeout", func(c *Config) { c.Server.Timeout = -5 * time.Second }, "server.timeout must be positive"},
+		{"missing unit timeout", func(c *Config) { c.Server.Timeout = 30 }, "durations need a unit"},
+		{"timeout too long", func(c *Config) { c.Server.Timeout = time.Hour }, "server.timeout must be between"},
+		{"zero flush interval", func(c *Config) { c.Buffer.FlushInterval = 0 }, "buffer.flush_interval must be positive"},
+		{"negative flush interval", func(c *Config) { c.Buffer.FlushInterval = -time.Second }, "buffer.flush_interval must be positive"},
+		{"missing unit flush interval", func(c *Config) { c.Buffer.FlushInterval = 30 }, "durations need a unit"},
+		{"windsurf idle timeout", func(c *Config) { c.Local.WindsurfIdleTimeout = 2 * time.Minute }, ""},
+		{"no mcp entry cap", func(c *Config) { c.Local.MaxMCPEntries = 0 }, ""},
+		{"negative mcp entry cap", func(c *Config) { c.Local.MaxMCPEntries = -1 }, "local.max_mcp_entries must be between"},
+		{"sequential installs", func(c *Config) { c.Local.MaxConcurrentInstalls = 1 }, ""},
+		{"zero concurrent installs", func(c *Config) { c.Local.MaxConcurrentInstalls = 0 }, "local.max_concurrent_installs must be between"},
+		{"zero sync attempts", func(c *Config) { c.Buffer.MaxSyncAttempts = 0 }, "buffer.max_sync_attempts must be between"},
+		{"long session end grace", func(c *Config) { c.Buffer.SessionEndGrace = time.Minute }, ""},
+		{"session end grace too long", func(c *Config) { c.Buffer.SessionEndGrace = time.Hour }, "buffer.session_end_grace must be between"},
+		{"no session byte cap", func(c *Config) { c.Buffer.MaxSessionBytes = 0 }, ""},
+		{"tiny session byte cap", func(c *Config) { c.Buffer.MaxSessionBytes = 1024 }, "buffer.max_session_bytes must be 0"},
+		{"remote syslog", func(c *Config) { c.Local.Syslog.Network = "udp"; c.Local.Syslog.Address = "logs:514" }, ""},
+		{"syslog network without address", func(c *Config) { c.Local.Syslog.Network = "tcp" }, "requires local.syslog.address"},
+		{"unknown syslog network", func(c *Config) { c.Local.Syslog.Network = "http" }, "unknown local.syslog.network"},
+		{"pinned device id", func(c *Config) { c.Server.Auth.HMAC.DeviceID = "ci-runner-7" }, ""},
+		{"token expiry buffer", func(c *Config) { c.Server.Auth.ExpiryBuffer = 15 * time.Minute }, ""},
+		{"file keyring backend", func(c *Config) { c.Server.Auth.KeyringBackend = KeyringBackendFile }, ""},
+		{"unknown keyring backend", func(c *Config) { c.Server.Auth.KeyringBackend = "kwallet" }, "unknown server.auth.keyring_backend"},
+		{"no token expiry buffer", func(c *Config) { c.Server.Auth.ExpiryBuffer = 0 }, ""},
+		{"token expiry buffer too long", func(c *Config) { c.Server.Auth.ExpiryBuffer = 2 * time.Hour }, "server.auth.expiry_buffer must be between"},
+		{"device id with spaces", func(c *Config) { c.Server.Auth.HMAC.DeviceID = " ci " }, "server.auth.hmac.device_id must be"},
+		{"metadata", func(c *Config) { c.Local.Metadata = map[string]string{"team": "platform", "cost.center": "eng-42"} }, ""},
+		{"metadata key with spaces", func(c *Config) { c.Local.Metadata = map[string]string{"cost center": "x"} }, "invalid local.metadata key"},
+		{"metadata value too long", func(c *Config) {
+			c.Local.Metadata = map[string]string{"team": strings.Repeat("x", 257)}
+		}, "local.metadata.team must be at most 256 bytes"},
+		{"windsurf idle timeout too short", func(c *Config) { c.Local.WindsurfIdleTimeout = time.Millisecond }, "local.windsurf_idle_timeout must be between"},
+		{"windsurf idle timeout outlives buffer", func(c *Config) { c.Local.WindsurfIdleTimeout = StaleBufferAge }, "local.windsurf_idle_timeout must be between"},
+		{"zero idle gap", func(c *Config) { c.Local.SessionFallback.IdleGap = 0 }, "idle_gap must be positive"},
+		{"device strategy ignores idle gap", func(c *Config) {
+			c.Local.SessionFallback.Strategy = SessionFallbackDevice
+			c.Local.SessionFallback.IdleGap = 0
+		}, ""},
+		{"unknown strategy", func(c *Config) { c.Local.SessionFallback.Strategy = "hourly" }, "unknown local.session_fallback.strategy"},
+		{"min sync interval", func(c *Config) { c.Server.MinSyncInterval = 10 * time.Second }, ""},
+		{"negative min sync interval", func(c *Config) { c.Server.MinSyncInterval = -time.Second }, "server.min_sync_interval must be positive"},
+		{"min sync interval too long", func(c *Config) { c.Server.MinSyncInterval = 2 * time.Hour }, "server.min_sync_interval must be between"},
+		{"no retries", func(c *Config) { c.Server.MaxRetries = 0; c.Server.RetryBackoff = 0 }, ""},
+		{"too many retries", func(c *Config) { c.Server.MaxRetries = 11 }, "server.max_retries must be between"},
+		{"negative retries", func(c *Config) { c.Server.MaxRetries = -1 }, "server.max_retries must be between"},
+		{"retry backoff too short", func(c *Config) { c.Server.RetryBackoff = time.Millisecond }, "server.retry_backoff must be between"},
+		{"unknown auth failure policy", func(c *Config) { c.Server.OnAuthFailure = "retry" }, "unknown server.on_auth_failure"},
+		{"tls 1.3", func(c *Config) { c.Server.MinTLSVersion = "1.3" }, ""},
+		{"unsupported tls version", func(c *Config) { c.Server.MinTLSVersion = "1.1" }, "invalid server.min_tls_version"},
+		{"tls cipher suites", func(c *Config) {
+			c.Server.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}
+		}, ""},
+		{"insecure tls cipher suite", func(c *Config) {
+			c.Server.TLSCipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"}
+		}, "invalid server.tls_cipher_suites"},
+		{"drop on auth failure", func(c *Config) { c.Server.OnAuthFailure = AuthFailureDrop }, ""},
+		{"pricing overrides", func(c *Config) {
+			c.Local.Pricing.ToolMultipliers = map[string]float64{"windsurf": 1.1}
+			c.Local.Pricing.ModelPrices = []ModelPrice{{Prefix: "claude-sonnet-4.5", PricePer1K: 0.006}}
+		}, ""},
+		{"mcp server overrides", func(c *Config) {
+			c.Local.MCP.ServerOverrides = []MCPServerOverride{{Tool: "search_tickets", Server: "helpdesk"}}
+		}, ""},
+		{"mcp server override without server", func(c *Config) {
+			c.Local.MCP.ServerOverrides = []MCPServerOverride{{Tool: "search_tickets"}}
+		}, "local.mcp.server_overrides[0] needs both tool and server"},
+		{"negative tool multiplier", func(c *Config) {
+			c.Local.Pricing.ToolMultipliers = map[string]float64{"windsurf": -1}
+		}, "tool_multipliers.windsurf must not be negative"},
+		{"negative model price", func(c *Config) {
+			c.Local.Pricing.ModelPrices = []ModelPrice{{Prefix: "gpt-4o", PricePer1K: -0.01}}
+		}, "price_per_1k must not be negative"},
+		{"confidence band", func(c *Config) { c.Local.Pricing.ConfidencePct = 35 }, ""},
+		{"negative confidence band", func(c *Config) { c.Local.Pricing.ConfidencePct = -5 }, "confidence_pct must be between 0 and 100"},
+		{"empty model prefix", func(c *Config) {
+			c.Local.Pricing.ModelPrices = []ModelPrice{{PricePer1K: 0.01}}
+		}, "prefix is required"},
+		{"repeated model prefix", func(c *Config) {
+			c.Local.Pricing.ModelPrices = []ModelPrice{{Prefix: "gpt-4o", PricePer1K: 0.01}, {Prefix: " gpt-4o", PricePer1K: 0.02}}
+		}, ""},
+		{"plain repo host", func(c *Config) { c.Local.RepoHost = RepoHostPlain }, ""},
+		{"unknown repo host mode", func(c *Config) { c.Local.RepoHost = "masked" }, "unknown local.repo_host"},
+		{"json log format", func(c *Config) { c.Log.Format = LogFormatJSON }, ""},
+		{"unknown log format", func(c *Config) { c.Log.Format = "logfmt" }, "unknown logging.format"},
+		{"error log level", func(c *Config) { c.Log.Level = "error" }, ""},
+		{"unknown log level", func(c *Config) { c.Log.Level = "trace" }, "invalid logging.level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPricingConfig_DuplicatePrefixes(t *testing.T) {
+	p := PricingConfig{ModelPrices: []ModelPrice{
+		{Prefix: "gpt-4o", PricePer1K: 0.01},
+		{Prefix: "claude", PricePer1K: 0.02},
+		{Prefix: " gpt-4o", PricePer1K: 0.03},
+	}}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate rejected a repeated prefix: %v", err)
+	}
+	err := p.DuplicatePrefixes()
+	if err == nil || !strings.Contains(err.Error(), "model_prices[2] repeats prefix gpt-4o from model_prices[0]") {
+		t.Errorf("DuplicatePrefixes = %v, want the repeated gpt-4o entry", err)
+	}
+	p.ModelPrices = p.ModelPrices[:2]
+	if err := p.DuplicatePrefixes(); err != nil {
+		t.Errorf("DuplicatePrefixes = %v, want nil", err)
+	}
+}
+
+func TestLoadPricingFile_ConfigSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `debug: true
+local:
+  pricing:
+    confidence_pct: 15
+    model_prices:
+      - prefix: acme-coder
+        price_per_1k: 0.002
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPricingFile(path)
+	if err != nil {
+		t.Fatalf("LoadPricingFile failed: %v", err)
+	}
+	if p.ConfidencePct != 15 || len(p.ModelPrices) != 1 || p.ModelPrices[0] != (ModelPrice{Prefix: "acme-coder", PricePer1K: 0.002}) {
+		t.Errorf("unexpected pricing: %+v", p)
+	}
+}
+
+func TestLoadConfig_MissingDurationUnit(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	content := "server:\n  timeout: 30\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "durations need a unit") {
+		t.Errorf("expected missing unit error, got %v", err)
+	}
+}
+
+func TestLoadConfig_LockedIgnoresEnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	content := `locked: true
+rich_traces: false
+server:
+  enabled: true
+  endpoint: "https://managed.example.com"
+  auth:
+    mode: api_key
+    api_key:
+      key_id: "apk_managed"
+      hmac_key: "managed-hmac"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	t.Setenv("INTENTRA_SERVER_ENDPOINT", "https://evil.example.com")
+	t.Setenv("INTENTRA_API_KEY_ID", "apk_user")
+	t.Setenv("INTENTRA_RICH_TRACES", "true")
+	t.Setenv("INTENTRA_LOCKED", "false")
+	t.Setenv("INTENTRA_NO_GIT", "1")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.Locked {
+		t.Error("INTENTRA_LOCKED must not unlock a locked config")
+	}
+	if cfg.Server.Endpoint != "https://managed.example.com" {
+		t.Errorf("endpoint = %q, want managed endpoint", cfg.Server.Endpoint)
+	}
+	if cfg.Server.Auth.APIKey.KeyID != "apk_managed" {
+		t.Errorf("key_id = %q, want apk_managed", cfg.Server.Auth.APIKey.KeyID)
+	}
+	if cfg.RichTraces {
+		t.Error("rich_traces should not be overridden by env when locked")
+	}
+	if cfg.Local.CollectGitMetadata {
+		t.Error("INTENTRA_NO_GIT should disable git metadata even when locked")
+	}
+}
+
+func TestLoadConfig_UnlockedAppliesEnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	t.Setenv("INTENTRA_SERVER_ENDPOINT", "https://override.example.com")
+	t.Setenv("INTENTRA_RICH_TRACES", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Server.Endpoint != "https://override.example.com" || !cfg.RichTraces {
+		t.Errorf("expected env overrides to apply, got endpoint=%q rich_traces=%v", cfg.Server.Endpoint, cfg.RichTraces)
+	}
+}
+
+func TestSaveConfig_RefusesLocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := "locked: true\ndebug: false\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Debug = true
+	if err := SaveConfig(cfg); !errors.Is(err, ErrConfigLocked) {
+		t.Errorf("SaveConfig error = %v, want ErrConfigLocked", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("locked config was modified:\n%s", data)
+	}
+}
+
+func TestSaveConfig_PreservesUnknownKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := "debug: false\nteam:\n  id: team-42\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Debug = true
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("failed to read saved config: %v", err)
+	}
+	if got := v.GetString("team.id"); got != "team-42" {
+		t.Errorf("team.id = %q, want team-42", got)
+	}
+	if !v.GetBool("debug") {
+		t.Error("debug was not updated")
+	}
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	// ErrUnknownKey is returned by SetValue for keys that cannot be set.
+	ErrUnknownKey = errors.New("unknown config key")
+
+	// ErrKeyNotSet is returned by UnsetValue when the file lacks the key.
+	ErrKeyNotSet = errors.New("key is not set in config file")
+)
+
+// keyKind is the value type of a settable config key.
+type keyKind int
+
+const (
+	kindString keyKind = iota
+	kindBool
+	kindInt
+	kindDuration
+)
+
+// settableKey describes a key that can be changed with SetValue. Secret
+// values must never be echoed back to the user.
+type settableKey struct {
+	kind   keyKind
+	secret bool
+}
+
+// settableKeys lists the scalar keys editable from the CLI. Keys holding
+// lists or maps (local.pricing) and locked are edited in the file directly.
+var settableKeys = map[string]settableKey{
+	"debug":       {kind: kindBool},
+	"rich_traces": {kind: kindBool},
+
+	"server.enabled":                  {kind: kindBool},
+	"server.endpoint":                 {kind: kindString},
+	"server.timeout":                  {kind: kindDuration},
+	"server.min_sync_interval":        {kind: kindDuration},
+	"server.max_retries":              {kind: kindInt},
+	"server.retry_backoff":            {kind: kindDuration},
+	"server.on_auth_failure":          {kind: kindString},
+	"server.min_tls_version":          {kind: kindString},
+	"server.batch_upload":             {kind: kindBool},
+	"server.auth.mode":                {kind: kindString},
+	"server.auth.api_key.key_id":      {kind: kindString},
+	"server.auth.api_key.secret":      {kind: kindString, secret: true},
+	"server.auth.api_key.hmac_key":    {kind: kindString, secret: true},
+	"server.auth.hmac.body_hash_mode": {kind: kindBool},
+	"server.auth.hmac.device_id":      {kind: kindString},
+	"server.auth.expiry_buffer":       {kind: kindDuration},
+	"server.auth.keyring_backend":     {kind: kindString},
+
+	"local.anthropic_api_key":         {kind: kindString, secret: true},
+	"local.model":                     {kind: kindString},
+	"local.scan_timeout":              {kind: kindInt},
+	"local.min_events_per_scan":       {kind: kindInt},
+	"local.chars_per_token":           {kind: kindInt},
+	"local.archive.enabled":           {kind: kindBool},
+	"local.archive.path":              {kind: kindString},
+	"local.archive.redacted":          {kind: kindBool},
+	"local.archive.include_events":    {kind: kindBool},
+	"local.collect_git_metadata":      {kind: kindBool},
+	"local.repo_host":                 {kind: kindString},
+	"local.model_selection":           {kind: kindString},
+	"local.keep_buffers":              {kind: kindBool},
+	"local.save_scans":                {kind: kindBool},
+	"local.warn_unknown_events":       {kind: kindBool},
+	"local.windsurf_idle_timeout":     {kind: kindDuration},
+	"local.max_mcp_entries":           {kind: kindInt},
+	"local.max_concurrent_installs":   {kind: kindInt},
+	"local.syslog.enabled":            {kind: kindBool},
+	"local.syslog.network":            {kind: kindString},
+	"local.syslog.address":            {kind: kindString},
+	"local.syslog.tag":                {kind: kindString},
+	"local.session_fallback.strategy": {kind: kindString},
+	"local.session_fallback.idle_gap": {kind: kindDuration},
+
+	"buffer.enabled":           {kind: kindBool},
+	"buffer.path":              {kind: kindString},
+	"buffer.max_size_mb":       {kind: kindInt},
+	"buffer.max_age_hours":     {kind: kindInt},
+	"buffer.flush_interval":    {kind: kindDuration},
+	"buffer.flush_threshold":   {kind: kindInt},
+	"buffer.max_sync_attempts": {kind: kindInt},
+	"buffer.max_session_bytes": {kind: kindInt},
+	"buffer.session_end_grace": {kind: kindDuration},
+
+	"logging.level":  {kind: kindString},
+	"logging.format": {kind: kindString},
+}
+
+// SettableKeys returns the keys accepted by SetValue, sorted.
+func SettableKeys() []string {
+	keys := make([]string, 0, len(settableKeys))
+	for k := range settableKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsSecretKey reports whether key holds a credential that must not be displayed.
+func IsSecretKey(key string) bool {
+	return settableKeys[key].secret
+}
+
+// parseValue converts a command-line value to the type stored under key.
+// Durations are stored in their string form, as SaveConfig writes them.
+func parseValue(key, raw string) (any, error) {
+	k, ok := settableKeys[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+	switch k.kind {
+	case kindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, raw)
+		}
+		return b, nil
+	case kindInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer, got %q", key, raw)
+		}
+		return n, nil
+	case kindDuration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a duration such as 30s or 5m, got %q", key, raw)
+		}
+		return d.String(), nil
+	default:
+		return raw, nil
+	}
+}
+
+// SetValue sets a single dotted key in the config file, leaving other keys
+// as written. The value is parsed for the key's type and the resulting
+// config must pass Validate. Environment overrides are not involved, so
+// nothing but the named key changes on disk.
+func SetValue(key, raw string) error {
+	value, err := parseValue(key, raw)
+	if err != nil {
+		return err
+	}
+
+	v, configPath, err := openConfigFile()
+	if err != nil {
+		return err
+	}
+	v.Set(key, value)
+
+	if err := validateSettings(v); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	if err := writeConfigFile(v, configPath); err != nil {
+		return err
+	}
+	InvalidateCache()
+	return nil
+}
+
+// UnsetValue removes a dotted key from the config file so that its default
+// applies again. Any key in the file may be removed, including sections
+// such as local.pricing. It returns ErrKeyNotSet if the file does not
+// contain the key.
+func UnsetValue(key string) error {
+	v, configPath, err := openConfigFile()
+	if err != nil {
+		return err
+	}
+
+	settings := v.AllSettings()
+	if !deleteSetting(settings, strings.Split(strings.ToLower(key), ".")) {
+		return fmt.Errorf("%w: %s", ErrKeyNotSet, key)
+	}
+	out := viper.New()
+	out.SetConfigType("yaml")
+	for k, val := range settings {
+		out.Set(k, val)
+	}
+
+	if err := validateSettings(out); err != nil {
+		return fmt.Errorf("config is invalid without %s: %w", key, err)
+	}
+	if err := writeConfigFile(out, configPath); err != nil {
+		return err
+	}
+	InvalidateCache()
+	return nil
+}
+
+// preservedOnReset lists the credential keys ResetConfig keeps.
+var preservedOnReset = []string{
+	"server.auth.mode",
+	"server.auth.api_key.key_id",
+	"server.auth.api_key.secret",
+	"server.auth.api_key.hmac_key",
+	"local.anthropic_api_key",
+}
+
+// ResetConfig rewrites the config file from DefaultConfig, keeping only the
+// credentials in preservedOnReset. Every other key, including ones added by
+// hand, is discarded.
+func ResetConfig() error {
+	v, configPath, err := openConfigFile()
+	if err != nil {
+		return err
+	}
+
+	out := viper.New()
+	out.SetConfigType("yaml")
+	setConfigValues(out, DefaultConfig())
+	for _, key := range preservedOnReset {
+		if v.IsSet(key) {
+			out.Set(key, v.Get(key))
+		}
+	}
+
+	if err := writeConfigFile(out, configPath); err != nil {
+		return err
+	}
+	InvalidateCache()
+	return nil
+}
+
+// openConfigFile reads the config file, if there is one, into a fresh viper
+// instance without defaults or environment overrides, so that writing it
+// back changes only what the caller sets. It refuses a locked config.
+func openConfigFile() (*viper.Viper, string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to determine config path: %w", err)
+	}
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to determine config directory: %w", err)
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return nil, "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if _, err := os.Stat(configPath); err == nil {
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, "", fmt.Errorf("failed to read config: %w", err)
+		}
+		if v.GetBool("locked") {
+			return nil, "", ErrConfigLocked
+		}
+	}
+	return v, configPath, nil
+}
+
+// validateSettings checks that v, layered over the defaults, is a valid config.
+func validateSettings(v *viper.Viper) error {
+	cfg := DefaultConfig()
+	if err := v.Unmarshal(cfg); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// deleteSetting removes the value at path from the nested settings map,
+// dropping sections left empty. It reports whether the path existed.
+func deleteSetting(settings map[string]any, path []string) bool {
+	if len(path) == 1 {
+		if _, ok := settings[path[0]]; !ok {
+			return false
+		}
+		delete(settings, path[0])
+		return true
+	}
+	child, ok := settings[path[0]].(map[string]any)
+	if !ok || !deleteSetting(child, path[1:]) {
+		return false
+	}
+	if len(child) == 0 {
+		delete(settings, path[0])
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetValue(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(path, []byte("local:\n  model: claude-3-5-haiku-latest\n  anthropic_api_key: ${ANTHROPIC_API_KEY}\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("INTENTRA_SERVER_ENDPOINT", "https://env.example.com")
+
+	for key, value := range map[string]string{
+		"local.model":                  "claude-opus-4.5",
+		"server.timeout":               "45s",
+		"local.keep_buffers":           "true",
+		"server.auth.api_key.hmac_key": "s3cret",
+	} {
+		if err := SetValue(key, value); err != nil {
+			t.Fatalf("SetValue(%s) failed: %v", key, err)
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Local.Model != "claude-opus-4.5" || cfg.Server.Timeout != 45*time.Second || !cfg.Local.KeepBuffers {
+		t.Errorf("values not persisted: model=%q timeout=%s keep_buffers=%v", cfg.Local.Model, cfg.Server.Timeout, cfg.Local.KeepBuffers)
+	}
+	if cfg.Server.Auth.APIKey.HMACKey != "s3cret" {
+		t.Errorf("hmac_key = %q, want s3cret", cfg.Server.Auth.APIKey.HMACKey)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "${ANTHROPIC_API_KEY}") {
+		t.Errorf("unrelated key was rewritten:\n%s", data)
+	}
+	if strings.Contains(string(data), "env.example.com") {
+		t.Errorf("environment override was persisted:\n%s", data)
+	}
+}
+
+func TestSetValue_Rejects(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	defer InvalidateCache()
+
+	tests := []struct {
+		key, value string
+		wantErr    string
+	}{
+		{"server.endpoit", "https://x", "unknown config key"},
+		{"local.pricing", "x", "unknown config key"},
+		{"debug", "yes please", "must be true or false"},
+		{"server.timeout", "30", "must be a duration"},
+		{"server.max_retries", "three", "must be an integer"},
+		{"local.repo_host", "masked", "unknown local.repo_host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := SetValue(tt.key, tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SetValue(%s, %s) = %v, want %q", tt.key, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+	if err := SetValue("nope", "1"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("error = %v, want ErrUnknownKey", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "config.yaml")); !os.IsNotExist(err) {
+		t.Error("rejected values must not create a config file")
+	}
+}
+
+func TestSetValue_RefusesLocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := "locked: true\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := SetValue("debug", "true"); !errors.Is(err, ErrConfigLocked) {
+		t.Errorf("SetValue error = %v, want ErrConfigLocked", err)
+	}
+}
+
+func TestUnsetValue(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := "server:\n  endpoint: https://api.example.com\nlocal:\n  model: claude-opus-4.5\n  pricing:\n    custom-model:\n      input_per_1k: 0.002\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if err := UnsetValue("server.endpoint"); err != nil {
+		t.Fatalf("UnsetValue failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "api.example.com") || strings.Contains(string(data), "server:") {
+		t.Errorf("server.endpoint not removed:\n%s", data)
+	}
+	if !strings.Contains(string(data), "claude-opus-4.5") || !strings.Contains(string(data), "custom-model") {
+		t.Errorf("unrelated keys were dropped:\n%s", data)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Server.Endpoint != DefaultConfig().Server.Endpoint {
+		t.Errorf("endpoint = %q, want default", cfg.Server.Endpoint)
+	}
+
+	if err := UnsetValue("server.endpoint"); !errors.Is(err, ErrKeyNotSet) {
+		t.Errorf("second UnsetValue error = %v, want ErrKeyNotSet", err)
+	}
+	if err := UnsetValue("local.pricing"); err != nil {
+		t.Errorf("UnsetValue(local.pricing) failed: %v", err)
+	}
+}
+
+func TestResetConfig_KeepsCredentials(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	InvalidateCache()
+	defer InvalidateCache()
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := "debug: true\nserver:\n  auth:\n    mode: api_key\n    api_key:\n      key_id: key-1\n      secret: hunter2\nlocal:\n  model: claude-opus-4.5\n  custom_note: hello\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if err := ResetConfig(); err != nil {
+		t.Fatalf("ResetConfig failed: %v", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	def := DefaultConfig()
+	if cfg.Debug || cfg.Local.Model != def.Local.Model {
+		t.Errorf("settings not reset: debug=%v model=%q", cfg.Debug, cfg.Local.Model)
+	}
+	if cfg.Server.Auth.Mode != "api_key" || cfg.Server.Auth.APIKey.KeyID != "key-1" || cfg.Server.Auth.APIKey.Secret != "hunter2" {
+		t.Errorf("credentials not preserved: %+v", cfg.Server.Auth)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "custom_note") {
+		t.Errorf("reset kept a non-credential key:\n%s", data)
+	}
+}
+
+func TestSaveConfig_KeepsUnmanagedKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := "local:\n  pricing:\n    custom-model:\n      input_per_1k: 0.002\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := SaveConfig(DefaultConfig()); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "custom-model") {
+		t.Errorf("SaveConfig dropped local.pricing:\n%s", data)
+	}
+
+	if err := os.WriteFile(path, []byte("debug: true\nthis is not yaml\n"), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := SaveConfig(DefaultConfig()); err == nil {
+		t.Error("SaveConfig should refuse to overwrite an unreadable config")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 )
 
@@ -17,7 +18,11 @@ func GetConfigDir() (string, error) {
 
 	switch runtime.GOOS {
 	case "windows":
-		return filepath.Join(os.Getenv("APPDATA"), "intentra"), nil
+		appData, err := WindowsAppData()
+		if err != nil {
+			return "", fmt.Errorf("%w (set INTENTRA_CONFIG_DIR to override)", err)
+		}
+		return filepath.Join(appData, "intentra"), nil
 	default:
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -27,6 +32,32 @@ func GetConfigDir() (string, error) {
 	}
 }
 
+// WindowsAppData returns the roaming application data directory. APPDATA
+// is unset in some service contexts, so it falls back to
+// %USERPROFILE%\AppData\Roaming rather than letting callers build a
+// relative path.
+func WindowsAppData() (string, error) {
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		return appData, nil
+	}
+	if profile := os.Getenv("USERPROFILE"); profile != "" {
+		return filepath.Join(profile, "AppData", "Roaming"), nil
+	}
+	return "", fmt.Errorf("cannot determine application data directory: neither APPDATA nor USERPROFILE is set")
+}
+
+// ExpandHome replaces a leading "~" in path with the user's home directory.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot expand %s: %w", path, err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
 // GetDataDir returns the data directory (same as config for now).
 func GetDataDir() (string, error) {
 	return GetConfigDir()
@@ -50,6 +81,46 @@ func GetScansDir() (string, error) {
 	return filepath.Join(dir, "scans"), nil
 }
 
+// GetConsumedBuffersDir returns the directory where consumed session buffers
+// are kept when local.keep_buffers is enabled.
+func GetConsumedBuffersDir() (string, error) {
+	dir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "consumed"), nil
+}
+
+// GetUnknownEventsFile returns the path to the record of unmapped hook event
+// types kept when local.warn_unknown_events is enabled.
+func GetUnknownEventsFile() (string, error) {
+	dir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "unknown_events.json"), nil
+}
+
+// GetInstallManifestFile returns the path to the record of the hooks
+// intentra has installed into each tool's config.
+func GetInstallManifestFile() (string, error) {
+	dir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "install_manifest.json"), nil
+}
+
+// GetHookLogFile returns the path to the log written by hook processes
+// outside debug mode.
+func GetHookLogFile() (string, error) {
+	dir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hooks.log"), nil
+}
+
 // GetEvidenceDir returns the evidence directory.
 func GetEvidenceDir() (string, error) {
 	dir, err := GetDataDir()
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWindowsAppData(t *testing.T) {
+	t.Setenv("APPDATA", filepath.Join("C:", "Roaming"))
+	t.Setenv("USERPROFILE", filepath.Join("C:", "Users", "dev"))
+	if got, err := WindowsAppData(); err != nil || got != filepath.Join("C:", "Roaming") {
+		t.Errorf("WindowsAppData() = %q, %v; want APPDATA", got, err)
+	}
+
+	t.Setenv("APPDATA", "")
+	want := filepath.Join("C:", "Users", "dev", "AppData", "Roaming")
+	if got, err := WindowsAppData(); err != nil || got != want {
+		t.Errorf("WindowsAppData() without APPDATA = %q, %v; want %q", got, err, want)
+	}
+
+	t.Setenv("USERPROFILE", "")
+	if got, err := WindowsAppData(); err == nil {
+		t.Errorf("WindowsAppData() with neither set = %q, want error", got)
+	}
+}
+
+func TestGetConfigDir_WindowsWithoutAppData(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("APPDATA is only used on Windows")
+	}
+	profile := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", "")
+	t.Setenv("APPDATA", "")
+	t.Setenv("USERPROFILE", profile)
+
+	dir, err := GetConfigDir()
+	if err != nil {
+		t.Fatalf("GetConfigDir: %v", err)
+	}
+	if want := filepath.Join(profile, "AppData", "Roaming", "intentra"); dir != want {
+		t.Errorf("GetConfigDir() = %q, want %q", dir, want)
+	}
+
+	t.Setenv("USERPROFILE", "")
+	if _, err := GetConfigDir(); err == nil || !strings.Contains(err.Error(), "INTENTRA_CONFIG_DIR") {
+		t.Errorf("GetConfigDir() error = %v, want hint to set INTENTRA_CONFIG_DIR", err)
+	}
+}
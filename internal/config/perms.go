@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/intentrahq/intentra-cli/internal/debug"
+)
+
+// Modes required for the config directory and the secrets stored in it,
+// in the spirit of ssh's StrictModes.
+const (
+	privateDirMode  os.FileMode = 0700
+	privateFileMode os.FileMode = 0600
+)
+
+// sensitiveFiles are the files in the config directory that may hold
+// credentials or API keys.
+var sensitiveFiles = []string{"config.yaml", "credentials.json", "credentials.enc", ".cache-key"}
+
+// PermissionIssue is a config path that grants more access than Want.
+type PermissionIssue struct {
+	Path string
+	Mode os.FileMode
+	Want os.FileMode
+}
+
+func (p PermissionIssue) String() string {
+	return fmt.Sprintf("%s is %04o, want %04o", p.Path, p.Mode, p.Want)
+}
+
+// CheckPermissions reports the config directory and sensitive files in it
+// that other users could read. Missing files are skipped, and Windows, where
+// mode bits do not reflect ACLs, is never reported.
+func CheckPermissions() ([]PermissionIssue, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+	dir, err := GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []PermissionIssue
+	check := func(path string, want os.FileMode) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to check permissions of %s: %w", path, err)
+		}
+		if mode := info.Mode().Perm(); mode&^want != 0 {
+			issues = append(issues, PermissionIssue{Path: path, Mode: mode, Want: want})
+		}
+		return nil
+	}
+
+	if err := check(dir, privateDirMode); err != nil {
+		return nil, err
+	}
+	for _, name := range sensitiveFiles {
+		if err := check(filepath.Join(dir, name), privateFileMode); err != nil {
+			return nil, err
+		}
+	}
+	return issues, nil
+}
+
+// FixPermissions removes the excess permission bits of each issue, so a
+// path is never made more permissive than it was.
+func FixPermissions(issues []PermissionIssue) error {
+	for _, issue := range issues {
+		if err := os.Chmod(issue.Path, issue.Mode&issue.Want); err != nil {
+			return fmt.Errorf("failed to fix permissions of %s: %w", issue.Path, err)
+		}
+	}
+	return nil
+}
+
+// warnPermissions logs a warning for each loosely permissioned config path,
+// plus the config file actually read when it lives elsewhere. Warnings go
+// through debug.Warn so hook processes send them to the hook log.
+func warnPermissions(cfgPath string) {
+	issues, err := CheckPermissions()
+	if err != nil {
+		return
+	}
+	for _, issue := range issues {
+		debug.Warn("%s has overly permissive permissions %o; run 'intentra doctor --fix-perms' or chmod %o",
+			issue.Path, issue.Mode, issue.Mode&issue.Want)
+	}
+
+	if cfgPath == "" || runtime.GOOS == "windows" {
+		return
+	}
+	if dir, err := GetConfigDir(); err == nil && filepath.Dir(cfgPath) == filepath.Clean(dir) {
+		return
+	}
+	if info, err := os.Stat(cfgPath); err == nil && info.Mode().Perm()&0o077 != 0 {
+		debug.Warn("config file %s has overly permissive permissions %o; consider chmod 600", cfgPath, info.Mode().Perm())
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/debug"
+)
+
+func TestCheckAndFixPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not checked on Windows")
+	}
+	dir := filepath.Join(t.TempDir(), "intentra")
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(dir, 0755)
+	files := map[string]os.FileMode{
+		"config.yaml":      0644,
+		"credentials.json": 0600,
+		".cache-key":       0640,
+		"events.jsonl":     0644, // not sensitive
+	}
+	for name, mode := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(path, mode)
+	}
+
+	issues, err := CheckPermissions()
+	if err != nil {
+		t.Fatalf("CheckPermissions failed: %v", err)
+	}
+	want := map[string]os.FileMode{
+		dir:                               0755,
+		filepath.Join(dir, "config.yaml"): 0644,
+		filepath.Join(dir, ".cache-key"):  0640,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues %v, want %d", len(issues), issues, len(want))
+	}
+	for _, issue := range issues {
+		if mode, ok := want[issue.Path]; !ok || issue.Mode != mode {
+			t.Errorf("unexpected issue %s", issue)
+		}
+	}
+
+	if err := FixPermissions(issues); err != nil {
+		t.Fatalf("FixPermissions failed: %v", err)
+	}
+	if issues, _ := CheckPermissions(); len(issues) != 0 {
+		t.Errorf("issues after fix: %v", issues)
+	}
+	info, _ := os.Stat(filepath.Join(dir, ".cache-key"))
+	if info.Mode().Perm() != 0600 {
+		t.Errorf(".cache-key mode = %o, want 0600", info.Mode().Perm())
+	}
+	info, _ = os.Stat(filepath.Join(dir, "events.jsonl"))
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("events.jsonl mode = %o, want untouched 0644", info.Mode().Perm())
+	}
+}
+
+func TestFixPermissions_NeverLoosens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte("x"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(path, 0444)
+
+	if err := FixPermissions([]PermissionIssue{{Path: path, Mode: 0444, Want: privateFileMode}}); err != nil {
+		t.Fatalf("FixPermissions failed: %v", err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0400 {
+		t.Errorf("mode = %o, want 0400", info.Mode().Perm())
+	}
+}
+
+func TestWarnPermissions_UsesLogOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not checked on Windows")
+	}
+	dir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", dir)
+	os.Chmod(dir, 0755)
+
+	var buf bytes.Buffer
+	prev := debug.SetOutput(&buf)
+	t.Cleanup(func() { debug.SetOutput(prev) })
+
+	warnPermissions("")
+	if !strings.Contains(buf.String(), dir+" has overly permissive permissions") {
+		t.Errorf("log output = %q, want a warning about %s", buf.String(), dir)
+	}
+}
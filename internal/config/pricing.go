@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// LoadPricingFile reads a standalone pricing file: the keys of local.pricing
+// (tool_multipliers, model_prices, confidence_pct) at the top level, or a
+// config file whose local.pricing section is used. The type comes from the
+// file extension, defaulting to YAML. Unknown keys are rejected so typos do
+// not silently fall back to built-in prices. The result is not validated.
+func LoadPricingFile(path string) (PricingConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if filepath.Ext(path) == "" {
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return PricingConfig{}, fmt.Errorf("error reading pricing file: %w", err)
+	}
+	if v.IsSet("local.pricing") {
+		v = v.Sub("local.pricing")
+	}
+
+	var p PricingConfig
+	if err := v.UnmarshalExact(&p); err != nil {
+		return PricingConfig{}, fmt.Errorf("error parsing pricing file: %w", err)
+	}
+	return p, nil
+}
@@ -1,38 +1,122 @@
 // Package debug provides debug logging utilities for the intentra CLI.
-// Debug output is controlled by the debug config option or -d flag.
+// Output is gated by logging.level; the debug config option or -d flag
+// forces the debug level.
 package debug
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"time"
 )
 
-// Enabled controls whether debug logging is active.
+// Enabled controls whether debug mode is active. It forces the debug level.
 var Enabled bool
 
-// Log writes a debug message to stderr if debug mode is enabled.
+// Level is a logging threshold, from most to least verbose.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// level is the threshold set from logging.level.
+var level = LevelWarn
+
+// ParseLevel parses a logging.level value: debug, info, warn, or error.
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelWarn, fmt.Errorf("unknown log level: %s (supported: debug, info, warn, error)", s)
+}
+
+// enabledAt reports whether messages at l should be written.
+func enabledAt(l Level) bool {
+	return Enabled || l >= level
+}
+
+// output is where log lines are written; swapped out in tests.
+var output io.Writer = os.Stderr
+
+// SetOutput redirects log output to w and returns the previous writer.
+// Call Configure afterwards for JSON output to use it.
+func SetOutput(w io.Writer) io.Writer {
+	prev := output
+	output = w
+	return prev
+}
+
+// jsonLogger is set when logging.format is "json"; nil means plain text.
+var jsonLogger *slog.Logger
+
+// Configure sets the threshold from logging.level (unknown values mean warn)
+// and the format from logging.format. "json" writes one JSON object per line
+// with time, level and msg fields, plus method, url and status for HTTP
+// requests; anything else is text.
+func Configure(logLevel, format string) {
+	level, _ = ParseLevel(logLevel)
+	if format == "json" {
+		jsonLogger = slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		return
+	}
+	jsonLogger = nil
+}
+
+// Log writes a debug message to stderr at the debug level.
 func Log(format string, args ...any) {
-	if Enabled {
-		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+	if !enabledAt(LevelDebug) {
+		return
+	}
+	if jsonLogger != nil {
+		jsonLogger.Debug(fmt.Sprintf(format, args...))
+		return
 	}
+	fmt.Fprintf(output, "[DEBUG] "+format+"\n", args...)
 }
 
-// LogHTTP logs an HTTP request with method, URL, and status code.
+// LogHTTP logs an HTTP request with method, URL, and status code at the debug level.
 func LogHTTP(method, url string, statusCode int) {
-	if Enabled {
-		ts := time.Now().UTC().Format(time.RFC3339)
+	if !enabledAt(LevelDebug) {
+		return
+	}
+	if jsonLogger != nil {
+		lvl, msg := slog.LevelDebug, "http request"
 		if statusCode == 0 {
-			fmt.Fprintf(os.Stderr, "[DEBUG] %s %s %s -> (failed)\n", ts, method, url)
-		} else {
-			fmt.Fprintf(os.Stderr, "[DEBUG] %s %s %s -> %d\n", ts, method, url, statusCode)
+			lvl, msg = slog.LevelWarn, "http request failed"
 		}
+		jsonLogger.Log(context.Background(), lvl, msg,
+			slog.String("method", method), slog.String("url", url), slog.Int("status", statusCode))
+		return
+	}
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if statusCode == 0 {
+		fmt.Fprintf(output, "[DEBUG] %s %s %s -> (failed)\n", ts, method, url)
+	} else {
+		fmt.Fprintf(output, "[DEBUG] %s %s %s -> %d\n", ts, method, url, statusCode)
 	}
 }
 
-// Warn logs a warning message to stderr if debug mode is enabled.
+// Warn logs a warning message to stderr at the warn level and above.
 func Warn(format string, args ...any) {
-	if Enabled {
-		fmt.Fprintf(os.Stderr, "[DEBUG] WARN: "+format+"\n", args...)
+	if !enabledAt(LevelWarn) {
+		return
+	}
+	if jsonLogger != nil {
+		jsonLogger.Warn(fmt.Sprintf(format, args...))
+		return
 	}
+	fmt.Fprintf(output, "[WARN] "+format+"\n", args...)
 }
@@ -1,6 +1,9 @@
 package debug
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +41,110 @@ func TestWarn_WhenEnabled(t *testing.T) {
 	defer func() { Enabled = false }()
 	Warn("warning message: %d", 456)
 }
+
+// captureOutput redirects log output to a buffer and enables logging in the given format.
+func captureOutput(t *testing.T, format string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	origOutput := output
+	output = &buf
+	Enabled = true
+	Configure("warn", format)
+	t.Cleanup(func() {
+		output = origOutput
+		Enabled = false
+		Configure("warn", "text")
+	})
+	return &buf
+}
+
+func TestConfigure_JSON(t *testing.T) {
+	buf := captureOutput(t, "json")
+
+	Log("loaded %d scans", 3)
+	Warn("disk %s", "full")
+	LogHTTP("POST", "https://api.example.com/scans", 201)
+	LogHTTP("GET", "https://api.example.com/health", 0)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4:\n%s", len(lines), buf.String())
+	}
+	var entries []map[string]any
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line is not JSON: %q", line)
+		}
+		if entry["time"] == nil {
+			t.Errorf("missing time field: %q", line)
+		}
+		entries = append(entries, entry)
+	}
+
+	if entries[0]["level"] != "DEBUG" || entries[0]["msg"] != "loaded 3 scans" {
+		t.Errorf("Log entry = %v", entries[0])
+	}
+	if entries[1]["level"] != "WARN" || entries[1]["msg"] != "disk full" {
+		t.Errorf("Warn entry = %v", entries[1])
+	}
+	if entries[2]["method"] != "POST" || entries[2]["url"] != "https://api.example.com/scans" || entries[2]["status"] != float64(201) {
+		t.Errorf("LogHTTP entry = %v", entries[2])
+	}
+	if entries[3]["level"] != "WARN" || entries[3]["status"] != float64(0) {
+		t.Errorf("failed LogHTTP entry = %v", entries[3])
+	}
+}
+
+func TestConfigure_Text(t *testing.T) {
+	buf := captureOutput(t, "text")
+
+	Warn("disk %s", "full")
+	LogHTTP("GET", "https://api.example.com/health", 0)
+
+	out := buf.String()
+	if !strings.Contains(out, "[WARN] disk full\n") || !strings.Contains(out, "GET https://api.example.com/health -> (failed)") {
+		t.Errorf("unexpected text output:\n%s", out)
+	}
+}
+
+func TestConfigure_Level(t *testing.T) {
+	tests := []struct {
+		level    string
+		debug    bool
+		wantLog  bool
+		wantWarn bool
+	}{
+		{"debug", false, true, true},
+		{"info", false, false, true},
+		{"warn", false, false, true},
+		{"error", false, false, false},
+		{"error", true, true, true}, // debug mode forces the debug level
+	}
+	for _, tt := range tests {
+		buf := captureOutput(t, "text")
+		Configure(tt.level, "text")
+		Enabled = tt.debug
+
+		Log("debug line")
+		LogHTTP("GET", "https://api.example.com/health", 200)
+		Warn("warn line")
+
+		out := buf.String()
+		if got := strings.Contains(out, "debug line") && strings.Contains(out, "-> 200"); got != tt.wantLog {
+			t.Errorf("level %s (debug=%v): debug output = %v, want %v", tt.level, tt.debug, got, tt.wantLog)
+		}
+		if got := strings.Contains(out, "warn line"); got != tt.wantWarn {
+			t.Errorf("level %s (debug=%v): warn output = %v, want %v", tt.level, tt.debug, got, tt.wantWarn)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel(""); err != nil || l != LevelWarn {
+		t.Errorf("ParseLevel(\"\") = %v, %v; want warn", l, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
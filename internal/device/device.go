@@ -15,6 +15,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/debug"
 )
 
 const (
@@ -27,8 +29,22 @@ var (
 	deviceIDMu     sync.Mutex
 )
 
+// configuredDeviceID is server.auth.hmac.device_id, used when neither
+// environment override is set. Replaced by SetConfiguredID.
+var configuredDeviceID string
+
+// Environment variables that override the hardware-derived device ID.
+// INTENTRA_DEVICE_ID takes precedence over INTENTRA_DEVICE_ID_FILE.
+const (
+	envDeviceID     = "INTENTRA_DEVICE_ID"
+	envDeviceIDFile = "INTENTRA_DEVICE_ID_FILE"
+)
+
 // GetDeviceID returns an HMAC-immutable device identifier.
 // The ID is deterministic based on hardware identifiers but cannot be reversed.
+// An override from INTENTRA_DEVICE_ID, INTENTRA_DEVICE_ID_FILE, or config
+// (see SetConfiguredID) is used as-is, in that order.
+// The result is cached for the life of the process; call ResetCache to re-resolve.
 // On failure, subsequent calls will retry instead of caching the error permanently.
 func GetDeviceID() (string, error) {
 	deviceIDMu.Lock()
@@ -38,7 +54,7 @@ func GetDeviceID() (string, error) {
 		return cachedDeviceID, nil
 	}
 
-	id, err := generateDeviceID()
+	id, err := resolveDeviceID()
 	if err != nil {
 		return "", err
 	}
@@ -46,11 +62,60 @@ func GetDeviceID() (string, error) {
 	return cachedDeviceID, nil
 }
 
-// generateDeviceID creates an HMAC-based immutable device ID.
+// ResetCache clears the cached device ID so the next GetDeviceID call
+// re-checks overrides and hardware identity. Intended for long-running
+// processes that need to pick up an identity change without restarting.
+func ResetCache() {
+	deviceIDMu.Lock()
+	defer deviceIDMu.Unlock()
+	cachedDeviceID = ""
+}
+
+// SetConfiguredID installs the device ID pinned in config. Call it once after
+// loading config; an empty id falls back to the hardware-derived ID. The
+// cache is cleared when the ID changes.
+func SetConfiguredID(id string) {
+	deviceIDMu.Lock()
+	defer deviceIDMu.Unlock()
+	id = strings.TrimSpace(id)
+	if id != configuredDeviceID {
+		configuredDeviceID = id
+		cachedDeviceID = ""
+	}
+}
+
+// resolveDeviceID returns the override device ID if configured, otherwise
+// the hardware-derived ID. Callers hold deviceIDMu.
+func resolveDeviceID() (string, error) {
+	if id := strings.TrimSpace(os.Getenv(envDeviceID)); id != "" {
+		return id, nil
+	}
+	if path := os.Getenv(envDeviceIDFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", envDeviceIDFile, err)
+		}
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id, nil
+		}
+		return "", fmt.Errorf("%s %s is empty", envDeviceIDFile, path)
+	}
+	if configuredDeviceID != "" {
+		return configuredDeviceID, nil
+	}
+	return generateDeviceID()
+}
+
+// generateDeviceID creates an HMAC-based immutable device ID. When ioreg or
+// reg hangs or is unavailable it falls back to getFallbackID, so a
+// restricted machine still gets a (less stable) device ID.
 func generateDeviceID() (string, error) {
-	hwID, err := getHardwareID()
+	hwID, err := getDeviceHardwareID()
 	if err != nil {
-		return "", fmt.Errorf("failed to get hardware ID: %w", err)
+		debug.Log("device: %v; using hostname-based device ID", err)
+		if hwID, err = getFallbackID(); err != nil {
+			return "", fmt.Errorf("failed to get hardware ID: %w", err)
+		}
 	}
 
 	// Create HMAC-SHA256 of hardware ID with salt
@@ -69,8 +134,35 @@ func generateDeviceID() (string, error) {
 // GetRawHardwareID retrieves the raw platform-specific hardware identifier.
 // Exported so that other packages (e.g. auth/encryption) can derive keys
 // from hardware identity without duplicating platform detection logic.
+// Unlike GetDeviceID it never falls back to the hostname-based ID, since a
+// transient lookup failure must not silently change derived keys.
 func GetRawHardwareID() (string, error) {
-	return getHardwareID()
+	return lookupHardwareID()
+}
+
+// lookupHardwareID is getHardwareID; swapped out in tests.
+var lookupHardwareID = getHardwareID
+
+// GetLegacyHardwareID returns the hardware identifier used by releases that
+// read the bare Linux machine ID, so data they encrypted can still be read.
+// ok is false when it is the same as GetRawHardwareID, as on every other
+// platform.
+func GetLegacyHardwareID() (id string, ok bool) {
+	if runtime.GOOS != "linux" {
+		return "", false
+	}
+	return readLinuxMachineID()
+}
+
+// getDeviceHardwareID returns the identifier hashed into the device ID. On
+// Linux that is the bare machine ID, as in earlier releases, so existing
+// installs keep their device ID; the HMAC in generateDeviceID already keeps
+// it from being revealed. Key derivation uses GetRawHardwareID instead.
+func getDeviceHardwareID() (string, error) {
+	if id, ok := GetLegacyHardwareID(); ok {
+		return id, nil
+	}
+	return lookupHardwareID()
 }
 
 // getHardwareID retrieves the hardware-specific identifier.
@@ -87,12 +179,20 @@ func getHardwareID() (string, error) {
 	}
 }
 
+// Commands queried for the hardware identifier on macOS and Windows, and how
+// long each may run; swapped out in tests.
+var (
+	ioregCommand      = "ioreg"
+	regCommand        = "reg"
+	hardwareIDTimeout = 5 * time.Second
+)
+
 // getMacOSHardwareUUID gets the hardware UUID on macOS.
 func getMacOSHardwareUUID() (string, error) {
 	// Use ioreg to get Hardware UUID
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), hardwareIDTimeout)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, "ioreg", "-rd1", "-c", "IOPlatformExpertDevice")
+	cmd := exec.CommandContext(ctx, ioregCommand, "-rd1", "-c", "IOPlatformExpertDevice")
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("ioreg failed: %w", err)
@@ -115,31 +215,69 @@ func getMacOSHardwareUUID() (string, error) {
 	return "", fmt.Errorf("IOPlatformUUID not found")
 }
 
-// getLinuxMachineID gets the machine ID on Linux.
+// linuxMachineIDPaths are read in order for the Linux machine ID; swapped
+// out in tests.
+var linuxMachineIDPaths = []string{
+	"/etc/machine-id", // systemd
+	"/var/lib/dbus/machine-id",
+}
+
+// linuxAppID identifies intentra when deriving an app-specific machine ID.
+// Changing it changes every key derived on Linux.
+var linuxAppID = [16]byte{
+	0xfe, 0xa8, 0x5c, 0x74, 0x7a, 0x59, 0x48, 0x41,
+	0xa7, 0x2b, 0x31, 0x0e, 0x3b, 0xb4, 0x53, 0xab,
+}
+
+// getLinuxMachineID gets an intentra-specific ID derived from the Linux
+// machine ID. systemd treats /etc/machine-id as confidential and asks
+// applications not to use it directly, so it is never returned as-is.
 func getLinuxMachineID() (string, error) {
-	// Try /etc/machine-id first (systemd)
-	paths := []string{
-		"/etc/machine-id",
-		"/var/lib/dbus/machine-id",
+	machineID, ok := readLinuxMachineID()
+	if !ok {
+		// Fallback to hostname-based ID
+		return getFallbackID()
 	}
+	return appSpecificMachineID(machineID, linuxAppID), nil
+}
 
-	for _, path := range paths {
+// readLinuxMachineID returns the bare Linux machine ID, if one is readable.
+func readLinuxMachineID() (string, bool) {
+	for _, path := range linuxMachineIDPaths {
 		data, err := os.ReadFile(path)
 		if err == nil {
-			return strings.TrimSpace(string(data)), nil
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id, true
+			}
 		}
 	}
+	return "", false
+}
 
-	// Fallback to hostname-based ID
-	return getFallbackID()
+// appSpecificMachineID derives an application-specific ID from a machine
+// ID the way sd_id128_get_machine_app_specific does: HMAC-SHA256 keyed by
+// the 128-bit machine ID over the app ID, truncated to 128 bits and marked
+// as a version 4 UUID. Machine IDs that are not 32 hex digits are used as
+// the key verbatim.
+func appSpecificMachineID(machineID string, appID [16]byte) string {
+	key, err := hex.DecodeString(machineID)
+	if err != nil || len(key) != 16 {
+		key = []byte(machineID)
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(appID[:])
+	id := h.Sum(nil)[:16]
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return hex.EncodeToString(id)
 }
 
 // getWindowsMachineGUID gets the machine GUID on Windows.
 func getWindowsMachineGUID() (string, error) {
 	// Query registry for MachineGuid
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), hardwareIDTimeout)
 	defer cancel()
-	cmd := exec.CommandContext(ctx, "reg", "query",
+	cmd := exec.CommandContext(ctx, regCommand, "query",
 		"HKLM\\SOFTWARE\\Microsoft\\Cryptography",
 		"/v", "MachineGuid")
 	output, err := cmd.Output()
@@ -163,10 +301,12 @@ func getWindowsMachineGUID() (string, error) {
 
 // DeviceMetadata contains device information for API requests.
 type DeviceMetadata struct {
-	Hostname        string `json:"hostname,omitempty"`
-	Username        string `json:"username,omitempty"`
-	Platform        string `json:"platform,omitempty"`
-	OSVersion       string `json:"os_version,omitempty"`
+	Hostname       string `json:"hostname,omitempty"`
+	Username       string `json:"username,omitempty"`
+	Platform       string `json:"platform,omitempty"`
+	OSVersion      string `json:"os_version,omitempty"`
+	HostApp        string `json:"host_app,omitempty"`         // TERM_PROGRAM of the launching terminal or IDE
+	HostAppVersion string `json:"host_app_version,omitempty"` // TERM_PROGRAM_VERSION
 }
 
 // GetMetadata returns device metadata for API requests.
@@ -181,10 +321,12 @@ func GetMetadata() DeviceMetadata {
 	osVersion := getOSVersion()
 
 	return DeviceMetadata{
-		Hostname:  hostname,
-		Username:  username,
-		Platform:  runtime.GOOS,
-		OSVersion: osVersion,
+		Hostname:       hostname,
+		Username:       username,
+		Platform:       runtime.GOOS,
+		OSVersion:      osVersion,
+		HostApp:        os.Getenv("TERM_PROGRAM"),
+		HostAppVersion: os.Getenv("TERM_PROGRAM_VERSION"),
 	}
 }
 
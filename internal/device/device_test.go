@@ -1,7 +1,14 @@
 package device
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestGetDeviceID(t *testing.T) {
@@ -50,3 +57,234 @@ func TestGetFallbackID(t *testing.T) {
 		t.Error("Fallback ID should not be empty")
 	}
 }
+
+func TestHardwareIDCommands_ErrorOnFailure(t *testing.T) {
+	origIoreg, origReg := ioregCommand, regCommand
+	t.Cleanup(func() { ioregCommand, regCommand = origIoreg, origReg })
+
+	missing := filepath.Join(t.TempDir(), "does-not-exist")
+	ioregCommand, regCommand = missing, missing
+
+	for name, get := range map[string]func() (string, error){
+		"macOS":   getMacOSHardwareUUID,
+		"Windows": getWindowsMachineGUID,
+	} {
+		if id, err := get(); err == nil {
+			t.Errorf("%s: id = %q, want an error", name, id)
+		}
+	}
+}
+
+func TestHardwareIDCommands_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	origIoreg, origTimeout := ioregCommand, hardwareIDTimeout
+	t.Cleanup(func() { ioregCommand, hardwareIDTimeout = origIoreg, origTimeout })
+
+	script := filepath.Join(t.TempDir(), "ioreg")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 10\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ioregCommand, hardwareIDTimeout = script, 50*time.Millisecond
+
+	start := time.Now()
+	if _, err := getMacOSHardwareUUID(); err == nil {
+		t.Error("expected an error from a hung ioreg")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("lookup took %v, timeout not applied", elapsed)
+	}
+}
+
+func TestGenerateDeviceID_FallsBackOnlyForDeviceID(t *testing.T) {
+	origIoreg, origLookup, origPaths := ioregCommand, lookupHardwareID, linuxMachineIDPaths
+	t.Cleanup(func() { ioregCommand, lookupHardwareID, linuxMachineIDPaths = origIoreg, origLookup, origPaths })
+	linuxMachineIDPaths = []string{filepath.Join(t.TempDir(), "missing")}
+
+	lookupHardwareID = getFallbackID
+	want, err := generateDeviceID()
+	if err != nil {
+		t.Fatalf("generateDeviceID: %v", err)
+	}
+
+	ioregCommand = filepath.Join(t.TempDir(), "does-not-exist")
+	lookupHardwareID = getMacOSHardwareUUID
+	if id, err := generateDeviceID(); err != nil || id != want {
+		t.Errorf("generateDeviceID = %q, %v; want the hostname-based ID %q", id, err, want)
+	}
+	if id, err := GetRawHardwareID(); err == nil {
+		t.Errorf("GetRawHardwareID = %q, want an error so key derivation does not switch keys", id)
+	}
+}
+
+func TestGenerateDeviceID_LinuxKeepsLegacyInput(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the legacy machine ID input applies only on Linux")
+	}
+	path := filepath.Join(t.TempDir(), "machine-id")
+	const machineID = "0123456789abcdef0123456789abcdef"
+	if err := os.WriteFile(path, []byte(machineID+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write machine-id: %v", err)
+	}
+	orig := linuxMachineIDPaths
+	linuxMachineIDPaths = []string{path}
+	t.Cleanup(func() { linuxMachineIDPaths = orig })
+
+	h := hmac.New(sha256.New, []byte(deviceIDSalt))
+	h.Write([]byte(machineID))
+	want := hex.EncodeToString(h.Sum(nil))[:32]
+
+	if id, err := generateDeviceID(); err != nil || id != want {
+		t.Errorf("generateDeviceID = %q, %v; want the ID earlier releases derived, %q", id, err, want)
+	}
+	if raw, err := GetRawHardwareID(); err != nil || raw == machineID {
+		t.Errorf("GetRawHardwareID = %q, %v; want the app-specific ID for key derivation", raw, err)
+	}
+}
+
+func TestResetCache_ReresolvesEnvOverride(t *testing.T) {
+	ResetCache()
+	t.Cleanup(ResetCache)
+
+	t.Setenv("INTENTRA_DEVICE_ID", "device-one")
+	id, err := GetDeviceID()
+	if err != nil {
+		t.Fatalf("GetDeviceID failed: %v", err)
+	}
+	if id != "device-one" {
+		t.Fatalf("expected override device-one, got %s", id)
+	}
+
+	t.Setenv("INTENTRA_DEVICE_ID", "device-two")
+	if id, _ := GetDeviceID(); id != "device-one" {
+		t.Errorf("expected cached device-one before reset, got %s", id)
+	}
+
+	ResetCache()
+	if id, _ := GetDeviceID(); id != "device-two" {
+		t.Errorf("expected device-two after reset, got %s", id)
+	}
+}
+
+func TestResetCache_ReresolvesFileOverride(t *testing.T) {
+	ResetCache()
+	t.Cleanup(ResetCache)
+
+	path := filepath.Join(t.TempDir(), "device_id")
+	if err := os.WriteFile(path, []byte("file-device-a\n"), 0600); err != nil {
+		t.Fatalf("failed to write override file: %v", err)
+	}
+	t.Setenv("INTENTRA_DEVICE_ID", "")
+	t.Setenv("INTENTRA_DEVICE_ID_FILE", path)
+
+	if id, _ := GetDeviceID(); id != "file-device-a" {
+		t.Fatalf("expected file-device-a, got %s", id)
+	}
+
+	if err := os.WriteFile(path, []byte("file-device-b"), 0600); err != nil {
+		t.Fatalf("failed to rewrite override file: %v", err)
+	}
+	ResetCache()
+	if id, _ := GetDeviceID(); id != "file-device-b" {
+		t.Errorf("expected file-device-b after reset, got %s", id)
+	}
+
+	t.Setenv("INTENTRA_DEVICE_ID_FILE", "")
+	ResetCache()
+	id, err := GetDeviceID()
+	if err != nil {
+		t.Fatalf("GetDeviceID failed: %v", err)
+	}
+	if len(id) != 32 {
+		t.Errorf("expected hardware-derived ID after clearing overrides, got %s", id)
+	}
+}
+
+func TestSetConfiguredID_Precedence(t *testing.T) {
+	ResetCache()
+	t.Cleanup(func() {
+		SetConfiguredID("")
+		ResetCache()
+	})
+	t.Setenv("INTENTRA_DEVICE_ID", "")
+	t.Setenv("INTENTRA_DEVICE_ID_FILE", "")
+
+	SetConfiguredID("pinned-device")
+	if id, _ := GetDeviceID(); id != "pinned-device" {
+		t.Fatalf("expected configured pinned-device, got %s", id)
+	}
+
+	t.Setenv("INTENTRA_DEVICE_ID", "env-device")
+	ResetCache()
+	if id, _ := GetDeviceID(); id != "env-device" {
+		t.Errorf("expected env override to win over config, got %s", id)
+	}
+
+	t.Setenv("INTENTRA_DEVICE_ID", "")
+	SetConfiguredID("other-device")
+	if id, _ := GetDeviceID(); id != "other-device" {
+		t.Errorf("expected changing the configured ID to clear the cache, got %s", id)
+	}
+
+	SetConfiguredID("")
+	id, err := GetDeviceID()
+	if err != nil {
+		t.Fatalf("GetDeviceID failed: %v", err)
+	}
+	if len(id) != 32 {
+		t.Errorf("expected hardware-derived ID without a configured ID, got %s", id)
+	}
+}
+
+func TestAppSpecificMachineID(t *testing.T) {
+	// Matches `systemd-id128 machine-id --app-specific=fea85c747a594841a72b310e3bb453ab`
+	// on a machine whose /etc/machine-id is 0123456789abcdef0123456789abcdef.
+	const machineID = "0123456789abcdef0123456789abcdef"
+	if got := appSpecificMachineID(machineID, linuxAppID); got != "a3ca9f81f8444b1a8aef7080cd5bd90e" {
+		t.Errorf("appSpecificMachineID = %s", got)
+	}
+	other := linuxAppID
+	other[0] ^= 0xff
+	if appSpecificMachineID(machineID, other) == appSpecificMachineID(machineID, linuxAppID) {
+		t.Error("different app IDs should derive different IDs")
+	}
+	if got := appSpecificMachineID("not-hex", linuxAppID); len(got) != 32 {
+		t.Errorf("non-hex machine ID should still derive a 128-bit ID, got %s", got)
+	}
+}
+
+func TestGetLinuxMachineID_NeverBare(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "machine-id")
+	const machineID = "0123456789abcdef0123456789abcdef"
+	if err := os.WriteFile(path, []byte(machineID+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write machine-id: %v", err)
+	}
+	orig := linuxMachineIDPaths
+	linuxMachineIDPaths = []string{filepath.Join(dir, "missing"), path}
+	t.Cleanup(func() { linuxMachineIDPaths = orig })
+
+	id1, err := getLinuxMachineID()
+	if err != nil {
+		t.Fatalf("getLinuxMachineID failed: %v", err)
+	}
+	id2, _ := getLinuxMachineID()
+	if id1 != id2 {
+		t.Errorf("not deterministic: %s != %s", id1, id2)
+	}
+	if id1 == machineID {
+		t.Error("getLinuxMachineID returned the bare machine ID")
+	}
+	if id1 != appSpecificMachineID(machineID, linuxAppID) {
+		t.Errorf("getLinuxMachineID = %s, want the app-specific ID", id1)
+	}
+	if bare, ok := readLinuxMachineID(); !ok || bare != machineID {
+		t.Errorf("readLinuxMachineID = %q, %v", bare, ok)
+	}
+
+	linuxMachineIDPaths = []string{filepath.Join(dir, "missing")}
+	if id, err := getLinuxMachineID(); err != nil || id == "" {
+		t.Errorf("expected hostname fallback without a machine ID, got %q, %v", id, err)
+	}
+}
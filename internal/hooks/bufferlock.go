@@ -0,0 +1,43 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Hook processes hold the buffer lock only for a single append or rename, so
+// a lock older than bufferLockStaleAge was left behind by a crashed process.
+const (
+	bufferLockTimeout      = 2 * time.Second
+	bufferLockStaleAge     = 10 * time.Second
+	bufferLockPollInterval = 5 * time.Millisecond
+)
+
+// acquireBufferLock takes an exclusive lock on the buffer at bufferPath by
+// creating a sibling ".lock" file, in the same way acquireCredentialLock
+// guards the credentials file. The returned function releases the lock.
+func acquireBufferLock(bufferPath string) (func(), error) {
+	lockFile := bufferPath + ".lock"
+	deadline := time.Now().Add(bufferLockTimeout)
+
+	for {
+		file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockFile) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create buffer lock: %w", err)
+		}
+
+		if info, err := os.Stat(lockFile); err == nil && time.Since(info.ModTime()) > bufferLockStaleAge {
+			os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout acquiring buffer lock")
+		}
+		time.Sleep(bufferLockPollInterval)
+	}
+}
@@ -0,0 +1,184 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// Windsurf has no stop hook, so by default every response ends a scan. With
+// local.windsurf_idle_timeout set, a response instead schedules a detached
+// finalizer that waits until the session's buffer has gone unmodified for
+// the timeout and then aggregates everything buffered into one scan. A
+// marker file next to the buffer keeps it to one finalizer per session.
+
+// handleDebouncedStop buffers a Windsurf response and makes sure a finalizer
+// is waiting for the session to go idle. If no finalizer can be started the
+// scan is finalized immediately, as without a timeout.
+func handleDebouncedStop(sessionKey string, event *models.Event, rawMap map[string]any, cfg *config.Config) error {
+	cleanupStaleBuffers()
+
+	if err := appendToBuffer(sessionKey, event, rawMap); err != nil {
+		return fmt.Errorf("failed to buffer event: %w", err)
+	}
+
+	if !claimFinalizer(sessionKey, cfg.Local.WindsurfIdleTimeout) {
+		debug.Log("session %s already has a pending finalizer", sessionKey)
+		return nil
+	}
+
+	payloadPath, err := writeSendPayload("finalize_idle", nil, "", sessionKey, "", 0)
+	if err != nil {
+		debug.Warn("failed to write finalize payload, ending scan now: %v", err)
+		releaseFinalizer(sessionKey)
+		return finalizeSession(sessionKey, string(ToolWindsurf), cfg)
+	}
+	if err := spawnDetachedSend(payloadPath); err != nil {
+		debug.Warn("failed to spawn finalizer, ending scan now: %v", err)
+		os.Remove(payloadPath)
+		releaseFinalizer(sessionKey)
+		return finalizeSession(sessionKey, string(ToolWindsurf), cfg)
+	}
+	return nil
+}
+
+// FinalizeIdleSession waits until the Windsurf session's buffer has been
+// idle for local.windsurf_idle_timeout and then aggregates it into a scan.
+// It runs in the detached process started by handleDebouncedStop.
+func FinalizeIdleSession(sessionKey string, cfg *config.Config) error {
+	idle := cfg.Local.WindsurfIdleTimeout
+	bufferPath := getBufferPath(sessionKey)
+
+	for {
+		info, err := os.Stat(bufferPath)
+		if err != nil {
+			// Already finalized, or cleaned up as stale.
+			releaseFinalizer(sessionKey)
+			return nil
+		}
+		wait := idle - time.Since(info.ModTime())
+		if wait <= 0 {
+			break
+		}
+		now := time.Now()
+		os.Chtimes(finalizerPath(sessionKey), now, now)
+		time.Sleep(wait)
+	}
+
+	// Release before taking the buffer: an event arriving from here on
+	// schedules a new finalizer rather than being left behind.
+	releaseFinalizer(sessionKey)
+	return finalizeSession(sessionKey, string(ToolWindsurf), cfg)
+}
+
+// finalizerPath returns the marker file held while a session has a pending finalizer.
+func finalizerPath(sessionKey string) string {
+	return getBufferPath(sessionKey) + ".finalize"
+}
+
+// claimFinalizer creates the session's finalizer marker and reports whether
+// the caller should start a finalizer.
+func claimFinalizer(sessionKey string, idle time.Duration) bool {
+	return claimMarker(finalizerPath(sessionKey), idle)
+}
+
+// claimMarker creates the marker at path and reports whether the caller
+// should start the detached process it guards. A waiting process touches its
+// marker at least once per period, so one older than twice the period was
+// left by a process that died and is replaced.
+func claimMarker(path string, period time.Duration) bool {
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return true
+		}
+		if !os.IsExist(err) {
+			debug.Warn("failed to create finalizer marker: %v", err)
+			return true
+		}
+		info, err := os.Stat(path)
+		if err == nil && time.Since(info.ModTime()) <= 2*period {
+			return false
+		}
+		os.Remove(path)
+	}
+	return true
+}
+
+func releaseFinalizer(sessionKey string) {
+	os.Remove(finalizerPath(sessionKey))
+}
+
+// A stop that arrives within server.min_sync_interval of the session's last
+// sync leaves its events buffered. So that they are not stranded when no
+// further stop arrives, the deferral schedules a detached flush that sends
+// the buffer once the interval has passed. A marker file next to the buffer
+// keeps it to one pending flush per session.
+
+// deferredFlushPath returns the marker file held while a session has a
+// pending deferred flush.
+func deferredFlushPath(sessionKey string) string {
+	return getBufferPath(sessionKey) + ".deferred"
+}
+
+// scheduleDeferredFlush starts a detached FlushDeferredSession for the
+// session unless one is already waiting.
+func scheduleDeferredFlush(sessionKey, tool string, cfg *config.Config) {
+	if !claimMarker(deferredFlushPath(sessionKey), cfg.Server.MinSyncInterval) {
+		return
+	}
+	payloadPath, err := writePayloadFile(models.SendPayload{Action: "flush_deferred", SessionKey: sessionKey, Tool: tool})
+	if err != nil {
+		debug.Warn("failed to write deferred flush payload: %v", err)
+		os.Remove(deferredFlushPath(sessionKey))
+		return
+	}
+	if err := spawnDetachedSend(payloadPath); err != nil {
+		debug.Warn("failed to spawn deferred flush: %v", err)
+		os.Remove(payloadPath)
+		os.Remove(deferredFlushPath(sessionKey))
+	}
+}
+
+// deferredFlushDue returns when a deferred session's buffer should be sent:
+// once server.min_sync_interval has passed since the last sync, or earlier if
+// the buffer would otherwise be removed as stale first.
+func deferredFlushDue(sessionKey string, bufferModTime time.Time, cfg *config.Config) time.Time {
+	due := GetLastSyncTime(sessionKey).Add(cfg.Server.MinSyncInterval)
+	if expiry := bufferModTime.Add(maxBufferAge - time.Minute); expiry.Before(due) {
+		due = expiry
+	}
+	return due
+}
+
+// FlushDeferredSession waits until the session's deferred sync is due and
+// then sends everything buffered as one scan. It runs in the detached process
+// started by scheduleDeferredFlush.
+func FlushDeferredSession(sessionKey, tool string, cfg *config.Config) error {
+	marker := deferredFlushPath(sessionKey)
+	bufferPath := getBufferPath(sessionKey)
+
+	for {
+		info, err := os.Stat(bufferPath)
+		if err != nil {
+			// Already sent by a later stop, or cleaned up as stale.
+			os.Remove(marker)
+			return nil
+		}
+		wait := time.Until(deferredFlushDue(sessionKey, info.ModTime(), cfg))
+		if wait <= 0 {
+			break
+		}
+		now := time.Now()
+		os.Chtimes(marker, now, now)
+		time.Sleep(wait)
+	}
+
+	os.Remove(marker)
+	return sendBufferedScan(sessionKey, tool, cfg, time.Now(), false)
+}
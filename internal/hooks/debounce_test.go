@@ -0,0 +1,197 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestProcessEvent_WindsurfIdleTimeoutDefersScan(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	key := "windsurf_traj-1"
+
+	cfg := config.DefaultConfig()
+	cfg.Local.WindsurfIdleTimeout = time.Minute
+	// A finalizer is already waiting, so no process is spawned.
+	if !claimFinalizer(key, cfg.Local.WindsurfIdleTimeout) {
+		t.Fatal("claimFinalizer should succeed on a fresh session")
+	}
+
+	for _, eventType := range []string{"pre_user_prompt", "post_cascade_response", "pre_user_prompt", "post_cascade_response"} {
+		input := bytes.NewBufferString(`{"trajectory_id":"traj-1","agent_action_name":"` + eventType + `"}`)
+		if err := ProcessEventWithEvent(input, cfg, "windsurf", eventType); err != nil {
+			t.Fatalf("ProcessEventWithEvent(%s) failed: %v", eventType, err)
+		}
+	}
+
+	events, err := readAndClearBuffer(key)
+	if err != nil {
+		t.Fatalf("readAndClearBuffer failed: %v", err)
+	}
+	if len(events) != 4 {
+		t.Errorf("expected all 4 events still buffered, got %d", len(events))
+	}
+}
+
+func TestClaimFinalizer(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	key := "windsurf_claim"
+	idle := time.Minute
+
+	if !claimFinalizer(key, idle) {
+		t.Fatal("first claim should succeed")
+	}
+	if claimFinalizer(key, idle) {
+		t.Error("second claim should fail while a finalizer is pending")
+	}
+
+	old := time.Now().Add(-3 * idle)
+	if err := os.Chtimes(finalizerPath(key), old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	if !claimFinalizer(key, idle) {
+		t.Error("claim should replace a stale marker")
+	}
+
+	releaseFinalizer(key)
+	if _, err := os.Stat(finalizerPath(key)); !os.IsNotExist(err) {
+		t.Error("releaseFinalizer should remove the marker")
+	}
+}
+
+func TestFinalizeIdleSession_WaitsForIdle(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	key := "windsurf_idle"
+
+	cfg := config.DefaultConfig()
+	cfg.Local.CollectGitMetadata = false
+	cfg.Local.WindsurfIdleTimeout = 50 * time.Millisecond
+
+	for _, raw := range []string{`{"trajectory_id":"idle","prompt":"hi"}`, `{"trajectory_id":"idle","response":"hello"}`} {
+		ev, rawMap, _, err := normalizeHookEvent([]byte(raw), "windsurf", "post_cascade_response")
+		if err != nil {
+			t.Fatalf("normalizeHookEvent failed: %v", err)
+		}
+		if err := appendToBuffer(key, ev, rawMap); err != nil {
+			t.Fatalf("appendToBuffer failed: %v", err)
+		}
+	}
+	claimFinalizer(key, cfg.Local.WindsurfIdleTimeout)
+
+	start := time.Now()
+	if err := FinalizeIdleSession(key, cfg); err != nil {
+		t.Fatalf("FinalizeIdleSession failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("finalized after %s, want to wait for the idle timeout", elapsed)
+	}
+	if _, err := os.Stat(getBufferPath(key)); !os.IsNotExist(err) {
+		t.Error("buffer should be consumed by finalization")
+	}
+	if _, err := os.Stat(finalizerPath(key)); !os.IsNotExist(err) {
+		t.Error("finalizer marker should be released")
+	}
+
+	// A later finalizer for the same session finds nothing to do.
+	claimFinalizer(key, cfg.Local.WindsurfIdleTimeout)
+	if err := FinalizeIdleSession(key, cfg); err != nil {
+		t.Errorf("FinalizeIdleSession on empty session = %v", err)
+	}
+	if _, err := os.Stat(finalizerPath(key)); !os.IsNotExist(err) {
+		t.Error("finalizer marker should be released when there is nothing to finalize")
+	}
+}
+
+func TestFinalizeSession_DeferredSyncSchedulesFlush(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	key := "claude_deferred"
+
+	var actions []string
+	origSpawn := spawnDetachedSend
+	t.Cleanup(func() { spawnDetachedSend = origSpawn })
+	spawnDetachedSend = func(path string) error {
+		defer os.Remove(path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var p models.SendPayload
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		actions = append(actions, p.Action+":"+p.Tool)
+		return nil
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Local.CollectGitMetadata = false
+	cfg.Server.MinSyncInterval = 50 * time.Millisecond
+	SaveLastScanID(key, "scan-1")
+
+	for i := 0; i < 2; i++ {
+		ev, rawMap, _, err := normalizeHookEvent([]byte(`{"session_id":"deferred","prompt":"hi"}`), "claude", "UserPromptSubmit")
+		if err != nil {
+			t.Fatalf("normalizeHookEvent failed: %v", err)
+		}
+		if err := appendToBuffer(key, ev, rawMap); err != nil {
+			t.Fatalf("appendToBuffer failed: %v", err)
+		}
+		if err := finalizeSession(key, "claude", cfg); err != nil {
+			t.Fatalf("finalizeSession failed: %v", err)
+		}
+	}
+	if len(actions) != 1 || actions[0] != "flush_deferred:claude" {
+		t.Fatalf("spawned %v, want one flush_deferred for claude", actions)
+	}
+
+	start := time.Now()
+	if err := FlushDeferredSession(key, "claude", cfg); err != nil {
+		t.Fatalf("FlushDeferredSession failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("flushed after %s, want to wait for server.min_sync_interval", elapsed)
+	}
+	if len(actions) != 2 || actions[1] != "send_scan:" {
+		t.Errorf("spawned %v, want the deferred buffer sent", actions)
+	}
+	if _, err := os.Stat(getBufferPath(key)); !os.IsNotExist(err) {
+		t.Error("buffer should be consumed by the deferred flush")
+	}
+	if _, err := os.Stat(deferredFlushPath(key)); !os.IsNotExist(err) {
+		t.Error("deferred flush marker should be removed")
+	}
+}
+
+func TestDeferredFlushDue_BeforeBufferExpires(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	key := "claude_due"
+	cfg := config.DefaultConfig()
+	cfg.Server.MinSyncInterval = time.Hour
+	SaveLastScanID(key, "scan-1")
+
+	modTime := time.Now()
+	due := deferredFlushDue(key, modTime, cfg)
+	if !due.Before(modTime.Add(maxBufferAge)) {
+		t.Errorf("due %s should come before the buffer goes stale at %s", due, modTime.Add(maxBufferAge))
+	}
+}
+
+func TestApplyConfig_ClampsWindsurfIdleTimeout(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Local.WindsurfIdleTimeout = 45 * time.Minute
+	if err := ApplyConfig(cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+	t.Cleanup(func() { ApplyConfig(config.DefaultConfig()) })
+	if got := cfg.Local.WindsurfIdleTimeout; got != config.MaxWindsurfIdleTimeout {
+		t.Errorf("idle timeout = %s, want it clamped to %s", got, config.MaxWindsurfIdleTimeout)
+	}
+}
@@ -0,0 +1,226 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ToolDiagnosis describes the on-disk state of a tool's hook configuration.
+type ToolDiagnosis struct {
+	Tool        Tool
+	Dir         string
+	DirExists   bool
+	DirWritable bool
+	Installed   bool
+	// HandlerPaths lists the distinct executables referenced by intentra
+	// hook commands, as written in the config (possibly a bare name).
+	HandlerPaths []string
+	Error        error
+}
+
+// Diagnose inspects a tool's hooks directory and config file without
+// modifying them.
+func Diagnose(tool Tool) ToolDiagnosis {
+	d := ToolDiagnosis{Tool: tool}
+
+	d.Installed, d.Dir, d.Error = checkStatus(tool)
+	if d.Dir == "" {
+		return d
+	}
+
+	info, err := os.Stat(d.Dir)
+	if err != nil || !info.IsDir() {
+		return d
+	}
+	d.DirExists = true
+	d.DirWritable = dirWritable(d.Dir)
+
+	if d.Installed {
+		d.HandlerPaths = referencedHandlerPaths(filepath.Join(d.Dir, toolRegistry[tool].checkFile))
+	}
+	return d
+}
+
+// dirWritable reports whether a file can be created in dir.
+func dirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".intentra-doctor-*")
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return true
+}
+
+// referencedHandlerPaths returns the handler executables named by intentra
+// hook commands in the config file at path.
+func referencedHandlerPaths(path string) []string {
+	seen := make(map[string]bool)
+	for _, cmd := range intentraHookCommands(path) {
+		seen[handlerFromCommand(cmd)] = true
+	}
+
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// intentraHookCommands returns the distinct intentra hook commands in the
+// config file at path, sorted.
+func intentraHookCommands(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil
+	}
+
+	// The powershell field only applies on Windows, where bash does not.
+	fields := map[string]bool{"command": true, "bash": true}
+	if runtime.GOOS == "windows" {
+		fields = map[string]bool{"command": true, "powershell": true}
+	}
+
+	seen := make(map[string]bool)
+	var walk func(v any)
+	walk = func(v any) {
+		switch val := v.(type) {
+		case map[string]any:
+			for k, child := range val {
+				if s, ok := child.(string); ok && fields[k] {
+					if handlerFromCommand(s) != "" {
+						seen[s] = true
+					}
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(cfg["hooks"])
+
+	cmds := make([]string, 0, len(seen))
+	for c := range seen {
+		cmds = append(cmds, c)
+	}
+	sort.Strings(cmds)
+	return cmds
+}
+
+// handlerFromCommand extracts the executable from a generated hook command
+// ("<quoted path> hook --tool ..."), undoing quotePathForShell. Returns ""
+// for commands that are not intentra hooks.
+func handlerFromCommand(cmd string) string {
+	idx := strings.Index(cmd, " hook --tool ")
+	if idx <= 0 {
+		return ""
+	}
+	p := cmd[:idx]
+	switch {
+	case len(p) >= 2 && p[0] == '\'' && p[len(p)-1] == '\'':
+		p = strings.ReplaceAll(p[1:len(p)-1], `'"'"'`, "'")
+	case len(p) >= 2 && p[0] == '"' && p[len(p)-1] == '"':
+		p = strings.ReplaceAll(p[1:len(p)-1], `\"`, `"`)
+	}
+	return p
+}
+
+// CurrentExecutable returns the symlink-free path of the running binary.
+func CurrentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate current executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		return resolved, nil
+	}
+	return exe, nil
+}
+
+// ResolveHandler resolves a handler path from a hook command to a
+// symlink-free path. A bare name is looked up on PATH, as the tool's shell
+// would.
+func ResolveHandler(p string) (string, error) {
+	found, err := exec.LookPath(p)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(found); err == nil {
+		return resolved, nil
+	}
+	return found, nil
+}
+
+// HandlerPathStatus reports whether one handler referenced by a tool's hooks
+// runs the expected executable.
+type HandlerPathStatus struct {
+	Tool     Tool
+	Path     string // handler as written in the config
+	Resolved string // resolved executable; empty if it could not be found
+	Stale    bool   // missing, or resolves to a different executable
+}
+
+// VerifyHandlerPaths compares the handler referenced by every installed
+// tool's hooks against os.Executable(). Tools without intentra hooks are
+// skipped.
+func VerifyHandlerPaths() ([]HandlerPathStatus, error) {
+	exe, err := CurrentExecutable()
+	if err != nil {
+		return nil, err
+	}
+	return verifyHandlerPaths(exe, ResolveHandler), nil
+}
+
+func verifyHandlerPaths(exe string, resolve func(string) (string, error)) []HandlerPathStatus {
+	var statuses []HandlerPathStatus
+	for _, tool := range AllTools() {
+		for _, p := range Diagnose(tool).HandlerPaths {
+			st := HandlerPathStatus{Tool: tool, Path: p}
+			if resolved, err := resolve(p); err == nil {
+				st.Resolved = resolved
+			}
+			st.Stale = st.Resolved != exe
+			statuses = append(statuses, st)
+		}
+	}
+	return statuses
+}
+
+// StaleTools returns the distinct tools with at least one stale handler.
+func StaleTools(statuses []HandlerPathStatus) []Tool {
+	var tools []Tool
+	seen := make(map[Tool]bool)
+	for _, st := range statuses {
+		if st.Stale && !seen[st.Tool] {
+			seen[st.Tool] = true
+			tools = append(tools, st.Tool)
+		}
+	}
+	return tools
+}
+
+// RepairHandlerPath picks the handler path to reinstall hooks with: the bare
+// name "intentra" when it resolves to exe on PATH (matching a normal
+// install), otherwise exe itself.
+func RepairHandlerPath(exe string, resolve func(string) (string, error)) string {
+	if resolved, err := resolve("intentra"); err == nil && resolved == exe {
+		return "intentra"
+	}
+	return exe
+}
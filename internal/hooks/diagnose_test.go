@@ -0,0 +1,126 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	d := Diagnose(ToolClaudeCode)
+	if d.DirExists || d.Installed {
+		t.Fatalf("expected missing dir before install, got %+v", d)
+	}
+
+	if err := Install(ToolClaudeCode, "/opt/intentra/bin/intentra"); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	d = Diagnose(ToolClaudeCode)
+	if !d.DirExists || !d.DirWritable || !d.Installed {
+		t.Errorf("expected existing, writable, installed dir, got %+v", d)
+	}
+	if want := []string{"/opt/intentra/bin/intentra"}; !reflect.DeepEqual(d.HandlerPaths, want) {
+		t.Errorf("HandlerPaths = %v, want %v", d.HandlerPaths, want)
+	}
+}
+
+func TestDiagnose_UnwritableDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".gemini")
+	if err := os.MkdirAll(dir, 0500); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	d := Diagnose(ToolGeminiCLI)
+	if !d.DirExists || d.DirWritable {
+		t.Errorf("expected existing but unwritable dir, got %+v", d)
+	}
+}
+
+func TestHandlerFromCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"'intentra' hook --tool claude --event Stop", "intentra"},
+		{`'/home/a b/intentra' hook --tool cursor --event stop`, "/home/a b/intentra"},
+		{`'/it'"'"'s/intentra' hook --tool cursor --event stop`, "/it's/intentra"},
+		{`"C:\intentra\intentra.exe" hook --tool cursor --event stop`, `C:\intentra\intentra.exe`},
+		{"intentra.exe hook --tool copilot --event stop", "intentra.exe"},
+		{"other-guard", ""},
+	}
+	for _, tt := range tests {
+		if got := handlerFromCommand(tt.cmd); got != tt.want {
+			t.Errorf("handlerFromCommand(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyHandlerPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Claude and Gemini nest commands in hooks arrays; Copilot uses bash/powershell.
+	for tool, handler := range map[Tool]string{
+		ToolClaudeCode: "/old/bin/intentra",
+		ToolGeminiCLI:  "intentra",
+		ToolCopilot:    "/old/bin/intentra",
+	} {
+		if err := Install(tool, handler); err != nil {
+			t.Fatalf("Install(%s) failed: %v", tool, err)
+		}
+	}
+
+	exe := "/new/bin/intentra"
+	resolve := func(p string) (string, error) {
+		if p == "intentra" {
+			return exe, nil
+		}
+		return "", os.ErrNotExist
+	}
+
+	statuses := verifyHandlerPaths(exe, resolve)
+	got := make(map[Tool]HandlerPathStatus)
+	for _, st := range statuses {
+		got[st.Tool] = st
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("expected 3 handler statuses, got %+v", statuses)
+	}
+	if st := got[ToolGeminiCLI]; st.Stale || st.Resolved != exe {
+		t.Errorf("gemini: expected current handler, got %+v", st)
+	}
+	for _, tool := range []Tool{ToolClaudeCode, ToolCopilot} {
+		if st := got[tool]; !st.Stale || st.Path != "/old/bin/intentra" {
+			t.Errorf("%s: expected stale /old/bin/intentra, got %+v", tool, st)
+		}
+	}
+
+	stale := StaleTools(statuses)
+	if len(stale) != 2 {
+		t.Errorf("StaleTools = %v, want claude and copilot", stale)
+	}
+}
+
+func TestRepairHandlerPath(t *testing.T) {
+	exe := "/new/bin/intentra"
+	onPath := func(string) (string, error) { return exe, nil }
+	elsewhere := func(string) (string, error) { return "/other/intentra", nil }
+
+	if got := RepairHandlerPath(exe, onPath); got != "intentra" {
+		t.Errorf("RepairHandlerPath (on PATH) = %q, want intentra", got)
+	}
+	if got := RepairHandlerPath(exe, elsewhere); got != exe {
+		t.Errorf("RepairHandlerPath (not on PATH) = %q, want %q", got, exe)
+	}
+}
@@ -11,12 +11,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,13 +29,18 @@ import (
 	"github.com/intentrahq/intentra-cli/internal/config"
 	"github.com/intentrahq/intentra-cli/internal/debug"
 	"github.com/intentrahq/intentra-cli/internal/device"
+	"github.com/intentrahq/intentra-cli/internal/httputil"
 	"github.com/intentrahq/intentra-cli/internal/queue"
 	"github.com/intentrahq/intentra-cli/internal/scanner"
 	"github.com/intentrahq/intentra-cli/pkg/models"
 )
 
 const (
-	maxBufferAge = 30 * time.Minute
+	maxBufferAge = config.StaleBufferAge
+
+	// Retention for buffers kept by local.keep_buffers.
+	maxKeptBuffers   = 50
+	maxKeptBufferAge = 7 * 24 * time.Hour
 )
 
 const cleanupMarkerFile = "intentra_cleanup_marker"
@@ -43,35 +50,100 @@ type bufferedEvent struct {
 	RawEvent map[string]any `json:"raw_event"`
 }
 
+// bufferDir holds per-session buffer and state files. Empty means os.TempDir().
+var bufferDir string
+
+// ConfigureBufferDir places per-session buffers alongside buffer.path (with
+// "~" expanded) when buffering is enabled, so they are private to the user
+// and survive temp cleanup. Otherwise, or if the directory cannot be created,
+// the system temp directory is used. Call it once after loading config.
+func ConfigureBufferDir(cfg *config.Config) {
+	bufferDir = ""
+	if cfg == nil || !cfg.Buffer.Enabled || cfg.Buffer.Path == "" {
+		return
+	}
+	path, err := config.ExpandHome(cfg.Buffer.Path)
+	if err != nil {
+		debug.Warn("buffer path unavailable, using temp dir: %v", err)
+		return
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		debug.Warn("failed to create buffer dir %s, using temp dir: %v", dir, err)
+		return
+	}
+	bufferDir = dir
+}
+
+// sessionFileDir returns the directory for per-session buffer and state files.
+func sessionFileDir() string {
+	if bufferDir != "" {
+		return bufferDir
+	}
+	return os.TempDir()
+}
+
 func getBufferPath(sessionKey string) string {
 	hash := sha256.Sum256([]byte(sessionKey))
 	filename := "intentra_buffer_" + hex.EncodeToString(hash[:8]) + ".jsonl"
-	return filepath.Join(os.TempDir(), filename)
+	return filepath.Join(sessionFileDir(), filename)
 }
 
 // GetLastScanPath returns the path to the file storing the last scan ID for a session.
 func GetLastScanPath(sessionKey string) string {
 	hash := sha256.Sum256([]byte(sessionKey))
 	filename := "intentra_lastscan_" + hex.EncodeToString(hash[:8]) + ".txt"
-	return filepath.Join(os.TempDir(), filename)
+	return filepath.Join(sessionFileDir(), filename)
 }
 
-// SaveLastScanID persists the scan ID for the given session key.
+// SaveLastScanID persists the scan ID for the given session key and records
+// the current time as the session's last sync.
 func SaveLastScanID(sessionKey, scanID string) {
-	path := GetLastScanPath(sessionKey)
-	if err := os.WriteFile(path, []byte(scanID), 0600); err != nil {
-		debug.Log("failed to write scan ID file: %v", err)
-	}
+	writeLastScanFile(sessionKey, scanID, time.Now())
 }
 
 // GetLastScanID retrieves the last persisted scan ID for the given session key.
 func GetLastScanID(sessionKey string) string {
-	path := GetLastScanPath(sessionKey)
-	data, err := os.ReadFile(path)
+	id, _ := readLastScanFile(sessionKey)
+	return id
+}
+
+// GetLastSyncTime returns when the given session last synced, or the zero
+// time if unknown.
+func GetLastSyncTime(sessionKey string) time.Time {
+	_, syncedAt := readLastScanFile(sessionKey)
+	return syncedAt
+}
+
+// The last-scan file holds the scan ID on the first line and, optionally, the
+// RFC 3339 time of the session's last sync on the second.
+func readLastScanFile(sessionKey string) (string, time.Time) {
+	data, err := os.ReadFile(GetLastScanPath(sessionKey))
 	if err != nil {
-		return ""
+		return "", time.Time{}
+	}
+	id, ts, _ := strings.Cut(string(data), "\n")
+	syncedAt, _ := time.Parse(time.RFC3339Nano, strings.TrimSpace(ts))
+	return strings.TrimSpace(id), syncedAt
+}
+
+func writeLastScanFile(sessionKey, scanID string, syncedAt time.Time) {
+	path := GetLastScanPath(sessionKey)
+	content := scanID + "\n" + syncedAt.UTC().Format(time.RFC3339Nano)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		debug.Log("failed to write scan ID file: %v", err)
 	}
-	return strings.TrimSpace(string(data))
+}
+
+// shouldDeferSync reports whether the session synced less than
+// server.min_sync_interval ago, in which case the stop event's events stay
+// buffered and are coalesced into the next sync.
+func shouldDeferSync(sessionKey string, cfg *config.Config, now time.Time) bool {
+	if cfg == nil || cfg.Server.MinSyncInterval <= 0 {
+		return false
+	}
+	last := GetLastSyncTime(sessionKey)
+	return !last.IsZero() && now.Sub(last) < cfg.Server.MinSyncInterval
 }
 
 // ClearLastScanID removes the persisted scan ID file for the given session key.
@@ -83,41 +155,101 @@ func ClearLastScanID(sessionKey string) {
 }
 
 func appendToBuffer(sessionKey string, event *models.Event, rawEvent map[string]any) error {
+	line, err := encodeBufferedEvent(event, rawEvent)
+	if err != nil {
+		return err
+	}
+	return appendBufferLine(sessionKey, line)
+}
+
+// encodeBufferedEvent returns the buffer line for an event, newline included.
+func encodeBufferedEvent(event *models.Event, rawEvent map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(bufferedEvent{Event: event, RawEvent: rawEvent}); err != nil {
+		return nil, fmt.Errorf("failed to encode buffered event: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func appendBufferLine(sessionKey string, line []byte) error {
 	bufferPath := getBufferPath(sessionKey)
+
+	// Concurrent hook processes for the same session can otherwise
+	// interleave partial lines on some filesystems.
+	release, err := acquireBufferLock(bufferPath)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	f, err := os.OpenFile(bufferPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to open buffer: %w", err)
 	}
 	defer f.Close()
 
-	entry := bufferedEvent{Event: event, RawEvent: rawEvent}
-	if err := json.NewEncoder(f).Encode(entry); err != nil {
+	if _, err := f.Write(line); err != nil {
 		return fmt.Errorf("failed to write to buffer: %w", err)
 	}
 
 	return nil
 }
 
+// flushOversizedBuffer sends the session's buffered events as a partial scan
+// when appending n more bytes would take the buffer past
+// buffer.max_session_bytes, so long sessions never build an unbounded buffer
+// or an oversized scan. A buffer that is empty or missing is left alone, so
+// a single event larger than the cap is still buffered.
+func flushOversizedBuffer(sessionKey, tool string, n int, cfg *config.Config) {
+	limit := cfg.Buffer.MaxSessionBytes
+	if limit <= 0 {
+		return
+	}
+	info, err := os.Stat(getBufferPath(sessionKey))
+	if err != nil || info.Size() == 0 || info.Size()+int64(n) <= limit {
+		return
+	}
+	debug.Log("session %s buffer at %d bytes, flushing partial scan (buffer.max_session_bytes: %d)", sessionKey, info.Size(), limit)
+	if err := sendBufferedScan(sessionKey, tool, cfg, time.Now(), true); err != nil {
+		debug.Warn("failed to flush partial scan: %v", err)
+	}
+}
+
 func readAndClearBuffer(sessionKey string) ([]bufferedEvent, error) {
+	events, claimed, err := takeBuffer(sessionKey)
+	if claimed != "" {
+		os.Remove(claimed)
+	}
+	return events, err
+}
+
+// takeBuffer claims the session's buffer and returns its events along with
+// the claimed file, which the caller removes or keeps via releaseBuffer.
+func takeBuffer(sessionKey string) ([]bufferedEvent, string, error) {
 	bufferPath := getBufferPath(sessionKey)
 
 	// Atomically move the buffer file to a temp name before reading.
 	// This prevents concurrent writers from losing events between read and delete.
+	// The lock keeps the rename from landing between a writer's open and write.
 	tmpPath := bufferPath + ".reading"
-	if err := os.Rename(bufferPath, tmpPath); err != nil {
+	release, err := acquireBufferLock(bufferPath)
+	if err != nil {
+		return nil, "", err
+	}
+	err = os.Rename(bufferPath, tmpPath)
+	release()
+	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil
+			return nil, "", nil
 		}
-		return nil, fmt.Errorf("failed to move buffer for reading: %w", err)
+		return nil, "", fmt.Errorf("failed to move buffer for reading: %w", err)
 	}
 
 	data, err := os.ReadFile(tmpPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read buffer: %w", err)
+		return nil, tmpPath, fmt.Errorf("failed to read buffer: %w", err)
 	}
 
-	os.Remove(tmpPath)
-
 	var events []bufferedEvent
 	sc := bufio.NewScanner(bytes.NewReader(data))
 	sc.Buffer(make([]byte, 64*1024), 10*1024*1024)
@@ -133,11 +265,90 @@ func readAndClearBuffer(sessionKey string) ([]bufferedEvent, error) {
 		events = append(events, entry)
 	}
 
-	return events, nil
+	return events, tmpPath, nil
+}
+
+// releaseBuffer disposes of a buffer claimed by takeBuffer. With
+// local.keep_buffers set and a scan produced, the buffer is moved to the
+// consumed buffers directory for post-hoc debugging; otherwise it is deleted.
+func releaseBuffer(claimed, scanID string, cfg *config.Config) {
+	if claimed == "" {
+		return
+	}
+	if cfg == nil || !cfg.Local.KeepBuffers || scanID == "" {
+		os.Remove(claimed)
+		return
+	}
+	if err := keepConsumedBuffer(claimed, scanID); err != nil {
+		debug.Warn("failed to keep consumed buffer: %v", err)
+		os.Remove(claimed)
+	}
+}
+
+// keepConsumedBuffer moves a claimed buffer to the consumed buffers directory
+// as <scanID>_<unix ms>.jsonl and enforces retention there.
+func keepConsumedBuffer(claimed, scanID string) error {
+	dir, err := config.GetConsumedBuffersDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create consumed buffers dir: %w", err)
+	}
+
+	dest := filepath.Join(dir, fmt.Sprintf("%s_%d.jsonl", scanID, time.Now().UnixMilli()))
+	if err := os.Rename(claimed, dest); err != nil {
+		// The temp dir may be on another filesystem.
+		data, readErr := os.ReadFile(claimed)
+		if readErr != nil {
+			return fmt.Errorf("failed to read buffer: %w", readErr)
+		}
+		if err := os.WriteFile(dest, data, 0600); err != nil {
+			return fmt.Errorf("failed to write consumed buffer: %w", err)
+		}
+		os.Remove(claimed)
+	}
+
+	pruneConsumedBuffers(dir, time.Now())
+	return nil
+}
+
+// pruneConsumedBuffers removes kept buffers older than maxKeptBufferAge and
+// then the oldest beyond maxKeptBuffers.
+func pruneConsumedBuffers(dir string, now time.Time) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return
+	}
+
+	type kept struct {
+		path    string
+		modTime time.Time
+	}
+	var remaining []kept
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if now.Sub(info.ModTime()) > maxKeptBufferAge {
+			os.Remove(f)
+			continue
+		}
+		remaining = append(remaining, kept{f, info.ModTime()})
+	}
+
+	if len(remaining) <= maxKeptBuffers {
+		return
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i].modTime.After(remaining[j].modTime) })
+	for _, k := range remaining[maxKeptBuffers:] {
+		os.Remove(k.path)
+	}
 }
 
 func cleanupStaleBuffers() {
-	markerPath := filepath.Join(os.TempDir(), cleanupMarkerFile)
+	markerPath := filepath.Join(sessionFileDir(), cleanupMarkerFile)
 	if info, err := os.Stat(markerPath); err == nil {
 		if time.Since(info.ModTime()) <= time.Hour {
 			return
@@ -150,41 +361,73 @@ func cleanupStaleBuffers() {
 	}
 
 	patterns := []string{
-		filepath.Join(os.TempDir(), "intentra_buffer_*.jsonl"),
-		filepath.Join(os.TempDir(), "intentra_lastscan_*.txt"),
-		filepath.Join(os.TempDir(), "intentra_send_*.json"),
+		filepath.Join(sessionFileDir(), "intentra_buffer_*.jsonl"),
+		filepath.Join(sessionFileDir(), "intentra_buffer_*.jsonl.lock"),
+		filepath.Join(sessionFileDir(), "intentra_buffer_*.jsonl.finalize"),
+		filepath.Join(sessionFileDir(), "intentra_buffer_*.jsonl.deferred"),
+		filepath.Join(sessionFileDir(), "intentra_lastscan_*.txt"),
+		filepath.Join(os.TempDir(), "intentra_send_*.json"), // send payloads are handed to a child process via temp
 	}
 
-	cutoff := time.Now().Add(-maxBufferAge)
+	now := time.Now()
 	for _, pattern := range patterns {
-		files, err := filepath.Glob(pattern)
+		removeOlderThan(pattern, now.Add(-maxBufferAge))
+	}
+	// Fallback state is rewritten on every event, so once it is older than
+	// the longest idle gap it can only mint a new ID.
+	removeOlderThan(filepath.Join(sessionFileDir(), "intentra_fallback_*.json"), now.Add(-config.MaxSessionIdleGap))
+	removeOlderThan(filepath.Join(sessionFileDir(), "intentra_fallback_*.json.lock"), now.Add(-maxBufferAge))
+}
+
+// removeOlderThan removes the files matching pattern last modified before
+// cutoff.
+func removeOlderThan(pattern string, cutoff time.Time) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
 		if err != nil {
 			continue
 		}
-		for _, f := range files {
-			info, err := os.Stat(f)
-			if err != nil {
-				continue
-			}
-			if info.ModTime().Before(cutoff) {
-				os.Remove(f)
-			}
+		if info.ModTime().Before(cutoff) {
+			os.Remove(f)
 		}
 	}
 }
 
-func collectGitMetadata() (repoName, repoURLHash, branchName, commitSHA string) {
+// gitMetadata holds repository context collected at scan time.
+// Identifying values (remote URL, author email) are stored only as hashes.
+// RepoHost is kept in plaintext here and hashed per config by repoHostValue.
+type gitMetadata struct {
+	RepoName    string
+	RepoURLHash string
+	RepoHost    string
+	BranchName  string
+	CommitSHA   string
+	Dirty       *bool // nil when git status failed or timed out
+	AuthorHash  string
+}
+
+// runGit executes git with the given arguments. Replaced in tests.
+var runGit = func(ctx context.Context, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, "git", args...).Output()
+}
+
+func collectGitMetadata() gitMetadata {
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	var meta gitMetadata
 
-	wg.Add(3)
+	wg.Add(5)
 
 	go func() {
 		defer wg.Done()
-		if out, err := exec.CommandContext(ctx, "git", "remote", "get-url", "origin").Output(); err == nil {
+		if out, err := runGit(ctx, "remote", "get-url", "origin"); err == nil {
 			remoteURL := strings.TrimSpace(string(out))
 			if remoteURL != "" {
 				hash := sha256.Sum256([]byte(remoteURL))
@@ -197,8 +440,9 @@ func collectGitMetadata() (repoName, repoURLHash, branchName, commitSHA string)
 				}
 				name = strings.TrimSuffix(name, ".git")
 				mu.Lock()
-				repoURLHash = hex.EncodeToString(hash[:])
-				repoName = name
+				meta.RepoURLHash = hex.EncodeToString(hash[:])
+				meta.RepoName = name
+				meta.RepoHost = parseRemoteHost(remoteURL)
 				mu.Unlock()
 			}
 		}
@@ -206,30 +450,115 @@ func collectGitMetadata() (repoName, repoURLHash, branchName, commitSHA string)
 
 	go func() {
 		defer wg.Done()
-		if out, err := exec.CommandContext(ctx, "git", "branch", "--show-current").Output(); err == nil {
+		if out, err := runGit(ctx, "branch", "--show-current"); err == nil {
+			mu.Lock()
+			meta.BranchName = strings.TrimSpace(string(out))
+			mu.Unlock()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if out, err := runGit(ctx, "rev-parse", "HEAD"); err == nil {
 			mu.Lock()
-			branchName = strings.TrimSpace(string(out))
+			meta.CommitSHA = strings.TrimSpace(string(out))
 			mu.Unlock()
 		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		if out, err := exec.CommandContext(ctx, "git", "rev-parse", "HEAD").Output(); err == nil {
+		// A timeout here leaves the dirty state unknown rather than
+		// delaying the scan. --no-optional-locks keeps git from taking
+		// .git/index.lock to refresh the index, so killing it on timeout
+		// cannot leave a stale lock behind, and skipping untracked files
+		// keeps large trees inside the timeout.
+		if out, err := runGit(ctx, "--no-optional-locks", "status", "--porcelain", "--untracked-files=no"); err == nil {
+			dirty := len(bytes.TrimSpace(out)) > 0
 			mu.Lock()
-			commitSHA = strings.TrimSpace(string(out))
+			meta.Dirty = &dirty
 			mu.Unlock()
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if out, err := runGit(ctx, "config", "user.email"); err == nil {
+			if hash := hashAuthorEmail(string(out)); hash != "" {
+				mu.Lock()
+				meta.AuthorHash = hash
+				mu.Unlock()
+			}
+		}
+	}()
+
 	wg.Wait()
 
-	return
+	return meta
+}
+
+// parseRemoteHost extracts the lowercase host from a git remote URL, dropping
+// credentials and port. Handles URL forms (https://, ssh://, git://) and
+// scp-like SSH remotes (git@host:owner/repo.git). Returns "" for local paths.
+func parseRemoteHost(remoteURL string) string {
+	remoteURL = strings.TrimSpace(remoteURL)
+	if remoteURL == "" {
+		return ""
+	}
+
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+
+	// scp-like syntax: [user@]host:path. A slash before the colon means a local path.
+	colon := strings.Index(remoteURL, ":")
+	if colon <= 0 || strings.Contains(remoteURL[:colon], "/") {
+		return ""
+	}
+	host := remoteURL[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return strings.ToLower(host)
+}
+
+// repoHostValue returns the remote host as configured by mode: plaintext,
+// a SHA-256 hex digest (the default), or "" when reporting is off.
+func repoHostValue(host, mode string) string {
+	if host == "" {
+		return ""
+	}
+	switch mode {
+	case config.RepoHostOff:
+		return ""
+	case config.RepoHostPlain:
+		return host
+	default:
+		hash := sha256.Sum256([]byte(host))
+		return hex.EncodeToString(hash[:])
+	}
+}
+
+// hashAuthorEmail returns a SHA-256 hex digest of a normalized git author email.
+// The plaintext email is never stored or transmitted.
+func hashAuthorEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(hash[:])
 }
 
 // --- createAggregatedScan and helpers ---
 
-func createAggregatedScan(events []bufferedEvent, tool string) *models.Scan {
+// createAggregatedScan builds a scan from buffered events. Git metadata is
+// collected unless disabled in cfg; a nil cfg uses defaults.
+func createAggregatedScan(events []bufferedEvent, tool string, cfg *config.Config) *models.Scan {
 	if len(events) == 0 {
 		return nil
 	}
@@ -237,38 +566,78 @@ func createAggregatedScan(events []bufferedEvent, tool string) *models.Scan {
 	scan := initScan(events, tool)
 	aggregateEventMetrics(events, scan)
 
-	scan.Model = normalizeModelID(detectFirstString(events, func(e *models.Event) string { return e.Model }), tool)
+	modelSelection := config.ModelSelectionFirst
+	if cfg != nil {
+		modelSelection = cfg.Local.ModelSelection
+	}
+	scan.Model, scan.MixedModels = selectScanModel(events, tool, modelSelection)
 	scan.GenerationID = detectFirstString(events, func(e *models.Event) string { return e.GenerationID })
+	scan.ToolVersion = detectFirstString(events, func(e *models.Event) string { return e.ToolVersion })
 
 	model := scan.Model
 	if model == "" {
-		if tool == string(ToolCopilot) {
-			model = "gpt-4o"
-		} else {
-			model = "claude-sonnet-4.5"
-		}
+		model = scanner.DefaultModel(tool)
 	}
 	scan.EstimatedCost = scanner.EstimateCost(scan.TotalTokens, model, tool)
-
-	scan.MCPToolUsage = aggregateMCPToolUsage(events, scan.EstimatedCost)
-
-	repoName, repoURLHash, branchName, commitSHA := collectGitMetadata()
-	scan.RepoName = repoName
-	scan.RepoURLHash = repoURLHash
-	scan.BranchName = branchName
-	scan.CommitSHA = commitSHA
+	scan.EstimatedCostLow, scan.EstimatedCostHigh = scanner.CostBand(scan.EstimatedCost)
+
+	maxMCPEntries := config.DefaultMaxMCPEntries
+	if cfg != nil {
+		maxMCPEntries = cfg.Local.MaxMCPEntries
+	}
+	scan.MCPToolUsage = capMCPToolUsage(aggregateMCPToolUsage(events, scan.EstimatedCost), maxMCPEntries)
+	scan.ToolCallCounts = capToolCallCounts(scan.ToolCallCounts, maxMCPEntries)
+
+	if cfg == nil || cfg.Local.CollectGitMetadata {
+		git := collectGitMetadata()
+		scan.RepoName = git.RepoName
+		scan.RepoURLHash = git.RepoURLHash
+		repoHostMode := config.RepoHostHash
+		if cfg != nil {
+			repoHostMode = cfg.Local.RepoHost
+		}
+		scan.RepoHost = repoHostValue(git.RepoHost, repoHostMode)
+		scan.BranchName = git.BranchName
+		scan.CommitSHA = git.CommitSHA
+		scan.WorkingTreeDirty = git.Dirty
+		scan.AuthorHash = git.AuthorHash
+	}
 
 	var allEvents []models.Event
 	for _, entry := range events {
-		allEvents = append(allEvents, *entry.Event)
+		e := *entry.Event
+		// Event.Prompt is redacted before buffering; fingerprint the prompt
+		// the tool sent, as scan aggregate does.
+		e.Prompt = rawPrompt(entry.RawEvent)
+		allEvents = append(allEvents, e)
 	}
 	scan.FilesModified = scanner.AggregateFilesModified(allEvents)
+	scan.NewFilesCount, scan.ModifiedFilesCount = scanner.CountFileChanges(scan.FilesModified)
+	scanner.SetFingerprints(scan, allEvents)
 
 	extractSessionEndMetadata(scan, tool, events)
 
 	return scan
 }
 
+// rawPrompt returns the prompt text in a raw hook payload: "prompt",
+// falling back to "initialPrompt" and then tool_info.user_prompt. It is what
+// Event.Prompt holds before redaction; nil yields "".
+func rawPrompt(raw map[string]any) string {
+	if v, ok := raw["prompt"].(string); ok && v != "" {
+		return v
+	}
+	if v, ok := raw["initialPrompt"].(string); ok && v != "" {
+		return v
+	}
+	if toolInfo, ok := raw["tool_info"].(map[string]any); ok {
+		if v, ok := toolInfo["user_prompt"].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
 func initScan(events []bufferedEvent, tool string) *models.Scan {
 	first := events[0]
 	last := events[len(events)-1]
@@ -323,16 +692,42 @@ func aggregateEventMetrics(events []bufferedEvent, scan *models.Scan) {
 		scan.InputTokens += ev.InputTokens
 		scan.OutputTokens += ev.OutputTokens
 		scan.ThinkingTokens += ev.ThinkingTokens
+		scan.ReportedCost += ev.ReportedCost
+		scan.TotalCharsChanged += ev.CharsChanged
+		scan.TotalLinesChanged += ev.LinesAdded + ev.LinesRemoved
 
 		if models.IsLLMCallEvent(normalizedType) {
 			scan.LLMCalls++
 		}
 		if models.IsToolCallEvent(normalizedType) {
 			scan.ToolCalls++
+			if scan.ToolCallCounts == nil {
+				scan.ToolCallCounts = make(map[string]int)
+			}
+			scan.ToolCallCounts[toolCallKey(ev, normalizedType)]++
+		}
+		if ev.RateLimited {
+			scan.RateLimitHits++
+		}
+		if ev.StopReason != "" {
+			scan.StopReason = ev.StopReason
+		}
+		if ev.NotificationType != "" {
+			if scan.NotificationCounts == nil {
+				scan.NotificationCounts = make(map[string]int)
+			}
+			scan.NotificationCounts[ev.NotificationType]++
+		}
+		if normalizedType == models.EventError {
+			if scan.ErrorCounts == nil {
+				scan.ErrorCounts = make(map[string]int)
+			}
+			scan.ErrorCounts[errorCountKey(ev)]++
 		}
 	}
 
 	scan.TotalTokens = scan.InputTokens + scan.OutputTokens + scan.ThinkingTokens
+	scan.DistinctToolsUsed = len(scan.ToolCallCounts)
 }
 
 func detectFirstString(events []bufferedEvent, extract func(*models.Event) string) string {
@@ -344,6 +739,38 @@ func detectFirstString(events []bufferedEvent, extract func(*models.Event) strin
 	return ""
 }
 
+// selectScanModel picks the model a scan is attributed to and reports whether
+// events named more than one model. In dominant mode the model with the most
+// tokens wins, ties going to the earlier model; otherwise, or when no event
+// with a model reports tokens, the first model seen is used.
+func selectScanModel(events []bufferedEvent, tool, mode string) (string, bool) {
+	var order []string
+	tokens := make(map[string]int)
+	for _, entry := range events {
+		model := normalizeModelID(entry.Event.Model, tool)
+		if model == "" {
+			continue
+		}
+		if _, seen := tokens[model]; !seen {
+			order = append(order, model)
+		}
+		tokens[model] += entry.Event.InputTokens + entry.Event.OutputTokens + entry.Event.ThinkingTokens
+	}
+	if len(order) == 0 {
+		return "", false
+	}
+
+	best := order[0]
+	if mode == config.ModelSelectionDominant {
+		for _, model := range order[1:] {
+			if tokens[model] > tokens[best] {
+				best = model
+			}
+		}
+	}
+	return best, len(order) > 1
+}
+
 // extractInt64 extracts an int64 from a map value that may be float64 or json.Number.
 func extractInt64(raw map[string]any, key string) int64 {
 	switch v := raw[key].(type) {
@@ -439,6 +866,76 @@ func aggregateMCPToolUsage(events []bufferedEvent, totalScanCost float64) []mode
 	return result
 }
 
+// mcpOtherName is the server and tool name of the entry that
+// capMCPToolUsage rolls truncated MCP tools into.
+const mcpOtherName = "other"
+
+// capMCPToolUsage keeps the maxEntries most-called MCP tools, ties broken by
+// server and tool name, and rolls the rest into a single "other" entry. A
+// maxEntries of zero or less keeps everything.
+func capMCPToolUsage(usage []models.MCPToolCall, maxEntries int) []models.MCPToolCall {
+	if maxEntries <= 0 || len(usage) <= maxEntries {
+		return usage
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if a.CallCount != b.CallCount {
+			return a.CallCount > b.CallCount
+		}
+		if a.ServerName != b.ServerName {
+			return a.ServerName < b.ServerName
+		}
+		return a.ToolName < b.ToolName
+	})
+
+	other := models.MCPToolCall{ServerName: mcpOtherName, ToolName: mcpOtherName}
+	for _, call := range usage[maxEntries:] {
+		other.CallCount += call.CallCount
+		other.TotalDuration += call.TotalDuration
+		other.EstimatedCost += call.EstimatedCost
+		other.ErrorCount += call.ErrorCount
+	}
+	debug.Log("MCP tool usage truncated: kept %d of %d tools, rolled %d calls into %q",
+		maxEntries, len(usage), other.CallCount, mcpOtherName)
+
+	return append(usage[:maxEntries:maxEntries], other)
+}
+
+// capToolCallCounts keeps the maxEntries most-called tools in counts, ties
+// broken by name, and sums the rest into an "other" entry, as
+// capMCPToolUsage does. A maxEntries of zero or less keeps everything.
+func capToolCallCounts(counts map[string]int, maxEntries int) map[string]int {
+	if maxEntries <= 0 || len(counts) <= maxEntries {
+		return counts
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+
+	capped := make(map[string]int, maxEntries+1)
+	for _, name := range names[:maxEntries] {
+		capped[name] = counts[name]
+	}
+	var rolled int
+	for _, name := range names[maxEntries:] {
+		rolled += counts[name]
+	}
+	capped[mcpOtherName] += rolled
+	debug.Log("tool call counts truncated: kept %d of %d tools, rolled %d calls into %q",
+		maxEntries, len(counts), rolled, mcpOtherName)
+	return capped
+}
+
 // --- normalizeHookEvent and helpers ---
 
 func normalizeHookEvent(rawJSON []byte, tool, eventType string) (*models.Event, map[string]any, NormalizedEventType, error) {
@@ -458,11 +955,15 @@ func normalizeHookEvent(rawJSON []byte, tool, eventType string) (*models.Event,
 
 	extractIdentifiers(event, raw)
 	extractToolMetadata(event, raw)
+	extractToolVersion(event, raw, tool)
 	extractToolIO(event, raw)
+	extractEditMetrics(event, raw, normalizedType)
 	extractContentFields(event, raw)
 	extractErrorFields(event, raw)
+	extractStopReason(event, raw, normalizedType)
 	extractMCPMetadata(event, raw, tool, normalizedType)
 	extractCompactionMetadata(event, raw, normalizedType)
+	extractNotificationMetadata(event, raw, normalizedType)
 
 	sanitizeEvent(event)
 
@@ -514,43 +1015,69 @@ func extractToolMetadata(event *models.Event, raw map[string]any) {
 	}
 }
 
-func extractToolIO(event *models.Event, raw map[string]any) {
-	if toolInput, ok := raw["tool_input"].(map[string]any); ok {
-		if inputJSON, err := json.Marshal(toolInput); err == nil {
-			event.ToolInput = inputJSON
-		}
-		if cmd, ok := toolInput["command"].(string); ok {
-			event.Command = cmd
-		}
-		if fp, ok := toolInput["file_path"].(string); ok {
-			event.FilePath = fp
-		}
-	}
+// toolVersionKeys are raw payload fields that carry the host app version,
+// in order of preference.
+var toolVersionKeys = []string{"cursor_version", "tool_version", "client_version", "ide_version"}
 
-	if toolArgs, ok := raw["toolArgs"].(string); ok && event.ToolInput == nil {
-		if b, err := json.Marshal(toolArgs); err == nil {
-			event.ToolInput = json.RawMessage(b)
+// vscodeBasedTools run hooks from a VS Code fork, which exports its version
+// as TERM_PROGRAM_VERSION when TERM_PROGRAM is "vscode".
+var vscodeBasedTools = map[string]bool{"cursor": true, "windsurf": true, "copilot": true}
+
+// extractToolVersion records the host app version from the payload, falling
+// back to the environment for VS Code-based tools. Empty when unavailable.
+func extractToolVersion(event *models.Event, raw map[string]any, tool string) {
+	for _, key := range toolVersionKeys {
+		if v, ok := raw[key].(string); ok && strings.TrimSpace(v) != "" {
+			event.ToolVersion = strings.TrimSpace(v)
+			return
 		}
 	}
+	if vscodeBasedTools[tool] && os.Getenv("TERM_PROGRAM") == "vscode" {
+		event.ToolVersion = strings.TrimSpace(os.Getenv("TERM_PROGRAM_VERSION"))
+	}
+}
+
+// toolInputKeys are raw payload fields carrying tool arguments, in order of
+// preference. Claude sends tool_input as an object; Gemini and Copilot send
+// tool_input or toolArgs either as an object or as a JSON-encoded string.
+var toolInputKeys = []string{"tool_input", "toolArgs"}
 
-	if toolOutput, ok := raw["tool_output"].(string); ok {
-		if escaped, err := json.Marshal(toolOutput); err == nil {
-			event.ToolOutput = json.RawMessage(escaped)
+// toolOutputKeys are raw payload fields carrying tool results, in order of
+// preference. Gemini's tool_response may be an object ({llmContent,
+// returnDisplay, error}), a list of content parts, or plain text.
+var toolOutputKeys = []string{"toolResult", "tool_response", "tool_output"}
+
+func extractToolIO(event *models.Event, raw map[string]any) {
+	for _, key := range toolInputKeys {
+		v, ok := raw[key]
+		if !ok || v == nil {
+			continue
 		}
-	} else if toolOutput, ok := raw["tool_output"].(map[string]any); ok {
-		if outputJSON, err := json.Marshal(toolOutput); err == nil {
-			event.ToolOutput = outputJSON
+		args := decodeToolArgs(v)
+		if args != nil {
+			if cmd, ok := args["command"].(string); ok {
+				event.Command = cmd
+			}
+			if fp, ok := args["file_path"].(string); ok {
+				event.FilePath = fp
+			}
+			v = args
 		}
-	}
-	if toolResp, ok := raw["tool_response"].(map[string]any); ok {
-		if respJSON, err := json.Marshal(toolResp); err == nil {
-			event.ToolOutput = respJSON
+		if inputJSON, err := json.Marshal(v); err == nil {
+			event.ToolInput = inputJSON
 		}
+		break
 	}
-	if toolResult, ok := raw["toolResult"].(map[string]any); ok {
-		if resultJSON, err := json.Marshal(toolResult); err == nil {
-			event.ToolOutput = resultJSON
+
+	for _, key := range toolOutputKeys {
+		v, ok := raw[key]
+		if !ok || v == nil {
+			continue
 		}
+		if outputJSON, err := json.Marshal(v); err == nil {
+			event.ToolOutput = outputJSON
+		}
+		break
 	}
 
 	if toolInfo, ok := raw["tool_info"].(map[string]any); ok {
@@ -560,9 +1087,6 @@ func extractToolIO(event *models.Event, raw map[string]any) {
 		if cmd, ok := toolInfo["command_line"].(string); ok {
 			event.Command = cmd
 		}
-		if prompt, ok := toolInfo["user_prompt"].(string); ok {
-			event.Prompt = prompt
-		}
 		if resp, ok := toolInfo["response"].(string); ok {
 			event.Response = resp
 		}
@@ -574,6 +1098,103 @@ func extractToolIO(event *models.Event, raw map[string]any) {
 	}
 }
 
+// decodeToolArgs returns tool arguments as an object, decoding them when
+// they arrive as a JSON-encoded string. It returns nil for anything else,
+// such as a plain command-line string.
+func decodeToolArgs(v any) map[string]any {
+	switch args := v.(type) {
+	case map[string]any:
+		return args
+	case string:
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(args), &decoded); err == nil {
+			return decoded
+		}
+	}
+	return nil
+}
+
+// writeToolNames are tools whose "content" input is a whole file written.
+var writeToolNames = map[string]bool{"Write": true, "write_file": true, "create_file": true}
+
+// extractEditMetrics measures structured edits on after-edit events: Claude
+// and Gemini old_string/new_string or written content in tool_input, and
+// Cursor and Windsurf "edits" arrays. Before-edit events carry the same input
+// and are skipped so edits are not counted twice.
+func extractEditMetrics(event *models.Event, raw map[string]any, normalizedType NormalizedEventType) {
+	if normalizedType != models.EventAfterFileEdit && normalizedType != models.EventAfterTool {
+		return
+	}
+
+	for _, src := range []any{raw["tool_input"], raw["tool_info"], raw} {
+		m, ok := src.(map[string]any)
+		if !ok {
+			continue
+		}
+		var edits []map[string]any
+		if list, ok := m["edits"].([]any); ok {
+			for _, e := range list {
+				if em, ok := e.(map[string]any); ok {
+					edits = append(edits, em)
+				}
+			}
+		} else if _, hasOld := m["old_string"]; hasOld || m["new_string"] != nil {
+			edits = append(edits, m)
+		} else if content, ok := m["content"].(string); ok && writeToolNames[event.ToolName] {
+			edits = append(edits, map[string]any{"new_string": content})
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		for _, e := range edits {
+			oldStr, _ := e["old_string"].(string)
+			newStr, _ := e["new_string"].(string)
+			chars, added, removed := measureEdit(oldStr, newStr)
+			event.CharsChanged += chars
+			event.LinesAdded += added
+			event.LinesRemoved += removed
+		}
+		return
+	}
+}
+
+// measureEdit returns the characters and lines removed and added when oldStr
+// is replaced by newStr, ignoring the text both share at the start and end.
+func measureEdit(oldStr, newStr string) (chars, linesAdded, linesRemoved int) {
+	oldRunes, newRunes := []rune(oldStr), []rune(newStr)
+	prefix := 0
+	for prefix < len(oldRunes) && prefix < len(newRunes) && oldRunes[prefix] == newRunes[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldRunes)-prefix && suffix < len(newRunes)-prefix &&
+		oldRunes[len(oldRunes)-1-suffix] == newRunes[len(newRunes)-1-suffix] {
+		suffix++
+	}
+	chars = len(oldRunes) - prefix - suffix + len(newRunes) - prefix - suffix
+
+	oldLines, newLines := splitLines(oldStr), splitLines(newStr)
+	first := 0
+	for first < len(oldLines) && first < len(newLines) && oldLines[first] == newLines[first] {
+		first++
+	}
+	last := 0
+	for last < len(oldLines)-first && last < len(newLines)-first &&
+		oldLines[len(oldLines)-1-last] == newLines[len(newLines)-1-last] {
+		last++
+	}
+	return chars, len(newLines) - first - last, len(oldLines) - first - last
+}
+
+// splitLines splits s into lines, ignoring a trailing newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
 func extractContentFields(event *models.Event, raw map[string]any) {
 	if v, ok := raw["command"].(string); ok && event.Command == "" {
 		event.Command = v
@@ -582,12 +1203,7 @@ func extractContentFields(event *models.Event, raw map[string]any) {
 		event.CommandOutput = v
 	}
 
-	if v, ok := raw["prompt"].(string); ok {
-		event.Prompt = v
-	}
-	if v, ok := raw["initialPrompt"].(string); ok && event.Prompt == "" {
-		event.Prompt = v
-	}
+	event.Prompt = rawPrompt(raw)
 	if v, ok := raw["response"].(string); ok {
 		event.Response = v
 	}
@@ -620,17 +1236,140 @@ func extractContentFields(event *models.Event, raw map[string]any) {
 	if v, ok := raw["output_tokens"].(float64); ok {
 		event.OutputTokens = int(v)
 	}
+
+	if usage, ok := raw["usage"].(map[string]any); ok {
+		if v, ok := usage["cost_usd"].(float64); ok && v > 0 {
+			event.ReportedCost = v
+		}
+		if event.InputTokens == 0 && event.OutputTokens == 0 {
+			extractUsageTokens(event, usage)
+		}
+	}
 }
 
+// extractUsageTokens reads token counts from a nested usage object, as sent
+// for OpenAI models: prompt_tokens/completion_tokens (Chat Completions) or
+// input_tokens/output_tokens (Responses), with reasoning_tokens either at the
+// top level or under completion_tokens_details/output_tokens_details.
+// OpenAI counts reasoning as part of the completion, so it is moved from
+// output to thinking tokens rather than counted twice.
+func extractUsageTokens(event *models.Event, usage map[string]any) {
+	input := firstNumber(usage, "prompt_tokens", "input_tokens")
+	output := firstNumber(usage, "completion_tokens", "output_tokens")
+	reasoning := firstNumber(usage, "reasoning_tokens")
+	for _, key := range []string{"completion_tokens_details", "output_tokens_details"} {
+		if details, ok := usage[key].(map[string]any); ok && reasoning == 0 {
+			reasoning = firstNumber(details, "reasoning_tokens")
+		}
+	}
+
+	event.InputTokens = input
+	event.ThinkingTokens = reasoning
+	event.OutputTokens = max(output-reasoning, 0)
+}
+
+// firstNumber returns the first non-negative number found under keys, or 0.
+func firstNumber(m map[string]any, keys ...string) int {
+	for _, key := range keys {
+		if v, ok := m[key].(float64); ok && v >= 0 {
+			return int(v)
+		}
+	}
+	return 0
+}
+
+// extractErrorFields populates error message, type, and code.
+// Copilot's errorOccurred sends {"error": {"message", "name", "stack"}}; the stack is not kept.
 func extractErrorFields(event *models.Event, raw map[string]any) {
 	if errObj, ok := raw["error"].(map[string]any); ok {
 		if msg, ok := errObj["message"].(string); ok {
 			event.Response = "Error: " + msg
 			event.Error = msg
 		}
+		if v, ok := errObj["name"].(string); ok {
+			event.ErrorType = v
+		} else if v, ok := errObj["type"].(string); ok {
+			event.ErrorType = v
+		}
+		switch v := errObj["code"].(type) {
+		case string:
+			event.ErrorCode = v
+		case float64:
+			event.ErrorCode = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		if event.Error == "" {
+			event.Error = event.ErrorType
+		}
 	} else if errStr, ok := raw["error"].(string); ok && errStr != "" {
 		event.Error = errStr
 	}
+	if v, ok := raw["error_type"].(string); ok && event.ErrorType == "" {
+		event.ErrorType = v
+	}
+
+	event.RateLimited = models.IsRateLimitError(event.Error) || hasRateLimitStatus(raw)
+}
+
+// errorCountKey returns the breakdown key for an error event: its type, then code, then "unknown".
+func errorCountKey(ev *models.Event) string {
+	switch {
+	case ev.ErrorType != "":
+		return ev.ErrorType
+	case ev.ErrorCode != "":
+		return ev.ErrorCode
+	default:
+		return "unknown"
+	}
+}
+
+// toolCallKey returns the tool usage key for a tool call event. MCP tools are
+// keyed "mcp:server/tool" so the hosts' different MCP naming schemes agree;
+// other tools use the host's tool name, falling back to the kind of call
+// (shell, edit, read) when the host does not name the tool.
+func toolCallKey(ev *models.Event, eventType models.NormalizedEventType) string {
+	if ev.IsMCPEvent() {
+		if ev.MCPServerName == "" {
+			return "mcp:" + ev.MCPToolName
+		}
+		return "mcp:" + ev.MCPServerName + "/" + ev.MCPToolName
+	}
+	if ev.ToolName != "" {
+		return ev.ToolName
+	}
+	switch eventType {
+	case models.EventAfterShell:
+		return "shell"
+	case models.EventAfterFileEdit:
+		return "edit"
+	case models.EventAfterFileRead:
+		return "read"
+	default:
+		return "unknown"
+	}
+}
+
+// hasRateLimitStatus reports whether a raw event carries an HTTP 429 status,
+// either at the top level or inside an error object.
+func hasRateLimitStatus(raw map[string]any) bool {
+	sources := []map[string]any{raw}
+	if errObj, ok := raw["error"].(map[string]any); ok {
+		sources = append(sources, errObj)
+	}
+	for _, src := range sources {
+		for _, key := range []string{"status", "status_code", "statusCode", "code"} {
+			switch v := src[key].(type) {
+			case float64:
+				if v == 429 {
+					return true
+				}
+			case string:
+				if models.IsRateLimitError(v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
 }
 
 // redactContent replaces a content string with a length-preserving placeholder.
@@ -659,6 +1398,85 @@ func sanitizeEvent(event *models.Event) {
 	event.FilePath = models.SanitizePath(event.FilePath)
 }
 
+// Notification types reported by tools, or inferred from the message when absent.
+const (
+	notificationPermission = "permission_prompt"
+	notificationIdle       = "idle_prompt"
+	notificationComplete   = "task_complete"
+	notificationOther      = "other"
+)
+
+// maxNotificationMessageLen caps stored notification message length.
+const maxNotificationMessageLen = 500
+
+// extractNotificationMetadata populates notification type and message for notification events.
+// Claude Code and Gemini CLI send notification_type; older versions send only a message.
+func extractNotificationMetadata(event *models.Event, raw map[string]any, normalizedType NormalizedEventType) {
+	if normalizedType != models.EventNotification {
+		return
+	}
+
+	msg, _ := raw["message"].(string)
+	event.NotificationMessage = models.TruncateUTF8(msg, maxNotificationMessageLen)
+
+	if v, ok := raw["notification_type"].(string); ok && v != "" {
+		event.NotificationType = normalizeNotificationType(v)
+		return
+	}
+	event.NotificationType = classifyNotification(msg)
+}
+
+// normalizeNotificationType maps tool-specific notification type names to snake_case.
+func normalizeNotificationType(t string) string {
+	switch t {
+	case "ToolPermission":
+		return notificationPermission
+	}
+	var b strings.Builder
+	for i, r := range t {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// classifyNotification infers a notification type from its message text.
+func classifyNotification(msg string) string {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "permission") || strings.Contains(lower, "approve"):
+		return notificationPermission
+	case strings.Contains(lower, "waiting for your input") || strings.Contains(lower, "idle"):
+		return notificationIdle
+	case strings.Contains(lower, "complete") || strings.Contains(lower, "finished") || strings.Contains(lower, "done"):
+		return notificationComplete
+	default:
+		return notificationOther
+	}
+}
+
+// stopReasonKeys are raw payload fields that carry why the agent stopped,
+// in order of preference. Cursor reports its stop outcome as "status".
+var stopReasonKeys = []string{"stop_reason", "stopReason", "status"}
+
+// extractStopReason records why the agent stopped on stop events.
+func extractStopReason(event *models.Event, raw map[string]any, normalizedType NormalizedEventType) {
+	if normalizedType != models.EventStop {
+		return
+	}
+	for _, key := range stopReasonKeys {
+		if v, ok := raw[key].(string); ok && strings.TrimSpace(v) != "" {
+			event.StopReason = strings.TrimSpace(v)
+			return
+		}
+	}
+}
+
 // extractCompactionMetadata populates compaction-specific fields for pre_compact events.
 // Cursor provides rich context window metrics; Claude Code and Gemini CLI provide only trigger type.
 func extractCompactionMetadata(event *models.Event, raw map[string]any, normalizedType NormalizedEventType) {
@@ -700,9 +1518,24 @@ func extractCompactionMetadata(event *models.Event, raw map[string]any, normaliz
 // extractMCPMetadata populates MCP-specific fields on the event based on the tool type.
 // Each AI coding tool exposes MCP data in a different format.
 func extractMCPMetadata(event *models.Event, raw map[string]any, tool string, normalizedType NormalizedEventType) {
+	// Error events describe the agent session, not a tool call; attributing them to
+	// MCP would inflate error counts on Copilot's pseudo-server.
+	if normalizedType == models.EventError {
+		return
+	}
+
 	isMCPHook := normalizedType == models.EventBeforeMCP || normalizedType == models.EventAfterMCP
 	isMCPToolUse := strings.HasPrefix(event.ToolName, "MCP:") || strings.HasPrefix(event.ToolName, "mcp__")
 
+	// Gemini CLI names MCP tools after the tool alone and describes the
+	// server in mcp_context instead.
+	if mcpCtx, ok := raw["mcp_context"].(map[string]any); ok && tool == string(ToolGeminiCLI) && !isMCPHook {
+		extractGeminiMCPContext(event, mcpCtx)
+		if event.MCPServerName != "" {
+			return
+		}
+	}
+
 	if !isMCPHook && !isMCPToolUse {
 		return
 	}
@@ -793,6 +1626,25 @@ func extractClaudeGeminiMCP(event *models.Event, raw map[string]any) {
 	}
 }
 
+// extractGeminiMCPContext reads the server and tool of a Gemini CLI MCP call
+// from the hook's mcp_context object.
+func extractGeminiMCPContext(event *models.Event, mcpCtx map[string]any) {
+	server, _ := mcpCtx["server_name"].(string)
+	if server == "" {
+		return
+	}
+	event.MCPServerName = server
+	event.MCPToolName = event.ToolName
+	if v, ok := mcpCtx["tool_name"].(string); ok && v != "" {
+		event.MCPToolName = v
+	}
+	if v, ok := mcpCtx["url"].(string); ok && v != "" {
+		event.MCPServerURL = models.SanitizeMCPServerURL(v)
+	} else if v, ok := mcpCtx["command"].(string); ok && v != "" {
+		event.MCPServerCmd = models.SanitizeMCPServerCmd(v)
+	}
+}
+
 // extractCopilotMCP handles GitHub Copilot MCP calls.
 // Copilot does not expose server names, so we use a pseudo-server.
 func extractCopilotMCP(event *models.Event, _ map[string]any) {
@@ -873,7 +1725,25 @@ var mcpToolToServer = map[string]string{
 	"list-errors":            "posthog",
 }
 
+// mcpServerOverrides holds local.mcp.server_overrides, consulted before mcpToolToServer.
+var mcpServerOverrides map[string]string
+
+// SetMCPServerOverrides installs config-provided MCP tool to server mappings.
+// Call it once after loading config; nil restores the built-in table alone.
+func SetMCPServerOverrides(overrides []config.MCPServerOverride) {
+	mcpServerOverrides = make(map[string]string, len(overrides))
+	for _, o := range overrides {
+		tool, server := strings.TrimSpace(o.Tool), strings.TrimSpace(o.Server)
+		if tool != "" && server != "" {
+			mcpServerOverrides[tool] = server
+		}
+	}
+}
+
 func inferMCPServerName(toolName string) string {
+	if server, ok := mcpServerOverrides[toolName]; ok {
+		return server
+	}
 	if server, ok := mcpToolToServer[toolName]; ok {
 		return server
 	}
@@ -911,6 +1781,8 @@ func extractHostFromURL(rawURL string) string {
 // --- ProcessEventWithEvent and helpers ---
 
 // ProcessEventWithEvent buffers events and sends aggregated scan on stop events.
+// The payload is either a single event object or a JSON array of event objects
+// batched by the tool; array elements are processed in order as separate events.
 func ProcessEventWithEvent(reader io.Reader, cfg *config.Config, tool, eventType string) error {
 	bufScanner := bufio.NewScanner(reader)
 	bufScanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
@@ -921,20 +1793,73 @@ func ProcessEventWithEvent(reader io.Reader, cfg *config.Config, tool, eventType
 		return nil
 	}
 
-	rawJSON := bufScanner.Bytes()
+	rawJSON := bytes.TrimSpace(bufScanner.Bytes())
 	if len(rawJSON) == 0 {
 		return nil
 	}
 
+	if rawJSON[0] != '[' {
+		return processHookPayload(rawJSON, cfg, tool, eventType)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(rawJSON, &batch); err != nil {
+		return fmt.Errorf("failed to normalize event: failed to parse event batch: %w", err)
+	}
+
+	// Keep going after a bad element so a trailing stop event still aggregates.
+	var errs []error
+	for i, elem := range batch {
+		if err := processHookPayload(elem, cfg, tool, batchEventType(elem, eventType)); err != nil {
+			errs = append(errs, fmt.Errorf("batch event %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// batchEventType returns the hook event name carried by a batched event
+// (hook_event_name or agent_action_name), falling back to the --event value.
+func batchEventType(rawJSON []byte, fallback string) string {
+	var names struct {
+		HookEventName   string `json:"hook_event_name"`
+		AgentActionName string `json:"agent_action_name"`
+	}
+	if err := json.Unmarshal(rawJSON, &names); err != nil {
+		return fallback
+	}
+	if names.HookEventName != "" {
+		return names.HookEventName
+	}
+	if names.AgentActionName != "" {
+		return names.AgentActionName
+	}
+	return fallback
+}
+
+// processHookPayload normalizes a single event object and buffers it, or
+// aggregates and sends the session's scan when it is a stop event.
+func processHookPayload(rawJSON []byte, cfg *config.Config, tool, eventType string) error {
 	event, rawMap, normalizedType, err := normalizeHookEvent(rawJSON, tool, eventType)
 	if err != nil {
 		return fmt.Errorf("failed to normalize event: %w", err)
 	}
 
+	if normalizedType == models.EventUnknown {
+		recordUnknownEvent(tool, eventType, cfg, time.Now())
+	}
+
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now().UTC()
 	}
 
+	// Notification messages are only kept with rich traces enabled.
+	if normalizedType == models.EventNotification && !cfg.RichTraces {
+		event.NotificationMessage = redactContent(event.NotificationMessage)
+		if msg, ok := rawMap["message"].(string); ok {
+			rawMap["message"] = redactContent(msg)
+		}
+	}
+
 	if event.DeviceID == "" {
 		deviceID, err := device.GetDeviceID()
 		if err == nil {
@@ -942,9 +1867,16 @@ func ProcessEventWithEvent(reader io.Reader, cfg *config.Config, tool, eventType
 		}
 	}
 
+	if event.ConversationID == "" && event.SessionID == "" {
+		event.ConversationID = fallbackConversationID(event, rawMap, cfg.Local.SessionFallback)
+	}
+
 	sessionKey, tool := deriveSessionKey(event, tool)
 
 	if IsStopEvent(normalizedType, tool) {
+		if tool == string(ToolWindsurf) && cfg.Local.WindsurfIdleTimeout > 0 {
+			return handleDebouncedStop(sessionKey, event, rawMap, cfg)
+		}
 		return handleStopEvent(sessionKey, tool, event, rawMap, cfg)
 	}
 
@@ -952,7 +1884,12 @@ func ProcessEventWithEvent(reader io.Reader, cfg *config.Config, tool, eventType
 		return handleSessionEndEvent(sessionKey, rawMap)
 	}
 
-	if err := appendToBuffer(sessionKey, event, rawMap); err != nil {
+	line, err := encodeBufferedEvent(event, rawMap)
+	if err != nil {
+		return fmt.Errorf("failed to buffer event: %w", err)
+	}
+	flushOversizedBuffer(sessionKey, tool, len(line), cfg)
+	if err := appendBufferLine(sessionKey, line); err != nil {
 		return fmt.Errorf("failed to buffer event: %w", err)
 	}
 
@@ -986,14 +1923,119 @@ func deriveSessionKey(event *models.Event, tool string) (string, string) {
 	return sessionKey, tool
 }
 
+// fallbackConversationID returns a synthetic conversation ID for events that carry
+// neither a conversation nor a session ID, according to the configured strategy.
+func fallbackConversationID(event *models.Event, rawMap map[string]any, fb config.SessionFallbackConfig) string {
+	legacy := event.DeviceID + "_default"
+
+	var scope string
+	switch fb.Strategy {
+	case config.SessionFallbackDevice:
+		return legacy
+	case config.SessionFallbackCwd:
+		cwd, _ := rawMap["cwd"].(string)
+		if cwd == "" {
+			cwd, _ = os.Getwd()
+		}
+		scope = event.DeviceID + "|" + cwd
+	case config.SessionFallbackIdleGap, "":
+		scope = event.DeviceID
+	default:
+		debug.Warn("unknown session_fallback strategy %q, using %s", fb.Strategy, config.SessionFallbackIdleGap)
+		scope = event.DeviceID
+	}
+
+	idleGap := fb.IdleGap
+	if idleGap <= 0 {
+		idleGap = maxBufferAge
+	}
+
+	id, err := resolveFallbackSession(scope, event.Timestamp, idleGap)
+	if err != nil {
+		debug.Warn("session fallback state unavailable, using %s: %v", legacy, err)
+		return legacy
+	}
+	return id
+}
+
+// fallbackState is the persisted synthetic conversation for one fallback scope.
+type fallbackState struct {
+	ID       string    `json:"id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+func getFallbackStatePath(scope string) string {
+	hash := sha256.Sum256([]byte(scope))
+	filename := "intentra_fallback_" + hex.EncodeToString(hash[:8]) + ".json"
+	return filepath.Join(sessionFileDir(), filename)
+}
+
+// resolveFallbackSession returns the synthetic conversation ID for scope at time now.
+// A new ID is minted when no prior event was seen within idleGap, so time-separated
+// sessions without IDs are not merged into one scan. The state is read and
+// written under the scope's lock.
+func resolveFallbackSession(scope string, now time.Time, idleGap time.Duration) (string, error) {
+	path := getFallbackStatePath(scope)
+
+	// Concurrent hooks for the same scope must agree on one ID.
+	unlock, err := acquireBufferLock(path)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	var state fallbackState
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			state = fallbackState{}
+		}
+	}
+
+	if state.ID == "" || now.Sub(state.LastSeen) > idleGap {
+		hash := sha256.Sum256([]byte(scope + "|" + now.UTC().Format(time.RFC3339Nano)))
+		state.ID = "fallback_" + hex.EncodeToString(hash[:6])
+	}
+	state.LastSeen = now
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal fallback state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write fallback state: %w", err)
+	}
+	return state.ID, nil
+}
+
 func handleStopEvent(sessionKey, tool string, event *models.Event, rawMap map[string]any, cfg *config.Config) error {
 	cleanupStaleBuffers()
 
 	if err := appendToBuffer(sessionKey, event, rawMap); err != nil {
 		return fmt.Errorf("failed to buffer event: %w", err)
 	}
+	return finalizeSession(sessionKey, tool, cfg)
+}
 
-	bufferedEvents, err := readAndClearBuffer(sessionKey)
+// finalizeSession aggregates the session's buffered events into a scan and
+// hands it to a detached process for sending.
+func finalizeSession(sessionKey, tool string, cfg *config.Config) error {
+	now := time.Now()
+	if shouldDeferSync(sessionKey, cfg, now) {
+		debug.Log("session %s synced within %s, deferring", sessionKey, cfg.Server.MinSyncInterval)
+		scheduleDeferredFlush(sessionKey, tool, cfg)
+		return nil
+	}
+	return sendBufferedScan(sessionKey, tool, cfg, now, false)
+}
+
+// sendBufferedScan claims the session's buffer, aggregates it into a scan and
+// hands the scan to a detached process for sending. A partial scan is one
+// flushed mid-session; it is marked as such and does not count as the
+// session's last sync for server.min_sync_interval.
+func sendBufferedScan(sessionKey, tool string, cfg *config.Config, now time.Time, partial bool) error {
+	bufferedEvents, claimed, err := takeBuffer(sessionKey)
+	var scanID string
+	defer func() { releaseBuffer(claimed, scanID, cfg) }()
 	if err != nil {
 		return fmt.Errorf("failed to read buffer: %w", err)
 	}
@@ -1002,20 +2044,36 @@ func handleStopEvent(sessionKey, tool string, event *models.Event, rawMap map[st
 		return nil
 	}
 
-	scan := createAggregatedScan(bufferedEvents, tool)
+	scan := createAggregatedScan(bufferedEvents, tool, cfg)
 	if scan == nil {
 		return nil
 	}
+	scanID = scan.ID
+	scan.Partial = partial
+
+	if cfg != nil {
+		if err := scanner.EmitSyslog(scan, cfg); err != nil {
+			debug.Warn("%v", err)
+		}
+	}
 
 	// Save scan locally if debug mode (fast local I/O, no network)
 	if debug.Enabled {
-		if err := scanner.SaveScan(scan); err != nil {
+		if cfg != nil && !cfg.Local.SaveScans {
+			debug.Log("Not saving scan %s locally (local.save_scans: false)", scan.ID)
+		} else if err := scanner.SaveScan(scan); err != nil {
 			debug.Warn("failed to save scan locally: %v", err)
 		} else {
 			debug.Log("Saved scan locally: %s", scan.ID)
 		}
 	}
 
+	// Record the sync attempt up front so stops arriving while the detached
+	// send is in flight are coalesced too.
+	if !partial && cfg != nil && cfg.Server.MinSyncInterval > 0 {
+		writeLastScanFile(sessionKey, GetLastScanID(sessionKey), now)
+	}
+
 	// Write payload and spawn detached child for network I/O
 	payloadPath, err := writeSendPayload("send_scan", scan, scan.ID, sessionKey, "", 0)
 	if err != nil {
@@ -1042,7 +2100,7 @@ func handleStopEvent(sessionKey, tool string, event *models.Event, rawMap map[st
 func handleStopEventInline(scan *models.Scan, sessionKey string, cfg *config.Config) error {
 	synced := false
 
-	creds, credErr := auth.GetValidCredentials()
+	creds, credErr := getValidCredentials()
 	if credErr != nil {
 		debug.Warn("credential check failed: %v", credErr)
 	}
@@ -1067,21 +2125,53 @@ func handleStopEventInline(scan *models.Scan, sessionKey string, cfg *config.Con
 	}
 
 	if !synced {
-		if err := queue.Enqueue(scan); err != nil {
-			debug.Warn("failed to queue scan offline: %v", err)
+		if err := StoreUnsyncedScan(scan, cfg, credErr); err != nil {
+			debug.Warn("%v", err)
 		}
 	}
 
 	if synced && scan.ID != "" {
 		SaveLastScanID(sessionKey, scan.ID)
 		if creds != nil {
-			go queue.FlushWithJWT(creds.AccessToken)
+			queue.TriggerFlushWithJWT(creds.AccessToken)
 		}
 	}
 
 	return nil
 }
 
+// getValidCredentials loads the login credentials; swapped out in tests.
+var getValidCredentials = auth.GetValidCredentials
+
+// StoreUnsyncedScan keeps a scan that could not be sent. If authErr is
+// auth.ErrRefreshFailed, meaning the login expired and could not be
+// refreshed, server.on_auth_failure decides whether the scan is queued
+// offline, dropped, or saved locally only. Other failures, including a
+// keyring that could not be read, always queue the scan.
+func StoreUnsyncedScan(scan *models.Scan, cfg *config.Config, authErr error) error {
+	policy := config.AuthFailureBuffer
+	if errors.Is(authErr, auth.ErrRefreshFailed) && cfg != nil && cfg.Server.OnAuthFailure != "" {
+		policy = cfg.Server.OnAuthFailure
+	}
+
+	switch policy {
+	case config.AuthFailureDrop:
+		debug.Warn("dropping scan %s: not authenticated (server.on_auth_failure: drop)", scan.ID)
+		return nil
+	case config.AuthFailureLocal:
+		if err := scanner.SaveScan(scan); err != nil {
+			return fmt.Errorf("failed to save scan locally: %w", err)
+		}
+		debug.Log("saved scan %s locally: not authenticated (server.on_auth_failure: local)", scan.ID)
+		return nil
+	default:
+		if err := queue.Enqueue(scan); err != nil {
+			return fmt.Errorf("failed to queue scan offline: %w", err)
+		}
+		return nil
+	}
+}
+
 func handleSessionEndEvent(sessionKey string, rawMap map[string]any) error {
 	lastScanID := GetLastScanID(sessionKey)
 	if lastScanID == "" {
@@ -1118,28 +2208,108 @@ func handleSessionEndEvent(sessionKey string, rawMap map[string]any) error {
 
 // RunHookHandlerWithToolAndEvent processes hooks with tool and event identifiers.
 func RunHookHandlerWithToolAndEvent(tool, event string) error {
+	// Redirect before loading so warnings logged by Load stay off the
+	// tool's stderr too.
+	restoreLog := useHookLog()
 	cfg, err := config.Load()
 	if err != nil {
+		restoreLog()
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	debug.Enabled = cfg.Debug
+	if cfg.Debug {
+		restoreLog()
+	} else {
+		defer restoreLog()
+	}
+	debug.Configure(cfg.Log.Level, cfg.Log.Format)
+	if err := ApplyConfig(cfg); err != nil {
+		return err
+	}
+	// The process exits on return; let a queue flush started by the
+	// event finish rather than cut it off mid-send.
+	defer queue.WaitForFlush()
 
 	return ProcessEventWithEvent(os.Stdin, cfg, tool, event)
 }
 
+// maxHookLogSize caps the hook log; a larger log is started afresh.
+const maxHookLogSize = 1 << 20
+
+// useHookLog sends log output to the hook log file and returns a func that
+// restores it. Some tools show a hook's stderr to the user, so outside debug
+// mode hook processes log to the file only. If the file cannot be opened,
+// output is discarded.
+func useHookLog() func() {
+	var w io.Writer = io.Discard
+	var file *os.File
+	if path, err := config.GetHookLogFile(); err == nil {
+		os.MkdirAll(filepath.Dir(path), 0700)
+		flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if info, err := os.Stat(path); err == nil && info.Size() > maxHookLogSize {
+			flags |= os.O_TRUNC
+		}
+		if f, err := os.OpenFile(path, flags, 0600); err == nil {
+			file, w = f, f
+		}
+	}
+	prev := debug.SetOutput(w)
+	return func() {
+		debug.SetOutput(prev)
+		if file != nil {
+			file.Close()
+		}
+	}
+}
+
+// ApplyConfig installs the process-wide settings taken from cfg: pricing and
+// MCP overrides, API retry policy and metadata, device and auth options, TLS,
+// buffer and queue limits. Both the CLI and the hook handler call it once
+// after loading config, so every process runs with the same settings.
+func ApplyConfig(cfg *config.Config) error {
+	// Validate bounds the Windsurf idle timeout, but Load does not call
+	// it; a longer one would let stale cleanup delete the buffer first.
+	if cfg.Local.WindsurfIdleTimeout > config.MaxWindsurfIdleTimeout {
+		debug.Warn("local.windsurf_idle_timeout %s exceeds %s; using %s",
+			cfg.Local.WindsurfIdleTimeout, config.MaxWindsurfIdleTimeout, config.MaxWindsurfIdleTimeout)
+		cfg.Local.WindsurfIdleTimeout = config.MaxWindsurfIdleTimeout
+	}
+	scanner.SetPricingOverrides(cfg.Local.Pricing)
+	SetMCPServerOverrides(cfg.Local.MCP.ServerOverrides)
+	api.SetRetryPolicy(cfg.Server)
+	api.SetScanMetadata(cfg.Local.Metadata)
+	api.SetBatchUpload(cfg.Server.BatchUpload)
+	device.SetConfiguredID(cfg.Server.Auth.HMAC.DeviceID)
+	auth.SetExpiryBuffer(cfg.Server.Auth.ExpiryBuffer)
+	if err := auth.SetKeyringBackend(cfg.Server.Auth.KeyringBackend); err != nil {
+		return err
+	}
+	if err := httputil.ConfigureTLS(cfg.Server.MinTLSVersion, cfg.Server.TLSCipherSuites); err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	ConfigureBufferDir(cfg)
+	queue.SetMaxSyncAttempts(cfg.Buffer.MaxSyncAttempts)
+	queue.SetSessionEndGrace(cfg.Buffer.SessionEndGrace)
+	SetMaxConcurrency(cfg.Local.MaxConcurrentInstalls)
+	return nil
+}
+
 // writeSendPayload marshals a models.SendPayload to a temp file and returns its absolute path.
 // On any error the temp file is removed before returning.
 func writeSendPayload(action string, scan *models.Scan, scanID, sessionKey, reason string, durationMs int64) (string, error) {
-	p := models.SendPayload{
+	return writePayloadFile(models.SendPayload{
 		Action:     action,
 		Scan:       scan,
 		ScanID:     scanID,
 		SessionKey: sessionKey,
 		Reason:     reason,
 		DurationMs: durationMs,
-	}
+	})
+}
 
+// writePayloadFile is writeSendPayload for a fully built payload.
+func writePayloadFile(p models.SendPayload) (string, error) {
 	data, err := json.Marshal(p)
 	if err != nil {
 		return "", fmt.Errorf("writeSendPayload: marshal: %w", err)
@@ -1166,10 +2336,13 @@ func writeSendPayload(action string, scan *models.Scan, scanID, sessionKey, reas
 	return path, nil
 }
 
-// spawnDetachedSend launches the current executable with the __send subcommand
+// spawnDetachedSend starts the detached sender; swapped out in tests.
+var spawnDetachedSend = startDetachedSend
+
+// startDetachedSend launches the current executable with the __send subcommand
 // in a detached process so it can outlive the hook handler. It returns as soon
 // as the child process has been started.
-func spawnDetachedSend(payloadPath string) error {
+func startDetachedSend(payloadPath string) error {
 	exe, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("spawnDetachedSend: resolve executable: %w", err)
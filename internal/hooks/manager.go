@@ -7,6 +7,10 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
 )
 
 // Tool represents an AI coding tool.
@@ -33,9 +37,40 @@ type ToolStatus struct {
 	Error     error
 }
 
+// HookOrder controls where intentra's entries are placed in each event's hook list.
+type HookOrder string
+
+const (
+	// HookOrderLast appends intentra's entries after existing hooks (default).
+	HookOrderLast HookOrder = "last"
+	// HookOrderFirst places intentra's entries before existing hooks so capture
+	// happens even if a later hook aborts event processing.
+	HookOrderFirst HookOrder = "first"
+)
+
+// ParseHookOrder validates a hook order name. An empty string means HookOrderLast.
+func ParseHookOrder(s string) (HookOrder, error) {
+	switch HookOrder(s) {
+	case "", HookOrderLast:
+		return HookOrderLast, nil
+	case HookOrderFirst:
+		return HookOrderFirst, nil
+	default:
+		return "", fmt.Errorf("invalid hook order %q (supported: first, last)", s)
+	}
+}
+
+// InstallOptions controls how hooks are merged into a tool's config.
+type InstallOptions struct {
+	Order HookOrder
+
+	// Version is the intentra version recorded in the install manifest.
+	Version string
+}
+
 // toolOps defines per-tool install, uninstall, and status-check operations.
 type toolOps struct {
-	install   func(string) error
+	install   func(string, InstallOptions) error
 	uninstall func() error
 	checkFile string
 	// checkHook inspects parsed JSON config to determine if hooks are installed.
@@ -80,8 +115,31 @@ var toolRegistry = map[Tool]toolOps{
 	},
 }
 
-// GetHooksDir returns the hooks directory for a tool.
+// hooksDirEnv lists, per tool, environment variables that override its
+// config directory for portable installs and non-default locations, in
+// order of precedence: intentra's own, then the tool's where it has one.
+var hooksDirEnv = map[Tool][]string{
+	ToolCursor:     {"INTENTRA_CURSOR_DIR"},
+	ToolClaudeCode: {"INTENTRA_CLAUDE_DIR", "CLAUDE_CONFIG_DIR"},
+	ToolGeminiCLI:  {"INTENTRA_GEMINI_DIR"},
+	ToolCopilot:    {"INTENTRA_COPILOT_DIR"},
+	ToolWindsurf:   {"INTENTRA_WINDSURF_DIR"},
+}
+
+// GetHooksDir returns the hooks directory for a tool, honoring the
+// overrides in hooksDirEnv.
 func GetHooksDir(tool Tool) (string, error) {
+	for _, name := range hooksDirEnv[tool] {
+		dir := os.Getenv(name)
+		if dir == "" {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			return "", fmt.Errorf("%s must be an absolute path, got %q", name, dir)
+		}
+		return filepath.Clean(dir), nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -106,9 +164,9 @@ func GetHooksDir(tool Tool) (string, error) {
 func getCursorHooksDir(home string) (string, error) {
 	switch runtime.GOOS {
 	case "windows":
-		appData := os.Getenv("APPDATA")
-		if appData == "" {
-			return "", fmt.Errorf("APPDATA environment variable not set")
+		appData, err := config.WindowsAppData()
+		if err != nil {
+			return "", err
 		}
 		return filepath.Join(appData, "Cursor"), nil
 	default:
@@ -134,7 +192,11 @@ func getCopilotHooksDir(home string) (string, error) {
 func getWindsurfHooksDir(home string) (string, error) {
 	switch runtime.GOOS {
 	case "windows":
-		return filepath.Join(os.Getenv("APPDATA"), "Windsurf"), nil
+		appData, err := config.WindowsAppData()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(appData, "Windsurf"), nil
 	default:
 		return filepath.Join(home, ".codeium", "windsurf"), nil
 	}
@@ -142,51 +204,138 @@ func getWindsurfHooksDir(home string) (string, error) {
 
 // Install installs hooks for the specified tool.
 func Install(tool Tool, handlerPath string) error {
+	return InstallWithOptions(tool, handlerPath, InstallOptions{})
+}
+
+// InstallWithOptions installs hooks for the specified tool using opts and
+// records them in the install manifest.
+func InstallWithOptions(tool Tool, handlerPath string, opts InstallOptions) error {
 	ops, ok := toolRegistry[tool]
 	if !ok {
 		return fmt.Errorf("unknown tool: %s", tool)
 	}
-	return ops.install(handlerPath)
+	if err := ops.install(handlerPath, opts); err != nil {
+		return err
+	}
+	if err := recordInstall(tool, opts.Version); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update install manifest for %s: %v\n", tool, err)
+	}
+	return nil
 }
 
 // InstallAll installs hooks for all supported tools.
 func InstallAll(handlerPath string) map[Tool]error {
+	return InstallAllWithOptions(handlerPath, InstallOptions{})
+}
+
+// InstallAllWithOptions installs hooks for all supported tools using opts.
+func InstallAllWithOptions(handlerPath string, opts InstallOptions) map[Tool]error {
+	var mu sync.Mutex
 	results := make(map[Tool]error)
-	for _, tool := range AllTools() {
-		results[tool] = Install(tool, handlerPath)
-	}
+	forEachTool(AllTools(), func(tool Tool) {
+		err := InstallWithOptions(tool, handlerPath, opts)
+		mu.Lock()
+		results[tool] = err
+		mu.Unlock()
+	})
 	return results
 }
 
-// Uninstall removes hooks for the specified tool.
+// Uninstall removes hooks for the specified tool and its install manifest
+// entry.
 func Uninstall(tool Tool) error {
 	ops, ok := toolRegistry[tool]
 	if !ok {
 		return fmt.Errorf("unknown tool: %s", tool)
 	}
-	return ops.uninstall()
+	if err := ops.uninstall(); err != nil {
+		return err
+	}
+	if err := recordUninstall(tool); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update install manifest for %s: %v\n", tool, err)
+	}
+	return nil
 }
 
 // UninstallAll removes hooks for all supported tools.
 func UninstallAll() map[Tool]error {
+	var mu sync.Mutex
 	results := make(map[Tool]error)
-	for _, tool := range AllTools() {
-		results[tool] = Uninstall(tool)
-	}
+	forEachTool(AllTools(), func(tool Tool) {
+		err := Uninstall(tool)
+		mu.Lock()
+		results[tool] = err
+		mu.Unlock()
+	})
 	return results
 }
 
-// Status returns installation status for all tools.
+// Status returns installation status for all tools, in AllTools order.
 func Status() []ToolStatus {
-	var statuses []ToolStatus
-	for _, tool := range AllTools() {
-		status := ToolStatus{Tool: tool}
-		status.Installed, status.Path, status.Error = checkStatus(tool)
-		statuses = append(statuses, status)
-	}
+	tools := AllTools()
+	statuses := make([]ToolStatus, len(tools))
+	index := make(map[Tool]int, len(tools))
+	for i, tool := range tools {
+		statuses[i].Tool = tool
+		index[tool] = i
+	}
+	forEachTool(tools, func(tool Tool) {
+		s := &statuses[index[tool]]
+		s.Installed, s.Path, s.Error = checkStatus(tool)
+	})
 	return statuses
 }
 
+// maxConcurrency is how many tools InstallAll, UninstallAll and Status work
+// on at once; see SetMaxConcurrency.
+var maxConcurrency = config.DefaultMaxConcurrentInstalls
+
+// SetMaxConcurrency sets how many tools InstallAll, UninstallAll and Status
+// work on at once. 1 handles them one at a time; values below 1 restore
+// config.DefaultMaxConcurrentInstalls.
+func SetMaxConcurrency(n int) {
+	if n < 1 {
+		n = config.DefaultMaxConcurrentInstalls
+	}
+	maxConcurrency = n
+}
+
+// forEachTool calls fn for each of tools on at most maxConcurrency
+// goroutines and returns once all calls have. Tools whose hooks directories
+// coincide, as the hooksDirEnv overrides allow, are handled one after
+// another so their edits to shared files never interleave.
+func forEachTool(tools []Tool, fn func(Tool)) {
+	var groups [][]Tool
+	byDir := make(map[string]int)
+	for _, tool := range tools {
+		if dir, err := GetHooksDir(tool); err == nil {
+			if g, ok := byDir[dir]; ok {
+				groups[g] = append(groups[g], tool)
+				continue
+			}
+			byDir[dir] = len(groups)
+		}
+		groups = append(groups, []Tool{tool})
+	}
+
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(group []Tool) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			for _, tool := range group {
+				fn(tool)
+			}
+		}(group)
+	}
+	wg.Wait()
+}
+
 // AnyHooksInstalled returns true if hooks are installed for any tool.
 // Short-circuits on first match instead of checking all tools.
 func AnyHooksInstalled() bool {
@@ -231,8 +380,9 @@ func checkStatus(tool Tool) (bool, string, error) {
 }
 
 // mergeHookEntries merges incoming hook entries into existing hooks by event type.
-// For each event type, if existing entries exist as []any, new entries are appended.
-func mergeHookEntries(existing, incoming map[string]any) map[string]any {
+// For each event type, if existing entries exist as []any, new entries are appended,
+// or prepended when order is HookOrderFirst.
+func mergeHookEntries(existing, incoming map[string]any, order HookOrder) map[string]any {
 	merged := make(map[string]any)
 	for k, v := range existing {
 		merged[k] = v
@@ -243,15 +393,20 @@ func mergeHookEntries(existing, incoming map[string]any) map[string]any {
 			merged[eventType] = newList
 			continue
 		}
+		var newItems []any
 		switch nl := newList.(type) {
 		case []any:
-			existingList = append(existingList, nl...)
+			newItems = nl
 		case []map[string]any:
 			for _, item := range nl {
-				existingList = append(existingList, item)
+				newItems = append(newItems, item)
 			}
 		}
-		merged[eventType] = existingList
+		if order == HookOrderFirst {
+			merged[eventType] = append(newItems, existingList...)
+		} else {
+			merged[eventType] = append(existingList, newItems...)
+		}
 	}
 	return merged
 }
@@ -313,7 +468,7 @@ func removeIntentraFromHooks(hooks map[string]any, innerFields, outerFields []st
 // installJSONHookFile installs hooks for tools that use a top-level hooks.json file
 // (Cursor, Copilot, Windsurf). It reads any existing config, removes old intentra entries,
 // merges in newly generated hooks, and writes the result.
-func installJSONHookFile(tool Tool, handlerPath string, generator func(string) (string, error), cleanInner, cleanOuter, preserveFields []string) error {
+func installJSONHookFile(tool Tool, handlerPath string, opts InstallOptions, generator func(string) (string, error), cleanInner, cleanOuter, preserveFields []string) error {
 	dir, err := GetHooksDir(tool)
 	if err != nil {
 		return fmt.Errorf("failed to get hooks directory: %w", err)
@@ -346,7 +501,7 @@ func installJSONHookFile(tool Tool, handlerPath string, generator func(string) (
 		if existingHooks, ok := existingConfig["hooks"].(map[string]any); ok {
 			cleanedHooks := removeIntentraFromHooks(existingHooks, cleanInner, cleanOuter)
 			if newHooks, ok := newConfig["hooks"].(map[string]any); ok {
-				existingConfig["hooks"] = mergeHookEntries(cleanedHooks, newHooks)
+				existingConfig["hooks"] = mergeHookEntries(cleanedHooks, newHooks, opts.Order)
 			}
 		} else {
 			existingConfig["hooks"] = newConfig["hooks"]
@@ -365,7 +520,7 @@ func installJSONHookFile(tool Tool, handlerPath string, generator func(string) (
 		return fmt.Errorf("failed to marshal hooks: %w", err)
 	}
 
-	return os.WriteFile(hooksFile, data, 0600)
+	return fileutil.WriteFileAtomic(hooksFile, data)
 }
 
 // uninstallJSONHookFile removes intentra hooks from a hooks.json file.
@@ -409,12 +564,12 @@ func uninstallJSONHookFile(tool Tool, cleanInner, cleanOuter []string) error {
 		return err
 	}
 
-	return os.WriteFile(hooksFile, newData, 0600)
+	return fileutil.WriteFileAtomic(hooksFile, newData)
 }
 
 // installSettingsHookFile installs hooks for tools that use settings.json with a nested
 // "hooks" key (Claude Code, Gemini CLI).
-func installSettingsHookFile(tool Tool, handlerPath string, generator func(string) (map[string]any, error), cleanInner, cleanOuter []string) error {
+func installSettingsHookFile(tool Tool, handlerPath string, opts InstallOptions, generator func(string) (map[string]any, error), cleanInner, cleanOuter []string) error {
 	dir, err := GetHooksDir(tool)
 	if err != nil {
 		return fmt.Errorf("failed to get hooks directory: %w", err)
@@ -443,7 +598,7 @@ func installSettingsHookFile(tool Tool, handlerPath string, generator func(strin
 
 	if existingHooks, ok := settings["hooks"].(map[string]any); ok {
 		cleanedHooks := removeIntentraFromHooks(existingHooks, cleanInner, cleanOuter)
-		settings["hooks"] = mergeHookEntries(cleanedHooks, newHooksConfig)
+		settings["hooks"] = mergeHookEntries(cleanedHooks, newHooksConfig, opts.Order)
 	} else {
 		settings["hooks"] = newHooksConfig
 	}
@@ -453,7 +608,7 @@ func installSettingsHookFile(tool Tool, handlerPath string, generator func(strin
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	return os.WriteFile(settingsFile, data, 0600)
+	return fileutil.WriteFileAtomic(settingsFile, data)
 }
 
 // uninstallSettingsHookFile removes intentra hooks from a settings.json file.
@@ -492,29 +647,29 @@ func uninstallSettingsHookFile(tool Tool, cleanInner, cleanOuter []string) error
 		return err
 	}
 
-	return os.WriteFile(settingsFile, newData, 0600)
+	return fileutil.WriteFileAtomic(settingsFile, newData)
 }
 
 // --- Tool-specific wrappers ---
 
-func installCursor(handlerPath string) error {
-	return installJSONHookFile(ToolCursor, handlerPath, GenerateCursorHooksJSON, nil, []string{"command", "bash"}, nil)
+func installCursor(handlerPath string, opts InstallOptions) error {
+	return installJSONHookFile(ToolCursor, handlerPath, opts, GenerateCursorHooksJSON, nil, []string{"command", "bash"}, nil)
 }
 
 func uninstallCursor() error {
 	return uninstallJSONHookFile(ToolCursor, nil, []string{"command", "bash"})
 }
 
-func installClaudeCode(handlerPath string) error {
-	return installSettingsHookFile(ToolClaudeCode, handlerPath, GenerateClaudeCodeHooks, []string{"command"}, []string{"command"})
+func installClaudeCode(handlerPath string, opts InstallOptions) error {
+	return installSettingsHookFile(ToolClaudeCode, handlerPath, opts, GenerateClaudeCodeHooks, []string{"command"}, []string{"command"})
 }
 
 func uninstallClaudeCode() error {
 	return uninstallSettingsHookFile(ToolClaudeCode, []string{"command"}, []string{"command"})
 }
 
-func installGeminiCLI(handlerPath string) error {
-	if err := installSettingsHookFile(ToolGeminiCLI, handlerPath, generateGeminiHooks, []string{"name", "command"}, nil); err != nil {
+func installGeminiCLI(handlerPath string, opts InstallOptions) error {
+	if err := installSettingsHookFile(ToolGeminiCLI, handlerPath, opts, generateGeminiHooks, []string{"name", "command"}, nil); err != nil {
 		return err
 	}
 	// Gemini CLI requires hooksConfig.enabled to be set for hooks to fire.
@@ -541,23 +696,23 @@ func installGeminiCLI(handlerPath string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(settingsFile, out, 0600)
+	return fileutil.WriteFileAtomic(settingsFile, out)
 }
 
 func uninstallGeminiCLI() error {
 	return uninstallSettingsHookFile(ToolGeminiCLI, []string{"name", "command"}, nil)
 }
 
-func installCopilot(handlerPath string) error {
-	return installJSONHookFile(ToolCopilot, handlerPath, GenerateCopilotHooksJSON, nil, []string{"bash", "powershell"}, []string{"version"})
+func installCopilot(handlerPath string, opts InstallOptions) error {
+	return installJSONHookFile(ToolCopilot, handlerPath, opts, GenerateCopilotHooksJSON, nil, []string{"bash", "powershell"}, []string{"version"})
 }
 
 func uninstallCopilot() error {
 	return uninstallJSONHookFile(ToolCopilot, nil, []string{"bash", "powershell"})
 }
 
-func installWindsurf(handlerPath string) error {
-	return installJSONHookFile(ToolWindsurf, handlerPath, GenerateWindsurfHooksJSON, nil, []string{"command", "bash"}, nil)
+func installWindsurf(handlerPath string, opts InstallOptions) error {
+	return installJSONHookFile(ToolWindsurf, handlerPath, opts, GenerateWindsurfHooksJSON, nil, []string{"command", "bash"}, nil)
 }
 
 func uninstallWindsurf() error {
@@ -1,10 +1,17 @@
 package hooks
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
 )
 
 func TestGenerateHooksJSON(t *testing.T) {
@@ -139,3 +146,386 @@ func TestInstallCursor(t *testing.T) {
 	}
 }
 
+
+func TestInstallWithOptions_Order(t *testing.T) {
+	existing := `{
+  "hooks": {
+    "PreToolUse": [
+      {"matcher": "*", "hooks": [{"type": "command", "command": "other-guard"}]}
+    ]
+  }
+}`
+
+	for _, order := range []HookOrder{HookOrderFirst, HookOrderLast} {
+		t.Run(string(order), func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			dir := filepath.Join(home, ".claude")
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			settingsFile := filepath.Join(dir, "settings.json")
+			if err := os.WriteFile(settingsFile, []byte(existing), 0600); err != nil {
+				t.Fatalf("failed to write settings: %v", err)
+			}
+
+			// Reinstall twice to confirm old intentra entries are replaced, not duplicated.
+			for i := 0; i < 2; i++ {
+				if err := InstallWithOptions(ToolClaudeCode, "intentra", InstallOptions{Order: order}); err != nil {
+					t.Fatalf("InstallWithOptions failed: %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(settingsFile)
+			if err != nil {
+				t.Fatalf("failed to read settings: %v", err)
+			}
+			var settings map[string]any
+			if err := json.Unmarshal(data, &settings); err != nil {
+				t.Fatalf("invalid settings JSON: %v", err)
+			}
+			list := settings["hooks"].(map[string]any)["PreToolUse"].([]any)
+			if len(list) != 2 {
+				t.Fatalf("expected 2 PreToolUse entries, got %d", len(list))
+			}
+
+			commandAt := func(i int) string {
+				inner := list[i].(map[string]any)["hooks"].([]any)
+				return inner[0].(map[string]any)["command"].(string)
+			}
+			intentraIdx := 1
+			if order == HookOrderFirst {
+				intentraIdx = 0
+			}
+			if !strings.Contains(commandAt(intentraIdx), "intentra") {
+				t.Errorf("expected intentra at index %d, got %q", intentraIdx, commandAt(intentraIdx))
+			}
+			if commandAt(1-intentraIdx) != "other-guard" {
+				t.Errorf("expected other-guard at index %d, got %q", 1-intentraIdx, commandAt(1-intentraIdx))
+			}
+		})
+	}
+}
+
+func TestParseHookOrder(t *testing.T) {
+	if o, err := ParseHookOrder(""); err != nil || o != HookOrderLast {
+		t.Errorf("ParseHookOrder(\"\") = %q, %v; want last", o, err)
+	}
+	if o, err := ParseHookOrder("first"); err != nil || o != HookOrderFirst {
+		t.Errorf("ParseHookOrder(first) = %q, %v; want first", o, err)
+	}
+	if _, err := ParseHookOrder("middle"); err == nil {
+		t.Error("expected error for invalid order")
+	}
+}
+
+func TestInstallUninstall_WritesConfigAtomically(t *testing.T) {
+	for _, tool := range AllTools() {
+		t.Run(string(tool), func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("APPDATA", t.TempDir())
+			dir, err := GetHooksDir(tool)
+			if err != nil {
+				t.Fatalf("GetHooksDir failed: %v", err)
+			}
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			configFile := filepath.Join(dir, toolRegistry[tool].checkFile)
+			if err := os.WriteFile(configFile, []byte(`{"userSetting": "keep"}`), 0600); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			var renames [][2]string
+			orig := fileutil.Rename
+			fileutil.Rename = func(src, dst string) error {
+				// The install manifest is written the same way; track the
+				// tool's config only.
+				if filepath.Dir(dst) == dir {
+					renames = append(renames, [2]string{src, dst})
+				}
+				return os.Rename(src, dst)
+			}
+			t.Cleanup(func() { fileutil.Rename = orig })
+
+			checkIntact := func(step string) {
+				t.Helper()
+				data, err := os.ReadFile(configFile)
+				if err != nil {
+					t.Fatalf("%s: failed to read config: %v", step, err)
+				}
+				var cfg map[string]any
+				if err := json.Unmarshal(data, &cfg); err != nil {
+					t.Fatalf("%s: config is not valid JSON: %v\n%s", step, err, data)
+				}
+				if cfg["userSetting"] != "keep" {
+					t.Errorf("%s: user setting lost: %s", step, data)
+				}
+			}
+
+			if err := Install(tool, "intentra"); err != nil {
+				t.Fatalf("Install failed: %v", err)
+			}
+			if len(renames) == 0 {
+				t.Fatal("install did not write through a temp file")
+			}
+			checkIntact("install")
+
+			installRenames := len(renames)
+			if err := Uninstall(tool); err != nil {
+				t.Fatalf("Uninstall failed: %v", err)
+			}
+			// hooks.json files holding nothing but intentra hooks are removed.
+			if _, err := os.Stat(configFile); err == nil {
+				if len(renames) == installRenames {
+					t.Error("uninstall did not write through a temp file")
+				}
+				checkIntact("uninstall")
+			}
+
+			for _, r := range renames {
+				if r[1] != configFile || filepath.Dir(r[0]) != dir || !strings.HasSuffix(r[0], ".tmp") {
+					t.Errorf("rename %s -> %s, want temp file in %s renamed to %s", r[0], r[1], dir, configFile)
+				}
+			}
+			if leftovers, _ := filepath.Glob(filepath.Join(dir, ".*.tmp")); len(leftovers) > 0 {
+				t.Errorf("temp files left behind: %v", leftovers)
+			}
+		})
+	}
+}
+
+func TestGetHooksDir_EnvOverride(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	for _, tool := range AllTools() {
+		for _, name := range hooksDirEnv[tool] {
+			t.Setenv(name, "")
+		}
+	}
+
+	claudeDir := filepath.Join(t.TempDir(), "claude-config")
+	t.Setenv("CLAUDE_CONFIG_DIR", claudeDir)
+	if dir, err := GetHooksDir(ToolClaudeCode); err != nil || dir != claudeDir {
+		t.Errorf("GetHooksDir(claude) = %q, %v; want CLAUDE_CONFIG_DIR %q", dir, err, claudeDir)
+	}
+	intentraDir := filepath.Join(t.TempDir(), "claude-portable")
+	t.Setenv("INTENTRA_CLAUDE_DIR", intentraDir)
+	if dir, _ := GetHooksDir(ToolClaudeCode); dir != intentraDir {
+		t.Errorf("INTENTRA_CLAUDE_DIR should take precedence, got %q", dir)
+	}
+
+	t.Setenv("INTENTRA_GEMINI_DIR", "relative/gemini")
+	if _, err := GetHooksDir(ToolGeminiCLI); err == nil || !strings.Contains(err.Error(), "absolute path") {
+		t.Errorf("expected absolute path error, got %v", err)
+	}
+
+	cursorDir := filepath.Join(t.TempDir(), "cursor-portable")
+	t.Setenv("INTENTRA_CURSOR_DIR", cursorDir)
+	if err := os.MkdirAll(cursorDir, 0700); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := Install(ToolCursor, "intentra"); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cursorDir, "hooks.json")); err != nil {
+		t.Errorf("hooks.json not written to override dir: %v", err)
+	}
+	installed, path, err := checkStatus(ToolCursor)
+	if err != nil || !installed || path != cursorDir {
+		t.Errorf("checkStatus(cursor) = %v, %q, %v; want installed in %q", installed, path, err, cursorDir)
+	}
+}
+
+func TestGetHooksDir_WindowsWithoutAppData(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("APPDATA is only used on Windows")
+	}
+	for _, tool := range AllTools() {
+		for _, name := range hooksDirEnv[tool] {
+			t.Setenv(name, "")
+		}
+	}
+	profile := t.TempDir()
+	t.Setenv("APPDATA", "")
+	t.Setenv("USERPROFILE", profile)
+
+	roaming := filepath.Join(profile, "AppData", "Roaming")
+	for tool, want := range map[Tool]string{
+		ToolCursor:   filepath.Join(roaming, "Cursor"),
+		ToolWindsurf: filepath.Join(roaming, "Windsurf"),
+	} {
+		if dir, err := GetHooksDir(tool); err != nil || dir != want {
+			t.Errorf("GetHooksDir(%s) = %q, %v; want %q", tool, dir, err, want)
+		}
+	}
+
+	t.Setenv("USERPROFILE", "")
+	for _, tool := range []Tool{ToolCursor, ToolWindsurf} {
+		if dir, err := GetHooksDir(tool); err == nil {
+			t.Errorf("GetHooksDir(%s) = %q, want error when APPDATA and USERPROFILE are unset", tool, dir)
+		}
+	}
+}
+
+func TestInstallAllUninstallAll_Concurrent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	for _, tool := range AllTools() {
+		for _, name := range hooksDirEnv[tool] {
+			t.Setenv(name, "")
+		}
+	}
+	SetMaxConcurrency(3)
+	t.Cleanup(func() { SetMaxConcurrency(0) })
+
+	results := InstallAll("intentra")
+	if len(results) != len(AllTools()) {
+		t.Fatalf("InstallAll returned %d results, want %d", len(results), len(AllTools()))
+	}
+	for tool, err := range results {
+		if err != nil {
+			t.Errorf("install %s: %v", tool, err)
+		}
+	}
+
+	statuses := Status()
+	for i, tool := range AllTools() {
+		if statuses[i].Tool != tool || !statuses[i].Installed || statuses[i].Error != nil {
+			t.Errorf("status[%d] = %+v, want %s installed", i, statuses[i], tool)
+		}
+	}
+
+	for tool, err := range UninstallAll() {
+		if err != nil {
+			t.Errorf("uninstall %s: %v", tool, err)
+		}
+	}
+	for _, s := range Status() {
+		if s.Installed {
+			t.Errorf("%s still installed after UninstallAll", s.Tool)
+		}
+	}
+}
+
+func TestForEachTool_BoundsConcurrency(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	for _, tool := range AllTools() {
+		for _, name := range hooksDirEnv[tool] {
+			t.Setenv(name, "")
+		}
+	}
+	shared := t.TempDir()
+	t.Setenv("INTENTRA_CURSOR_DIR", shared)
+	t.Setenv("INTENTRA_WINDSURF_DIR", shared)
+	t.Cleanup(func() { SetMaxConcurrency(0) })
+
+	for _, limit := range []int{1, 2, 5} {
+		SetMaxConcurrency(limit)
+
+		var mu sync.Mutex
+		active, peak := 0, 0
+		activeDirs := make(map[string]bool)
+		seen := make(map[Tool]int)
+		forEachTool(AllTools(), func(tool Tool) {
+			dir, _ := GetHooksDir(tool)
+			mu.Lock()
+			if activeDirs[dir] {
+				t.Errorf("limit %d: two tools ran in %s at once", limit, dir)
+			}
+			activeDirs[dir] = true
+			seen[tool]++
+			active++
+			peak = max(peak, active)
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			delete(activeDirs, dir)
+			active--
+			mu.Unlock()
+		})
+
+		if peak > limit {
+			t.Errorf("limit %d: %d tools ran at once", limit, peak)
+		}
+		for _, tool := range AllTools() {
+			if seen[tool] != 1 {
+				t.Errorf("limit %d: %s ran %d times, want 1", limit, tool, seen[tool])
+			}
+		}
+	}
+}
+
+func TestInstallManifest(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	for _, tool := range AllTools() {
+		for _, name := range hooksDirEnv[tool] {
+			t.Setenv(name, "")
+		}
+	}
+
+	readManifest := func() *InstallManifest {
+		t.Helper()
+		m, err := ReadInstallManifest()
+		if err != nil {
+			t.Fatalf("ReadInstallManifest failed: %v", err)
+		}
+		return m
+	}
+
+	if m := readManifest(); len(m.Tools) != 0 {
+		t.Errorf("manifest before install = %+v, want empty", m)
+	}
+
+	before := time.Now().UTC()
+	if err := InstallWithOptions(ToolCursor, "intentra", InstallOptions{Version: "1.2.3"}); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	for tool, err := range InstallAllWithOptions("intentra", InstallOptions{Version: "1.2.3"}) {
+		if err != nil {
+			t.Fatalf("install %s failed: %v", tool, err)
+		}
+	}
+
+	m := readManifest()
+	if len(m.Tools) != len(AllTools()) {
+		t.Fatalf("manifest has %d tools, want %d: %+v", len(m.Tools), len(AllTools()), m)
+	}
+	for _, tool := range AllTools() {
+		entry := m.Tools[tool]
+		dir, _ := GetHooksDir(tool)
+		if entry.ConfigFile != filepath.Join(dir, toolRegistry[tool].checkFile) {
+			t.Errorf("%s config file = %q", tool, entry.ConfigFile)
+		}
+		if len(entry.Commands) == 0 || !strings.Contains(entry.Commands[0], "hook --tool "+string(tool)) {
+			t.Errorf("%s commands = %v", tool, entry.Commands)
+		}
+		if entry.IntentraVersion != "1.2.3" || entry.InstalledAt.Before(before.Add(-time.Second)) {
+			t.Errorf("%s entry = %+v", tool, entry)
+		}
+	}
+
+	if err := Uninstall(ToolCursor); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if m := readManifest(); len(m.Tools) != len(AllTools())-1 {
+		t.Errorf("manifest after uninstalling cursor = %+v", m)
+	} else if _, ok := m.Tools[ToolCursor]; ok {
+		t.Error("cursor still in manifest after uninstall")
+	}
+
+	for tool, err := range UninstallAll() {
+		if err != nil && tool != ToolCursor {
+			t.Fatalf("uninstall %s failed: %v", tool, err)
+		}
+	}
+	path, _ := config.GetInstallManifestFile()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("manifest should be removed once no tools remain, stat err = %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
+)
+
+// The install manifest records what intentra has written into each tool's
+// config, so admins can audit its footprint on a machine. Install adds or
+// replaces a tool's entry and Uninstall removes it; the file is deleted
+// once no tools remain.
+
+// InstallManifest is the content of the install manifest file.
+type InstallManifest struct {
+	Tools map[Tool]ManifestEntry `json:"tools"`
+}
+
+// ManifestEntry records one tool's hook installation.
+type ManifestEntry struct {
+	ConfigFile      string    `json:"config_file"`
+	Commands        []string  `json:"commands"`
+	InstalledAt     time.Time `json:"installed_at"`
+	IntentraVersion string    `json:"intentra_version,omitempty"`
+}
+
+// ReadInstallManifest returns the install manifest, empty when no hooks
+// have been installed.
+func ReadInstallManifest() (*InstallManifest, error) {
+	path, err := config.GetInstallManifestFile()
+	if err != nil {
+		return nil, err
+	}
+	return readInstallManifest(path)
+}
+
+func readInstallManifest(path string) (*InstallManifest, error) {
+	m := &InstallManifest{Tools: make(map[Tool]ManifestEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if m.Tools == nil {
+		m.Tools = make(map[Tool]ManifestEntry)
+	}
+	return m, nil
+}
+
+// recordInstall sets tool's manifest entry from the hook commands now in
+// its config file.
+func recordInstall(tool Tool, version string) error {
+	dir, err := GetHooksDir(tool)
+	if err != nil {
+		return err
+	}
+	configFile := filepath.Join(dir, toolRegistry[tool].checkFile)
+	entry := ManifestEntry{
+		ConfigFile:      configFile,
+		Commands:        intentraHookCommands(configFile),
+		InstalledAt:     time.Now().UTC(),
+		IntentraVersion: version,
+	}
+	return updateInstallManifest(func(m *InstallManifest) { m.Tools[tool] = entry })
+}
+
+// recordUninstall removes tool's manifest entry.
+func recordUninstall(tool Tool) error {
+	return updateInstallManifest(func(m *InstallManifest) { delete(m.Tools, tool) })
+}
+
+// updateInstallManifest applies update to the manifest under a lock, since
+// InstallAll and UninstallAll handle several tools at once.
+func updateInstallManifest(update func(*InstallManifest)) error {
+	path, err := config.GetInstallManifestFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	release, err := acquireBufferLock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	m, err := readInstallManifest(path)
+	if err != nil {
+		return err
+	}
+	update(m)
+
+	if len(m.Tools) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove install manifest: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal install manifest: %w", err)
+	}
+	if err := fileutil.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write install manifest: %w", err)
+	}
+	return nil
+}
@@ -201,8 +201,9 @@ func normalizeModelID(model string, tool string) string {
 // Each tool has exactly ONE designated terminal event to prevent duplicate scans.
 //
 // NOTE: Windsurf does not provide a dedicated "stop" hook. We use
-// EventAfterResponse as the best available proxy, but this means scans may
-// be incomplete if the session continues after the last observed response.
+// EventAfterResponse as the best available proxy, so by default each
+// response ends a scan. With local.windsurf_idle_timeout set, a response only
+// schedules the scan, which is finalized once the session goes idle.
 // Windsurf sessions that end without a final response will not generate a scan.
 func IsStopEvent(eventType NormalizedEventType, tool string) bool {
 	switch tool {
@@ -0,0 +1,63 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestNormalizers_CoverInstalledHookTypes(t *testing.T) {
+	for _, tool := range AllTools() {
+		t.Run(string(tool), func(t *testing.T) {
+			n := GetNormalizer(string(tool))
+			if n.Tool() != string(tool) {
+				t.Fatalf("GetNormalizer(%s) returned the %q normalizer", tool, n.Tool())
+			}
+			for _, native := range HookEvents(tool) {
+				if got := n.NormalizeEventType(native); got == models.EventUnknown {
+					t.Errorf("%s event %s normalizes to %s", tool, native, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizers_EventMappings(t *testing.T) {
+	tests := []struct {
+		tool   Tool
+		native string
+		want   NormalizedEventType
+	}{
+		{ToolCursor, "beforeSubmitPrompt", models.EventBeforePrompt},
+		{ToolCursor, "afterAgentResponse", models.EventAfterResponse},
+		{ToolCursor, "afterMCPExecution", models.EventAfterMCP},
+		{ToolCursor, "afterFileEdit", models.EventAfterFileEdit},
+		{ToolCursor, "stop", models.EventStop},
+		{ToolClaudeCode, "UserPromptSubmit", models.EventBeforePrompt},
+		{ToolClaudeCode, "PostToolUse", models.EventAfterTool},
+		{ToolClaudeCode, "Stop", models.EventStop},
+		{ToolClaudeCode, "SessionEnd", models.EventSessionEnd},
+		{ToolGeminiCLI, "BeforeAgent", models.EventBeforePrompt},
+		{ToolGeminiCLI, "AfterAgent", models.EventAfterResponse},
+		{ToolGeminiCLI, "AfterTool", models.EventAfterTool},
+		{ToolGeminiCLI, "SessionEnd", models.EventSessionEnd},
+		{ToolCopilot, "userPromptSubmitted", models.EventBeforePrompt},
+		{ToolCopilot, "postToolUse", models.EventAfterTool},
+		{ToolCopilot, "errorOccurred", models.EventError},
+		{ToolCopilot, "sessionEnd", models.EventSessionEnd},
+		{ToolWindsurf, "pre_user_prompt", models.EventBeforePrompt},
+		{ToolWindsurf, "post_cascade_response", models.EventAfterResponse},
+		{ToolWindsurf, "post_mcp_tool_use", models.EventAfterMCP},
+		{ToolWindsurf, "post_write_code", models.EventAfterFileEdit},
+		{ToolCursor, "notAHook", models.EventUnknown},
+	}
+	for _, tt := range tests {
+		if got := GetNormalizer(string(tt.tool)).NormalizeEventType(tt.native); got != tt.want {
+			t.Errorf("%s %s = %s, want %s", tt.tool, tt.native, got, tt.want)
+		}
+	}
+
+	if got := GetNormalizer("unknown-tool").NormalizeEventType("Stop"); got != models.EventUnknown {
+		t.Errorf("unknown tool normalized Stop to %s, want %s", got, models.EventUnknown)
+	}
+}
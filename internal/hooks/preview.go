@@ -0,0 +1,88 @@
+package hooks
+
+import (
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/device"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// What the hook handler would do with a previewed event.
+const (
+	PreviewActionBuffer     = "buffer"
+	PreviewActionEndScan    = "end_scan"
+	PreviewActionSessionEnd = "session_end"
+)
+
+// EventPreview is the result of running a sample hook payload through the
+// normalization steps of the hook handler.
+type EventPreview struct {
+	Event          *models.Event       `json:"event"`
+	NormalizedType NormalizedEventType `json:"normalized_type"`
+	// Tool is the tool the event is attributed to, which differs from the
+	// requested tool when a Claude event joins an active Cursor session.
+	Tool       string   `json:"tool"`
+	SessionKey string   `json:"session_key,omitempty"`
+	BufferPath string   `json:"buffer_path,omitempty"`
+	Action     string   `json:"action"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// PreviewEvent normalizes a hook payload the way the hook handler does and
+// reports where it would be buffered, without buffering it, sending
+// anything, or updating session fallback state.
+func PreviewEvent(rawJSON []byte, tool, eventType string, cfg *config.Config) (*EventPreview, error) {
+	event, _, normalizedType, err := normalizeHookEvent(rawJSON, tool, eventType)
+	if err != nil {
+		return nil, err
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if normalizedType == models.EventNotification && !cfg.RichTraces {
+		event.NotificationMessage = redactContent(event.NotificationMessage)
+	}
+	if event.DeviceID == "" {
+		if deviceID, err := device.GetDeviceID(); err == nil {
+			event.DeviceID = deviceID
+		}
+	}
+
+	p := &EventPreview{Event: event, NormalizedType: normalizedType, Tool: tool}
+	if normalizedType == models.EventUnknown {
+		p.Notes = append(p.Notes, "event type "+eventType+" is not recognized for "+tool+"; check --tool and --event")
+	}
+
+	if event.ConversationID == "" && event.SessionID == "" {
+		strategy := cfg.Local.SessionFallback.Strategy
+		if strategy != config.SessionFallbackDevice {
+			if strategy == "" {
+				strategy = config.SessionFallbackIdleGap
+			}
+			p.Notes = append(p.Notes, "payload has no conversation or session ID; the session is assigned at runtime by session_fallback strategy "+strategy)
+			p.Action = previewAction(normalizedType, tool)
+			return p, nil
+		}
+		event.ConversationID = event.DeviceID + "_default"
+	}
+
+	p.SessionKey, p.Tool = deriveSessionKey(event, tool)
+	p.BufferPath = getBufferPath(p.SessionKey)
+	p.Action = previewAction(normalizedType, p.Tool)
+	if p.Action == PreviewActionEndScan && p.Tool == string(ToolWindsurf) && cfg.Local.WindsurfIdleTimeout > 0 {
+		p.Notes = append(p.Notes, "the scan ends once the session is idle for "+cfg.Local.WindsurfIdleTimeout.String())
+	}
+	return p, nil
+}
+
+func previewAction(normalizedType NormalizedEventType, tool string) string {
+	switch {
+	case IsStopEvent(normalizedType, tool):
+		return PreviewActionEndScan
+	case IsSessionEndEvent(normalizedType, tool):
+		return PreviewActionSessionEnd
+	default:
+		return PreviewActionBuffer
+	}
+}
@@ -0,0 +1,55 @@
+package hooks
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func TestPreviewEvent(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	cfg := config.DefaultConfig()
+
+	tests := []struct {
+		name, tool, eventType, raw string
+		wantType                   NormalizedEventType
+		wantKey                    string
+		wantAction                 string
+	}{
+		{"response", "cursor", "afterAgentResponse", `{"conversation_id":"c1"}`, models.EventAfterResponse, "cursor_c1", PreviewActionBuffer},
+		{"stop", "cursor", "stop", `{"conversation_id":"c1"}`, models.EventStop, "cursor_c1", PreviewActionEndScan},
+		{"session end", "claude", "SessionEnd", `{"session_id":"s1"}`, models.EventSessionEnd, "claude_s1", PreviewActionSessionEnd},
+		{"windsurf response", "windsurf", "post_cascade_response", `{"trajectory_id":"t1"}`, models.EventAfterResponse, "windsurf_t1", PreviewActionEndScan},
+		{"no ids", "claude", "UserPromptSubmit", `{"prompt":"hi"}`, models.EventBeforePrompt, "", PreviewActionBuffer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := PreviewEvent([]byte(tt.raw), tt.tool, tt.eventType, cfg)
+			if err != nil {
+				t.Fatalf("PreviewEvent failed: %v", err)
+			}
+			if p.NormalizedType != tt.wantType || p.SessionKey != tt.wantKey || p.Action != tt.wantAction {
+				t.Errorf("got type=%s key=%q action=%s, want type=%s key=%q action=%s",
+					p.NormalizedType, p.SessionKey, p.Action, tt.wantType, tt.wantKey, tt.wantAction)
+			}
+			if tt.wantKey != "" && p.BufferPath != getBufferPath(tt.wantKey) {
+				t.Errorf("BufferPath = %s, want %s", p.BufferPath, getBufferPath(tt.wantKey))
+			}
+			if tt.wantKey == "" && len(p.Notes) == 0 {
+				t.Error("expected a note explaining the missing session ID")
+			}
+		})
+	}
+
+	for _, pattern := range []string{"intentra_buffer_*", "intentra_fallback_*"} {
+		if files, _ := filepath.Glob(filepath.Join(sessionFileDir(), pattern)); len(files) > 0 {
+			t.Errorf("preview wrote %v", files)
+		}
+	}
+	if _, err := PreviewEvent([]byte(`not json`), "cursor", "stop", cfg); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
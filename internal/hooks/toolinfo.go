@@ -0,0 +1,79 @@
+package hooks
+
+import "path/filepath"
+
+// ToolInfo describes a supported tool: where its hooks are installed and
+// which of its native hook events intentra captures.
+type ToolInfo struct {
+	Tool       Tool   `json:"tool"`
+	Name       string `json:"name"`
+	ConfigFile string `json:"config_file,omitempty"`
+	// Events lists the native hook events intentra installs, in install order.
+	Events []string `json:"events"`
+	// StopEvents are the native events that end a scan; SessionEndEvents
+	// attach session-end metadata to the last scan instead.
+	StopEvents       []string `json:"stop_events"`
+	SessionEndEvents []string `json:"session_end_events,omitempty"`
+	Notes            string   `json:"notes,omitempty"`
+}
+
+// toolNames are the display names of the supported tools.
+var toolNames = map[Tool]string{
+	ToolCursor:     "Cursor",
+	ToolClaudeCode: "Claude Code",
+	ToolGeminiCLI:  "Gemini CLI",
+	ToolCopilot:    "GitHub Copilot",
+	ToolWindsurf:   "Windsurf",
+}
+
+// toolNotes explains scan boundaries that differ from a plain stop hook.
+var toolNotes = map[Tool]string{
+	ToolWindsurf:  "No stop hook: each response ends a scan unless local.windsurf_idle_timeout is set.",
+	ToolCopilot:   "Scans end at session end.",
+	ToolGeminiCLI: "Scans end at session end.",
+}
+
+// HookEvents returns a copy of the native hook events intentra installs for tool.
+func HookEvents(tool Tool) []string {
+	var events []string
+	switch tool {
+	case ToolCursor:
+		events = cursorHookTypes
+	case ToolClaudeCode:
+		events = claudeCodeHookTypes
+	case ToolGeminiCLI:
+		events = geminiHookTypes
+	case ToolCopilot:
+		events = copilotHookTypes
+	case ToolWindsurf:
+		events = windsurfHookTypes
+	}
+	return append([]string(nil), events...)
+}
+
+// DescribeTool returns the metadata for tool. Stop and session-end events
+// are derived from the normalizer, so they match what the hook handler does.
+func DescribeTool(tool Tool) ToolInfo {
+	info := ToolInfo{
+		Tool:       tool,
+		Name:       toolNames[tool],
+		Events:     HookEvents(tool),
+		StopEvents: []string{},
+		Notes:      toolNotes[tool],
+	}
+	if dir, err := GetHooksDir(tool); err == nil {
+		info.ConfigFile = filepath.Join(dir, toolRegistry[tool].checkFile)
+	}
+
+	n := GetNormalizer(string(tool))
+	for _, native := range info.Events {
+		normalized := n.NormalizeEventType(native)
+		switch {
+		case IsStopEvent(normalized, string(tool)):
+			info.StopEvents = append(info.StopEvents, native)
+		case IsSessionEndEvent(normalized, string(tool)):
+			info.SessionEndEvents = append(info.SessionEndEvents, native)
+		}
+	}
+	return info
+}
@@ -0,0 +1,34 @@
+package hooks
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDescribeTool_ScanBoundaries(t *testing.T) {
+	tests := []struct {
+		tool       Tool
+		stop       []string
+		sessionEnd []string
+	}{
+		{ToolCursor, []string{"stop"}, []string{"sessionEnd"}},
+		{ToolClaudeCode, []string{"Stop"}, []string{"SessionEnd"}},
+		{ToolGeminiCLI, []string{"SessionEnd"}, nil},
+		{ToolCopilot, []string{"sessionEnd"}, nil},
+		{ToolWindsurf, []string{"post_cascade_response"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.tool), func(t *testing.T) {
+			info := DescribeTool(tt.tool)
+			if !slices.Equal(info.StopEvents, tt.stop) {
+				t.Errorf("StopEvents = %v, want %v", info.StopEvents, tt.stop)
+			}
+			if !slices.Equal(info.SessionEndEvents, tt.sessionEnd) {
+				t.Errorf("SessionEndEvents = %v, want %v", info.SessionEndEvents, tt.sessionEnd)
+			}
+			if info.Name == "" {
+				t.Error("missing display name")
+			}
+		})
+	}
+}
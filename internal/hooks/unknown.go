@@ -0,0 +1,153 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
+)
+
+// Events whose type a tool's normalizer does not map are still buffered, but
+// never count as LLM calls, tool calls or stops. With
+// local.warn_unknown_events set, each tool/event pair is counted in
+// unknown_events.json so 'intentra doctor' can surface missing mappings.
+
+// Bounds on unknown_events.json: entries not seen for unknownEventMaxAge are
+// dropped, and past maxUnknownEvents the least recently seen are evicted.
+const (
+	maxUnknownEvents   = 100
+	unknownEventMaxAge = 30 * 24 * time.Hour
+)
+
+// UnknownEvent counts hook events of one unmapped type from one tool.
+type UnknownEvent struct {
+	Tool      string    `json:"tool"`
+	EventType string    `json:"event_type"`
+	Count     int       `json:"count"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// recordUnknownEvent notes an event whose type normalized to EventUnknown.
+// It is always debug-logged, and counted on disk when
+// local.warn_unknown_events is enabled.
+func recordUnknownEvent(tool, eventType string, cfg *config.Config, now time.Time) {
+	if cfg == nil || !cfg.Local.WarnUnknownEvents {
+		debug.Log("event type %q is not mapped for %s; buffered as unknown", eventType, tool)
+		return
+	}
+	count, err := countUnknownEvent(tool, eventType, now)
+	if err != nil {
+		debug.Warn("failed to record unknown event type: %v", err)
+		return
+	}
+	debug.Log("event type %q is not mapped for %s; buffered as unknown (seen %d times)", eventType, tool, count)
+}
+
+// countUnknownEvent increments the on-disk count for tool/eventType and
+// returns the new count. Expired entries are dropped on the way, and the
+// file is kept to maxUnknownEvents entries.
+func countUnknownEvent(tool, eventType string, now time.Time) (int, error) {
+	path, err := config.GetUnknownEventsFile()
+	if err != nil {
+		return 0, err
+	}
+	release, err := acquireBufferLock(path)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	recorded, err := readUnknownEvents(path)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.Add(-unknownEventMaxAge)
+	events := recorded[:0]
+	for _, e := range recorded {
+		if e.LastSeen.After(cutoff) {
+			events = append(events, e)
+		}
+	}
+	i := 0
+	for i < len(events) && (events[i].Tool != tool || events[i].EventType != eventType) {
+		i++
+	}
+	if i == len(events) {
+		events = append(events, UnknownEvent{Tool: tool, EventType: eventType})
+	}
+	events[i].Count++
+	events[i].LastSeen = now.UTC()
+	count := events[i].Count
+
+	if len(events) > maxUnknownEvents {
+		sort.SliceStable(events, func(a, b int) bool { return events[a].LastSeen.After(events[b].LastSeen) })
+		events = events[:maxUnknownEvents]
+	}
+
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal unknown events: %w", err)
+	}
+	if err := fileutil.WriteFileAtomic(path, data); err != nil {
+		return 0, fmt.Errorf("failed to write unknown events: %w", err)
+	}
+	return count, nil
+}
+
+// ClearUnknownEvents removes the record of unmapped event types.
+func ClearUnknownEvents() error {
+	path, err := config.GetUnknownEventsFile()
+	if err != nil {
+		return err
+	}
+	release, err := acquireBufferLock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear unknown events: %w", err)
+	}
+	return nil
+}
+
+// UnknownEvents returns the recorded unmapped event types, most frequent
+// first. It is empty unless local.warn_unknown_events has been enabled.
+func UnknownEvents() ([]UnknownEvent, error) {
+	path, err := config.GetUnknownEventsFile()
+	if err != nil {
+		return nil, err
+	}
+	events, err := readUnknownEvents(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Count != events[j].Count {
+			return events[i].Count > events[j].Count
+		}
+		return events[i].Tool+"/"+events[i].EventType < events[j].Tool+"/"+events[j].EventType
+	})
+	return events, nil
+}
+
+func readUnknownEvents(path string) ([]UnknownEvent, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unknown events: %w", err)
+	}
+	var events []UnknownEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,91 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Supported values for the minimum TLS version.
+const (
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// transport is the TLS-configured transport installed by ConfigureTLS.
+// Nil means http.DefaultTransport.
+var transport http.RoundTripper
+
+// ParseTLSVersion converts "1.2" or "1.3" to its crypto/tls constant. An
+// empty version is TLS 1.2.
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case TLSVersion12, "":
+		return tls.VersionTLS12, nil
+	case TLSVersion13:
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s (supported: %s, %s)", version, TLSVersion12, TLSVersion13)
+	}
+}
+
+// ParseCipherSuites converts cipher suite names as listed by
+// tls.CipherSuites (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) to their
+// IDs. Suites Go considers insecure are rejected.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// NewTLSConfig returns a tls.Config enforcing minVersion and, for TLS 1.2
+// connections, restricted to cipherSuites. TLS 1.3 suites are not
+// configurable in Go and are always the secure defaults.
+func NewTLSConfig(minVersion string, cipherSuites []string) (*tls.Config, error) {
+	version, err := ParseTLSVersion(minVersion)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := ParseCipherSuites(cipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{MinVersion: version, CipherSuites: suites}, nil
+}
+
+// ConfigureTLS applies the TLS settings to DefaultClient and to the
+// transport returned by Transport. Call it once after loading config.
+func ConfigureTLS(minVersion string, cipherSuites []string) error {
+	tlsConfig, err := NewTLSConfig(minVersion, cipherSuites)
+	if err != nil {
+		return err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	transport = t
+	DefaultClient.Transport = t
+	return nil
+}
+
+// Transport returns the transport HTTP clients should use, so they honor
+// the TLS settings installed by ConfigureTLS.
+func Transport() http.RoundTripper {
+	if transport == nil {
+		return http.DefaultTransport
+	}
+	return transport
+}
@@ -0,0 +1,42 @@
+package httputil
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestConfigureTLS(t *testing.T) {
+	t.Cleanup(func() {
+		transport = nil
+		DefaultClient.Transport = nil
+	})
+
+	if Transport() != http.DefaultTransport {
+		t.Error("Transport() before ConfigureTLS should be http.DefaultTransport")
+	}
+
+	suite := "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"
+	if err := ConfigureTLS(TLSVersion12, []string{suite}); err != nil {
+		t.Fatalf("ConfigureTLS failed: %v", err)
+	}
+	for name, rt := range map[string]http.RoundTripper{"Transport()": Transport(), "DefaultClient": DefaultClient.Transport} {
+		tr, ok := rt.(*http.Transport)
+		if !ok || tr.TLSClientConfig == nil {
+			t.Fatalf("%s = %T, want *http.Transport with TLS config", name, rt)
+		}
+		if tr.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+			t.Errorf("%s MinVersion = %#x, want TLS 1.2", name, tr.TLSClientConfig.MinVersion)
+		}
+		if got := tr.TLSClientConfig.CipherSuites; len(got) != 1 || got[0] != tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 {
+			t.Errorf("%s CipherSuites = %v, want only %s", name, got, suite)
+		}
+	}
+
+	if err := ConfigureTLS("1.0", nil); err == nil {
+		t.Error("expected error for TLS 1.0")
+	}
+	if err := ConfigureTLS(TLSVersion12, []string{"TLS_RSA_WITH_RC4_128_SHA"}); err == nil {
+		t.Error("expected error for insecure cipher suite")
+	}
+}
@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// deadDirName is the queue subdirectory holding scans that exceeded the
+// failure limit. They are excluded from flushes and the queue size limit.
+const deadDirName = "dead"
+
+// DeadLetter is a scan that was moved out of the queue after repeated
+// flush failures.
+type DeadLetter struct {
+	Scan      *models.Scan
+	Attempts  int
+	LastError string
+	Path      string
+}
+
+// SetMaxSyncAttempts sets how many failed flushes a queued scan survives
+// before it is dead-lettered. Values below 1 keep the default.
+func SetMaxSyncAttempts(n int) {
+	if n < 1 {
+		n = config.DefaultMaxSyncAttempts
+	}
+	maxFlushFails = n
+}
+
+func deadDir() (string, error) {
+	dir, err := queueDir()
+	if err != nil {
+		return "", err
+	}
+	dDir := filepath.Join(dir, deadDirName)
+	if err := os.MkdirAll(dDir, 0700); err != nil {
+		return "", err
+	}
+	return dDir, nil
+}
+
+// moveToDead moves a queued scan and its failure counter into the
+// dead-letter directory.
+func moveToDead(scanPath string) error {
+	dDir, err := deadDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(scanPath, filepath.Join(dDir, filepath.Base(scanPath))); err != nil {
+		return err
+	}
+	fp := failurePath(scanPath)
+	if err := os.Rename(fp, filepath.Join(dDir, filepath.Base(fp))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeadLetters lists scans moved out of the queue after repeated failures,
+// with their attempt count and last error. Entries that cannot be read are
+// skipped with a warning, as in DequeueAll.
+func DeadLetters() ([]DeadLetter, error) {
+	dDir, err := deadDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter dir: %w", err)
+	}
+
+	key, err := getQueueKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive decryption key: %w", err)
+	}
+
+	var result []DeadLetter
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExtension) {
+			continue
+		}
+		path := filepath.Join(dDir, entry.Name())
+		scan, err := readScanFile(path, key)
+		if err != nil {
+			debug.Warn("%s: %v", entry.Name(), err)
+			continue
+		}
+		attempts, lastErr := readFailures(failurePath(path))
+		result = append(result, DeadLetter{Scan: scan, Attempts: attempts, LastError: lastErr, Path: path})
+	}
+	return result, nil
+}
+
+// RetryDead moves every dead-lettered scan back into the queue with a
+// fresh failure count and returns how many were requeued.
+func RetryDead() (int, error) {
+	dead, err := DeadLetters()
+	if err != nil {
+		return 0, err
+	}
+	dir, err := queueDir()
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	now := time.Now()
+	for _, d := range dead {
+		dest := filepath.Join(dir, filepath.Base(d.Path))
+		if err := os.Rename(d.Path, dest); err != nil {
+			return requeued, fmt.Errorf("failed to requeue scan %s: %w", d.Scan.ID, err)
+		}
+		// Reset the age so the requeued scan isn't expired on the next flush.
+		_ = os.Chtimes(dest, now, now)
+		os.Remove(failurePath(d.Path))
+		requeued++
+	}
+	return requeued, nil
+}
@@ -1,42 +1,231 @@
 package queue
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/intentrahq/intentra-cli/internal/api"
 	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/pkg/models"
 )
 
-// FlushWithJWT sends all queued scans using a JWT access token.
-// Scans that fail are tracked; after 10 failures a scan is dropped from the queue.
-// Returns the number of scans successfully sent.
-func FlushWithJWT(accessToken string) int {
+// FlushResult reports the outcome of a queue flush.
+type FlushResult struct {
+	Sent         int // scans delivered and removed from the queue
+	Failed       int // scans that failed and remain queued for retry
+	DeadLettered int // scans moved to the dead-letter directory after exceeding the failure limit
+	Remaining    int // scans left in the queue after the flush
+}
+
+// flushMu serializes flushes within a process so two passes never send the
+// same queued scan.
+var flushMu sync.Mutex
+
+// ErrFlushLocked is returned by Flush when another process holds the flush
+// lock and is already sending the queue.
+var ErrFlushLocked = errors.New("offline queue is being flushed by another process")
+
+// Background flush state for TriggerFlushWithJWT: at most one flush runs at
+// a time, and triggers that arrive meanwhile are coalesced into one rerun.
+var (
+	bgMu      sync.Mutex
+	bgRunning bool
+	bgPending bool
+	bgToken   string
+	bgWG      sync.WaitGroup
+
+	// backgroundFlush is the flush run by TriggerFlushWithJWT; swapped out in tests.
+	backgroundFlush = flushWithJWT
+)
+
+// flushLockName is the lock file in the queue directory held while a flush
+// runs, so separate processes never send the queue in parallel.
+const flushLockName = ".flush.lock"
+
+// flushLockStaleAge is how long a flush lock may go untouched before it is
+// treated as left behind by a process that died mid-flush. A running flush
+// touches the lock before every batch of scans.
+const flushLockStaleAge = 2 * time.Minute
+
+// tryFlushLock takes the cross-process flush lock by creating flushLockName,
+// as the hooks buffer lock does. It does not wait: it returns ErrFlushLocked
+// when another process already holds the lock. On success it returns the
+// lock file path; remove it to release the lock.
+func tryFlushLock() (string, error) {
+	dir, err := queueDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get queue dir: %w", err)
+	}
+	lockFile := filepath.Join(dir, flushLockName)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return lockFile, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create flush lock: %w", err)
+		}
+		if info, err := os.Stat(lockFile); err == nil && time.Since(info.ModTime()) <= flushLockStaleAge {
+			return "", ErrFlushLocked
+		}
+		os.Remove(lockFile)
+	}
+	return "", ErrFlushLocked
+}
+
+// flushBatchSize is how many queued scans Flush passes to send at a time.
+const flushBatchSize = 50
+
+// Flush sends up to limit queued scans with send (0 sends all), at most
+// flushBatchSize per call. send reports partial failure with a
+// *api.BatchError; any other error fails every scan in the call.
+// Scans that fail are tracked; after buffer.max_sync_attempts failures a scan
+// is moved to the dead-letter directory so it stops blocking the queue.
+// Flush holds the cross-process flush lock while it runs and returns
+// ErrFlushLocked without sending anything if another process holds it.
+func Flush(send func([]*models.Scan) error, limit int) (FlushResult, error) {
+	flushMu.Lock()
+	defer flushMu.Unlock()
+
+	var res FlushResult
+
+	lockFile, err := tryFlushLock()
+	if err != nil {
+		return res, err
+	}
+	defer os.Remove(lockFile)
+
 	queued, err := DequeueAll()
 	if err != nil {
-		debug.Warn("failed to read offline queue: %v", err)
-		return 0
+		return res, fmt.Errorf("failed to read offline queue: %w", err)
 	}
-	if len(queued) == 0 {
-		return 0
+	if limit > 0 && len(queued) > limit {
+		queued = queued[:limit]
+	}
+
+	if len(queued) > 0 {
+		debug.Log("Flushing %d queued scan(s)", len(queued))
 	}
+	for start := 0; start < len(queued); start += flushBatchSize {
+		// Keep the lock fresh so a long flush is not mistaken for a stale one.
+		now := time.Now()
+		os.Chtimes(lockFile, now, now)
 
-	debug.Log("Flushing %d queued scan(s)", len(queued))
-	sent := 0
-	for _, qs := range queued {
-		if err := api.SendScanWithJWT(qs.Scan, accessToken); err != nil {
-			debug.Warn("failed to flush queued scan %s: %v", qs.Scan.ID, err)
-			if removed := RecordFailure(qs.Path); removed {
-				debug.Warn("removed queued scan %s after %d failed attempts", qs.Scan.ID, maxFlushFails)
+		chunk := queued[start:min(start+flushBatchSize, len(queued))]
+		scans := make([]*models.Scan, len(chunk))
+		for i, qs := range chunk {
+			scans[i] = qs.Scan
+		}
+		sendErr := send(scans)
+		var batchErr *api.BatchError
+		errors.As(sendErr, &batchErr)
+
+		for _, qs := range chunk {
+			err := sendErr
+			if batchErr != nil {
+				err = nil
+				if msg, failed := batchErr.Failed[qs.Scan.ID]; failed {
+					err = errors.New(msg)
+				}
+			}
+			if err != nil {
+				debug.Warn("failed to flush queued scan %s: %v", qs.Scan.ID, err)
+				if removed := RecordFailure(qs.Path, err); removed {
+					debug.Warn("dead-lettered queued scan %s after %d failed attempts", qs.Scan.ID, maxFlushFails)
+					res.DeadLettered++
+				} else {
+					res.Failed++
+				}
+				continue
 			}
-			continue
+			Remove(qs.Path)
+			res.Sent++
+			debug.Log("Flushed queued scan: %s", qs.Scan.ID)
 		}
-		Remove(qs.Path)
-		sent++
-		debug.Log("Flushed queued scan: %s", qs.Scan.ID)
 	}
 
+	res.Remaining = PendingCount()
+	return res, nil
+}
+
+// FlushWithJWT sends all queued scans and then pending session-end
+// PATCHes (which may target those scans) using a JWT access token, and
+// prints how many scans were synced. Returns the number of scans sent.
+func FlushWithJWT(accessToken string) int {
+	sent := flushWithJWT(accessToken)
 	if sent > 0 {
 		fmt.Printf("Synced %d offline scan(s) to intentra.sh\n", sent)
 	}
 	return sent
 }
+
+// flushWithJWT is FlushWithJWT without the summary line, for hook processes
+// whose stdout belongs to the AI tool.
+func flushWithJWT(accessToken string) int {
+	defer FlushSessionEnds(func(ctx context.Context, se SessionEnd) error {
+		return api.PatchSessionEndContext(ctx, se.ScanID, accessToken, se.Reason, se.DurationMs)
+	})
+
+	res, err := Flush(func(scans []*models.Scan) error {
+		return api.SendScansWithJWT(scans, accessToken)
+	}, 0)
+	if errors.Is(err, ErrFlushLocked) {
+		debug.Log("%v", err)
+		return 0
+	}
+	if err != nil {
+		debug.Warn("%v", err)
+		return 0
+	}
+	if res.Sent > 0 {
+		debug.Log("Synced %d offline scan(s)", res.Sent)
+	}
+	return res.Sent
+}
+
+// TriggerFlushWithJWT runs a queue flush in the background unless one is
+// already running. In that case the running flush makes one more pass with
+// the latest token when it finishes, so scans queued meanwhile are picked up
+// without starting another goroutine per trigger. Short-lived processes must
+// call WaitForFlush before exiting so the flush is not cut off mid-send.
+func TriggerFlushWithJWT(accessToken string) {
+	bgMu.Lock()
+	defer bgMu.Unlock()
+	bgToken = accessToken
+	if bgRunning {
+		bgPending = true
+		return
+	}
+	bgRunning = true
+	bgWG.Add(1)
+	go func() {
+		defer bgWG.Done()
+		token := accessToken
+		for {
+			backgroundFlush(token)
+
+			bgMu.Lock()
+			if !bgPending {
+				bgRunning = false
+				bgMu.Unlock()
+				return
+			}
+			bgPending = false
+			token = bgToken
+			bgMu.Unlock()
+		}
+	}()
+}
+
+// WaitForFlush blocks until the flush started by TriggerFlushWithJWT, if
+// any, has finished.
+func WaitForFlush() {
+	bgWG.Wait()
+}
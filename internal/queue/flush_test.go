@@ -0,0 +1,423 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/api"
+	"github.com/intentrahq/intentra-cli/internal/auth"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+func enqueueScans(t *testing.T, n int) {
+	t.Helper()
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	for i := 0; i < n; i++ {
+		if err := Enqueue(&models.Scan{ID: fmt.Sprintf("scan-%d", i)}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+}
+
+// perScan adapts a per-scan send func to Flush, reporting failures as a
+// *api.BatchError the way api.SendScansWithJWT does.
+func perScan(send func(*models.Scan) error) func([]*models.Scan) error {
+	return func(scans []*models.Scan) error {
+		failed := make(map[string]string)
+		for _, s := range scans {
+			if err := send(s); err != nil {
+				failed[s.ID] = err.Error()
+			}
+		}
+		if len(failed) > 0 {
+			return &api.BatchError{Failed: failed}
+		}
+		return nil
+	}
+}
+
+func TestFlush_SendsAll(t *testing.T) {
+	enqueueScans(t, 3)
+
+	var sent []string
+	res, err := Flush(perScan(func(s *models.Scan) error {
+		sent = append(sent, s.ID)
+		return nil
+	}), 0)
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if res.Sent != 3 || res.Failed != 0 || res.Remaining != 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if len(sent) != 3 {
+		t.Errorf("sent %d scans, want 3", len(sent))
+	}
+	if PendingCount() != 0 {
+		t.Errorf("PendingCount = %d, want 0", PendingCount())
+	}
+}
+
+func TestFlush_RespectsLimit(t *testing.T) {
+	enqueueScans(t, 5)
+
+	res, err := Flush(perScan(func(*models.Scan) error { return nil }), 2)
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if res.Sent != 2 || res.Remaining != 3 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestFlush_CountsFailures(t *testing.T) {
+	enqueueScans(t, 2)
+
+	res, err := Flush(perScan(func(s *models.Scan) error {
+		if s.ID == "scan-0" {
+			return errors.New("server unavailable")
+		}
+		return nil
+	}), 0)
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if res.Sent != 1 || res.Failed != 1 || res.Remaining != 1 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestFlush_SendsInBatches(t *testing.T) {
+	enqueueScans(t, flushBatchSize+2)
+
+	var sizes []int
+	res, err := Flush(func(scans []*models.Scan) error {
+		sizes = append(sizes, len(scans))
+		if len(sizes) == 2 {
+			return errors.New("server unavailable")
+		}
+		return nil
+	}, 0)
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(sizes) != 2 || sizes[0] != flushBatchSize || sizes[1] != 2 {
+		t.Errorf("batch sizes = %v, want [%d 2]", sizes, flushBatchSize)
+	}
+	// An error other than *api.BatchError fails the whole batch.
+	if res.Sent != flushBatchSize || res.Failed != 2 || res.Remaining != 2 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestGetStatus_TracksFailures(t *testing.T) {
+	enqueueScans(t, 4)
+
+	if st := GetStatus(); st.Pending != 4 || st.FailedCount != 0 || st.LastError != "" {
+		t.Fatalf("initial status = %+v", st)
+	}
+
+	_, err := Flush(perScan(func(s *models.Scan) error {
+		switch s.ID {
+		case "scan-0", "scan-1":
+			return errors.New("API returned 401: key expired")
+		case "scan-2":
+			return errors.New("connection refused")
+		}
+		return nil
+	}), 0)
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	st := GetStatus()
+	if st.Pending != 3 || st.FailedCount != 3 {
+		t.Errorf("status = %+v, want 3 pending and 3 failed", st)
+	}
+	if st.LastError != "API returned 401: key expired" {
+		t.Errorf("LastError = %q, want the most common error", st.LastError)
+	}
+}
+
+func TestFlush_DeadLettersAfterMaxAttempts(t *testing.T) {
+	enqueueScans(t, 2)
+	SetMaxSyncAttempts(2)
+	t.Cleanup(func() { SetMaxSyncAttempts(0) })
+
+	failScan0 := func(s *models.Scan) error {
+		if s.ID == "scan-0" {
+			return errors.New("API returned 401")
+		}
+		return errors.New("server unavailable")
+	}
+	if res, _ := Flush(perScan(failScan0), 0); res.DeadLettered != 0 || res.Failed != 2 {
+		t.Fatalf("first flush = %+v", res)
+	}
+	res, err := Flush(perScan(failScan0), 0)
+	if err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if res.DeadLettered != 2 || res.Remaining != 0 {
+		t.Errorf("second flush = %+v, want both scans dead-lettered", res)
+	}
+
+	dead, err := DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	if len(dead) != 2 {
+		t.Fatalf("got %d dead letters, want 2", len(dead))
+	}
+	for _, d := range dead {
+		if d.Attempts != 2 || d.LastError == "" {
+			t.Errorf("dead letter %s = %d attempts, last error %q", d.Scan.ID, d.Attempts, d.LastError)
+		}
+	}
+	if st := GetStatus(); st.DeadCount != 2 || st.Pending != 0 {
+		t.Errorf("status = %+v, want 2 dead and 0 pending", st)
+	}
+
+	n, err := RetryDead()
+	if err != nil {
+		t.Fatalf("RetryDead failed: %v", err)
+	}
+	if n != 2 || PendingCount() != 2 {
+		t.Errorf("requeued %d, pending %d; want 2 and 2", n, PendingCount())
+	}
+	if st := GetStatus(); st.DeadCount != 0 || st.FailedCount != 0 {
+		t.Errorf("status after retry = %+v, want fresh failure counts", st)
+	}
+
+	res, err = Flush(perScan(func(*models.Scan) error { return nil }), 0)
+	if err != nil || res.Sent != 2 {
+		t.Errorf("flush after retry = %+v, %v; want 2 sent", res, err)
+	}
+}
+
+func TestDeadLetters_SkipsUnreadable(t *testing.T) {
+	enqueueScans(t, 1)
+	SetMaxSyncAttempts(1)
+	t.Cleanup(func() { SetMaxSyncAttempts(0) })
+
+	if res, _ := Flush(perScan(func(*models.Scan) error { return errors.New("API returned 401") }), 0); res.DeadLettered != 1 {
+		t.Fatalf("flush = %+v, want the scan dead-lettered", res)
+	}
+	dDir, err := deadDir()
+	if err != nil {
+		t.Fatalf("deadDir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dDir, "corrupt"+fileExtension), []byte("not encrypted"), 0600); err != nil {
+		t.Fatalf("failed to write corrupt dead letter: %v", err)
+	}
+
+	dead, err := DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	if len(dead) != 1 || dead[0].Scan.ID != "scan-0" {
+		t.Errorf("dead letters = %+v, want scan-0 only", dead)
+	}
+}
+
+func TestRecordFailure_KeepsScanWhenDeadLetterFails(t *testing.T) {
+	enqueueScans(t, 1)
+	SetMaxSyncAttempts(1)
+	t.Cleanup(func() { SetMaxSyncAttempts(0) })
+
+	dir, err := queueDir()
+	if err != nil {
+		t.Fatalf("queueDir failed: %v", err)
+	}
+	// A file where the dead-letter directory should be makes the move fail.
+	if err := os.WriteFile(filepath.Join(dir, deadDirName), nil, 0600); err != nil {
+		t.Fatalf("failed to block dead-letter dir: %v", err)
+	}
+
+	res, _ := Flush(perScan(func(*models.Scan) error { return errors.New("API returned 401") }), 0)
+	if res.DeadLettered != 0 || res.Failed != 1 {
+		t.Errorf("flush = %+v, want the scan kept as failed", res)
+	}
+	if PendingCount() != 1 {
+		t.Errorf("pending = %d, want the scan still queued", PendingCount())
+	}
+}
+
+func TestFlushSessionEnds(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+
+	if err := EnqueueSessionEnd(SessionEnd{ScanID: "scan-1", Reason: "user_exit", DurationMs: 5000}); err != nil {
+		t.Fatalf("EnqueueSessionEnd failed: %v", err)
+	}
+	if err := EnqueueSessionEnd(SessionEnd{ScanID: "../escape"}); err == nil {
+		t.Error("EnqueueSessionEnd should reject unsafe scan IDs")
+	}
+
+	sent, failed := FlushSessionEnds(func(context.Context, SessionEnd) error { return errors.New("server unavailable") })
+	if sent != 0 || failed != 1 {
+		t.Errorf("failing flush = %d sent, %d failed; want 0, 1", sent, failed)
+	}
+	pending, err := PendingSessionEnds()
+	if err != nil || len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("pending after failure = %+v, %v; want one entry with 1 attempt", pending, err)
+	}
+
+	var got SessionEnd
+	sent, failed = FlushSessionEnds(func(_ context.Context, se SessionEnd) error {
+		got = se
+		return nil
+	})
+	if sent != 1 || failed != 0 {
+		t.Errorf("retry = %d sent, %d failed; want 1, 0", sent, failed)
+	}
+	if got.ScanID != "scan-1" || got.Reason != "user_exit" || got.DurationMs != 5000 {
+		t.Errorf("retried %+v, want original reason and duration", got)
+	}
+	if pending, _ := PendingSessionEnds(); len(pending) != 0 {
+		t.Errorf("%d session ends still queued after a successful retry", len(pending))
+	}
+}
+
+func TestFlushSessionEnds_GraceTimeout(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	SetSessionEndGrace(20 * time.Millisecond)
+	t.Cleanup(func() { SetSessionEndGrace(0) })
+
+	if err := EnqueueSessionEnd(SessionEnd{ScanID: "scan-1", Reason: "user_exit"}); err != nil {
+		t.Fatalf("EnqueueSessionEnd failed: %v", err)
+	}
+	sent, failed := FlushSessionEnds(func(ctx context.Context, _ SessionEnd) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+	if sent != 0 || failed != 1 {
+		t.Errorf("stalled PATCH = %d sent, %d failed; want 0, 1", sent, failed)
+	}
+	if pending, _ := PendingSessionEnds(); len(pending) != 1 {
+		t.Errorf("%d session ends queued, want the timed-out PATCH kept", len(pending))
+	}
+}
+
+func TestTriggerFlushWithJWT_SingleFlight(t *testing.T) {
+	t.Setenv("INTENTRA_CONFIG_DIR", t.TempDir())
+	var mu sync.Mutex
+	var running, maxRunning, runs int
+	var lastToken string
+	backgroundFlush = func(token string) int {
+		mu.Lock()
+		running++
+		runs++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		lastToken = token
+		mu.Unlock()
+		return 0
+	}
+	t.Cleanup(func() { backgroundFlush = flushWithJWT })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			TriggerFlushWithJWT(fmt.Sprintf("token-%d", i))
+		}(i)
+	}
+	wg.Wait()
+	TriggerFlushWithJWT("token-last")
+	WaitForFlush()
+
+	if maxRunning != 1 {
+		t.Errorf("max concurrent flushes = %d, want 1", maxRunning)
+	}
+	if runs < 1 || runs >= 50 {
+		t.Errorf("flush ran %d times for 101 triggers, want them coalesced", runs)
+	}
+	if lastToken != "token-last" {
+		t.Errorf("last flush used %q, want the latest token", lastToken)
+	}
+}
+
+func TestFlush_CrossProcessLock(t *testing.T) {
+	enqueueScans(t, 2)
+	send := func(*models.Scan) error { return nil }
+
+	dir, err := queueDir()
+	if err != nil {
+		t.Fatalf("queueDir: %v", err)
+	}
+	lockFile := filepath.Join(dir, flushLockName)
+	if err := os.WriteFile(lockFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Flush(perScan(send), 0); !errors.Is(err, ErrFlushLocked) {
+		t.Errorf("Flush while another process held the lock = %v, want ErrFlushLocked", err)
+	}
+	if n := PendingCount(); n != 2 {
+		t.Errorf("PendingCount = %d, want the queue untouched", n)
+	}
+
+	stale := time.Now().Add(-2 * flushLockStaleAge)
+	if err := os.Chtimes(lockFile, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Flush(perScan(send), 0)
+	if err != nil {
+		t.Fatalf("Flush after the lock went stale: %v", err)
+	}
+	if res.Sent != 2 {
+		t.Errorf("sent %d scans, want 2", res.Sent)
+	}
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Errorf("flush lock left behind: %v", err)
+	}
+}
+
+func TestReadScanFile_LegacyKey(t *testing.T) {
+	legacy, ok := auth.DeriveLegacyKey(queueKeySalt, queueKeyInfo)
+	if !ok {
+		t.Skip("no legacy Linux machine ID on this host")
+	}
+	key, err := getQueueKey()
+	if err != nil {
+		t.Fatalf("getQueueKey: %v", err)
+	}
+
+	plaintext, _ := json.Marshal(&models.Scan{ID: "scan-legacy"})
+	ciphertext, err := auth.Encrypt(plaintext, legacy)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "scan-legacy.enc")
+	if err := os.WriteFile(path, ciphertext, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	scan, err := readScanFile(path, key)
+	if err != nil || scan.ID != "scan-legacy" {
+		t.Fatalf("readScanFile = %+v, %v; want the scan queued under the legacy key", scan, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Decrypt(data, key); err != nil {
+		t.Errorf("queued scan not re-encrypted under the current key: %v", err)
+	}
+}
@@ -17,19 +17,23 @@ import (
 	"github.com/intentrahq/intentra-cli/internal/auth"
 	"github.com/intentrahq/intentra-cli/internal/config"
 	"github.com/intentrahq/intentra-cli/internal/debug"
+	"github.com/intentrahq/intentra-cli/internal/fileutil"
 	"github.com/intentrahq/intentra-cli/pkg/models"
 )
 
 const (
 	maxQueueSize   = 500
 	maxAgeHours    = 72
-	maxFlushFails  = 10
 	fileExtension  = ".scan.enc"
 	failsExtension = ".failures"
 	queueKeySalt   = "intentra-queue-key-v1"
 	queueKeyInfo   = "scan-queue-encryption"
 )
 
+// maxFlushFails is how many failed flushes a scan survives before it is
+// moved to the dead-letter directory. See SetMaxSyncAttempts.
+var maxFlushFails = config.DefaultMaxSyncAttempts
+
 var (
 	cachedKey    []byte
 	cachedKeyErr error
@@ -126,28 +130,50 @@ func DequeueAll() ([]QueuedScan, error) {
 			continue
 		}
 
-		ciphertext, err := os.ReadFile(path)
+		scan, err := readScanFile(path, key)
 		if err != nil {
-			debug.Warn("failed to read queued scan %s: %v", entry.Name(), err)
+			debug.Warn("%s: %v", entry.Name(), err)
 			continue
 		}
 
-		plaintext, err := auth.Decrypt(ciphertext, key)
-		if err != nil {
-			debug.Warn("failed to decrypt queued scan %s: %v", entry.Name(), err)
-			continue
-		}
+		result = append(result, QueuedScan{Scan: scan, Path: path})
+	}
 
-		var scan models.Scan
-		if err := json.Unmarshal(plaintext, &scan); err != nil {
-			debug.Warn("failed to unmarshal queued scan %s: %v", entry.Name(), err)
-			continue
-		}
+	return result, nil
+}
 
-		result = append(result, QueuedScan{Scan: &scan, Path: path})
+// readScanFile reads and decrypts a single queued scan file.
+func readScanFile(path string, key []byte) (*models.Scan, error) {
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queued scan: %w", err)
 	}
 
-	return result, nil
+	plaintext, err := auth.Decrypt(ciphertext, key)
+	if err != nil {
+		// Scans queued by releases that keyed the queue off the bare
+		// Linux machine ID.
+		legacy, ok := auth.DeriveLegacyKey(queueKeySalt, queueKeyInfo)
+		if !ok {
+			return nil, fmt.Errorf("failed to decrypt queued scan: %w", err)
+		}
+		if plaintext, err = auth.Decrypt(ciphertext, legacy); err != nil {
+			return nil, fmt.Errorf("failed to decrypt queued scan: %w", err)
+		}
+		// Re-encrypt under the current key so the next read no longer
+		// needs the legacy one.
+		if ciphertext, err := auth.Encrypt(plaintext, key); err == nil {
+			if err := fileutil.WriteFileAtomic(path, ciphertext); err != nil {
+				debug.Warn("failed to re-encrypt queued scan %s: %v", path, err)
+			}
+		}
+	}
+
+	var scan models.Scan
+	if err := json.Unmarshal(plaintext, &scan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal queued scan: %w", err)
+	}
+	return &scan, nil
 }
 
 // Remove deletes a queued scan file and its failure counter after successful send.
@@ -195,28 +221,101 @@ func queueDir() (string, error) {
 	return qDir, nil
 }
 
-// RecordFailure increments the failure counter for a queued scan.
-// Returns true if the scan should be removed (exceeded maxFlushFails).
-func RecordFailure(scanPath string) bool {
+// RecordFailure increments the failure counter for a queued scan and
+// records sendErr as its last error. Once the scan reaches maxFlushFails
+// it is moved to the dead-letter directory and RecordFailure returns true.
+// If the move fails the scan stays queued, to be retried on the next flush.
+func RecordFailure(scanPath string, sendErr error) bool {
 	fp := failurePath(scanPath)
-	count := 0
-
-	data, err := os.ReadFile(fp)
-	if err == nil {
-		_, _ = fmt.Sscanf(string(data), "%d", &count)
-	}
+	count, _ := readFailures(fp)
 
 	count++
-	_ = os.WriteFile(fp, []byte(fmt.Sprintf("%d", count)), 0600)
+	record := fmt.Sprintf("%d", count)
+	if sendErr != nil {
+		record += "\n" + strings.ReplaceAll(sendErr.Error(), "\n", " ")
+	}
+	_ = os.WriteFile(fp, []byte(record), 0600)
 
 	if count >= maxFlushFails {
-		Remove(scanPath)
-		os.Remove(fp)
+		if err := moveToDead(scanPath); err != nil {
+			debug.Warn("failed to dead-letter queued scan %s, keeping it queued: %v", scanPath, err)
+			return false
+		}
 		return true
 	}
 	return false
 }
 
+// readFailures returns the failure count and last error stored in a
+// failure counter file. Counters written before errors were recorded
+// hold only the count.
+func readFailures(fp string) (int, string) {
+	data, err := os.ReadFile(fp)
+	if err != nil {
+		return 0, ""
+	}
+	countStr, lastErr, _ := strings.Cut(string(data), "\n")
+	count := 0
+	_, _ = fmt.Sscanf(countStr, "%d", &count)
+	return count, lastErr
+}
+
+// Status summarizes the offline queue.
+type Status struct {
+	Pending     int    // scans waiting in the queue
+	FailedCount int    // queued scans that failed at least one flush
+	LastError   string // most common last error among failed scans
+	DeadCount   int    // scans moved to the dead-letter directory
+}
+
+// GetStatus returns the queue's pending and failure counts. Ties for the
+// most common error are broken alphabetically so the result is stable.
+func GetStatus() Status {
+	var st Status
+	dir, err := queueDir()
+	if err != nil {
+		return st
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return st
+	}
+
+	errCounts := make(map[string]int)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExtension) {
+			continue
+		}
+		st.Pending++
+		count, lastErr := readFailures(failurePath(filepath.Join(dir, e.Name())))
+		if count == 0 {
+			continue
+		}
+		st.FailedCount++
+		if lastErr != "" {
+			errCounts[lastErr]++
+		}
+	}
+
+	if dDir, err := deadDir(); err == nil {
+		if dead, err := os.ReadDir(dDir); err == nil {
+			for _, e := range dead {
+				if !e.IsDir() && strings.HasSuffix(e.Name(), fileExtension) {
+					st.DeadCount++
+				}
+			}
+		}
+	}
+
+	for msg, n := range errCounts {
+		best := errCounts[st.LastError]
+		if n > best || (n == best && msg < st.LastError) {
+			st.LastError = msg
+		}
+	}
+	return st
+}
+
 func failurePath(scanPath string) string {
 	return strings.TrimSuffix(scanPath, fileExtension) + failsExtension
 }
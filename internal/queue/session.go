@@ -0,0 +1,165 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
+)
+
+// sessionEndDirName is the queue subdirectory holding session-end PATCHes
+// that could not be sent, one JSON file per scan ID.
+const sessionEndDirName = "session_end"
+
+// SessionEnd is session-end metadata waiting to be patched onto a scan
+// that was already sent.
+type SessionEnd struct {
+	ScanID     string `json:"scan_id"`
+	Reason     string `json:"reason,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+}
+
+// sessionEndGrace bounds one session-end PATCH, retries included. See
+// SetSessionEndGrace.
+var sessionEndGrace = config.DefaultSessionEndGrace
+
+// SetSessionEndGrace sets how long a session-end PATCH may take before it
+// is given up on and left queued. Values below 1ns keep the default.
+func SetSessionEndGrace(d time.Duration) {
+	if d <= 0 {
+		d = config.DefaultSessionEndGrace
+	}
+	sessionEndGrace = d
+}
+
+// SessionEndContext returns a context that expires after the session-end
+// grace timeout.
+func SessionEndContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), sessionEndGrace)
+}
+
+func sessionEndDir() (string, error) {
+	dir, err := queueDir()
+	if err != nil {
+		return "", err
+	}
+	sDir := filepath.Join(dir, sessionEndDirName)
+	if err := os.MkdirAll(sDir, 0700); err != nil {
+		return "", err
+	}
+	return sDir, nil
+}
+
+// EnqueueSessionEnd stores a session-end PATCH for retry on the next sync.
+// A later entry for the same scan replaces the earlier one.
+func EnqueueSessionEnd(se SessionEnd) error {
+	if err := validateQueueID(se.ScanID); err != nil {
+		return err
+	}
+	dir, err := sessionEndDir()
+	if err != nil {
+		return fmt.Errorf("failed to get queue dir: %w", err)
+	}
+	data, err := json.Marshal(se)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session end: %w", err)
+	}
+
+	path := filepath.Join(dir, se.ScanID+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write queued session end: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to finalize queued session end: %w", err)
+	}
+	debug.Log("Queued session end for scan %s", se.ScanID)
+	return nil
+}
+
+// PendingSessionEnds returns the queued session-end PATCHes.
+func PendingSessionEnds() ([]SessionEnd, error) {
+	dir, err := sessionEndDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session end queue: %w", err)
+	}
+
+	var pending []SessionEnd
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			debug.Warn("failed to read queued session end %s: %v", e.Name(), err)
+			continue
+		}
+		var se SessionEnd
+		if err := json.Unmarshal(data, &se); err != nil {
+			debug.Warn("failed to parse queued session end %s: %v", e.Name(), err)
+			continue
+		}
+		pending = append(pending, se)
+	}
+	return pending, nil
+}
+
+// FlushSessionEnds retries queued session-end PATCHes with patch, giving
+// each one buffer.session_end_grace. Sent entries are removed; failed ones
+// stay queued until they have failed buffer.max_sync_attempts times. It
+// returns how many were sent and failed.
+func FlushSessionEnds(patch func(context.Context, SessionEnd) error) (sent, failed int) {
+	pending, err := PendingSessionEnds()
+	if err != nil {
+		debug.Warn("%v", err)
+		return 0, 0
+	}
+	dir, err := sessionEndDir()
+	if err != nil {
+		return 0, 0
+	}
+
+	for _, se := range pending {
+		path := filepath.Join(dir, se.ScanID+".json")
+		ctx, cancel := SessionEndContext()
+		err := patch(ctx, se)
+		cancel()
+		if err != nil {
+			failed++
+			se.Attempts++
+			if se.Attempts >= maxFlushFails {
+				debug.Warn("dropping session end for scan %s after %d failed attempts: %v", se.ScanID, se.Attempts, err)
+				os.Remove(path)
+				continue
+			}
+			debug.Warn("failed to flush session end for scan %s: %v", se.ScanID, err)
+			if err := EnqueueSessionEnd(se); err != nil {
+				debug.Warn("%v", err)
+			}
+			continue
+		}
+		os.Remove(path)
+		sent++
+	}
+	return sent, failed
+}
+
+// validateQueueID rejects scan IDs that are unsafe to use as file names.
+func validateQueueID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid scan ID: %q", id)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/intentrahq/intentra-cli/internal/config"
 	"github.com/intentrahq/intentra-cli/pkg/models"
 )
 
@@ -100,6 +101,9 @@ func createScan(conversationID string, events []models.Event) models.Scan {
 		scan.InputTokens += e.InputTokens
 		scan.OutputTokens += e.OutputTokens
 		scan.ThinkingTokens += e.ThinkingTokens
+		scan.ReportedCost += e.ReportedCost
+		scan.TotalCharsChanged += e.CharsChanged
+		scan.TotalLinesChanged += e.LinesAdded + e.LinesRemoved
 
 		eventType := models.NormalizedEventType(e.NormalizedType)
 		if models.IsLLMCallEvent(eventType) {
@@ -108,20 +112,60 @@ func createScan(conversationID string, events []models.Event) models.Scan {
 		if models.IsToolCallEvent(eventType) {
 			scan.ToolCalls++
 		}
+		if e.RateLimited {
+			scan.RateLimitHits++
+		}
+		if e.StopReason != "" {
+			scan.StopReason = e.StopReason
+		}
+		if e.NotificationType != "" {
+			if scan.NotificationCounts == nil {
+				scan.NotificationCounts = make(map[string]int)
+			}
+			scan.NotificationCounts[e.NotificationType]++
+		}
 	}
 
 	scan.TotalTokens = scan.InputTokens + scan.OutputTokens + scan.ThinkingTokens
 	scan.EstimatedCost = EstimateCost(scan.TotalTokens, getModel(events), getTool(events))
+	scan.EstimatedCostLow, scan.EstimatedCostHigh = CostBand(scan.EstimatedCost)
+
+	SetFingerprints(&scan, events)
 
 	return scan
 }
 
+// SetFingerprints sets scan's exact and similarity fingerprints from the
+// prompts of events. Both the scan aggregate command and the hook handler
+// use it, so the same session yields the same fingerprints either way; the
+// events must carry the prompt text the tool sent, not a redacted
+// placeholder.
+func SetFingerprints(scan *models.Scan, events []models.Event) {
+	var prompts []string
+	for _, e := range events {
+		if e.Prompt != "" {
+			prompts = append(prompts, e.Prompt)
+		}
+	}
+	scan.Fingerprint = CalculateFingerprint(prompts)
+	scan.SimFingerprint = CalculateSimFingerprint(prompts)
+}
+
 func getModel(events []models.Event) string {
 	for _, e := range events {
 		if e.Model != "" {
 			return e.Model
 		}
 	}
+	return DefaultModel(getTool(events))
+}
+
+// DefaultModel returns the model assumed for pricing when a scan's events
+// carry no model: gpt-4o for Copilot, claude-sonnet-4.5 otherwise.
+func DefaultModel(tool string) string {
+	if tool == "copilot" {
+		return "gpt-4o"
+	}
 	return "claude-sonnet-4.5"
 }
 
@@ -139,40 +183,254 @@ func getTool(events []models.Event) string {
 var sortedModelPrefixes []string
 
 func init() {
-	sortedModelPrefixes = make([]string, 0, len(modelPricing))
-	for prefix := range modelPricing {
-		sortedModelPrefixes = append(sortedModelPrefixes, prefix)
+	sortedModelPrefixes = sortedPrefixes(modelPricing)
+}
+
+// sortedPrefixes returns the keys of prices sorted by length descending.
+func sortedPrefixes(prices map[string]float64) []string {
+	prefixes := make([]string, 0, len(prices))
+	for prefix := range prices {
+		prefixes = append(prefixes, prefix)
 	}
-	sort.Slice(sortedModelPrefixes, func(i, j int) bool {
-		return len(sortedModelPrefixes[i]) > len(sortedModelPrefixes[j])
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
 	})
+	return prefixes
 }
 
 // EstimateCost calculates the estimated cost for a given number of tokens and model.
 // Falls back to a default price of $0.005/1K tokens if the model is not recognized.
 // Applies tool-specific pricing multipliers when tool is provided.
 func EstimateCost(tokens int, model string, tool ...string) float64 {
-	var basePrice float64
-	matched := false
+	_, basePrice := lookupModelPrice(model)
+	multiplier := 1.0
+	if len(tool) > 0 {
+		multiplier = toolMultiplier(tool[0])
+	}
+	return float64(tokens) / 1000.0 * basePrice * multiplier
+}
+
+// defaultPricePer1K is used when a model does not match any known prefix.
+const defaultPricePer1K = 0.005
+
+// pricingTable is the built-in pricing with config overrides applied.
+type pricingTable struct {
+	priceOverrides          map[string]float64
+	sortedOverridePrefixes  []string
+	toolMultiplierOverrides map[string]float64
+	costBandPct             float64
+}
+
+// newPricingTable layers the overrides in p over the built-in tables.
+func newPricingTable(p config.PricingConfig) pricingTable {
+	t := pricingTable{
+		priceOverrides:          make(map[string]float64, len(p.ModelPrices)),
+		toolMultiplierOverrides: p.ToolMultipliers,
+		costBandPct:             defaultCostBandPct,
+	}
+	for _, mp := range p.ModelPrices {
+		if prefix := strings.TrimSpace(mp.Prefix); prefix != "" {
+			t.priceOverrides[prefix] = mp.PricePer1K
+		}
+	}
+	t.sortedOverridePrefixes = sortedPrefixes(t.priceOverrides)
+	if p.ConfidencePct > 0 {
+		t.costBandPct = p.ConfidencePct
+	}
+	return t
+}
+
+// activePricing is the table used for all estimates.
+var activePricing = newPricingTable(config.PricingConfig{})
+
+// SetPricingOverrides installs config-provided model prices and tool
+// multipliers. Call it once after loading config, before estimating costs;
+// a zero PricingConfig restores the built-in tables.
+func SetPricingOverrides(p config.PricingConfig) {
+	activePricing = newPricingTable(p)
+}
+
+// defaultCostBandPct is the default half-width of the cost confidence band.
+// Client-side estimates use blended rates and cannot see cache usage.
+const defaultCostBandPct = 20.0
+
+// CostBand returns the low and high ends of the confidence band around a
+// point cost estimate (local.pricing.confidence_pct, default 20%).
+func CostBand(cost float64) (low, high float64) {
+	delta := cost * activePricing.costBandPct / 100
+	return cost - delta, cost + delta
+}
+
+// lookupModelPrice returns the active table's price for model; see
+// pricingTable.modelPrice.
+func lookupModelPrice(model string) (string, float64) {
+	return activePricing.modelPrice(model)
+}
+
+// modelPrice returns the longest matching pricing prefix for model and
+// its price per 1K tokens, checking config overrides before built-in prices.
+// A provider prefix such as "anthropic/" is ignored. The prefix is empty when
+// the default price applies.
+func (t pricingTable) modelPrice(model string) (string, float64) {
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		model = model[idx+1:]
+	}
+	for _, prefix := range t.sortedOverridePrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return prefix, t.priceOverrides[prefix]
+		}
+	}
 	for _, prefix := range sortedModelPrefixes {
 		if strings.HasPrefix(model, prefix) {
-			basePrice = modelPricing[prefix]
-			matched = true
-			break
+			return prefix, modelPricing[prefix]
 		}
 	}
-	if !matched {
-		basePrice = 0.005
+	return "", defaultPricePer1K
+}
+
+// toolMultiplier returns the active table's multiplier for tool.
+func toolMultiplier(tool string) float64 {
+	return activePricing.toolMultiplier(tool)
+}
+
+// toolMultiplier returns the pricing multiplier for tool, checking config
+// overrides first, or 1.0 if unknown.
+func (t pricingTable) toolMultiplier(tool string) float64 {
+	if m, ok := t.toolMultiplierOverrides[tool]; ok {
+		return m
 	}
-	multiplier := 1.0
-	if len(tool) > 0 {
-		if m, ok := toolPricingMultipliers[tool[0]]; ok {
-			multiplier = m
-		}
+	if m, ok := toolPricingMultipliers[tool]; ok {
+		return m
 	}
-	return float64(tokens) / 1000.0 * basePrice * multiplier
+	return 1.0
+}
+
+// CostBreakdown itemizes a cost estimate by token direction.
+type CostBreakdown struct {
+	Model         string  `json:"model"`
+	Tool          string  `json:"tool,omitempty"`
+	MatchedPrefix string  `json:"matched_prefix,omitempty"`
+	PricePer1K    float64 `json:"price_per_1k"`
+	Multiplier    float64 `json:"multiplier"`
+	InputTokens   int     `json:"input_tokens"`
+	OutputTokens  int     `json:"output_tokens"`
+	InputCost     float64 `json:"input_cost"`
+	OutputCost    float64 `json:"output_cost"`
+	TotalCost     float64 `json:"total_cost"`
+}
+
+// EstimateCostBreakdown prices input and output tokens separately using the
+// same rates as EstimateCost. TotalCost equals EstimateCost for the summed tokens.
+func EstimateCostBreakdown(inputTokens, outputTokens int, model, tool string) CostBreakdown {
+	prefix, price := lookupModelPrice(model)
+	multiplier := toolMultiplier(tool)
+
+	b := CostBreakdown{
+		Model:         model,
+		Tool:          tool,
+		MatchedPrefix: prefix,
+		PricePer1K:    price,
+		Multiplier:    multiplier,
+		InputTokens:   inputTokens,
+		OutputTokens:  outputTokens,
+		InputCost:     float64(inputTokens) / 1000.0 * price * multiplier,
+		OutputCost:    float64(outputTokens) / 1000.0 * price * multiplier,
+	}
+	b.TotalCost = b.InputCost + b.OutputCost
+	return b
 }
 
+// ScanCostBreakdown explains a scan's estimated cost: every token type is
+// priced at the scan model's rate times the tool multiplier, and the
+// per-type costs sum to TotalCost.
+type ScanCostBreakdown struct {
+	ScanID        string  `json:"scan_id"`
+	Model         string  `json:"model"`
+	DefaultModel  bool    `json:"default_model,omitempty"` // the scan named no model; the tool's default was priced
+	Tool          string  `json:"tool,omitempty"`
+	MatchedPrefix string  `json:"matched_prefix,omitempty"`
+	PricePer1K    float64 `json:"price_per_1k"`
+	Multiplier    float64 `json:"multiplier"`
+
+	InputTokens    int `json:"input_tokens"`
+	OutputTokens   int `json:"output_tokens"`
+	ThinkingTokens int `json:"thinking_tokens"`
+	// OtherTokens is the part of the scan's total not split by type, as in
+	// server scans that report only a total.
+	OtherTokens int `json:"other_tokens,omitempty"`
+
+	InputCost    float64 `json:"input_cost"`
+	OutputCost   float64 `json:"output_cost"`
+	ThinkingCost float64 `json:"thinking_cost"`
+	OtherCost    float64 `json:"other_cost,omitempty"`
+	TotalCost    float64 `json:"total_cost"`
+	CostLow      float64 `json:"cost_low"`
+	CostHigh     float64 `json:"cost_high"`
+
+	// MCPCost is the share of the recorded estimate attributed to MCP tool
+	// calls, across MCPTools distinct tools.
+	MCPCost  float64 `json:"mcp_cost,omitempty"`
+	MCPTools int     `json:"mcp_tools,omitempty"`
+
+	// RecordedCost is the estimate stored on the scan. It differs from
+	// TotalCost when pricing has changed since the scan was made.
+	RecordedCost float64 `json:"recorded_cost"`
+	// ReportedCost is the tool's own cost figure, when it sent one.
+	ReportedCost float64 `json:"reported_cost,omitempty"`
+}
+
+// ExplainScanCost recomputes s's estimated cost under the active pricing,
+// the way the hook handler prices a scan: all tokens at the scan model's
+// rate, or the tool's default model's when the scan names none.
+func ExplainScanCost(s *models.Scan) ScanCostBreakdown {
+	model := s.Model
+	b := ScanCostBreakdown{
+		ScanID:         s.ID,
+		Tool:           s.Tool,
+		InputTokens:    s.InputTokens,
+		OutputTokens:   s.OutputTokens,
+		ThinkingTokens: s.ThinkingTokens,
+		RecordedCost:   s.EstimatedCost,
+		ReportedCost:   s.ReportedCost,
+	}
+	if model == "" {
+		model = DefaultModel(s.Tool)
+		b.DefaultModel = true
+	}
+	b.Model = model
+	b.MatchedPrefix, b.PricePer1K = lookupModelPrice(model)
+	b.Multiplier = toolMultiplier(s.Tool)
+	if typed := s.InputTokens + s.OutputTokens + s.ThinkingTokens; s.TotalTokens > typed {
+		b.OtherTokens = s.TotalTokens - typed
+	}
+
+	rate := b.PricePer1K * b.Multiplier / 1000.0
+	b.InputCost = float64(b.InputTokens) * rate
+	b.OutputCost = float64(b.OutputTokens) * rate
+	b.ThinkingCost = float64(b.ThinkingTokens) * rate
+	b.OtherCost = float64(b.OtherTokens) * rate
+	b.TotalCost = b.InputCost + b.OutputCost + b.ThinkingCost + b.OtherCost
+	b.CostLow, b.CostHigh = CostBand(b.TotalCost)
+
+	for _, call := range s.MCPToolUsage {
+		b.MCPCost += call.EstimatedCost
+	}
+	b.MCPTools = len(s.MCPToolUsage)
+	return b
+}
+
+// CountFileChanges splits files from AggregateFilesModified into those the
+// scan created and existing files it edited.
+func CountFileChanges(files []map[string]any) (newFiles, modifiedFiles int) {
+	for _, f := range files {
+		if isNew, _ := f["is_new_file"].(bool); isNew {
+			newFiles++
+		} else {
+			modifiedFiles++
+		}
+	}
+	return newFiles, modifiedFiles
+}
 
 // AggregateFilesModified builds per-file edit statistics from a slice of events.
 func AggregateFilesModified(events []models.Event) []map[string]any {
@@ -204,9 +462,14 @@ func AggregateFilesModified(events []models.Event) []map[string]any {
 		case models.EventAfterFileEdit:
 			s.editCount++
 			// Edit content (new_string/old_string) is stripped by sanitizeEvent before
-			// events are persisted, so real line counts are unavailable here. Fall back
-			// to the token-based heuristic until edit ranges are captured in Phase 1b.
-			s.linesAdded += ev.OutputTokens / 15
+			// events are persisted; use the line counts measured at capture time when
+			// the tool sent a structured edit, else the token-based heuristic.
+			if ev.LinesAdded > 0 || ev.LinesRemoved > 0 {
+				s.linesAdded += ev.LinesAdded
+				s.linesRemoved += ev.LinesRemoved
+			} else {
+				s.linesAdded += ev.OutputTokens / 15
+			}
 			if !s.seenBefore {
 				s.isNew = true
 			}
@@ -1,9 +1,12 @@
 package scanner
 
 import (
+	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/intentrahq/intentra-cli/internal/config"
 	"github.com/intentrahq/intentra-cli/pkg/models"
 )
 
@@ -37,6 +40,38 @@ func TestAggregateMultipleConversations(t *testing.T) {
 	}
 }
 
+func TestAggregateEvents_CountsRateLimitHits(t *testing.T) {
+	events := []models.Event{
+		{NormalizedType: "before_prompt", ConversationID: "conv-1", Timestamp: time.Now()},
+		{NormalizedType: "after_tool", ConversationID: "conv-1", Timestamp: time.Now(), Error: "429", RateLimited: true},
+		{NormalizedType: "after_tool", ConversationID: "conv-1", Timestamp: time.Now(), Error: "exit status 1"},
+		{NormalizedType: "after_response", ConversationID: "conv-1", Timestamp: time.Now(), RateLimited: true},
+	}
+
+	scans := AggregateEvents(events)
+	if len(scans) != 1 {
+		t.Fatalf("Expected 1 scan, got %d", len(scans))
+	}
+	if scans[0].RateLimitHits != 2 {
+		t.Errorf("RateLimitHits = %d, want 2", scans[0].RateLimitHits)
+	}
+}
+
+func TestAggregateEvents_StopReason(t *testing.T) {
+	events := []models.Event{
+		{NormalizedType: "before_prompt", ConversationID: "conv-1", Timestamp: time.Now()},
+		{NormalizedType: "stop", ConversationID: "conv-1", Timestamp: time.Now(), StopReason: "end_turn"},
+	}
+
+	scans := AggregateEvents(events)
+	if len(scans) != 1 {
+		t.Fatalf("Expected 1 scan, got %d", len(scans))
+	}
+	if scans[0].StopReason != "end_turn" {
+		t.Errorf("StopReason = %q, want end_turn", scans[0].StopReason)
+	}
+}
+
 func TestEstimateCost(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -98,6 +133,83 @@ func TestEstimateCost_LongestPrefixWins(t *testing.T) {
 }
 
 
+func TestEstimateCostBreakdown(t *testing.T) {
+	tests := []struct {
+		model  string
+		tool   string
+		input  int
+		output int
+		prefix string
+	}{
+		{"claude-sonnet-4.5-20250301", "", 1000, 500, "claude-sonnet-4.5"},
+		{"gpt-4o-2024-11-20", "copilot", 2000, 250, "gpt-4o"},
+		{"claude-opus-4.5", "windsurf", 1500, 1500, "claude-opus-4.5"},
+		{"some-unknown-model", "", 1000, 1000, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			b := EstimateCostBreakdown(tt.input, tt.output, tt.model, tt.tool)
+			if b.MatchedPrefix != tt.prefix {
+				t.Errorf("MatchedPrefix = %q, want %q", b.MatchedPrefix, tt.prefix)
+			}
+			want := EstimateCost(tt.input+tt.output, tt.model, tt.tool)
+			if diff := b.TotalCost - want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("TotalCost = %f, want %f", b.TotalCost, want)
+			}
+			if diff := b.InputCost + b.OutputCost - b.TotalCost; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("InputCost + OutputCost = %f, want %f", b.InputCost+b.OutputCost, b.TotalCost)
+			}
+		})
+	}
+}
+
+func TestExplainScanCost(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		scan models.Scan
+	}{
+		{"model and tool multiplier", models.Scan{ID: "s1", Tool: "windsurf", Model: "claude-opus-4.5", InputTokens: 1200, OutputTokens: 800, ThinkingTokens: 300, TotalTokens: 2300}},
+		{"tool default model", models.Scan{ID: "s2", Tool: "copilot", InputTokens: 500, OutputTokens: 250, TotalTokens: 750}},
+		{"total only", models.Scan{ID: "s3", Tool: "claude", Model: "claude-sonnet-4.5", InputTokens: 100, TotalTokens: 1100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.scan
+			s.StartTime = start
+			model := s.Model
+			if model == "" {
+				model = DefaultModel(s.Tool)
+			}
+			s.EstimatedCost = EstimateCost(s.TotalTokens, model, s.Tool)
+
+			b := ExplainScanCost(&s)
+			if sum := b.InputCost + b.OutputCost + b.ThinkingCost + b.OtherCost; math.Abs(sum-b.TotalCost) > 1e-9 {
+				t.Errorf("per-type costs sum to %f, want %f", sum, b.TotalCost)
+			}
+			product := float64(s.TotalTokens) / 1000 * b.PricePer1K * b.Multiplier
+			if math.Abs(product-b.TotalCost) > 1e-9 {
+				t.Errorf("tokens × rate × multiplier = %f, want %f", product, b.TotalCost)
+			}
+			if math.Abs(b.TotalCost-s.EstimatedCost) > 1e-9 {
+				t.Errorf("TotalCost = %f, want recorded %f", b.TotalCost, s.EstimatedCost)
+			}
+			if b.DefaultModel != (s.Model == "") {
+				t.Errorf("DefaultModel = %v for model %q", b.DefaultModel, s.Model)
+			}
+		})
+	}
+
+	s := models.Scan{
+		Tool: "claude", Model: "claude-sonnet-4.5", InputTokens: 1000, TotalTokens: 1000, EstimatedCost: 1,
+		MCPToolUsage: []models.MCPToolCall{{ToolName: "a", EstimatedCost: 0.25}, {ToolName: "b", EstimatedCost: 0.5}},
+	}
+	if b := ExplainScanCost(&s); b.MCPCost != 0.75 || b.MCPTools != 2 {
+		t.Errorf("MCP portion = $%f across %d tools, want $0.75 across 2", b.MCPCost, b.MCPTools)
+	}
+}
+
 func TestAggregateEvents_SkipsEmptyConversationID(t *testing.T) {
 	events := []models.Event{
 		{ConversationID: "", Timestamp: time.Now(), NormalizedType: "after_response"},
@@ -148,6 +260,26 @@ func TestAggregateFilesModified(t *testing.T) {
 	})
 }
 
+func TestCountFileChanges(t *testing.T) {
+	events := []models.Event{
+		{FilePath: "/foo/old.go", NormalizedType: "before_file_edit"},
+		{FilePath: "/foo/old.go", NormalizedType: "after_file_edit"},
+		{FilePath: "/foo/new.go", NormalizedType: "after_file_edit"},
+		{FilePath: "/foo/other.go", NormalizedType: "before_file_edit"},
+		{FilePath: "/foo/other.go", NormalizedType: "after_file_edit"},
+		{FilePath: "/foo/fresh.go", NormalizedType: "after_file_edit"},
+		{FilePath: "/foo/fresh.go", NormalizedType: "after_file_edit"},
+	}
+	newFiles, modified := CountFileChanges(AggregateFilesModified(events))
+	if newFiles != 2 || modified != 2 {
+		t.Errorf("CountFileChanges = %d new, %d modified; want 2, 2", newFiles, modified)
+	}
+
+	if n, m := CountFileChanges(nil); n != 0 || m != 0 {
+		t.Errorf("CountFileChanges(nil) = %d, %d; want 0, 0", n, m)
+	}
+}
+
 func TestSanitizePath(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
 		if models.SanitizePath("") != "" {
@@ -161,3 +293,106 @@ func TestSanitizePath(t *testing.T) {
 		}
 	})
 }
+
+func TestEstimateCost_ConfigOverrides(t *testing.T) {
+	t.Cleanup(func() { SetPricingOverrides(config.PricingConfig{}) })
+
+	SetPricingOverrides(config.PricingConfig{
+		ToolMultipliers: map[string]float64{"windsurf": 1.5, "cursor": 0.5},
+		ModelPrices: []config.ModelPrice{
+			{Prefix: "claude-sonnet-4.5", PricePer1K: 0.01},
+			{Prefix: "acme-coder", PricePer1K: 0.002},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		model    string
+		tool     string
+		expected float64
+	}{
+		{"overridden model", "claude-sonnet-4.5-20250301", "", 0.01},
+		{"new model", "acme-coder-v2", "", 0.002},
+		{"built-in fallback", "gpt-4o-2024-11-20", "", 0.005},
+		{"overridden multiplier", "claude-sonnet-4.5", "windsurf", 0.015},
+		{"discount multiplier", "gpt-4o", "cursor", 0.0025},
+		{"built-in multiplier fallback", "gpt-4o", "copilot", 0.005},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateCost(1000, tt.model, tt.tool)
+			if diff := got - tt.expected; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("EstimateCost(1000, %q, %q) = %f, want %f", tt.model, tt.tool, got, tt.expected)
+			}
+		})
+	}
+
+	b := EstimateCostBreakdown(1000, 0, "claude-sonnet-4.5", "")
+	if b.MatchedPrefix != "claude-sonnet-4.5" || b.PricePer1K != 0.01 {
+		t.Errorf("breakdown = %+v, want override price", b)
+	}
+
+	SetPricingOverrides(config.PricingConfig{})
+	if got := EstimateCost(1000, "claude-sonnet-4.5", "windsurf"); got-0.0066*1.2 > 1e-9 || got-0.0066*1.2 < -1e-9 {
+		t.Errorf("after reset EstimateCost = %f, want built-in %f", got, 0.0066*1.2)
+	}
+}
+
+func TestEstimateCost_IgnoresProviderPrefix(t *testing.T) {
+	for _, model := range []string{"anthropic/claude-sonnet-4.5-20250301", "openai/gpt-4o", "google/gemini-2.5-pro"} {
+		bare := model[strings.Index(model, "/")+1:]
+		if got, want := EstimateCost(1000, model, "cursor"), EstimateCost(1000, bare, "cursor"); got != want {
+			t.Errorf("EstimateCost(%q) = %f, want %f (same as %q)", model, got, want, bare)
+		}
+	}
+	if b := EstimateCostBreakdown(1000, 0, "anthropic/claude-opus-4.5", ""); b.MatchedPrefix != "claude-opus-4.5" {
+		t.Errorf("MatchedPrefix = %q, want claude-opus-4.5", b.MatchedPrefix)
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	if got := DefaultModel("copilot"); got != "gpt-4o" {
+		t.Errorf("DefaultModel(copilot) = %q, want gpt-4o", got)
+	}
+	if got := DefaultModel("claude"); got != "claude-sonnet-4.5" {
+		t.Errorf("DefaultModel(claude) = %q, want claude-sonnet-4.5", got)
+	}
+}
+
+func TestCostBand_BracketsEstimate(t *testing.T) {
+	t.Cleanup(func() { SetPricingOverrides(config.PricingConfig{}) })
+
+	for _, cost := range []float64{0.0066, 1.25, 42} {
+		low, high := CostBand(cost)
+		if !(low < cost && cost < high) {
+			t.Errorf("CostBand(%f) = [%f, %f], want band around estimate", cost, low, high)
+		}
+		if diff := (high - cost) - (cost - low); diff > 1e-12 || diff < -1e-12 {
+			t.Errorf("CostBand(%f) is not symmetric: [%f, %f]", cost, low, high)
+		}
+	}
+
+	SetPricingOverrides(config.PricingConfig{ConfidencePct: 50})
+	if low, high := CostBand(2); low != 1 || high != 3 {
+		t.Errorf("CostBand(2) at 50%% = [%f, %f], want [1, 3]", low, high)
+	}
+
+	if low, high := CostBand(0); low != 0 || high != 0 {
+		t.Errorf("CostBand(0) = [%f, %f], want zero band", low, high)
+	}
+}
+
+func TestAggregateEvents_SetsCostBand(t *testing.T) {
+	events := []models.Event{
+		{NormalizedType: "before_prompt", ConversationID: "conv-band", Timestamp: time.Now(), InputTokens: 1000},
+		{NormalizedType: "after_response", ConversationID: "conv-band", Timestamp: time.Now(), OutputTokens: 500},
+	}
+	scans := AggregateEvents(events)
+	if len(scans) != 1 {
+		t.Fatalf("Expected 1 scan, got %d", len(scans))
+	}
+	s := scans[0]
+	if !(s.EstimatedCostLow < s.EstimatedCost && s.EstimatedCost < s.EstimatedCostHigh) {
+		t.Errorf("band [%f, %f] does not bracket estimate %f", s.EstimatedCostLow, s.EstimatedCostHigh, s.EstimatedCost)
+	}
+}
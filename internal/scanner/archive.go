@@ -17,6 +17,7 @@ type archivedScan struct {
 	ID              string          `json:"scan_id"`
 	DeviceID        string          `json:"device_id,omitempty"`
 	Tool            string          `json:"tool,omitempty"`
+	Model           string          `json:"model,omitempty"`
 	ConversationID  string          `json:"conversation_id,omitempty"`
 	SessionID       string          `json:"session_id,omitempty"`
 	StartTime       time.Time       `json:"start_time"`
@@ -56,15 +57,10 @@ func archiveScan(scan *models.Scan, cfg *config.Config) error {
 		return nil
 	}
 
-	archiveDir := cfg.Local.Archive.Path
-	if archiveDir == "" {
-		if dataDir, err := config.GetDataDir(); err == nil {
-			archiveDir = filepath.Join(dataDir, "archive")
-		} else {
-			return fmt.Errorf("failed to determine data directory: %w", err)
-		}
+	archiveDir, err := ArchiveDir(cfg)
+	if err != nil {
+		return err
 	}
-	archiveDir = os.ExpandEnv(archiveDir)
 
 	if err := os.MkdirAll(archiveDir, 0700); err != nil {
 		return err
@@ -85,6 +81,144 @@ func archiveScan(scan *models.Scan, cfg *config.Config) error {
 	return os.WriteFile(filename, data, 0600)
 }
 
+// ArchiveDir returns the configured local archive directory, defaulting to
+// the archive directory under the data dir.
+func ArchiveDir(cfg *config.Config) (string, error) {
+	archiveDir := cfg.Local.Archive.Path
+	if archiveDir == "" {
+		dataDir, err := config.GetDataDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine data directory: %w", err)
+		}
+		archiveDir = filepath.Join(dataDir, "archive")
+	}
+	return os.ExpandEnv(archiveDir), nil
+}
+
+// loadArchive reads all archived scans in dir. Files that cannot be read or
+// parsed are counted in skipped rather than failing the whole read.
+func loadArchive(dir string) (scans []archivedScan, skipped int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			skipped++
+			continue
+		}
+		var a archivedScan
+		if err := json.Unmarshal(data, &a); err != nil || a.ID == "" {
+			skipped++
+			continue
+		}
+		scans = append(scans, a)
+	}
+	return scans, skipped, nil
+}
+
+// ArchiveGroupStats holds totals for one tool or model in the archive.
+type ArchiveGroupStats struct {
+	Scans         int     `json:"scans"`
+	TotalTokens   int     `json:"total_tokens"`
+	EstimatedCost float64 `json:"estimated_cost"`
+}
+
+// ArchiveStats summarizes the scans in the local archive.
+type ArchiveStats struct {
+	Dir           string                       `json:"dir"`
+	Scans         int                          `json:"scans"`
+	Skipped       int                          `json:"skipped,omitempty"`
+	FirstStart    *time.Time                   `json:"first_start,omitempty"`
+	LastEnd       *time.Time                   `json:"last_end,omitempty"`
+	TotalTokens   int                          `json:"total_tokens"`
+	EstimatedCost float64                      `json:"estimated_cost"`
+	WithEvents    int                          `json:"scans_with_events"`
+	Redacted      int                          `json:"scans_with_redacted_events"`
+	ByTool        map[string]ArchiveGroupStats `json:"by_tool,omitempty"`
+	ByModel       map[string]ArchiveGroupStats `json:"by_model,omitempty"`
+}
+
+// ComputeArchiveStats reads the archive at dir and summarizes it. A missing
+// directory yields empty stats.
+func ComputeArchiveStats(dir string) (ArchiveStats, error) {
+	st := ArchiveStats{Dir: dir}
+
+	scans, skipped, err := loadArchive(dir)
+	if err != nil {
+		return st, err
+	}
+	st.Skipped = skipped
+	if len(scans) == 0 {
+		return st, nil
+	}
+	st.ByTool = make(map[string]ArchiveGroupStats)
+	st.ByModel = make(map[string]ArchiveGroupStats)
+
+	for _, a := range scans {
+		st.Scans++
+		st.TotalTokens += a.TotalTokens
+		st.EstimatedCost += a.EstimatedCost
+
+		if !a.StartTime.IsZero() && (st.FirstStart == nil || a.StartTime.Before(*st.FirstStart)) {
+			start := a.StartTime
+			st.FirstStart = &start
+		}
+		if !a.EndTime.IsZero() && (st.LastEnd == nil || a.EndTime.After(*st.LastEnd)) {
+			end := a.EndTime
+			st.LastEnd = &end
+		}
+
+		if len(a.Events) > 0 {
+			st.WithEvents++
+			if a.Events[0].ContentHash != "" {
+				st.Redacted++
+			}
+		}
+
+		addArchiveGroup(st.ByTool, valueOrUnknown(a.Tool), a)
+		addArchiveGroup(st.ByModel, valueOrUnknown(archivedModel(a)), a)
+	}
+	return st, nil
+}
+
+// archivedModel returns the scan's model, falling back to the first event
+// model for archives written before the scan-level field existed.
+func archivedModel(a archivedScan) string {
+	if a.Model != "" {
+		return a.Model
+	}
+	for _, e := range a.Events {
+		if e.Model != "" {
+			return e.Model
+		}
+	}
+	return ""
+}
+
+func addArchiveGroup(groups map[string]ArchiveGroupStats, key string, a archivedScan) {
+	g := groups[key]
+	g.Scans++
+	g.TotalTokens += a.TotalTokens
+	g.EstimatedCost += a.EstimatedCost
+	groups[key] = g
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
 func createarchivedScan(scan *models.Scan, cfg *config.Config) *archivedScan {
 	eventTypeCounts := make(map[string]int)
 	for _, e := range scan.Events {
@@ -97,6 +231,7 @@ func createarchivedScan(scan *models.Scan, cfg *config.Config) *archivedScan {
 		ID:              scan.ID,
 		DeviceID:        scan.DeviceID,
 		Tool:            scan.Tool,
+		Model:           scan.Model,
 		ConversationID:  scan.ConversationID,
 		SessionID:       "",
 		StartTime:       scan.StartTime,
@@ -267,3 +267,74 @@ func TestArchiveScan(t *testing.T) {
 		}
 	})
 }
+
+func TestComputeArchiveStats(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	cfg := &config.Config{}
+	cfg.Local.Archive.Enabled = true
+	cfg.Local.Archive.Path = dir
+	cfg.Local.Archive.IncludeEvents = true
+	cfg.Local.Archive.Redacted = true
+
+	scans := []*models.Scan{
+		{ID: "a1", Tool: "cursor", Model: "claude-sonnet-4.5", TotalTokens: 1000, EstimatedCost: 0.5,
+			StartTime: base, EndTime: base.Add(time.Minute),
+			Events: []models.Event{{HookType: "afterResponse", Prompt: "secret"}}},
+		{ID: "a2", Tool: "cursor", Model: "gpt-4o", TotalTokens: 500, EstimatedCost: 0.25,
+			StartTime: base.Add(24 * time.Hour), EndTime: base.Add(25 * time.Hour)},
+		{ID: "a3", Tool: "claude", Model: "claude-sonnet-4.5", TotalTokens: 250, EstimatedCost: 0.25,
+			StartTime: base.Add(-time.Hour), EndTime: base.Add(-30 * time.Minute)},
+	}
+	for i, s := range scans {
+		if i == 1 {
+			cfg.Local.Archive.IncludeEvents = false
+		}
+		if err := archiveScan(s, cfg); err != nil {
+			t.Fatalf("archiveScan(%s) failed: %v", s.ID, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{not json"), 0600); err != nil {
+		t.Fatalf("failed to write broken file: %v", err)
+	}
+
+	st, err := ComputeArchiveStats(dir)
+	if err != nil {
+		t.Fatalf("ComputeArchiveStats failed: %v", err)
+	}
+	if st.Scans != 3 || st.Skipped != 1 {
+		t.Errorf("Scans = %d, Skipped = %d, want 3 and 1", st.Scans, st.Skipped)
+	}
+	if st.TotalTokens != 1750 {
+		t.Errorf("TotalTokens = %d, want 1750", st.TotalTokens)
+	}
+	if st.EstimatedCost < 0.9999 || st.EstimatedCost > 1.0001 {
+		t.Errorf("EstimatedCost = %f, want 1.0", st.EstimatedCost)
+	}
+	if st.FirstStart == nil || !st.FirstStart.Equal(base.Add(-time.Hour)) {
+		t.Errorf("FirstStart = %v, want %v", st.FirstStart, base.Add(-time.Hour))
+	}
+	if st.LastEnd == nil || !st.LastEnd.Equal(base.Add(25*time.Hour)) {
+		t.Errorf("LastEnd = %v, want %v", st.LastEnd, base.Add(25*time.Hour))
+	}
+	if st.WithEvents != 1 || st.Redacted != 1 {
+		t.Errorf("WithEvents = %d, Redacted = %d, want 1 and 1", st.WithEvents, st.Redacted)
+	}
+	if g := st.ByTool["cursor"]; g.Scans != 2 || g.TotalTokens != 1500 {
+		t.Errorf("ByTool[cursor] = %+v", g)
+	}
+	if g := st.ByModel["claude-sonnet-4.5"]; g.Scans != 2 || g.TotalTokens != 1250 {
+		t.Errorf("ByModel[claude-sonnet-4.5] = %+v", g)
+	}
+}
+
+func TestComputeArchiveStats_MissingDir(t *testing.T) {
+	st, err := ComputeArchiveStats(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("expected no error for missing archive, got %v", err)
+	}
+	if st.Scans != 0 || st.ByTool != nil {
+		t.Errorf("expected empty stats, got %+v", st)
+	}
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fingerprintPromptLen caps how much of each prompt contributes to the exact fingerprint.
+const fingerprintPromptLen = 200
+
+// simHashCount is the number of MinHash functions in a sim-fingerprint.
+// Each contributes 16 bits, so fingerprints are simHashCount*4 hex characters.
+const simHashCount = 32
+
+// simShingleSize is the number of consecutive words per shingle.
+const simShingleSize = 3
+
+// simSeed fixes the MinHash function family so fingerprints are reproducible
+// across machines and releases. Changing it invalidates all stored values.
+const simSeed uint64 = 0x9e3779b97f4a7c15
+
+// CalculateFingerprint returns an exact fingerprint over a session's prompts.
+// Prompts are lowercased, trimmed, truncated and sorted before hashing, so the
+// result is independent of prompt order. Returns "" when there are no prompts.
+func CalculateFingerprint(prompts []string) string {
+	normalized := make([]string, 0, len(prompts))
+	for _, p := range prompts {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if len(p) > fingerprintPromptLen {
+			p = p[:fingerprintPromptLen]
+		}
+		normalized = append(normalized, p)
+	}
+	if len(normalized) == 0 {
+		return ""
+	}
+	sort.Strings(normalized)
+
+	hash := sha256.Sum256([]byte(strings.Join(normalized, "\n")))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// CalculateSimFingerprint returns a similarity-tolerant fingerprint over a
+// session's prompts using MinHash over word shingles. Near-identical prompt
+// sets share most components; compare with SimFingerprintSimilarity.
+// Returns "" when the prompts contain no words.
+func CalculateSimFingerprint(prompts []string) string {
+	shingles := promptShingles(prompts)
+	if len(shingles) == 0 {
+		return ""
+	}
+
+	var mins [simHashCount]uint64
+	for i := range mins {
+		mins[i] = ^uint64(0)
+	}
+	for shingle := range shingles {
+		base := fnvHash(shingle)
+		for i := range mins {
+			if h := mixHash(base, uint64(i)); h < mins[i] {
+				mins[i] = h
+			}
+		}
+	}
+
+	buf := make([]byte, 2*simHashCount)
+	for i, m := range mins {
+		binary.BigEndian.PutUint16(buf[2*i:], uint16(m>>48))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// SimFingerprintSimilarity estimates the Jaccard similarity of the prompt sets
+// behind two sim-fingerprints, from 0 (unrelated) to 1 (identical).
+// Returns 0 if either fingerprint is empty or they have different lengths.
+func SimFingerprintSimilarity(a, b string) float64 {
+	if a == "" || len(a) != len(b) || len(a)%4 != 0 {
+		return 0
+	}
+	matches := 0
+	components := len(a) / 4
+	for i := 0; i < len(a); i += 4 {
+		if a[i:i+4] == b[i:i+4] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(components)
+}
+
+// promptShingles splits prompts into lowercase word shingles.
+// Prompts shorter than one shingle contribute their words as a single shingle.
+func promptShingles(prompts []string) map[string]struct{} {
+	shingles := make(map[string]struct{})
+	for _, p := range prompts {
+		words := strings.FieldsFunc(strings.ToLower(p), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) < simShingleSize {
+			shingles[strings.Join(words, " ")] = struct{}{}
+			continue
+		}
+		for i := 0; i+simShingleSize <= len(words); i++ {
+			shingles[strings.Join(words[i:i+simShingleSize], " ")] = struct{}{}
+		}
+	}
+	return shingles
+}
+
+func fnvHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mixHash derives the i-th MinHash function from a base hash (splitmix64 finalizer).
+func mixHash(base, i uint64) uint64 {
+	x := base ^ (simSeed * (i + 1))
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+var baselinePrompts = []string{
+	"Refactor the payment service so that retries use exponential backoff and log each failed attempt with the request id",
+	"Add unit tests for the new retry logic covering timeouts, server errors and the maximum attempt limit",
+	"Update the README to describe the retry configuration options and their default values",
+}
+
+func TestCalculateFingerprint(t *testing.T) {
+	a := CalculateFingerprint([]string{"Fix the bug", "Add tests"})
+	b := CalculateFingerprint([]string{"  add TESTS ", "fix the bug"})
+	if a == "" || a != b {
+		t.Errorf("expected order/case-insensitive match, got %q and %q", a, b)
+	}
+	if c := CalculateFingerprint([]string{"Fix the bug", "Add more tests"}); c == a {
+		t.Error("expected a different fingerprint for edited prompts")
+	}
+	if got := CalculateFingerprint(nil); got != "" {
+		t.Errorf("expected empty fingerprint for no prompts, got %q", got)
+	}
+}
+
+func TestCalculateSimFingerprint_Reproducible(t *testing.T) {
+	a := CalculateSimFingerprint(baselinePrompts)
+	b := CalculateSimFingerprint(baselinePrompts)
+	if a == "" || a != b {
+		t.Fatalf("expected identical fingerprints, got %q and %q", a, b)
+	}
+	if len(a) != simHashCount*4 {
+		t.Errorf("fingerprint length = %d, want %d", len(a), simHashCount*4)
+	}
+	if got := SimFingerprintSimilarity(a, b); got != 1 {
+		t.Errorf("similarity of identical fingerprints = %f, want 1", got)
+	}
+}
+
+func TestCalculateSimFingerprint_NearIdenticalMatch(t *testing.T) {
+	edited := []string{
+		"Refactor the payment service so that retries use exponential backoff and log every failed attempt with the request id",
+		"Add unit tests for the new retry logic covering timeouts, server errors and the maximum attempt limit",
+		"Update the README to describe the retry configuration options and their default values.",
+	}
+
+	base := CalculateSimFingerprint(baselinePrompts)
+	near := CalculateSimFingerprint(edited)
+	if got := SimFingerprintSimilarity(base, near); got < 0.7 {
+		t.Errorf("near-identical similarity = %f, want >= 0.7", got)
+	}
+	if CalculateFingerprint(baselinePrompts) == CalculateFingerprint(edited) {
+		t.Error("exact fingerprint should differ for edited prompts")
+	}
+}
+
+func TestCalculateSimFingerprint_DifferentPromptsDiverge(t *testing.T) {
+	other := []string{
+		"Write a bash script that rotates nginx logs nightly and compresses archives older than a week",
+		"Explain why the kubernetes pod keeps entering CrashLoopBackOff after the config map change",
+	}
+
+	base := CalculateSimFingerprint(baselinePrompts)
+	diff := CalculateSimFingerprint(other)
+	if got := SimFingerprintSimilarity(base, diff); got > 0.2 {
+		t.Errorf("unrelated similarity = %f, want <= 0.2", got)
+	}
+}
+
+func TestSimFingerprintSimilarity_Invalid(t *testing.T) {
+	fp := CalculateSimFingerprint(baselinePrompts)
+	if got := SimFingerprintSimilarity("", fp); got != 0 {
+		t.Errorf("empty fingerprint similarity = %f, want 0", got)
+	}
+	if got := SimFingerprintSimilarity(fp[:8], fp); got != 0 {
+		t.Errorf("mismatched length similarity = %f, want 0", got)
+	}
+}
+
+func TestAggregateEvents_SetsFingerprints(t *testing.T) {
+	events := []models.Event{
+		{NormalizedType: "before_prompt", ConversationID: "conv-1", Timestamp: time.Now(), Prompt: baselinePrompts[0]},
+		{NormalizedType: "stop", ConversationID: "conv-1", Timestamp: time.Now()},
+	}
+
+	scans := AggregateEvents(events)
+	if len(scans) != 1 {
+		t.Fatalf("Expected 1 scan, got %d", len(scans))
+	}
+	if scans[0].Fingerprint == "" || scans[0].SimFingerprint == "" {
+		t.Errorf("expected fingerprints to be set, got %q / %q", scans[0].Fingerprint, scans[0].SimFingerprint)
+	}
+}
@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+)
+
+// PriceChange is a model price or tool multiplier that differs between the
+// active pricing and a candidate.
+type PriceChange struct {
+	Name      string  `json:"name"`
+	Old       float64 `json:"old"`
+	New       float64 `json:"new"`
+	ChangePct float64 `json:"change_pct"` // zero when Old is zero
+}
+
+// PricingDiff lists what would change if a candidate PricingConfig replaced
+// the active overrides.
+type PricingDiff struct {
+	Models           []PriceChange `json:"models"`
+	Tools            []PriceChange `json:"tools"`
+	OldConfidencePct float64       `json:"old_confidence_pct"`
+	NewConfidencePct float64       `json:"new_confidence_pct"`
+}
+
+// Empty reports whether the candidate prices everything the same.
+func (d PricingDiff) Empty() bool {
+	return len(d.Models) == 0 && len(d.Tools) == 0 && d.OldConfidencePct == d.NewConfidencePct
+}
+
+// ComparePricing prices every known model prefix and tool under the active
+// table and under candidate, returning those that differ, sorted by name.
+// Known means built in or named by either set of overrides.
+func ComparePricing(candidate config.PricingConfig) PricingDiff {
+	next := newPricingTable(candidate)
+	diff := PricingDiff{
+		OldConfidencePct: activePricing.costBandPct,
+		NewConfidencePct: next.costBandPct,
+	}
+
+	models := make(map[string]bool)
+	for _, prices := range []map[string]float64{modelPricing, activePricing.priceOverrides, next.priceOverrides} {
+		for prefix := range prices {
+			models[prefix] = true
+		}
+	}
+	for _, model := range slices.Sorted(maps.Keys(models)) {
+		_, oldPrice := activePricing.modelPrice(model)
+		_, newPrice := next.modelPrice(model)
+		if oldPrice != newPrice {
+			diff.Models = append(diff.Models, newPriceChange(model, oldPrice, newPrice))
+		}
+	}
+
+	tools := make(map[string]bool)
+	for _, multipliers := range []map[string]float64{toolPricingMultipliers, activePricing.toolMultiplierOverrides, next.toolMultiplierOverrides} {
+		for tool := range multipliers {
+			tools[tool] = true
+		}
+	}
+	for _, tool := range slices.Sorted(maps.Keys(tools)) {
+		if oldM, newM := activePricing.toolMultiplier(tool), next.toolMultiplier(tool); oldM != newM {
+			diff.Tools = append(diff.Tools, newPriceChange(tool, oldM, newM))
+		}
+	}
+	return diff
+}
+
+func newPriceChange(name string, oldV, newV float64) PriceChange {
+	c := PriceChange{Name: name, Old: oldV, New: newV}
+	if oldV != 0 {
+		c.ChangePct = (newV - oldV) / oldV * 100
+	}
+	return c
+}
@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+// syslogWriter is the subset of *syslog.Writer used to emit scan records.
+type syslogWriter interface {
+	Info(msg string) error
+	Close() error
+}
+
+// dialSyslog connects to syslog; platform-specific and swapped out in tests.
+var dialSyslog = dialSystemSyslog
+
+// syslogTimeout bounds connecting to and writing to syslog, so an unreachable
+// remote server cannot hang the stop hook; swapped out in tests.
+var syslogTimeout = 2 * time.Second
+
+// EmitSyslog writes a one-line record of scan to syslog when
+// local.syslog.enabled is set. It is a no-op otherwise. It gives up after
+// syslogTimeout, leaving the attempt to finish or fail in the background.
+func EmitSyslog(scan *models.Scan, cfg *config.Config) error {
+	sc := cfg.Local.Syslog
+	if !sc.Enabled {
+		return nil
+	}
+	tag := sc.Tag
+	if tag == "" {
+		tag = config.DefaultSyslogTag
+	}
+
+	dial, record := dialSyslog, formatSyslogRecord(scan)
+	done := make(chan error, 1)
+	go func() { done <- writeSyslog(dial, sc.Network, sc.Address, tag, record) }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(syslogTimeout):
+		return fmt.Errorf("syslog did not respond within %s", syslogTimeout)
+	}
+}
+
+func writeSyslog(dial func(network, addr, tag string) (syslogWriter, error), network, addr, tag, record string) error {
+	w, err := dial(network, addr, tag)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer w.Close()
+
+	if err := w.Info(record); err != nil {
+		return fmt.Errorf("failed to write to syslog: %w", err)
+	}
+	return nil
+}
+
+// formatSyslogRecord renders scan as space-separated key=value fields,
+// quoting values that contain spaces, quotes, or equals signs.
+func formatSyslogRecord(scan *models.Scan) string {
+	durationMs := int64(0)
+	if !scan.EndTime.IsZero() && !scan.StartTime.IsZero() {
+		durationMs = scan.EndTime.Sub(scan.StartTime).Milliseconds()
+	}
+
+	fields := [][2]string{
+		{"scan_id", scan.ID},
+		{"tool", scan.Tool},
+		{"model", scan.Model},
+		{"total_tokens", strconv.Itoa(scan.TotalTokens)},
+		{"input_tokens", strconv.Itoa(scan.InputTokens)},
+		{"output_tokens", strconv.Itoa(scan.OutputTokens)},
+		{"llm_calls", strconv.Itoa(scan.LLMCalls)},
+		{"tool_calls", strconv.Itoa(scan.ToolCalls)},
+		{"estimated_cost", strconv.FormatFloat(scan.EstimatedCost, 'f', 6, 64)},
+		{"duration_ms", strconv.FormatInt(durationMs, 10)},
+	}
+	if scan.RepoName != "" {
+		fields = append(fields, [2]string{"repo", scan.RepoName})
+	}
+	if scan.BranchName != "" {
+		fields = append(fields, [2]string{"branch", scan.BranchName})
+	}
+
+	var b strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		value := f[1]
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(f[0])
+		b.WriteByte('=')
+		b.WriteString(value)
+	}
+	return b.String()
+}
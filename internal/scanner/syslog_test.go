@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/pkg/models"
+)
+
+type fakeSyslog struct {
+	network, addr, tag string
+	messages           []string
+	closed             bool
+}
+
+func (f *fakeSyslog) Info(msg string) error {
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+func (f *fakeSyslog) Close() error {
+	f.closed = true
+	return nil
+}
+
+func useFakeSyslog(t *testing.T) *fakeSyslog {
+	t.Helper()
+	fake := &fakeSyslog{}
+	orig := dialSyslog
+	dialSyslog = func(network, addr, tag string) (syslogWriter, error) {
+		fake.network, fake.addr, fake.tag = network, addr, tag
+		return fake, nil
+	}
+	t.Cleanup(func() { dialSyslog = orig })
+	return fake
+}
+
+func TestEmitSyslog(t *testing.T) {
+	fake := useFakeSyslog(t)
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	scan := &models.Scan{
+		ID:            "scan-1",
+		Tool:          "claude",
+		Model:         "claude-sonnet-4",
+		TotalTokens:   1500,
+		InputTokens:   1000,
+		OutputTokens:  500,
+		LLMCalls:      3,
+		EstimatedCost: 0.0125,
+		StartTime:     start,
+		EndTime:       start.Add(2 * time.Second),
+		RepoName:      "my repo",
+	}
+
+	cfg := config.DefaultConfig()
+	if err := EmitSyslog(scan, cfg); err != nil || len(fake.messages) != 0 {
+		t.Fatalf("disabled syslog wrote %v (err %v)", fake.messages, err)
+	}
+
+	cfg.Local.Syslog.Enabled = true
+	cfg.Local.Syslog.Network = "udp"
+	cfg.Local.Syslog.Address = "logs:514"
+	if err := EmitSyslog(scan, cfg); err != nil {
+		t.Fatalf("EmitSyslog failed: %v", err)
+	}
+	if len(fake.messages) != 1 || !fake.closed {
+		t.Fatalf("messages = %v, closed = %v; want one write and a close", fake.messages, fake.closed)
+	}
+	if fake.network != "udp" || fake.addr != "logs:514" || fake.tag != "intentra" {
+		t.Errorf("dialed %s %s tag %s", fake.network, fake.addr, fake.tag)
+	}
+	msg := fake.messages[0]
+	for _, want := range []string{"scan_id=scan-1", "tool=claude", "total_tokens=1500", "estimated_cost=0.012500", "duration_ms=2000", `repo="my repo"`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("record %q missing %s", msg, want)
+		}
+	}
+	if strings.Contains(msg, "\n") {
+		t.Errorf("record should be a single line: %q", msg)
+	}
+}
+
+func TestEmitSyslog_DialError(t *testing.T) {
+	orig := dialSyslog
+	dialSyslog = func(network, addr, tag string) (syslogWriter, error) {
+		return nil, errors.New("no syslog daemon")
+	}
+	t.Cleanup(func() { dialSyslog = orig })
+
+	cfg := config.DefaultConfig()
+	cfg.Local.Syslog.Enabled = true
+	if err := EmitSyslog(&models.Scan{ID: "scan-1"}, cfg); err == nil || !strings.Contains(err.Error(), "no syslog daemon") {
+		t.Errorf("EmitSyslog error = %v, want dial failure", err)
+	}
+}
+
+func TestEmitSyslog_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	orig, origTimeout := dialSyslog, syslogTimeout
+	dialSyslog = func(network, addr, tag string) (syslogWriter, error) {
+		<-release
+		return nil, errors.New("unreachable")
+	}
+	syslogTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { dialSyslog, syslogTimeout = orig, origTimeout })
+
+	cfg := config.DefaultConfig()
+	cfg.Local.Syslog.Enabled = true
+	cfg.Local.Syslog.Network = "tcp"
+	cfg.Local.Syslog.Address = "203.0.113.1:514"
+
+	start := time.Now()
+	err := EmitSyslog(&models.Scan{ID: "scan-1"}, cfg)
+	if err == nil || !strings.Contains(err.Error(), "did not respond") {
+		t.Errorf("EmitSyslog error = %v, want timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("EmitSyslog blocked for %s", elapsed)
+	}
+}
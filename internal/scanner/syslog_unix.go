@@ -0,0 +1,11 @@
+//go:build !windows
+
+package scanner
+
+import "log/syslog"
+
+// dialSystemSyslog connects to the local syslog daemon (which journald
+// also reads) or, when network is set, to a remote syslog server.
+func dialSystemSyslog(network, addr, tag string) (syslogWriter, error) {
+	return syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package scanner
+
+import "errors"
+
+// dialSystemSyslog reports that syslog is unavailable; Windows has no
+// syslog daemon and log/syslog does not build there.
+func dialSystemSyslog(network, addr, tag string) (syslogWriter, error) {
+	return nil, errors.New("syslog is not supported on Windows")
+}
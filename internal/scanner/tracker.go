@@ -9,8 +9,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/intentrahq/intentra-cli/internal/config"
+	"github.com/intentrahq/intentra-cli/internal/debug"
 	"github.com/intentrahq/intentra-cli/pkg/models"
 )
 
@@ -114,6 +116,8 @@ func LoadScans() ([]models.Scan, error) {
 	}
 
 	var scans []models.Scan
+	var modTimes []time.Time
+	byID := make(map[string]int)
 	for _, entry := range entries {
 		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
 			continue
@@ -128,35 +132,71 @@ func LoadScans() ([]models.Scan, error) {
 		if err := json.Unmarshal(data, &scan); err != nil {
 			continue
 		}
+		var modTime time.Time
+		if info, err := entry.Info(); err == nil {
+			modTime = info.ModTime()
+		}
+
+		// Two files can carry the same scan ID (e.g. after an ID scheme
+		// change); keep one so totals aren't double-counted.
+		if i, ok := byID[scan.ID]; ok {
+			debug.Warn("duplicate scan ID %s in %s; keeping the newest copy", scan.ID, entry.Name())
+			if newerScan(scan, modTime, scans[i], modTimes[i]) {
+				scans[i], modTimes[i] = scan, modTime
+			}
+			continue
+		}
+		byID[scan.ID] = len(scans)
 		scans = append(scans, scan)
+		modTimes = append(modTimes, modTime)
 	}
 
 	return scans, nil
 }
 
-// LoadScan reads a single scan by ID.
-func LoadScan(id string) (*models.Scan, error) {
+// newerScan reports whether scan a is newer than b, comparing EndTime and
+// falling back to file modification time when the end times are equal.
+func newerScan(a models.Scan, aMod time.Time, b models.Scan, bMod time.Time) bool {
+	if !a.EndTime.Equal(b.EndTime) {
+		return a.EndTime.After(b.EndTime)
+	}
+	return aMod.After(bMod)
+}
+
+// ScanPath returns the file a scan with the given ID is stored in, rejecting
+// IDs that would resolve outside the scans directory.
+func ScanPath(id string) (string, error) {
 	if err := validateScanID(id); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	scansDir, err := config.GetScansDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to determine scans path: %w", err)
+		return "", fmt.Errorf("failed to determine scans path: %w", err)
 	}
 	filename := filepath.Join(scansDir, id+".json")
 
 	absFilename, err := filepath.Abs(filename)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	absScansDir, err := filepath.Abs(scansDir)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	relPath, err := filepath.Rel(absScansDir, absFilename)
 	if err != nil || strings.HasPrefix(relPath, "..") {
-		return nil, ErrInvalidScanID
+		return "", ErrInvalidScanID
+	}
+
+	return filename, nil
+}
+
+// LoadScan reads a single scan by ID.
+func LoadScan(id string) (*models.Scan, error) {
+	filename, err := ScanPath(id)
+	if err != nil {
+		return nil, err
 	}
 
 	data, err := os.ReadFile(filename)
@@ -172,30 +212,13 @@ func LoadScan(id string) (*models.Scan, error) {
 	return &scan, nil
 }
 
-// DeleteScan removes a scan file by ID.
+// DeleteScan removes a scan file by ID. The file is not read, so a corrupt
+// scan can be deleted too.
 func DeleteScan(id string) error {
-	if err := validateScanID(id); err != nil {
-		return err
-	}
-
-	scansDir, err := config.GetScansDir()
-	if err != nil {
-		return fmt.Errorf("failed to determine scans path: %w", err)
-	}
-	filename := filepath.Join(scansDir, id+".json")
-
-	absFilename, err := filepath.Abs(filename)
-	if err != nil {
-		return err
-	}
-	absScansDir, err := filepath.Abs(scansDir)
+	filename, err := ScanPath(id)
 	if err != nil {
 		return err
 	}
-	relPath, err := filepath.Rel(absScansDir, absFilename)
-	if err != nil || strings.HasPrefix(relPath, "..") {
-		return ErrInvalidScanID
-	}
 
 	err = os.Remove(filename)
 	if err != nil && !os.IsNotExist(err) {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/intentrahq/intentra-cli/pkg/models"
 )
@@ -203,6 +204,54 @@ func TestLoadScans(t *testing.T) {
 	}
 }
 
+func TestLoadScans_DuplicateIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
+	scansDir := filepath.Join(tmpDir, "scans")
+	if err := os.MkdirAll(scansDir, 0700); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	end := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	write := func(name string, scan models.Scan, mod time.Time) {
+		t.Helper()
+		data, _ := json.Marshal(scan)
+		path := filepath.Join(scansDir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+
+	// Newer EndTime wins regardless of file order.
+	write("a-old.json", models.Scan{ID: "dup", TotalTokens: 100, EndTime: end}, end)
+	write("b-new.json", models.Scan{ID: "dup", TotalTokens: 200, EndTime: end.Add(time.Hour)}, end)
+	// Equal EndTime falls back to file mtime.
+	write("c-stale.json", models.Scan{ID: "same-end", TotalTokens: 1, EndTime: end}, end.Add(time.Hour))
+	write("d-fresh.json", models.Scan{ID: "same-end", TotalTokens: 2, EndTime: end}, end.Add(2*time.Hour))
+	write("e-unique.json", models.Scan{ID: "unique"}, end)
+
+	scans, err := LoadScans()
+	if err != nil {
+		t.Fatalf("LoadScans failed: %v", err)
+	}
+	if len(scans) != 3 {
+		t.Fatalf("expected 3 scans after de-duplication, got %d", len(scans))
+	}
+	got := map[string]int{}
+	for _, s := range scans {
+		got[s.ID] = s.TotalTokens
+	}
+	if got["dup"] != 200 {
+		t.Errorf("dup kept TotalTokens=%d, want 200 (newest EndTime)", got["dup"])
+	}
+	if got["same-end"] != 2 {
+		t.Errorf("same-end kept TotalTokens=%d, want 2 (newest mtime)", got["same-end"])
+	}
+}
+
 func TestLoadScans_EmptyDir(t *testing.T) {
 	tmpDir := t.TempDir()
 	os.Setenv("INTENTRA_CONFIG_DIR", tmpDir)
@@ -4,6 +4,7 @@
 package models
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -11,6 +12,7 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // TokenBreakdown provides per-category token attribution for context analysis.
@@ -40,6 +42,7 @@ type Event struct {
 	UserEmail      string    `json:"user_email,omitempty"`
 	DeviceID       string    `json:"device_id,omitempty"`
 	Tool           string    `json:"tool,omitempty"`
+	ToolVersion    string    `json:"tool_version,omitempty"` // host app version (e.g. Cursor 1.7.2) when known
 
 	Prompt        string          `json:"prompt,omitempty"`
 	Response      string          `json:"response,omitempty"`
@@ -61,6 +64,15 @@ type Event struct {
 	ThinkingTokens int `json:"thinking_tokens,omitempty"`
 	DurationMs     int `json:"duration_ms,omitempty"`
 
+	// CharsChanged, LinesAdded and LinesRemoved measure a structured file edit
+	// (old/new strings or written content) on after-edit events.
+	CharsChanged int `json:"chars_changed,omitempty"`
+	LinesAdded   int `json:"lines_added,omitempty"`
+	LinesRemoved int `json:"lines_removed,omitempty"`
+
+	// ReportedCost is the tool's own cost estimate in USD (usage.cost_usd), when sent.
+	ReportedCost float64 `json:"reported_cost,omitempty"`
+
 	ContextUsagePercent int    `json:"context_usage_percent,omitempty"`
 	ContextTokens       int    `json:"context_tokens,omitempty"`
 	ContextWindowSize   int    `json:"context_window_size,omitempty"`
@@ -75,7 +87,17 @@ type Event struct {
 	SubagentDepth      int             `json:"subagent_depth,omitempty"`
 	TokenBreakdownData *TokenBreakdown `json:"token_breakdown,omitempty"`
 
-	Error string `json:"error,omitempty"`
+	Error       string `json:"error,omitempty"`
+	ErrorType   string `json:"error_type,omitempty"`
+	ErrorCode   string `json:"error_code,omitempty"`
+	RateLimited bool   `json:"rate_limited,omitempty"`
+
+	// StopReason is why the agent stopped (e.g. end_turn, max_tokens),
+	// reported on stop events by tools that provide it.
+	StopReason string `json:"stop_reason,omitempty"`
+
+	NotificationType    string `json:"notification_type,omitempty"`
+	NotificationMessage string `json:"notification_message,omitempty"`
 }
 
 // IsMCPEvent returns true if this event is an MCP tool invocation.
@@ -83,6 +105,44 @@ func (e *Event) IsMCPEvent() bool {
 	return e.MCPServerName != "" || e.MCPToolName != ""
 }
 
+// SanitizeUTF8 replaces invalid UTF-8 in the event's content fields with
+// U+FFFD. Binary command or tool output would otherwise reach the payload as
+// raw bytes, which the server rejects.
+func (e *Event) SanitizeUTF8() {
+	for _, field := range []*string{&e.Prompt, &e.Response, &e.Thought, &e.Command, &e.CommandOutput, &e.NotificationMessage} {
+		*field = ValidUTF8(*field)
+	}
+	// Invalid bytes can only occur inside JSON strings, so replacing them
+	// keeps the raw JSON well formed.
+	if len(e.ToolInput) > 0 && !utf8.Valid(e.ToolInput) {
+		e.ToolInput = bytes.ToValidUTF8(e.ToolInput, []byte("\uFFFD"))
+	}
+	if len(e.ToolOutput) > 0 && !utf8.Valid(e.ToolOutput) {
+		e.ToolOutput = bytes.ToValidUTF8(e.ToolOutput, []byte("\uFFFD"))
+	}
+}
+
+// ValidUTF8 returns s with each run of invalid UTF-8 replaced by U+FFFD.
+func ValidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
+
+// TruncateUTF8 shortens s to at most n bytes without splitting a multi-byte
+// character, and replaces any invalid UTF-8 in what remains.
+func TruncateUTF8(s string, n int) string {
+	if len(s) > n {
+		cut := n
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut]
+	}
+	return ValidUTF8(s)
+}
+
 // SanitizeMCPServerURL strips query parameters from a URL to prevent leaking API keys.
 // Returns only scheme + host + path.
 func SanitizeMCPServerURL(rawURL string) string {
@@ -200,6 +260,26 @@ func IsToolCallEvent(eventType NormalizedEventType) bool {
 		eventType == EventAfterMCP
 }
 
+// IsRateLimitError returns true if an error message indicates the provider
+// throttled the request (a standalone 429 status or a "rate limit" style message).
+func IsRateLimitError(msg string) bool {
+	if msg == "" {
+		return false
+	}
+	lower := strings.ToLower(msg)
+	for _, marker := range []string{"rate limit", "rate-limit", "ratelimit", "rate_limit", "too many requests", "throttl", "resource_exhausted"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	for _, field := range strings.FieldsFunc(lower, func(r rune) bool { return r < '0' || r > '9' }) {
+		if field == "429" {
+			return true
+		}
+	}
+	return false
+}
+
 // MCPServerURLHash returns a short hash of the sanitized server URL or command.
 // Used as a deduplication key alongside server name.
 func MCPServerURLHash(serverURL, serverCmd string) string {
@@ -2,8 +2,10 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestEventUnmarshal(t *testing.T) {
@@ -137,6 +139,12 @@ func TestBuildAPIPayload(t *testing.T) {
 		if _, ok := payload["files_modified"]; ok {
 			t.Error("files_modified should be omitted when empty")
 		}
+		if _, ok := payload["new_files_count"]; ok {
+			t.Error("new_files_count should be omitted when empty")
+		}
+		if _, ok := payload["partial"]; ok {
+			t.Error("partial should be omitted when false")
+		}
 	})
 
 	t.Run("optional fields included when set", func(t *testing.T) {
@@ -147,9 +155,19 @@ func TestBuildAPIPayload(t *testing.T) {
 			RepoName:         "myrepo",
 			RepoURLHash:      "abc123",
 			BranchName:       "main",
+			NewFilesCount:    2,
+			Partial:          true,
 		}
 		payload := scan.BuildAPIPayload("dev-1", false)
 
+		if payload["partial"] != true {
+			t.Errorf("partial = %v, want true", payload["partial"])
+		}
+
+		if payload["new_files_count"] != 2 || payload["modified_files_count"] != 0 {
+			t.Errorf("file counts = %v/%v, want 2/0", payload["new_files_count"], payload["modified_files_count"])
+		}
+
 		if payload["session_end_reason"] != "user_exit" {
 			t.Errorf("session_end_reason = %v", payload["session_end_reason"])
 		}
@@ -316,3 +334,198 @@ func TestIsMCPEvent(t *testing.T) {
 		}
 	})
 }
+
+func TestIsRateLimitError(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"", false},
+		{"HTTP 429 Too Many Requests", true},
+		{"status 429", true},
+		{"You have been rate limited, please retry", true},
+		{"rate_limit_exceeded", true},
+		{"RESOURCE_EXHAUSTED: quota exceeded", true},
+		{"request throttled by provider", true},
+		{"read 1429 bytes", false},
+		{"file not found", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRateLimitError(tt.msg); got != tt.want {
+			t.Errorf("IsRateLimitError(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestBuildAPIPayload_RateLimitHits(t *testing.T) {
+	scan := &Scan{Tool: "claude", RateLimitHits: 3}
+	payload := scan.BuildAPIPayload("device-abc", false)
+	if payload["rate_limit_hits"] != 3 {
+		t.Errorf("rate_limit_hits = %v, want 3", payload["rate_limit_hits"])
+	}
+
+	scan.RateLimitHits = 0
+	payload = scan.BuildAPIPayload("device-abc", false)
+	if _, ok := payload["rate_limit_hits"]; ok {
+		t.Error("rate_limit_hits should be omitted when zero")
+	}
+}
+
+func TestBuildAPIPayload_AuthorHash(t *testing.T) {
+	scan := &Scan{Tool: "claude", AuthorHash: "abc123"}
+	payload := scan.BuildAPIPayload("device-abc", false)
+	if payload["author_hash"] != "abc123" {
+		t.Errorf("author_hash = %v, want abc123", payload["author_hash"])
+	}
+}
+
+func TestBuildAPIPayload_WorkingTreeDirty(t *testing.T) {
+	dirty := true
+	scan := &Scan{Tool: "claude", CommitSHA: "abc123", WorkingTreeDirty: &dirty}
+	payload := scan.BuildAPIPayload("device-abc", false)
+	if payload["commit_sha"] != "abc123" || payload["working_tree_dirty"] != true {
+		t.Errorf("commit_sha = %v, working_tree_dirty = %v", payload["commit_sha"], payload["working_tree_dirty"])
+	}
+
+	dirty = false
+	payload = scan.BuildAPIPayload("device-abc", false)
+	if v, ok := payload["working_tree_dirty"]; !ok || v != false {
+		t.Errorf("working_tree_dirty = %v, want false for a clean tree", v)
+	}
+
+	scan.WorkingTreeDirty = nil
+	payload = scan.BuildAPIPayload("device-abc", false)
+	if _, ok := payload["working_tree_dirty"]; ok {
+		t.Error("working_tree_dirty should be omitted when unknown")
+	}
+}
+
+func TestBuildAPIPayload_Notifications(t *testing.T) {
+	scan := &Scan{
+		Tool:               "claude",
+		NotificationCounts: map[string]int{"permission_prompt": 2},
+		Events: []Event{
+			{NormalizedType: "notification", NotificationType: "permission_prompt", NotificationMessage: "needs permission"},
+		},
+	}
+
+	payload := scan.BuildAPIPayload("device-abc", false)
+	counts, ok := payload["notification_counts"].(map[string]int)
+	if !ok || counts["permission_prompt"] != 2 {
+		t.Errorf("notification_counts = %v, want permission_prompt:2", payload["notification_counts"])
+	}
+	events := payload["events"].([]map[string]any)
+	if events[0]["notification_type"] != "permission_prompt" {
+		t.Errorf("notification_type = %v, want permission_prompt", events[0]["notification_type"])
+	}
+	if _, ok := events[0]["notification_message"]; ok {
+		t.Error("notification_message should only be included with rich traces")
+	}
+
+	payload = scan.BuildAPIPayload("device-abc", true)
+	events = payload["events"].([]map[string]any)
+	if events[0]["notification_message"] != "needs permission" {
+		t.Errorf("notification_message = %v, want needs permission", events[0]["notification_message"])
+	}
+}
+
+func TestBuildAPIPayload_ErrorCounts(t *testing.T) {
+	scan := &Scan{
+		Tool:        "copilot",
+		ErrorCounts: map[string]int{"TimeoutError": 2},
+		Events: []Event{
+			{NormalizedType: "error", Error: "Network timeout", ErrorType: "TimeoutError", ErrorCode: "ETIMEDOUT"},
+		},
+	}
+
+	payload := scan.BuildAPIPayload("device-abc", false)
+	counts, ok := payload["error_counts"].(map[string]int)
+	if !ok || counts["TimeoutError"] != 2 {
+		t.Errorf("error_counts = %v, want TimeoutError:2", payload["error_counts"])
+	}
+	events := payload["events"].([]map[string]any)
+	if events[0]["error_type"] != "TimeoutError" || events[0]["error_code"] != "ETIMEDOUT" {
+		t.Errorf("unexpected event error fields: %v", events[0])
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{"short", "abc", 10, "abc"},
+		{"ascii cut", "abcdef", 3, "abc"},
+		{"cut inside rune", "ab\u00e9", 3, "ab"},
+		{"cut after rune", "ab\u00e9c", 4, "ab\u00e9"},
+		{"invalid bytes", "a\xffb", 10, "a\uFFFDb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateUTF8(tt.in, tt.n); got != tt.want {
+				t.Errorf("TruncateUTF8(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAPIPayload_InvalidUTF8(t *testing.T) {
+	scan := &Scan{
+		Tool: "claude",
+		Events: []Event{{
+			NormalizedType: "after_shell",
+			Command:        "cat a.out",
+			CommandOutput:  "ELF\x7f\xff\xfe\x00ok",
+			ToolOutput:     json.RawMessage("{\"stdout\":\"bin\xc3\x28ary\"}"),
+			// A 3-byte character straddling the truncation limit.
+			ToolInput: json.RawMessage(strings.Repeat("a", maxTraceContentLen-1) + "\u20ac"),
+		}},
+	}
+
+	payload := scan.BuildAPIPayload("dev-1", true)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !utf8.Valid(data) {
+		t.Fatal("payload JSON is not valid UTF-8")
+	}
+
+	ev := payload["events"].([]map[string]any)[0]
+	if got := ev["command_output"]; got != "ELF\x7f\uFFFD\x00ok" {
+		t.Errorf("command_output = %q", got)
+	}
+	if got := ev["tool_output"]; got != "{\"stdout\":\"bin\uFFFD(ary\"}" {
+		t.Errorf("tool_output = %q", got)
+	}
+	if got := ev["tool_input"].(string); got != strings.Repeat("a", maxTraceContentLen-1) {
+		t.Errorf("tool_input not cut at a character boundary: ...%q", got[len(got)-4:])
+	}
+	if scan.Events[0].CommandOutput != "ELF\x7f\xff\xfe\x00ok" {
+		t.Error("BuildAPIPayload modified the scan's events")
+	}
+}
+
+func TestBuildAPIPayload_InvalidUTF8RawEvents(t *testing.T) {
+	scan := &Scan{
+		Tool: "claude",
+		RawEvents: []map[string]any{{
+			"hook_event_name": "PostToolUse",
+			"tool_output":     map[string]any{"stdout": "\xff\xfe", "lines": []any{"ok", "\xc0"}},
+		}},
+	}
+
+	data, err := json.Marshal(scan.BuildAPIPayload("dev-1", false))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !utf8.Valid(data) || !strings.Contains(string(data), "\"stdout\":\"\uFFFD\"") || !strings.Contains(string(data), "[\"ok\",\"\uFFFD\"]") {
+		t.Errorf("raw events not sanitized: %s", data)
+	}
+	if scan.RawEvents[0]["tool_output"].(map[string]any)["stdout"] != "\xff\xfe" {
+		t.Error("BuildAPIPayload modified the scan's raw events")
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
@@ -24,6 +25,33 @@ const (
 	ScanStatusReviewed  ScanStatus = "reviewed"
 )
 
+// ParseScanStatus returns the ScanStatus named by s.
+func ParseScanStatus(s string) (ScanStatus, error) {
+	switch status := ScanStatus(s); status {
+	case ScanStatusPending, ScanStatusAnalyzing, ScanStatusReviewed:
+		return status, nil
+	}
+	return "", fmt.Errorf("unknown scan status %q (want pending, analyzing or reviewed)", s)
+}
+
+// EffectiveStatus returns s.Status, treating a scan without one as pending.
+func (s *Scan) EffectiveStatus() ScanStatus {
+	if s.Status == "" {
+		return ScanStatusPending
+	}
+	return s.Status
+}
+
+// EffectiveReviewStatus returns s.ReviewStatus, treating a scan without one
+// as pending. Unlike Status, which tracks upload state, it is only set by
+// 'intentra scan mark'.
+func (s *Scan) EffectiveReviewStatus() ScanStatus {
+	if s.ReviewStatus == "" {
+		return ScanStatusPending
+	}
+	return s.ReviewStatus
+}
+
 // ScanSource identifies the origin of a scan event.
 type ScanSource struct {
 	Tool      string `json:"tool,omitempty"`
@@ -48,10 +76,12 @@ type Scan struct {
 	ID             string      `json:"scan_id"`
 	DeviceID       string      `json:"device_id"`
 	Tool           string      `json:"tool,omitempty"`
+	ToolVersion    string      `json:"tool_version,omitempty"`
 	ConversationID string      `json:"conversation_id,omitempty"`
 	GenerationID   string      `json:"generation_id,omitempty"`
 	Model          string      `json:"model,omitempty"`
 	Status         ScanStatus  `json:"status,omitempty"`
+	ReviewStatus   ScanStatus  `json:"review_status,omitempty"` // triage state from 'intentra scan mark'; sync ignores it
 	StartTime      time.Time   `json:"start_time,omitempty"`
 	EndTime        time.Time   `json:"end_time,omitempty"`
 	Source         *ScanSource `json:"source,omitempty"`
@@ -64,23 +94,67 @@ type Scan struct {
 	LLMCalls       int     `json:"llm_calls"`
 	ToolCalls      int     `json:"tool_calls"`
 	EstimatedCost  float64 `json:"estimated_cost"`
+	RateLimitHits  int     `json:"rate_limit_hits,omitempty"`
+
+	// EstimatedCostLow and EstimatedCostHigh bracket EstimatedCost with the
+	// configured confidence band, since client-side pricing is approximate.
+	EstimatedCostLow  float64 `json:"estimated_cost_low,omitempty"`
+	EstimatedCostHigh float64 `json:"estimated_cost_high,omitempty"`
+
+	// ReportedCost sums the cost the tool itself reported on its events. Zero
+	// when the tool does not report cost; the server prefers it when set.
+	ReportedCost float64 `json:"reported_cost,omitempty"`
+
+	// TotalCharsChanged and TotalLinesChanged sum the structured edit metrics
+	// of the scan's events: how much code the tool actually changed.
+	TotalCharsChanged int `json:"total_chars_changed,omitempty"`
+	TotalLinesChanged int `json:"total_lines_changed,omitempty"`
+
+	// NewFilesCount and ModifiedFilesCount split FilesModified into files
+	// the scan created and existing files it edited.
+	NewFilesCount      int `json:"new_files_count,omitempty"`
+	ModifiedFilesCount int `json:"modified_files_count,omitempty"`
+
+	// MixedModels is set when the scan's events named more than one model.
+	MixedModels bool `json:"mixed_models,omitempty"`
+
+	// Partial is set when the scan was flushed mid-session because the
+	// session's buffer reached buffer.max_session_bytes; later scans for
+	// the same conversation carry the rest of its events.
+	Partial bool `json:"partial,omitempty"`
 
 	RawEvents []map[string]any `json:"raw_events,omitempty"`
 
-	Fingerprint  string         `json:"fingerprint,omitempty"`
-	FilesHash    string         `json:"files_hash,omitempty"`
-	ActionCounts map[string]int `json:"action_counts,omitempty"`
+	Fingerprint    string         `json:"fingerprint,omitempty"`
+	SimFingerprint string         `json:"sim_fingerprint,omitempty"`
+	FilesHash      string         `json:"files_hash,omitempty"`
+	ActionCounts   map[string]int `json:"action_counts,omitempty"`
+
+	NotificationCounts map[string]int `json:"notification_counts,omitempty"`
+	ErrorCounts        map[string]int `json:"error_counts,omitempty"`
+
+	// ToolCallCounts counts the scan's tool calls by tool name, and
+	// DistinctToolsUsed is the number of different tools it called.
+	ToolCallCounts    map[string]int `json:"tool_call_counts,omitempty"`
+	DistinctToolsUsed int            `json:"distinct_tools_used,omitempty"`
 
 	MCPToolUsage []MCPToolCall `json:"mcp_tool_usage,omitempty"`
 
+	// StopReason is the stop reason of the scan's terminal event, showing
+	// how the agent's turn ended (e.g. end_turn or max_tokens).
+	StopReason string `json:"stop_reason,omitempty"`
+
 	SessionEndReason  string `json:"session_end_reason,omitempty"`
 	SessionDurationMs int64  `json:"session_duration_ms,omitempty"`
 
-	RepoName      string           `json:"repo_name,omitempty"`
-	RepoURLHash   string           `json:"repo_url_hash,omitempty"`
-	BranchName    string           `json:"branch_name,omitempty"`
-	CommitSHA     string           `json:"commit_sha,omitempty"`
-	FilesModified []map[string]any `json:"files_modified,omitempty"`
+	RepoName         string           `json:"repo_name,omitempty"`
+	RepoURLHash      string           `json:"repo_url_hash,omitempty"`
+	RepoHost         string           `json:"repo_host,omitempty"` // plaintext or hashed per local.repo_host
+	BranchName       string           `json:"branch_name,omitempty"`
+	CommitSHA        string           `json:"commit_sha,omitempty"`
+	WorkingTreeDirty *bool            `json:"working_tree_dirty,omitempty"` // uncommitted changes to tracked files at scan time; nil if unknown
+	AuthorHash       string           `json:"author_hash,omitempty"`
+	FilesModified    []map[string]any `json:"files_modified,omitempty"`
 }
 
 // SendPayload is the JSON envelope written to a temp file by the hook handler
@@ -92,6 +166,7 @@ type SendPayload struct {
 	SessionKey string `json:"session_key,omitempty"`
 	Reason     string `json:"reason,omitempty"`
 	DurationMs int64  `json:"duration_ms,omitempty"`
+	Tool       string `json:"tool,omitempty"`
 }
 
 var (
@@ -153,9 +228,55 @@ func (s *Scan) BuildAPIPayload(deviceID string, richTraces bool) map[string]any
 		"model":           s.Model,
 	}
 
+	if s.Fingerprint != "" {
+		body["fingerprint"] = s.Fingerprint
+	}
+	if s.SimFingerprint != "" {
+		body["sim_fingerprint"] = s.SimFingerprint
+	}
+	if s.EstimatedCostHigh > 0 {
+		body["estimated_cost_low"] = s.EstimatedCostLow
+		body["estimated_cost_high"] = s.EstimatedCostHigh
+	}
+	if s.ReportedCost > 0 {
+		body["reported_cost"] = s.ReportedCost
+	}
+	if s.TotalCharsChanged > 0 || s.TotalLinesChanged > 0 {
+		body["total_chars_changed"] = s.TotalCharsChanged
+		body["total_lines_changed"] = s.TotalLinesChanged
+	}
+	if s.NewFilesCount > 0 || s.ModifiedFilesCount > 0 {
+		body["new_files_count"] = s.NewFilesCount
+		body["modified_files_count"] = s.ModifiedFilesCount
+	}
+	if s.MixedModels {
+		body["mixed_models"] = true
+	}
+	if s.Partial {
+		body["partial"] = true
+	}
+	if s.ToolVersion != "" {
+		body["tool_version"] = s.ToolVersion
+	}
+	if s.RateLimitHits > 0 {
+		body["rate_limit_hits"] = s.RateLimitHits
+	}
+	if len(s.NotificationCounts) > 0 {
+		body["notification_counts"] = s.NotificationCounts
+	}
+	if len(s.ErrorCounts) > 0 {
+		body["error_counts"] = s.ErrorCounts
+	}
+	if len(s.ToolCallCounts) > 0 {
+		body["tool_call_counts"] = s.ToolCallCounts
+		body["distinct_tools_used"] = s.DistinctToolsUsed
+	}
 	if len(s.MCPToolUsage) > 0 {
 		body["mcp_tool_usage"] = s.MCPToolUsage
 	}
+	if s.StopReason != "" {
+		body["stop_reason"] = s.StopReason
+	}
 	if s.SessionEndReason != "" {
 		body["session_end_reason"] = s.SessionEndReason
 	}
@@ -168,12 +289,21 @@ func (s *Scan) BuildAPIPayload(deviceID string, richTraces bool) map[string]any
 	if s.RepoURLHash != "" {
 		body["repo_url_hash"] = s.RepoURLHash
 	}
+	if s.RepoHost != "" {
+		body["repo_host"] = s.RepoHost
+	}
 	if s.BranchName != "" {
 		body["branch_name"] = s.BranchName
 	}
 	if s.CommitSHA != "" {
 		body["commit_sha"] = s.CommitSHA
 	}
+	if s.WorkingTreeDirty != nil {
+		body["working_tree_dirty"] = *s.WorkingTreeDirty
+	}
+	if s.AuthorHash != "" {
+		body["author_hash"] = s.AuthorHash
+	}
 	if len(s.FilesModified) > 0 {
 		sanitized := make([]map[string]any, len(s.FilesModified))
 		for i, entry := range s.FilesModified {
@@ -195,15 +325,23 @@ func (s *Scan) BuildAPIPayload(deviceID string, richTraces bool) map[string]any
 	return body
 }
 
+// maxTraceContentLen caps each rich-trace content field in the payload, in bytes.
+const maxTraceContentLen = 10000
+
 // buildEventPayload converts raw events or structured events into API-ready maps.
 // When richTraces is true, tool inputs/outputs and command content are included (truncated to 10KB).
 func buildEventPayload(rawEvents []map[string]any, events []Event, richTraces bool) []map[string]any {
 	if len(rawEvents) > 0 {
-		return rawEvents
+		result := make([]map[string]any, len(rawEvents))
+		for i, raw := range rawEvents {
+			result[i] = validUTF8Value(raw).(map[string]any)
+		}
+		return result
 	}
 
 	var result []map[string]any
 	for _, ev := range events {
+		ev.SanitizeUTF8()
 		evMap := map[string]any{
 			"hook_type":       string(ev.HookType),
 			"normalized_type": ev.NormalizedType,
@@ -219,6 +357,12 @@ func buildEventPayload(rawEvents []map[string]any, events []Event, richTraces bo
 				"thinking": ev.ThinkingTokens,
 			},
 		}
+		if ev.ToolVersion != "" {
+			evMap["tool_version"] = ev.ToolVersion
+		}
+		if ev.ReportedCost > 0 {
+			evMap["reported_cost"] = ev.ReportedCost
+		}
 		if ev.CompactionTrigger != "" {
 			evMap["compaction_trigger"] = ev.CompactionTrigger
 		}
@@ -242,32 +386,16 @@ func buildEventPayload(rawEvents []map[string]any, events []Event, richTraces bo
 		}
 		if richTraces {
 			if len(ev.ToolInput) > 0 {
-				input := string(ev.ToolInput)
-				if len(input) > 10000 {
-					input = input[:10000]
-				}
-				evMap["tool_input"] = input
+				evMap["tool_input"] = TruncateUTF8(string(ev.ToolInput), maxTraceContentLen)
 			}
 			if len(ev.ToolOutput) > 0 {
-				output := string(ev.ToolOutput)
-				if len(output) > 10000 {
-					output = output[:10000]
-				}
-				evMap["tool_output"] = output
+				evMap["tool_output"] = TruncateUTF8(string(ev.ToolOutput), maxTraceContentLen)
 			}
 			if ev.Command != "" {
-				cmd := ev.Command
-				if len(cmd) > 10000 {
-					cmd = cmd[:10000]
-				}
-				evMap["command"] = cmd
+				evMap["command"] = TruncateUTF8(ev.Command, maxTraceContentLen)
 			}
 			if ev.CommandOutput != "" {
-				cmdOut := ev.CommandOutput
-				if len(cmdOut) > 10000 {
-					cmdOut = cmdOut[:10000]
-				}
-				evMap["command_output"] = cmdOut
+				evMap["command_output"] = TruncateUTF8(ev.CommandOutput, maxTraceContentLen)
 			}
 		}
 		if ev.ParentSessionID != "" {
@@ -285,8 +413,45 @@ func buildEventPayload(rawEvents []map[string]any, events []Event, richTraces bo
 		if ev.TokenBreakdownData != nil {
 			evMap["token_breakdown"] = ev.TokenBreakdownData
 		}
+		if ev.RateLimited {
+			evMap["rate_limited"] = true
+		}
+		if ev.ErrorType != "" {
+			evMap["error_type"] = ev.ErrorType
+		}
+		if ev.ErrorCode != "" {
+			evMap["error_code"] = ev.ErrorCode
+		}
+		if ev.NotificationType != "" {
+			evMap["notification_type"] = ev.NotificationType
+			if richTraces && ev.NotificationMessage != "" {
+				evMap["notification_message"] = ev.NotificationMessage
+			}
+		}
 		result = append(result, evMap)
 	}
 	return result
 }
 
+// validUTF8Value returns a copy of a decoded JSON value with invalid UTF-8
+// in its strings and keys replaced by U+FFFD.
+func validUTF8Value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return ValidUTF8(v)
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[ValidUTF8(k)] = validUTF8Value(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = validUTF8Value(e)
+		}
+		return s
+	default:
+		return v
+	}
+}